@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// ============================================
+// DETEKSI ANOMALI HARGA (GET /harga/anomalies)
+// Modified z-score (Iglewicz & Hoaglin): 0.6745 * |x - median| / MAD.
+// Median & MAD tahan outlier, jadi satu harga salah ketik (mis. 450000 vs 45000)
+// tidak ikut menggeser patokannya seperti pada mean/stddev.
+// ============================================
+
+const (
+	defaultAnomalyThreshold = 3.5
+	maxAnomalyThreshold     = 100
+	defaultAnomalyPoints    = 365
+	minAnomalySeries        = 5 // di bawah ini median/MAD belum bisa dipercaya
+	// madScale membuat MAD sebanding dengan stddev untuk data normal
+	madScale = 0.6745
+	// meanADScale dipakai saat MAD = 0 (lebih dari separuh harga identik)
+	meanADScale = 0.7979
+)
+
+type PriceAnomaly struct {
+	Price Price   `json:"price"`
+	Score float64 `json:"score"` // modified z-score bertanda: + di atas median, - di bawah
+}
+
+type PriceAnomalyReport struct {
+	Region    string         `json:"region"`
+	Points    int            `json:"points"`
+	Threshold float64        `json:"threshold"`
+	Median    float64        `json:"median"`
+	MAD       float64        `json:"mad"`
+	Anomalies []PriceAnomaly `json:"anomalies"`
+	Note      string         `json:"note,omitempty"`
+}
+
+// median tidak mengubah urutan values; values kosong = 0
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// DetectPriceAnomalies series diasumsikan sudah satu region
+func DetectPriceAnomalies(series []Price, threshold float64) PriceAnomalyReport {
+	report := PriceAnomalyReport{Points: len(series), Threshold: threshold, Anomalies: []PriceAnomaly{}}
+	if len(series) < minAnomalySeries {
+		report.Note = fmt.Sprintf("Data baru %d harga, minimal %d untuk mendeteksi anomali", len(series), minAnomalySeries)
+		return report
+	}
+
+	values := Map(series, func(p Price) float64 { return p.Price })
+	report.Median = median(values)
+	deviations := Map(values, func(v float64) float64 { return math.Abs(v - report.Median) })
+	report.MAD = median(deviations)
+
+	// MAD = 0: pakai simpangan rata-rata absolut supaya harga yang berbeda tetap terdeteksi
+	scale := report.MAD / madScale
+	if report.MAD == 0 {
+		meanAD := Reduce(deviations, 0.0, func(acc, d float64) float64 { return acc + d }) / float64(len(deviations))
+		if meanAD == 0 {
+			report.Note = "Semua harga identik, tidak ada anomali"
+			return report
+		}
+		scale = meanAD / meanADScale
+		report.Note = "MAD = 0 (mayoritas harga identik); skor memakai simpangan rata-rata absolut"
+	}
+
+	scored := Map(series, func(p Price) PriceAnomaly {
+		return PriceAnomaly{Price: p, Score: math.Round((p.Price-report.Median)/scale*100) / 100}
+	})
+	report.Anomalies = Filter(scored, func(a PriceAnomaly) bool { return math.Abs(a.Score) > threshold })
+	return report
+}
+
+func PriceAnomaliesHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		q := r.URL.Query()
+		region := getRegionOrDefault(q.Get("region"))
+
+		threshold := defaultAnomalyThreshold
+		if raw := q.Get("threshold"); raw != "" {
+			v, err := strconv.ParseFloat(raw, 64)
+			if err != nil || v <= 0 || v > maxAnomalyThreshold {
+				return NewAPIError(http.StatusBadRequest, "invalid_threshold",
+					fmt.Sprintf("threshold harus angka > 0 dan <= %d", maxAnomalyThreshold))
+			}
+			threshold = v
+		}
+
+		series, err := priceStore.ByRegion(region, defaultAnomalyPoints)
+		if err != nil {
+			return err
+		}
+		report := DetectPriceAnomalies(series, threshold)
+		report.Region = region
+		return respondJSON(w, http.StatusOK, report)
+	})(w, r)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestDetectPriceAnomaliesFindsInjectedOutlier(t *testing.T) {
+	series := Map([]float64{41000, 42000, 43000, 42500, 450000, 41500, 43500}, func(v float64) Price {
+		return testPrice("Jember", v, "2026-03-01")
+	})
+
+	report := DetectPriceAnomalies(series, defaultAnomalyThreshold)
+	if report.Median != 42500 || report.MAD != 1000 || report.Points != 7 {
+		t.Fatalf("median %v MAD %v points %d, ingin 42500 1000 7", report.Median, report.MAD, report.Points)
+	}
+	// 450000 = 45000 yang terbaca dengan pemisah ribuan salah
+	if len(report.Anomalies) != 1 || report.Anomalies[0].Price.Price != 450000 || report.Anomalies[0].Score != 274.86 {
+		t.Fatalf("anomali = %+v, ingin hanya 450000 skor 274.86", report.Anomalies)
+	}
+
+	// Ambang lebih rendah ikut menandai harga yang sedikit menyimpang, dengan skor bertanda
+	report = DetectPriceAnomalies(series, 1)
+	if len(report.Anomalies) != 2 || report.Anomalies[0].Price.Price != 41000 || report.Anomalies[0].Score != -1.01 {
+		t.Fatalf("anomali ambang 1 = %+v", report.Anomalies)
+	}
+}
+
+func TestDetectPriceAnomaliesEdgeCases(t *testing.T) {
+	prices := func(values ...float64) []Price {
+		return Map(values, func(v float64) Price { return testPrice("Jember", v, "2026-03-01") })
+	}
+
+	short := DetectPriceAnomalies(prices(41000, 410000, 42000), defaultAnomalyThreshold)
+	if short.Anomalies == nil || len(short.Anomalies) != 0 || short.Note == "" {
+		t.Fatalf("seri pendek = %+v, ingin daftar kosong + note", short)
+	}
+
+	// Mayoritas identik: MAD 0, skor jatuh ke simpangan rata-rata absolut
+	flat := DetectPriceAnomalies(prices(42000, 42000, 42000, 42000, 42000, 420000), defaultAnomalyThreshold)
+	if flat.MAD != 0 || flat.Note == "" || len(flat.Anomalies) != 1 || flat.Anomalies[0].Price.Price != 420000 {
+		t.Fatalf("MAD 0 = %+v", flat)
+	}
+
+	same := DetectPriceAnomalies(prices(42000, 42000, 42000, 42000, 42000), defaultAnomalyThreshold)
+	if len(same.Anomalies) != 0 || same.Note == "" {
+		t.Fatalf("harga identik = %+v", same)
+	}
+}
+
+func TestPriceAnomaliesHandler(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ps PriceStore, _ WeatherStore) {
+		mustAdd(t, ps,
+			testPrice("Jember", 41000, "2026-03-01"),
+			testPrice("Jember", 42000, "2026-03-02"),
+			testPrice("Jember", 43000, "2026-03-03"),
+			testPrice("Jember", 420000, "2026-03-04"),
+			testPrice("Jember", 42500, "2026-03-05"),
+			testPrice("Jember", 41500, "2026-03-06"),
+			testPrice("Malang", 38000, "2026-03-01"),
+			testPrice("Malang", 380000, "2026-03-02"),
+		)
+		flushWrites(t)
+
+		rec := serve(PriceAnomaliesHandler, http.MethodGet, "/harga/anomalies?region=Jember", "")
+		var report PriceAnomalyReport
+		decodeBody(t, rec, &report)
+		if rec.Code != http.StatusOK || report.Region != "Jember" || report.Points != 6 || report.Threshold != defaultAnomalyThreshold {
+			t.Fatalf("GET /harga/anomalies = %d %+v", rec.Code, report)
+		}
+		if len(report.Anomalies) != 1 || report.Anomalies[0].Price.Price != 420000 || report.Anomalies[0].Price.RecordedAt != "2026-03-04" {
+			t.Fatalf("anomali Jember = %+v, ingin hanya 420000", report.Anomalies)
+		}
+
+		// Malang hanya 2 harga: outlier tidak dinilai
+		rec = serve(PriceAnomaliesHandler, http.MethodGet, "/harga/anomalies?region=Malang", "")
+		report = PriceAnomalyReport{}
+		decodeBody(t, rec, &report)
+		if rec.Code != http.StatusOK || report.Anomalies == nil || len(report.Anomalies) != 0 || report.Note == "" {
+			t.Fatalf("seri pendek = %d %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	for _, threshold := range []string{"abc", "0", "-1", "101"} {
+		rec := serve(PriceAnomaliesHandler, http.MethodGet, "/harga/anomalies?region=Jember&threshold="+threshold, "")
+		var env struct{ Error APIError }
+		decodeBody(t, rec, &env)
+		if rec.Code != http.StatusBadRequest || env.Error.Code != "invalid_threshold" {
+			t.Fatalf("threshold=%s = %d %s", threshold, rec.Code, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ============================================
+// API ERROR ENVELOPE
+// Semua error dikirim ke client dengan bentuk yang sama:
+//   {"error": {"code": "...", "message": "...", "details": ...}}
+// ============================================
+
+type APIError struct {
+	Status  int         `json:"-"`
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+func NewAPIError(status int, code, message string) *APIError {
+	return &APIError{Status: status, Code: code, Message: message}
+}
+
+// WithDetails mengembalikan salinan error dengan informasi tambahan (mis. field yang salah)
+func (e *APIError) WithDetails(details interface{}) *APIError {
+	copied := *e
+	copied.Details = details
+	return &copied
+}
+
+// codeForStatus kode default dari status HTTP: 404 -> "not_found"
+func codeForStatus(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ReplaceAll(strings.ToLower(text), " ", "_")
+}
+
+type apiErrorEnvelope struct {
+	Error *APIError `json:"error"`
+}
+
+func writeAPIError(w http.ResponseWriter, apiErr *APIError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(apiErr.Status)
+	json.NewEncoder(w).Encode(apiErrorEnvelope{Error: apiErr})
+}
+
+// asAPIError membungkus error biasa menjadi 500 internal_error
+func asAPIError(err error) *APIError {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	return NewAPIError(http.StatusInternalServerError, "internal_error", err.Error())
+}
+
+// weatherAPIError membedakan salah konfigurasi (503) dari kegagalan fetch cuaca
+func weatherAPIError(err error) *APIError {
+	if errors.Is(err, ErrMissingAPIKey) {
+		return errWeatherNotConfigured
+	}
+	if errors.Is(err, ErrCircuitOpen) {
+		return errWeatherRateLimited
+	}
+	return errWeatherUnavailable
+}
+
+// Error yang dipakai di banyak handler/middleware
+var (
+	errAPIKeyRequired       = NewAPIError(http.StatusUnauthorized, "missing_api_key", "API key diperlukan")
+	errAPIKeyInvalid        = NewAPIError(http.StatusForbidden, "invalid_api_key", "API key tidak valid")
+	errBodyTooLarge         = NewAPIError(http.StatusRequestEntityTooLarge, "body_too_large", "Request body terlalu besar")
+	errInvalidBody          = NewAPIError(http.StatusBadRequest, "invalid_body", "Request body tidak valid")
+	errWeatherUnavailable   = NewAPIError(http.StatusInternalServerError, "weather_unavailable", "Gagal mengambil data cuaca")
+	errWeatherNotConfigured = NewAPIError(http.StatusServiceUnavailable, "weather_not_configured",
+		"Layanan cuaca belum dikonfigurasi: operator perlu mengisi OWM_API_KEY")
+	errWeatherRateLimited = NewAPIError(http.StatusServiceUnavailable, "weather_rate_limited",
+		"OpenWeatherMap sedang membatasi request, coba lagi beberapa saat")
+	errRequestTimeout = NewAPIError(http.StatusGatewayTimeout, "timeout", "Request melebihi batas waktu")
+)
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// errorEnvelopeKeys key level atas & key objek error dari response JSON
+func errorEnvelopeKeys(t *testing.T, rec *httptest.ResponseRecorder) (top, inner []string) {
+	t.Helper()
+	var raw map[string]map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &raw); err != nil {
+		t.Fatalf("body bukan envelope error (%v): %s", err, rec.Body.String())
+	}
+	for k, v := range raw {
+		top = append(top, k)
+		for ik := range v {
+			inner = append(inner, ik)
+		}
+	}
+	sort.Strings(inner)
+	return top, inner
+}
+
+func TestErrorEnvelopeShape(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+	prevKeys := apiKeys
+	apiKeys = []string{"rahasia"}
+	t.Cleanup(func() { apiKeys = prevKeys })
+
+	route := func(handler http.HandlerFunc, middleware ...MiddlewareFunc) HandlerFunc {
+		return routeHandler(Route{Pattern: "/uji", Handler: handler, Methods: []string{http.MethodPost}, Middleware: middleware})
+	}
+	tests := []struct {
+		name     string
+		handler  HandlerFunc
+		method   string
+		status   int
+		code     string
+		wantKeys []string
+	}{
+		{"method salah", route(AddPriceHandler), http.MethodGet, http.StatusMethodNotAllowed, "method_not_allowed", []string{"code", "message"}},
+		{"tanpa API key", route(AddPriceHandler, withAPIKey), http.MethodPost, http.StatusUnauthorized, "missing_api_key", []string{"code", "message"}},
+		{"error biasa", route(func(w http.ResponseWriter, r *http.Request) {
+			withErrorHandling(func(http.ResponseWriter, *http.Request) error { return errors.New("disk penuh") })(w, r)
+		}), http.MethodPost, http.StatusInternalServerError, "internal_error", []string{"code", "message"}},
+		{"panic", route(func(http.ResponseWriter, *http.Request) { panic("rusak") }),
+			http.MethodPost, http.StatusInternalServerError, "internal_server_error", []string{"code", "message"}},
+		{"dengan details", route(BatchRecommendationHandler), http.MethodPost, http.StatusBadRequest, "invalid_body", []string{"code", "details", "message"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.handler(rec, httptest.NewRequest(tt.method, "/uji", nil))
+
+			top, inner := errorEnvelopeKeys(t, rec)
+			if rec.Code != tt.status || rec.Header().Get("Content-Type") != "application/json" {
+				t.Fatalf("status = %d (%s), ingin %d JSON", rec.Code, rec.Header().Get("Content-Type"), tt.status)
+			}
+			if !reflect.DeepEqual(top, []string{"error"}) || !reflect.DeepEqual(inner, tt.wantKeys) {
+				t.Fatalf("envelope = %v %v, ingin [error] %v: %s", top, inner, tt.wantKeys, rec.Body.String())
+			}
+			var env struct{ Error APIError }
+			decodeBody(t, rec, &env)
+			if env.Error.Code != tt.code || env.Error.Message == "" {
+				t.Fatalf("error = %+v, ingin kode %q", env.Error, tt.code)
+			}
+		})
+	}
+}
+
+func TestAPIErrorHelpers(t *testing.T) {
+	details := errInvalidBody.WithDetails("field price wajib")
+	if errInvalidBody.Details != nil || details.Details != "field price wajib" || details.Code != errInvalidBody.Code {
+		t.Fatalf("WithDetails mengubah error asal: %+v / %+v", errInvalidBody, details)
+	}
+
+	wrapped := fmt.Errorf("handler: %w", errBodyTooLarge)
+	if got := asAPIError(wrapped); got != errBodyTooLarge {
+		t.Fatalf("asAPIError(wrapped) = %+v", got)
+	}
+	if got := asAPIError(errors.New("boom")); got.Status != http.StatusInternalServerError || got.Code != "internal_error" {
+		t.Fatalf("asAPIError(boom) = %+v", got)
+	}
+
+	weather := map[error]*APIError{
+		ErrMissingAPIKey:                      errWeatherNotConfigured,
+		fmt.Errorf("owm: %w", ErrCircuitOpen): errWeatherRateLimited,
+		errors.New("dial tcp: i/o timeout"):   errWeatherUnavailable,
+	}
+	for err, want := range weather {
+		if got := weatherAPIError(err); got != want {
+			t.Fatalf("weatherAPIError(%v) = %s, ingin %s", err, got.Code, want.Code)
+		}
+	}
+
+	if got := codeForStatus(http.StatusNotFound); got != "not_found" {
+		t.Fatalf("codeForStatus(404) = %q", got)
+	}
+}
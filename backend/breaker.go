@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// ============================================
+// CIRCUIT BREAKER
+// Setelah Threshold kegagalan beruntun, panggilan ke upstream langsung ditolak
+// selama Cooldown (open). Setelah itu satu panggilan percobaan diizinkan
+// (half-open): sukses menutup breaker, gagal membukanya lagi.
+// ============================================
+
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// ErrCircuitOpen dikembalikan Allow selama breaker open (atau probe half-open sedang berjalan)
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// BreakerConfig ambang & durasi cooldown; Threshold 0 = breaker nonaktif
+type BreakerConfig struct {
+	Threshold int
+	Cooldown  time.Duration
+}
+
+type CircuitBreaker struct {
+	name string
+	cfg  BreakerConfig
+	now  func() time.Time
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func NewCircuitBreaker(name string, cfg BreakerConfig) *CircuitBreaker {
+	return &CircuitBreaker{name: name, cfg: cfg, now: time.Now, state: BreakerClosed}
+}
+
+// Allow menolak panggilan selama open; saat cooldown habis, satu panggilan menjadi probe
+func (b *CircuitBreaker) Allow() error {
+	if b.cfg.Threshold <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		remaining := b.cfg.Cooldown - b.now().Sub(b.openedAt)
+		if remaining > 0 {
+			return fmt.Errorf("%s: %w (coba lagi dalam %s)", b.name, ErrCircuitOpen, remaining.Round(time.Second))
+		}
+		b.state = BreakerHalfOpen
+		log.Printf("⚡ Circuit breaker %s half-open, mencoba satu request", b.name)
+		fallthrough
+	case BreakerHalfOpen:
+		if b.probing {
+			return fmt.Errorf("%s: %w (probe sedang berjalan)", b.name, ErrCircuitOpen)
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Success menutup breaker dan mereset hitungan kegagalan
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state != BreakerClosed {
+		log.Printf("✓ Circuit breaker %s tertutup kembali", b.name)
+	}
+	b.state, b.failures, b.probing = BreakerClosed, 0, false
+}
+
+// Failure menghitung kegagalan; breaker terbuka saat ambang tercapai atau probe gagal
+func (b *CircuitBreaker) Failure() {
+	if b.cfg.Threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.cfg.Threshold {
+		if b.state != BreakerOpen {
+			log.Printf("⚡ Circuit breaker %s terbuka setelah %d kegagalan, cooldown %s", b.name, b.failures, b.cfg.Cooldown)
+		}
+		b.state, b.openedAt, b.probing = BreakerOpen, b.now(), false
+	}
+}
+
+// Ignore untuk hasil yang tidak relevan bagi breaker (mis. error jaringan lokal):
+// hanya melepas slot probe half-open tanpa mengubah state
+func (b *CircuitBreaker) Ignore() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+type BreakerSnapshot struct {
+	Name      string       `json:"name"`
+	State     BreakerState `json:"state"`
+	Failures  int          `json:"consecutive_failures"`
+	Threshold int          `json:"threshold"`
+	OpenedAt  *time.Time   `json:"opened_at,omitempty"`
+	RetryIn   string       `json:"retry_in,omitempty"`
+}
+
+func (b *CircuitBreaker) Snapshot() BreakerSnapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	snap := BreakerSnapshot{Name: b.name, State: b.state, Failures: b.failures, Threshold: b.cfg.Threshold}
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		snap.OpenedAt = &openedAt
+	}
+	if b.state == BreakerOpen {
+		if remaining := b.cfg.Cooldown - b.now().Sub(b.openedAt); remaining > 0 {
+			snap.RetryIn = remaining.Round(time.Second).String()
+		}
+	}
+	return snap
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestBreaker breaker dengan jam palsu; advance memajukan jam
+func newTestBreaker(name string, cfg BreakerConfig) (b *CircuitBreaker, advance func(time.Duration)) {
+	now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	b = NewCircuitBreaker(name, cfg)
+	b.now = func() time.Time { return now }
+	return b, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestCircuitBreakerOpensAndCloses(t *testing.T) {
+	b, advance := newTestBreaker("test", BreakerConfig{Threshold: 3, Cooldown: time.Minute})
+
+	// Sukses di tengah mereset hitungan kegagalan beruntun
+	b.Failure()
+	b.Failure()
+	b.Success()
+	b.Failure()
+	b.Failure()
+	if s := b.Snapshot(); s.State != BreakerClosed || s.Failures != 2 {
+		t.Fatalf("setelah 2 kegagalan = %+v, ingin closed", s)
+	}
+
+	b.Failure()
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Allow saat open = %v, ingin ErrCircuitOpen", err)
+	}
+	if s := b.Snapshot(); s.State != BreakerOpen || s.RetryIn != "1m0s" || s.OpenedAt == nil {
+		t.Fatalf("snapshot open = %+v", s)
+	}
+
+	// Cooldown habis: satu probe, request lain tetap ditolak
+	advance(time.Minute)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe half-open ditolak: %v", err)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("request kedua saat probe = %v, ingin ErrCircuitOpen", err)
+	}
+	if s := b.Snapshot(); s.State != BreakerHalfOpen {
+		t.Fatalf("state = %s, ingin half_open", s.State)
+	}
+
+	// Probe gagal: langsung open lagi tanpa menunggu ambang
+	b.Failure()
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Allow setelah probe gagal = %v", err)
+	}
+
+	advance(time.Minute)
+	if err := b.Allow(); err != nil {
+		t.Fatal(err)
+	}
+	b.Success()
+	if s := b.Snapshot(); s.State != BreakerClosed || s.Failures != 0 || s.OpenedAt != nil {
+		t.Fatalf("setelah probe sukses = %+v, ingin closed", s)
+	}
+}
+
+func TestCircuitBreakerIgnoreReleasesProbe(t *testing.T) {
+	b, advance := newTestBreaker("test", BreakerConfig{Threshold: 1, Cooldown: time.Second})
+	b.Failure()
+	advance(time.Second)
+	if err := b.Allow(); err != nil {
+		t.Fatal(err)
+	}
+	// Error jaringan lokal: slot probe dilepas, state tetap half-open
+	b.Ignore()
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe berikutnya ditolak: %v", err)
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	b, _ := newTestBreaker("test", BreakerConfig{Threshold: 0})
+	for i := 0; i < 10; i++ {
+		b.Failure()
+	}
+	if err := b.Allow(); err != nil || b.Snapshot().State != BreakerClosed {
+		t.Fatalf("breaker nonaktif = %v, %+v", err, b.Snapshot())
+	}
+}
+
+func TestOWMBreakerSharedAcrossRegions(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+	var calls atomic.Int32
+	var limited atomic.Bool
+	limited.Store(true)
+	useOWMServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if limited.Load() {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(owmTestBody))
+	})
+	var advance func(time.Duration)
+	owmBreaker, advance = newTestBreaker("openweathermap", BreakerConfig{Threshold: 2, Cooldown: time.Minute})
+
+	// Retry ke-2 membuka breaker; percobaan ke-3 tidak sampai ke OWM
+	if _, err := FetchWeather("Jember"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("FetchWeather = %v, ingin ErrCircuitOpen", err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("request ke OWM = %d, ingin 2", calls.Load())
+	}
+
+	// Semua region lain langsung gagal tanpa memanggil OWM
+	if got := FetchMultipleRegionsWeather([]string{"Malang", "Surabaya", "Banyuwangi"}); len(got) != 0 {
+		t.Fatalf("hasil saat breaker open = %v", got)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("request ke OWM saat breaker open = %d, ingin tetap 2", calls.Load())
+	}
+
+	rec := serve(ReadinessHandler, http.MethodGet, "/ready", "")
+	var ready struct {
+		Status string
+		Checks struct {
+			Breaker BreakerSnapshot `json:"weather_rate_limit_breaker"`
+		}
+	}
+	decodeBody(t, rec, &ready)
+	if ready.Status != "degraded" || ready.Checks.Breaker.State != BreakerOpen {
+		t.Fatalf("GET /ready = %s", rec.Body.String())
+	}
+
+	// Setelah cooldown OWM pulih: probe sukses menutup breaker
+	limited.Store(false)
+	advance(time.Minute)
+	if _, err := FetchWeather("Jember"); err != nil {
+		t.Fatalf("FetchWeather setelah cooldown: %v", err)
+	}
+	if s := owmBreaker.Snapshot(); s.State != BreakerClosed {
+		t.Fatalf("breaker = %s, ingin closed", s.State)
+	}
+}
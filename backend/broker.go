@@ -0,0 +1,72 @@
+package main
+
+import (
+	"log"
+	"sync"
+)
+
+// ============================================
+// PUB/SUB BROKER - LIVE PRICE UPDATES
+// Broker in-process untuk menyebarkan harga baru ke semua subscriber (SSE)
+// ============================================
+
+// PriceBroker menyimpan satu buffered channel per client
+type PriceBroker struct {
+	mu         sync.RWMutex
+	clients    map[chan Price]struct{}
+	bufferSize int
+}
+
+func NewPriceBroker(bufferSize int) *PriceBroker {
+	return &PriceBroker{
+		clients:    make(map[chan Price]struct{}),
+		bufferSize: bufferSize,
+	}
+}
+
+// Subscribe mendaftarkan client baru dan mengembalikan channel miliknya
+func (b *PriceBroker) Subscribe() chan Price {
+	ch := make(chan Price, b.bufferSize)
+
+	b.mu.Lock()
+	b.clients[ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch
+}
+
+// Unsubscribe menghapus client dan menutup channel-nya (aman dipanggil berkali-kali)
+func (b *PriceBroker) Unsubscribe(ch chan Price) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.clients[ch]; ok {
+		delete(b.clients, ch)
+		close(ch)
+	}
+}
+
+// Publish mengirim harga ke semua client tanpa blocking.
+// Client yang lambat (buffer penuh) akan melewatkan event ini.
+func (b *PriceBroker) Publish(p Price) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.clients {
+		select {
+		case ch <- p:
+		default:
+			log.Printf("⚠️  SSE client buffer penuh, event harga %s dilewati", p.Region)
+		}
+	}
+}
+
+// ClientCount jumlah subscriber aktif
+func (b *PriceBroker) ClientCount() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.clients)
+}
+
+// Broker global yang dipakai oleh semua insert site
+var priceBroker = NewPriceBroker(16)
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============================================
+// KONFIGURASI TERPUSAT
+// Semua environment variable dibaca sekali di sini saat startup,
+// lalu subset-nya diteruskan ke komponen yang membutuhkan
+// ============================================
+
+// WeatherConfig subset konfigurasi untuk provider cuaca (OWM)
+type WeatherConfig struct {
+	APIKey  string
+	BaseURL string // tanpa trailing slash, mis. https://api.openweathermap.org/data/2.5
+	Timeout time.Duration
+	// RateLimitBreaker dibuka setelah Threshold respons 429 beruntun dari OWM
+	RateLimitBreaker BreakerConfig
+	// RetryAttempts total percobaan (1 = tanpa retry) untuk error jaringan, 5xx & 429;
+	// jeda awal RetryBaseDelay lalu berlipat dua (+ jitter) tiap percobaan
+	RetryAttempts  int
+	RetryBaseDelay time.Duration
+}
+
+// DBConfig subset konfigurasi untuk SQLite
+type DBConfig struct {
+	Path        string
+	SchemaPath  string
+	BusyTimeout time.Duration // lama menunggu lock sebelum SQLITE_BUSY
+	JournalMode string        // salah satu validJournalModes
+}
+
+// validJournalModes mode journal SQLite yang diizinkan (lowercase)
+var validJournalModes = []string{"wal", "delete", "truncate", "persist", "memory", "off"}
+
+// TLSConfig sertifikat untuk listen HTTPS langsung (tanpa reverse proxy)
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+}
+
+// Enabled true jika cert & key diset; selain itu server memakai HTTP biasa
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" && t.KeyFile != ""
+}
+
+// WeatherHistoryConfig scheduler pengumpulan sampel cuaca untuk grafik tren
+type WeatherHistoryConfig struct {
+	Interval time.Duration // 0 = nonaktif
+	Regions  []string
+}
+
+type Config struct {
+	Port           string
+	DB             DBConfig
+	Weather        WeatherConfig
+	HTTP           HTTPClientConfig // transport bersama untuk OWM & scraper
+	TLS            TLSConfig
+	LogLevel       string
+	CORSOrigins    []string // juga dipakai untuk Origin upgrade websocket
+	APIKeys        []string
+	MaxBodyBytes   int64
+	WSPushInterval time.Duration
+	PriceSim       PriceSimulationConfig
+	ScrapeMode     ScrapeMode
+	ScrapeInterval time.Duration // 0 = scheduler scraping nonaktif
+	ScrapeBreaker  BreakerConfig // circuit breaker scraper BAPPEBTI
+	WeatherHistory WeatherHistoryConfig
+	RegionAliases  map[string]string
+	// PersistPriceFallback simpan harga hasil fallback /harga/current ke DB
+	PersistPriceFallback bool
+
+	// BAPPEBTICommodities komoditas yang di-scrape dari BAPPEBTI (satu halaman per komoditas)
+	BAPPEBTICommodities []string
+
+	Retention RetentionConfig
+	// PriceStore backend penyimpanan harga & weather history, salah satu validPriceStores
+	PriceStore string
+
+	// ScraperUserAgent dikirim pada semua request scraper
+	ScraperUserAgent string
+	// ScraperPoliteDelay jeda antar request scraper ke situs yang sama (0 = tanpa jeda)
+	ScraperPoliteDelay time.Duration
+}
+
+var validLogLevels = []string{"debug", "info", "warn", "error"}
+
+func DefaultConfig() Config {
+	return Config{
+		Port: "8080",
+		DB: DBConfig{
+			Path:        "tobacco.db",
+			SchemaPath:  "../sql/schema.sql",
+			BusyTimeout: 5 * time.Second,
+			JournalMode: "wal",
+		},
+		Weather: WeatherConfig{
+			BaseURL:          defaultOWMBaseURL,
+			Timeout:          10 * time.Second,
+			RateLimitBreaker: defaultOWMBreakerConfig,
+			RetryAttempts:    defaultOWMRetryAttempts,
+			RetryBaseDelay:   defaultOWMRetryBaseDelay,
+		},
+		HTTP:           DefaultHTTPClientConfig(),
+		LogLevel:       "info",
+		CORSOrigins:    []string{"*"},
+		MaxBodyBytes:   defaultMaxBodyBytes,
+		WSPushInterval: defaultWSPushInterval,
+		PriceSim:       DefaultPriceSimulationConfig(),
+		ScrapeMode:     ScrapeModeFirstSuccess,
+		ScrapeBreaker:  defaultBAPPEBTIBreakerConfig,
+		WeatherHistory: WeatherHistoryConfig{
+			Regions: defaultMultiRegions,
+		},
+		BAPPEBTICommodities: defaultBAPPEBTICommodities,
+		Retention: RetentionConfig{
+			WeatherDays: defaultRetentionWeatherDays,
+			BatchSize:   defaultRetentionBatchSize,
+		},
+		PriceStore:         priceStoreSQLite,
+		ScraperUserAgent:   defaultScraperUserAgent,
+		ScraperPoliteDelay: defaultScraperPoliteDelay,
+	}
+}
+
+// splitList memecah "a, b,c" menjadi []string tanpa elemen kosong
+func splitList(raw string) []string {
+	return Filter(Map(strings.Split(raw, ","), strings.TrimSpace), func(s string) bool {
+		return s != ""
+	})
+}
+
+// configLoader membantu parsing env sambil mengumpulkan semua error sekaligus
+type configLoader struct {
+	getenv func(string) string
+	errs   []error
+}
+
+func (l *configLoader) string(key string, target *string) {
+	if v := strings.TrimSpace(l.getenv(key)); v != "" {
+		*target = v
+	}
+}
+
+func (l *configLoader) duration(key string, target *time.Duration) {
+	raw := l.getenv(key)
+	if raw == "" {
+		return
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		l.errs = append(l.errs, fmt.Errorf("%s harus durasi positif (mis. \"30s\"), didapat %q", key, raw))
+		return
+	}
+	*target = d
+}
+
+func (l *configLoader) int64(key string, target *int64) {
+	raw := l.getenv(key)
+	if raw == "" {
+		return
+	}
+	v, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || v <= 0 {
+		l.errs = append(l.errs, fmt.Errorf("%s harus bilangan bulat positif, didapat %q", key, raw))
+		return
+	}
+	*target = v
+}
+
+func (l *configLoader) int(key string, target *int) {
+	raw := l.getenv(key)
+	if raw == "" {
+		return
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v < 0 {
+		l.errs = append(l.errs, fmt.Errorf("%s harus bilangan bulat >= 0, didapat %q", key, raw))
+		return
+	}
+	*target = v
+}
+
+func (l *configLoader) float(key string, target *float64) {
+	raw := l.getenv(key)
+	if raw == "" {
+		return
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || v < 0 {
+		l.errs = append(l.errs, fmt.Errorf("%s harus angka >= 0, didapat %q", key, raw))
+		return
+	}
+	*target = v
+}
+
+func (l *configLoader) bool(key string, target *bool) {
+	raw := l.getenv(key)
+	if raw == "" {
+		return
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s harus true/false, didapat %q", key, raw))
+		return
+	}
+	*target = v
+}
+
+func (l *configLoader) list(key string, target *[]string) {
+	if raw := l.getenv(key); raw != "" {
+		*target = splitList(raw)
+	}
+}
+
+func (l *configLoader) require(key, value string) {
+	if value == "" {
+		l.errs = append(l.errs, fmt.Errorf("%s wajib diisi", key))
+	}
+}
+
+// LoadConfig membaca konfigurasi dari environment. Semua nilai yang hilang
+// atau tidak valid dikumpulkan menjadi satu error.
+func LoadConfig(getenv func(string) string) (Config, error) {
+	cfg := DefaultConfig()
+	l := &configLoader{getenv: getenv}
+
+	l.string("PORT", &cfg.Port)
+	l.string("DB_PATH", &cfg.DB.Path)
+	l.string("SCHEMA_PATH", &cfg.DB.SchemaPath)
+	l.duration("DB_BUSY_TIMEOUT", &cfg.DB.BusyTimeout)
+	l.string("DB_JOURNAL_MODE", &cfg.DB.JournalMode)
+	cfg.Weather.APIKey = strings.TrimSpace(getenv("OWM_API_KEY"))
+	l.string("OWM_BASE_URL", &cfg.Weather.BaseURL)
+	l.duration("WEATHER_TIMEOUT", &cfg.Weather.Timeout)
+	l.int("OWM_BREAKER_THRESHOLD", &cfg.Weather.RateLimitBreaker.Threshold)
+	l.duration("OWM_BREAKER_COOLDOWN", &cfg.Weather.RateLimitBreaker.Cooldown)
+	l.int("OWM_RETRY_ATTEMPTS", &cfg.Weather.RetryAttempts)
+	l.duration("OWM_RETRY_BASE_DELAY", &cfg.Weather.RetryBaseDelay)
+	l.duration("HTTP_TIMEOUT", &cfg.HTTP.Timeout)
+	l.duration("HTTP_DIAL_TIMEOUT", &cfg.HTTP.DialTimeout)
+	l.duration("HTTP_IDLE_CONN_TIMEOUT", &cfg.HTTP.IdleConnTimeout)
+	l.int("HTTP_MAX_IDLE_CONNS_PER_HOST", &cfg.HTTP.MaxIdleConnsPerHost)
+	l.string("TLS_CERT", &cfg.TLS.CertFile)
+	l.string("TLS_KEY", &cfg.TLS.KeyFile)
+	l.string("LOG_LEVEL", &cfg.LogLevel)
+	l.list("CORS_ORIGINS", &cfg.CORSOrigins)
+	l.list("API_KEYS", &cfg.APIKeys)
+	l.int64("MAX_BODY_BYTES", &cfg.MaxBodyBytes)
+	l.duration("WS_PUSH_INTERVAL", &cfg.WSPushInterval)
+	l.list("SIM_PRICE_REGIONS", &cfg.PriceSim.Regions)
+	l.float("SIM_PRICE_BASE", &cfg.PriceSim.BasePrice)
+	l.float("SIM_PRICE_VARIANCE", &cfg.PriceSim.Variance)
+	l.bool("SIM_PRICE_SEED_FROM_HISTORY", &cfg.PriceSim.SeedFromHistory)
+
+	l.duration("SCRAPE_INTERVAL", &cfg.ScrapeInterval)
+	l.int("BAPPEBTI_BREAKER_THRESHOLD", &cfg.ScrapeBreaker.Threshold)
+	l.duration("BAPPEBTI_BREAKER_COOLDOWN", &cfg.ScrapeBreaker.Cooldown)
+	l.list("BAPPEBTI_COMMODITIES", &cfg.BAPPEBTICommodities)
+	l.duration("WEATHER_HISTORY_INTERVAL", &cfg.WeatherHistory.Interval)
+	l.list("WEATHER_HISTORY_REGIONS", &cfg.WeatherHistory.Regions)
+	l.bool("PRICE_FALLBACK_PERSIST", &cfg.PersistPriceFallback)
+	l.duration("RETENTION_INTERVAL", &cfg.Retention.Interval)
+	l.int("RETENTION_WEATHER_DAYS", &cfg.Retention.WeatherDays)
+	l.int("RETENTION_PRICE_DAYS", &cfg.Retention.PriceDays)
+	l.int("RETENTION_BATCH_SIZE", &cfg.Retention.BatchSize)
+	l.string("PRICE_STORE", &cfg.PriceStore)
+	l.string("SCRAPER_USER_AGENT", &cfg.ScraperUserAgent)
+	l.duration("SCRAPER_POLITE_DELAY", &cfg.ScraperPoliteDelay)
+
+	if raw := getenv("SCRAPE_MODE"); raw != "" {
+		mode, err := ParseScrapeMode(raw)
+		if err != nil {
+			l.errs = append(l.errs, fmt.Errorf("SCRAPE_MODE: %w", err))
+		} else {
+			cfg.ScrapeMode = mode
+		}
+	}
+
+	if raw := getenv("REGION_ALIASES"); raw != "" {
+		aliases, err := parseRegionAliases(raw)
+		if err != nil {
+			l.errs = append(l.errs, fmt.Errorf("REGION_ALIASES: %w", err))
+		} else {
+			cfg.RegionAliases = aliases
+		}
+	}
+
+	l.require("PORT", cfg.Port)
+	l.require("DB_PATH", cfg.DB.Path)
+	l.require("SCHEMA_PATH", cfg.DB.SchemaPath)
+	l.require("SCRAPER_USER_AGENT", strings.TrimSpace(cfg.ScraperUserAgent))
+
+	if port, err := strconv.Atoi(cfg.Port); err != nil || port < 1 || port > 65535 {
+		l.errs = append(l.errs, fmt.Errorf("PORT harus 1-65535, didapat %q", cfg.Port))
+	}
+
+	cfg.DB.JournalMode = strings.ToLower(cfg.DB.JournalMode)
+	if len(Filter(validJournalModes, func(m string) bool { return m == cfg.DB.JournalMode })) == 0 {
+		l.errs = append(l.errs, fmt.Errorf("DB_JOURNAL_MODE harus salah satu dari %s, didapat %q",
+			strings.Join(validJournalModes, "/"), cfg.DB.JournalMode))
+	}
+
+	cfg.Weather.BaseURL = strings.TrimRight(cfg.Weather.BaseURL, "/")
+	if u, err := url.Parse(cfg.Weather.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		l.errs = append(l.errs, fmt.Errorf("OWM_BASE_URL harus URL http(s) absolut, didapat %q", cfg.Weather.BaseURL))
+	}
+
+	if cfg.Weather.RetryAttempts < 1 {
+		l.errs = append(l.errs, fmt.Errorf("OWM_RETRY_ATTEMPTS harus >= 1, didapat %d", cfg.Weather.RetryAttempts))
+	}
+
+	if cfg.HTTP.MaxIdleConnsPerHost < 1 {
+		l.errs = append(l.errs, fmt.Errorf("HTTP_MAX_IDLE_CONNS_PER_HOST harus >= 1, didapat %d", cfg.HTTP.MaxIdleConnsPerHost))
+	}
+
+	if (cfg.TLS.CertFile == "") != (cfg.TLS.KeyFile == "") {
+		l.errs = append(l.errs, errors.New("TLS_CERT dan TLS_KEY harus diset bersamaan"))
+	}
+
+	cfg.LogLevel = strings.ToLower(cfg.LogLevel)
+	if len(Filter(validLogLevels, func(lv string) bool { return lv == cfg.LogLevel })) == 0 {
+		l.errs = append(l.errs, fmt.Errorf("LOG_LEVEL harus salah satu dari %s, didapat %q",
+			strings.Join(validLogLevels, "/"), cfg.LogLevel))
+	}
+
+	if len(cfg.CORSOrigins) == 0 {
+		l.errs = append(l.errs, errors.New("CORS_ORIGINS tidak boleh kosong"))
+	}
+
+	if cfg.WeatherHistory.Interval > 0 && len(cfg.WeatherHistory.Regions) == 0 {
+		l.errs = append(l.errs, errors.New("WEATHER_HISTORY_REGIONS tidak boleh kosong jika WEATHER_HISTORY_INTERVAL diset"))
+	}
+
+	if cfg.Retention.WeatherDays < 1 {
+		l.errs = append(l.errs, fmt.Errorf("RETENTION_WEATHER_DAYS harus >= 1, didapat %d", cfg.Retention.WeatherDays))
+	}
+	if cfg.Retention.PriceDays < 0 {
+		l.errs = append(l.errs, fmt.Errorf("RETENTION_PRICE_DAYS harus >= 0 (0 = nonaktif), didapat %d", cfg.Retention.PriceDays))
+	}
+	if cfg.Retention.BatchSize < 1 {
+		l.errs = append(l.errs, fmt.Errorf("RETENTION_BATCH_SIZE harus >= 1, didapat %d", cfg.Retention.BatchSize))
+	}
+
+	cfg.PriceStore = strings.ToLower(cfg.PriceStore)
+	if len(Filter(validPriceStores, func(s string) bool { return s == cfg.PriceStore })) == 0 {
+		l.errs = append(l.errs, fmt.Errorf("PRICE_STORE harus salah satu dari %s, didapat %q",
+			strings.Join(validPriceStores, "/"), cfg.PriceStore))
+	}
+
+	if len(cfg.BAPPEBTICommodities) == 0 {
+		l.errs = append(l.errs, errors.New("BAPPEBTI_COMMODITIES tidak boleh kosong"))
+	}
+
+	if len(cfg.PriceSim.Regions) == 0 {
+		l.errs = append(l.errs, errors.New("SIM_PRICE_REGIONS tidak boleh kosong"))
+	}
+	if cfg.PriceSim.BasePrice <= 0 {
+		l.errs = append(l.errs, errors.New("SIM_PRICE_BASE harus > 0"))
+	}
+	if cfg.PriceSim.Variance >= cfg.PriceSim.BasePrice {
+		l.errs = append(l.errs, errors.New("SIM_PRICE_VARIANCE harus lebih kecil dari SIM_PRICE_BASE"))
+	}
+
+	if len(l.errs) > 0 {
+		return cfg, fmt.Errorf("konfigurasi tidak valid:\n%w", errors.Join(l.errs...))
+	}
+	return cfg, nil
+}
+
+// ============================================
+// LOG LEVEL
+// ============================================
+
+var logLevel = "info"
+
+// logDebugf hanya menulis log ketika LOG_LEVEL=debug
+func logDebugf(format string, args ...interface{}) {
+	if logLevel == "debug" {
+		log.Printf(format, args...)
+	}
+}
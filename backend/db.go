@@ -2,16 +2,20 @@ package main
 
 import (
     "database/sql"
+    "fmt"
     "log"
+    "net/url"
     "os"
+    "strings"
+    "time"
 
     _ "modernc.org/sqlite"
 )
 
 var DB *sql.DB
 
-func InitDB() {
-    dbPath := "tobacco.db"
+func InitDB(cfg DBConfig) {
+    dbPath := cfg.Path
 
     // Cek apakah file DB sudah ada
     if _, err := os.Stat(dbPath); os.IsNotExist(err) {
@@ -27,7 +31,7 @@ func InitDB() {
 
     // Koneksi ke SQLite dengan parameter anti-lock
     // PENTING: tambahkan query parameters untuk mengatasi database locking
-    database, err := sql.Open("sqlite", dbPath+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+    database, err := sql.Open("sqlite", sqliteDSN(cfg))
     if err != nil {
         log.Fatal("Gagal membuka database:", err)
     }
@@ -41,17 +45,298 @@ func InitDB() {
     }
 
     log.Println("Database terhubung:", dbPath)
+    logEffectivePragmas(database)
 
     // Jalankan schema.sql
-    schema, err := os.ReadFile("../sql/schema.sql")
+    schema, err := os.ReadFile(cfg.SchemaPath)
     if err != nil {
         log.Fatal("Gagal membaca schema.sql:", err)
     }
 
-    if _, err := database.Exec(string(schema)); err != nil {
+    if err := applySchema(database, string(schema)); err != nil {
         log.Fatal("Gagal menjalankan schema:", err)
     }
 
+    if err := migrateSchema(database); err != nil {
+        log.Fatal("Gagal migrasi schema:", err)
+    }
+
+    // Tidak fatal agar /ready tetap bisa melaporkan detailnya ke orchestrator
+    if err := VerifySchema(database); err != nil {
+        log.Printf("⚠️  %v", err)
+    }
+
     log.Println("Schema database OK")
     DB = database
-}
\ No newline at end of file
+
+    // Semua write async lewat satu goroutine writer
+    dbWriter = NewDBWriter(database, dbWriterQueueSize)
+}
+
+// sqliteDSN menyusun DSN dari config. Nilai pragma di-encode lewat url.Values
+// (bukan digabung mentah) dan journal mode sudah divalidasi di LoadConfig.
+func sqliteDSN(cfg DBConfig) string {
+    params := url.Values{}
+    params.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", cfg.BusyTimeout.Milliseconds()))
+    params.Add("_pragma", fmt.Sprintf("journal_mode(%s)", cfg.JournalMode))
+    return "file:" + (&url.URL{Path: cfg.Path}).EscapedPath() + "?" + params.Encode()
+}
+
+// logEffectivePragmas mencatat nilai pragma yang benar-benar aktif (SQLite bisa
+// menolak journal mode tertentu, mis. WAL di filesystem jaringan)
+func logEffectivePragmas(db *sql.DB) {
+    var busyTimeout int
+    var journalMode string
+    if err := db.QueryRow("PRAGMA busy_timeout").Scan(&busyTimeout); err != nil {
+        log.Printf("⚠️  Gagal membaca busy_timeout: %v", err)
+        return
+    }
+    if err := db.QueryRow("PRAGMA journal_mode").Scan(&journalMode); err != nil {
+        log.Printf("⚠️  Gagal membaca journal_mode: %v", err)
+        return
+    }
+    log.Printf("✓ SQLite pragma: busy_timeout=%dms, journal_mode=%s", busyTimeout, journalMode)
+}
+
+// columnMigration kolom yang ditambahkan setelah schema awal.
+// CREATE TABLE IF NOT EXISTS tidak menambah kolom ke tabel lama, jadi perlu ALTER.
+type columnMigration struct {
+    Table      string
+    Column     string
+    Definition string
+    Backfill   string // opsional: UPDATE untuk mengisi baris lama setelah kolom ditambahkan
+}
+
+var columnMigrations = []columnMigration{
+    {Table: "prices", Column: "price_min", Definition: "REAL"},
+    {Table: "prices", Column: "price_max", Definition: "REAL"},
+    {Table: "prices", Column: "source_type", Definition: "TEXT NOT NULL DEFAULT 'unknown'", Backfill: backfillPriceSourceType},
+    {Table: "prices", Column: "quality", Definition: "TEXT"},
+    {Table: "prices", Column: "source_name", Definition: "TEXT"},
+    {Table: "prices", Column: "source_url", Definition: "TEXT"},
+    {Table: "prices", Column: "scraped_at", Definition: "TEXT", Backfill: backfillPriceScrapeMetadata},
+}
+
+// backfillPriceSourceType klasifikasi best-effort dari teks source yang ditulis versi lama
+const backfillPriceSourceType = `UPDATE prices SET source_type = CASE
+        WHEN source LIKE 'BAPPEBTI%' THEN 'bappebti'
+        WHEN source LIKE 'News Portal%' THEN 'news'
+        WHEN source LIKE '%(Last checked:%' THEN 'research'
+        WHEN source LIKE 'Simulated Market Data%' THEN 'simulation'
+        WHEN source = 'CSV Import' THEN 'csv_import'
+        WHEN source LIKE 'manual%' THEN 'manual'
+        ELSE 'unknown'
+    END`
+
+// backfillPriceScrapeMetadata memecah source lama berformat "<sumber> (Scraped: <quality>)";
+// URL tidak pernah disimpan sehingga source_url baris lama tetap NULL
+const backfillPriceScrapeMetadata = `UPDATE prices SET
+        source_name = substr(source, 1, instr(source, ' (Scraped: ') - 1),
+        quality = substr(source, instr(source, ' (Scraped: ') + 11, length(source) - instr(source, ' (Scraped: ') - 11),
+        scraped_at = recorded_at
+    WHERE instr(source, ' (Scraped: ') > 0 AND source LIKE '%)'`
+
+// expectedTable kolom yang dibaca/ditulis aplikasi untuk satu tabel
+type expectedTable struct {
+    Name    string
+    Columns []string
+}
+
+var expectedSchema = []expectedTable{
+    {Name: "prices", Columns: []string{"id", "region", "price", "price_min", "price_max", "unit", "source", "source_type",
+        "quality", "source_name", "source_url", "scraped_at", "recorded_at", "created_at"}},
+    {Name: "weather_history", Columns: []string{"id", "region", "temp_c", "humidity", "rain_mm", "fetched_at", "created_at"}},
+}
+
+// SchemaDriftError daftar tabel/kolom yang hilang dari database
+type SchemaDriftError struct {
+    Missing []string `json:"missing"`
+}
+
+func (e *SchemaDriftError) Error() string {
+    return "schema database tidak sesuai, tidak ditemukan: " + strings.Join(e.Missing, ", ")
+}
+
+// VerifySchema memastikan semua tabel & kolom di expectedSchema ada.
+// Kolom yang hilang membuat Scan gagal diam-diam, jadi harus ketahuan sejak awal.
+func VerifySchema(db *sql.DB) error {
+    var missing []string
+    for _, table := range expectedSchema {
+        columns, err := tableColumns(db, table.Name)
+        if err != nil {
+            return err
+        }
+        if len(columns) == 0 {
+            missing = append(missing, "tabel "+table.Name)
+            continue
+        }
+        for _, column := range table.Columns {
+            if !columns[column] {
+                missing = append(missing, table.Name+"."+column)
+            }
+        }
+    }
+
+    if len(missing) > 0 {
+        return &SchemaDriftError{Missing: missing}
+    }
+    return nil
+}
+
+// tableColumns mengembalikan set nama kolom sebuah tabel
+func tableColumns(db *sql.DB, table string) (map[string]bool, error) {
+    rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    columns := make(map[string]bool)
+    for rows.Next() {
+        var (
+            cid       int
+            name      string
+            colType   string
+            notNull   int
+            dfltValue sql.NullString
+            pk        int
+        )
+        if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+            return nil, err
+        }
+        columns[name] = true
+    }
+    return columns, rows.Err()
+}
+
+// applySchema menjalankan schema.sql per statement. Objek yang sudah ada (schema lama
+// tanpa IF NOT EXISTS, mis. CREATE INDEX) tidak dianggap fatal supaya restart aman;
+// kolom baru tetap ditangani migrateSchema.
+// Catatan: statement dipisah per ";" - schema tidak boleh memakai trigger/BEGIN...END.
+func applySchema(db *sql.DB, schema string) error {
+    for _, stmt := range splitSQLStatements(schema) {
+        if _, err := db.Exec(stmt); err != nil {
+            if isAlreadyExistsError(err) {
+                log.Printf("ℹ️  Schema: %v (dilewati)", err)
+                continue
+            }
+            return fmt.Errorf("%s: %w", firstSQLLine(stmt), err)
+        }
+    }
+    return nil
+}
+
+// splitSQLStatements memecah script per ";" dan membuang potongan yang hanya berisi komentar
+func splitSQLStatements(script string) []string {
+    var statements []string
+    for _, chunk := range strings.Split(script, ";") {
+        if firstSQLLine(chunk) != "" {
+            statements = append(statements, strings.TrimSpace(chunk))
+        }
+    }
+    return statements
+}
+
+// firstSQLLine baris pertama yang bukan komentar ("" jika tidak ada), juga untuk pesan error
+func firstSQLLine(stmt string) string {
+    for _, line := range strings.Split(stmt, "\n") {
+        line = strings.TrimSpace(line)
+        if line != "" && !strings.HasPrefix(line, "--") {
+            return line
+        }
+    }
+    return ""
+}
+
+func isAlreadyExistsError(err error) bool {
+    return strings.Contains(strings.ToLower(err.Error()), "already exists")
+}
+
+// migrateSchema menambahkan kolom yang belum ada pada database lama
+func migrateSchema(db *sql.DB) error {
+    for _, m := range columnMigrations {
+        columns, err := tableColumns(db, m.Table)
+        if err != nil {
+            return err
+        }
+        if columns[m.Column] {
+            continue
+        }
+
+        stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.Table, m.Column, m.Definition)
+        if _, err := db.Exec(stmt); err != nil {
+            return fmt.Errorf("%s: %w", stmt, err)
+        }
+        log.Printf("✓ Migrasi: kolom %s.%s ditambahkan", m.Table, m.Column)
+
+        if m.Backfill != "" {
+            res, err := db.Exec(m.Backfill)
+            if err != nil {
+                return fmt.Errorf("backfill %s.%s: %w", m.Table, m.Column, err)
+            }
+            if n, err := res.RowsAffected(); err == nil {
+                log.Printf("✓ Migrasi: %d baris %s.%s diisi", n, m.Table, m.Column)
+            }
+        }
+    }
+    return migrateWeatherFetchedAt(db)
+}
+
+// legacyFetchedAtLayout format time.Time.String() yang dulu tersimpan di
+// weather_history.fetched_at (mis. "2025-12-11 13:58:39.72 +0700 +07 m=+487.86")
+const legacyFetchedAtLayout = "2006-01-02 15:04:05.999999999 -0700"
+
+// migrateWeatherFetchedAt mengubah fetched_at lama menjadi UTC "YYYY-MM-DD HH:MM:SS"
+// agar bisa dipakai date()/datetime() SQLite. Baris yang sudah benar dilewati.
+func migrateWeatherFetchedAt(db *sql.DB) error {
+    rows, err := db.Query(`SELECT id, fetched_at FROM weather_history
+        WHERE fetched_at NOT GLOB '[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9] [0-9][0-9]:[0-9][0-9]:[0-9][0-9]'`)
+    if err != nil {
+        return err
+    }
+
+    converted := make(map[int64]string)
+    for rows.Next() {
+        var id int64
+        var raw string
+        if err := rows.Scan(&id, &raw); err != nil {
+            rows.Close()
+            return err
+        }
+        // Buang zona singkatan & bacaan monotonic ("+07 m=+...") setelah offset numerik
+        fields := strings.Fields(raw)
+        if len(fields) < 3 {
+            log.Printf("⚠️  Migrasi: fetched_at tidak dikenali (id %d): %q", id, raw)
+            continue
+        }
+        t, err := time.Parse(legacyFetchedAtLayout, strings.Join(fields[:3], " "))
+        if err != nil {
+            log.Printf("⚠️  Migrasi: fetched_at tidak dikenali (id %d): %q", id, raw)
+            continue
+        }
+        converted[id] = formatFetchedAt(t)
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return err
+    }
+    if len(converted) == 0 {
+        return nil
+    }
+
+    tx, err := db.Begin()
+    if err != nil {
+        return err
+    }
+    for id, fetchedAt := range converted {
+        if _, err := tx.Exec(`UPDATE weather_history SET fetched_at = ? WHERE id = ?`, fetchedAt, id); err != nil {
+            tx.Rollback()
+            return err
+        }
+    }
+    if err := tx.Commit(); err != nil {
+        return err
+    }
+    log.Printf("✓ Migrasi: %d baris weather_history.fetched_at dikonversi ke UTC", len(converted))
+    return nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// openRawDB SQLite kosong tanpa schema.sql, untuk mensimulasikan migrasi yang tertinggal
+func openRawDB(t *testing.T, statements ...string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "drift.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func TestVerifySchema(t *testing.T) {
+	openTestDB(t)
+	if err := VerifySchema(DB); err != nil {
+		t.Fatalf("schema asli: %v", err)
+	}
+
+	// prices tanpa kolom source_type & quality, weather_history tidak ada
+	drifted := openRawDB(t, `CREATE TABLE prices (id INTEGER PRIMARY KEY, region TEXT, price REAL, price_min REAL,
+		price_max REAL, unit TEXT, source TEXT, source_name TEXT, source_url TEXT, scraped_at TEXT,
+		recorded_at TEXT, created_at TEXT)`)
+	var drift *SchemaDriftError
+	if err := VerifySchema(drifted); !errors.As(err, &drift) {
+		t.Fatalf("VerifySchema = %v, ingin SchemaDriftError", err)
+	}
+	want := []string{"prices.source_type", "prices.quality", "tabel weather_history"}
+	if !reflect.DeepEqual(drift.Missing, want) {
+		t.Fatalf("Missing = %v, ingin %v", drift.Missing, want)
+	}
+}
+
+func TestReadinessHandlerReportsSchemaDrift(t *testing.T) {
+	prev := DB
+	DB = openRawDB(t, `CREATE TABLE prices (id INTEGER PRIMARY KEY, region TEXT)`)
+	t.Cleanup(func() { DB = prev })
+	useStores(t, SQLitePriceStore{}, SQLiteWeatherStore{})
+
+	rec := serve(ReadinessHandler, http.MethodGet, "/ready", "")
+	var env struct {
+		Error struct {
+			Code    string
+			Details SchemaDriftError
+		}
+	}
+	decodeBody(t, rec, &env)
+	if rec.Code != http.StatusServiceUnavailable || env.Error.Code != "schema_drift" {
+		t.Fatalf("GET /ready = %d %s", rec.Code, rec.Body.String())
+	}
+	// Semua kolom prices selain id & region, ditambah tabel weather_history
+	if len(env.Error.Details.Missing) != len(expectedSchema[0].Columns)-2+1 {
+		t.Fatalf("details = %v", env.Error.Details.Missing)
+	}
+}
+
+func TestMigrationClassifiesLegacyPriceSources(t *testing.T) {
+	// Schema versi awal: belum ada source_type & metadata scraping
+	path := filepath.Join(t.TempDir(), "legacy.db")
+	legacy, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		`CREATE TABLE prices (id INTEGER PRIMARY KEY AUTOINCREMENT, region TEXT NOT NULL, price REAL NOT NULL,
+			unit TEXT, source TEXT, recorded_at TEXT NOT NULL, created_at TEXT DEFAULT (datetime('now')))`,
+		`INSERT INTO prices (region, price, unit, source, recorded_at) VALUES
+			('Jember', 1, 'kg', 'BAPPEBTI (Scraped: Grade A)', '2026-03-01'),
+			('Jember', 1, 'kg', 'BAPPEBTI (Scraped: Grade B)', '2026-03-02'),
+			('Temanggung', 1, 'kg', 'DPRD Jember Report (Last checked: 2024-09-15) (Scraped: standard)', '2026-03-02'),
+			('Jember', 1, 'kg', 'Simulated Market Data', '2026-03-02'),
+			('Jember', 1, 'kg', 'CSV Import', '2026-03-03'),
+			('Jember', 1, 'kg', 'manual', '2026-03-03'),
+			('Jember', 1, 'kg', 'entah dari mana', '2026-03-03')`,
+	} {
+		if _, err := legacy.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	legacy.Close()
+
+	openTestDBAt(t, path)
+	breakdown, err := SQLitePriceStore{}.SourceBreakdown("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{sourceTypeBAPPEBTI: 2, sourceTypeResearch: 1, sourceTypeSimulation: 1,
+		sourceTypeImport: 1, sourceTypeManual: 1, sourceTypeUnknown: 1}
+	if breakdown.Total != 7 || !reflect.DeepEqual(breakdown.ByType, want) {
+		t.Fatalf("ByType = %v (total %d), ingin %v", breakdown.ByType, breakdown.Total, want)
+	}
+
+	// Source lama "<sumber> (Scraped: <quality>)" dipecah ke kolom baru
+	p, err := SQLitePriceStore{}.GetLatest("Temanggung")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.SourceName == nil || *p.SourceName != "DPRD Jember Report (Last checked: 2024-09-15)" ||
+		p.Quality == nil || *p.Quality != "standard" || p.ScrapedAt == nil || *p.ScrapedAt != "2026-03-02" {
+		t.Fatalf("metadata = %+v", p)
+	}
+}
+
+func TestInitDBTwiceOnSameFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "restart.db")
+	openTestDBAt(t, path)
+	useStores(t, SQLitePriceStore{}, SQLiteWeatherStore{})
+	mustAdd(t, priceStore, testPrice("Jember", 42000, "2026-03-01"))
+
+	// Restart kedua: schema.sql & migrasi dijalankan ulang pada file yang sudah berisi data
+	openTestDBAt(t, path)
+	if err := VerifySchema(DB); err != nil {
+		t.Fatalf("schema setelah start kedua: %v", err)
+	}
+	latest, err := priceStore.GetLatest("Jember")
+	if err != nil || latest.Price != 42000 {
+		t.Fatalf("GetLatest setelah start kedua = %+v, %v", latest, err)
+	}
+}
+
+func TestApplySchemaSkipsExistingObjects(t *testing.T) {
+	db := openRawDB(t)
+	// Tanpa IF NOT EXISTS: eksekusi kedua memicu "already exists" yang harus dilewati
+	schema := `-- tabel uji
+		CREATE TABLE catatan (id INTEGER PRIMARY KEY, isi TEXT);
+		CREATE INDEX idx_catatan_isi ON catatan(isi);
+		-- hanya komentar;
+		`
+	for i := 1; i <= 2; i++ {
+		if err := applySchema(db, schema); err != nil {
+			t.Fatalf("applySchema ke-%d: %v", i, err)
+		}
+	}
+
+	// Error lain tetap fatal dan menyebut statement yang gagal
+	err := applySchema(db, "CREATE TABLE catatan_baru (id INTEGER);\nINSERT INTO tabel_hilang VALUES (1)")
+	if err == nil || !strings.Contains(err.Error(), "INSERT INTO tabel_hilang") {
+		t.Fatalf("applySchema statement rusak = %v, ingin error yang menyebut statement-nya", err)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================
+// SERIALIZED DB WRITER
+// SQLite hanya punya 1 writer (SetMaxOpenConns(1)); write async dikirim
+// ke satu goroutine lewat channel agar tidak saling berebut koneksi
+// ============================================
+
+const (
+	dbWriterQueueSize   = 256
+	dbWriteMaxAttempts  = 4
+	dbWriteBaseBackoff  = 50 * time.Millisecond
+	dbWriteSubmitWait   = 5 * time.Second
+	sqliteBusyErrCode   = 5 // SQLITE_BUSY
+	sqliteLockedErrCode = 6 // SQLITE_LOCKED
+)
+
+var (
+	ErrWriterClosed   = errors.New("db writer sudah ditutup")
+	ErrWriteQueueFull = errors.New("antrean write penuh")
+)
+
+type writeResult struct {
+	res sql.Result
+	err error
+}
+
+type writeJob struct {
+	label string
+	query string
+	args  []interface{}
+	tx    func(*sql.Tx) error // jika diisi, job dijalankan sebagai satu transaksi
+	done  chan writeResult    // nil = async, hasil tidak ditunggu
+}
+
+// DBWriter menjalankan job write satu per satu dengan retry saat database sibuk
+type DBWriter struct {
+	db   *sql.DB
+	jobs chan writeJob
+	wg   sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+func NewDBWriter(db *sql.DB, queueSize int) *DBWriter {
+	w := &DBWriter{
+		db:   db,
+		jobs: make(chan writeJob, queueSize),
+	}
+
+	w.wg.Add(1)
+	go w.run()
+
+	return w
+}
+
+func (w *DBWriter) run() {
+	defer w.wg.Done()
+	for job := range w.jobs {
+		if job.tx != nil {
+			job.done <- writeResult{err: runTx(w.db, job.tx)}
+			continue
+		}
+		res, err := execWithRetry(w.db, job.query, job.args...)
+		if job.done != nil {
+			job.done <- writeResult{res: res, err: err}
+			continue
+		}
+		if err != nil {
+			log.Printf("⚠️  Warning - write %s gagal: %v", job.label, err)
+		}
+	}
+}
+
+// Exec mengantrekan write dan menunggu hasilnya (pengganti DB.Exec untuk INSERT/UPDATE/DELETE)
+func (w *DBWriter) Exec(query string, args ...interface{}) (sql.Result, error) {
+	result := w.submit(writeJob{label: "sync", query: query, args: args})
+	return result.res, result.err
+}
+
+// Tx menjalankan fn dalam satu transaksi di goroutine writer; rollback jika fn error
+func (w *DBWriter) Tx(fn func(*sql.Tx) error) error {
+	return w.submit(writeJob{label: "tx", tx: fn}).err
+}
+
+// submit mengantrekan job sinkron dan menunggu hasilnya
+func (w *DBWriter) submit(job writeJob) writeResult {
+	job.done = make(chan writeResult, 1)
+
+	w.mu.RLock()
+	if w.closed {
+		w.mu.RUnlock()
+		return writeResult{err: ErrWriterClosed}
+	}
+	select {
+	case w.jobs <- job:
+	case <-time.After(dbWriteSubmitWait):
+		w.mu.RUnlock()
+		return writeResult{err: ErrWriteQueueFull}
+	}
+	w.mu.RUnlock()
+
+	return <-job.done
+}
+
+// ExecAsync mengantrekan write tanpa menunggu hasilnya (fire & forget).
+// Jika antrean penuh, write dibuang dengan warning agar caller tidak ikut terblokir.
+func (w *DBWriter) ExecAsync(label, query string, args ...interface{}) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		log.Printf("⚠️  Warning - db writer sudah ditutup, %s dibuang", label)
+		return
+	}
+	select {
+	case w.jobs <- writeJob{label: label, query: query, args: args}:
+	default:
+		log.Printf("⚠️  Warning - antrean write penuh, %s dibuang", label)
+	}
+}
+
+// Close menunggu semua write yang sudah diantrekan selesai
+func (w *DBWriter) Close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.jobs)
+	}
+	w.mu.Unlock()
+	w.wg.Wait()
+}
+
+// isSQLiteBusy mendeteksi error sementara akibat lock database
+func isSQLiteBusy(err error) bool {
+	var coded interface{ Code() int }
+	if errors.As(err, &coded) {
+		code := coded.Code() & 0xff // extended code -> primary code
+		return code == sqliteBusyErrCode || code == sqliteLockedErrCode
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "SQLITE_BUSY") || strings.Contains(msg, "database is locked")
+}
+
+// execWithRetry menjalankan Exec dengan exponential backoff khusus untuk SQLITE_BUSY
+func execWithRetry(db *sql.DB, query string, args ...interface{}) (sql.Result, error) {
+	backoff := func(attempt int) time.Duration { return dbWriteBaseBackoff << (attempt - 1) }
+	return RetryWithResult(context.Background(), dbWriteMaxAttempts, backoff, isSQLiteBusy, func() (sql.Result, error) {
+		return db.Exec(query, args...)
+	})
+}
+
+func runTx(db *sql.DB, fn func(*sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+var dbWriter *DBWriter
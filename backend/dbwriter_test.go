@@ -0,0 +1,107 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDBWriterConcurrentWrites(t *testing.T) {
+	openTestDB(t)
+	prices, weather := SQLitePriceStore{}, SQLiteWeatherStore{}
+
+	const workers, perWorker = 16, 25
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*perWorker*2)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				region := fmt.Sprintf("Region %02d", w)
+				// Bergantian: Exec tunggal, Tx dua baris, write async, dan baca di sela-selanya
+				switch i % 3 {
+				case 0:
+					if _, err := prices.Add(testPrice(region, float64(40000+i), "2026-01-01")); err != nil {
+						errs <- err
+					}
+				case 1:
+					if _, err := prices.AddBatch([]Price{
+						testPrice(region, float64(40000+i), "2026-01-02"),
+						testPrice(region, float64(41000+i), "2026-01-03"),
+					}); err != nil {
+						errs <- err
+					}
+				case 2:
+					weather.Add(WeatherHistoryRow{Region: region, TempC: floatPtr(27), FetchedAt: formatFetchedAt(time.Now())})
+				}
+				if _, err := prices.GetAll(PriceQuery{Region: region, Limit: 5}); err != nil {
+					errs <- err
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	flushWrites(t)
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("write/baca gagal (busy=%v): %v", isSQLiteBusy(err), err)
+	}
+
+	// Per worker: 9 Exec (i%3==0) + 8 Tx x 2 baris, 8 write cuaca async
+	var priceRows, weatherRows int
+	DB.QueryRow("SELECT COUNT(*) FROM prices").Scan(&priceRows)
+	DB.QueryRow("SELECT COUNT(*) FROM weather_history").Scan(&weatherRows)
+	if want := workers * (9 + 8*2); priceRows != want {
+		t.Fatalf("%d baris prices, ingin %d", priceRows, want)
+	}
+	if want := workers * 8; weatherRows != want {
+		t.Fatalf("%d baris weather_history, ingin %d", weatherRows, want)
+	}
+}
+
+func TestDBWriterTxRollsBackOnError(t *testing.T) {
+	openTestDB(t)
+	errBoom := errors.New("gagal di tengah")
+	err := dbWriter.Tx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(insertPriceSQL, insertPriceArgs(testPrice("Jember", 40000, "2026-01-01"))...); err != nil {
+			return err
+		}
+		return errBoom
+	})
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("Tx = %v, ingin %v", err, errBoom)
+	}
+	var n int
+	DB.QueryRow("SELECT COUNT(*) FROM prices").Scan(&n)
+	if n != 0 {
+		t.Fatalf("%d baris tersisa setelah rollback", n)
+	}
+}
+
+func TestDBWriterClosed(t *testing.T) {
+	openTestDB(t)
+	writer := NewDBWriter(DB, 1)
+	writer.Close()
+	if _, err := writer.Exec("DELETE FROM prices"); !errors.Is(err, ErrWriterClosed) {
+		t.Fatalf("Exec setelah Close = %v, ingin ErrWriterClosed", err)
+	}
+	writer.Close() // Close kedua tidak panic
+}
+
+func TestIsSQLiteBusy(t *testing.T) {
+	tests := map[string]bool{
+		"database is locked":           true,
+		"SQLITE_BUSY: cannot commit":   true,
+		"UNIQUE constraint failed: id": false,
+	}
+	for msg, want := range tests {
+		if got := isSQLiteBusy(errors.New(msg)); got != want {
+			t.Fatalf("isSQLiteBusy(%q) = %v, ingin %v", msg, got, want)
+		}
+	}
+}
@@ -1,10 +1,28 @@
 package main
 
 import (
+	"container/list"
+	"context"
+	"crypto/subtle"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"math"
+	"math/big"
+	"mime"
 	"net/http"
+	"net/url"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"golang.org/x/sync/singleflight"
 )
 
 // ============================================
@@ -21,10 +39,10 @@ type MiddlewareFunc func(HandlerFunc) HandlerFunc
 // ============================================
 
 func getRegionOrDefault(region string) string {
-	if region == "" {
+	if strings.TrimSpace(region) == "" {
 		return "Jember"
 	}
-	return region
+	return NormalizeRegion(region)
 }
 
 func buildRecommendationResponse(result, region string, temp, humidity, rain float64) map[string]interface{} {
@@ -49,8 +67,9 @@ func respondJSON(w http.ResponseWriter, statusCode int, data interface{}) error
 	return json.NewEncoder(w).Encode(data)
 }
 
+// respondError menulis APIError dengan kode default sesuai status (lihat codeForStatus)
 func respondError(w http.ResponseWriter, message string, statusCode int) {
-	http.Error(w, message, statusCode)
+	writeAPIError(w, NewAPIError(statusCode, codeForStatus(statusCode), message))
 }
 
 // ============================================
@@ -84,7 +103,10 @@ func withJSONContentType(next HandlerFunc) HandlerFunc {
 	}
 }
 
+// withMethodValidation 405 + header Allow untuk method di luar allowedMethods.
+// OPTIONS (termasuk preflight CORS) dijawab 204 dengan Allow tanpa memanggil handler.
 func withMethodValidation(allowedMethods ...string) MiddlewareFunc {
+	allow := strings.Join(append(append([]string{}, allowedMethods...), http.MethodOptions), ", ")
 	return func(next HandlerFunc) HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
 			for _, method := range allowedMethods {
@@ -93,16 +115,88 @@ func withMethodValidation(allowedMethods ...string) MiddlewareFunc {
 					return
 				}
 			}
+			w.Header().Set("Allow", allow)
+			if r.Method == http.MethodOptions {
+				w.Header().Set("Access-Control-Allow-Methods", allow)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
 			respondError(w, "Method tidak didukung", http.StatusMethodNotAllowed)
 		}
 	}
 }
 
+// apiKeys diisi sekali saat startup dari Config.APIKeys (env API_KEYS).
+// Kosong = autentikasi nonaktif (untuk local dev).
+var apiKeys []string
+
+func extractAPIKey(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
+
+func isValidAPIKey(key string, keys []string) bool {
+	valid := false
+	for _, k := range keys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(k)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
+
+func withAPIKey(next HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(apiKeys) == 0 {
+			next(w, r)
+			return
+		}
+
+		key := extractAPIKey(r)
+		if key == "" {
+			writeAPIError(w, errAPIKeyRequired)
+			return
+		}
+		if !isValidAPIKey(key, apiKeys) {
+			writeAPIError(w, errAPIKeyInvalid)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// maxBodyBytes batas ukuran body POST/PUT (Config.MaxBodyBytes / env MAX_BODY_BYTES)
+const defaultMaxBodyBytes int64 = 1 << 20 // 1MB
+
+var maxBodyBytes = defaultMaxBodyBytes
+
+func withBodyLimit(limit int64) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost || r.Method == http.MethodPut {
+				if r.ContentLength > limit {
+					writeAPIError(w, errBodyTooLarge)
+					return
+				}
+				r.Body = http.MaxBytesReader(w, r.Body, limit)
+			}
+			next(w, r)
+		}
+	}
+}
+
+func isBodyTooLarge(err error) bool {
+	var maxErr *http.MaxBytesError
+	return errors.As(err, &maxErr)
+}
+
 func withErrorHandling(handler func(http.ResponseWriter, *http.Request) error) HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if err := handler(w, r); err != nil {
 			log.Printf("Handler error: %v", err)
-			respondError(w, err.Error(), http.StatusInternalServerError)
+			writeAPIError(w, asAPIError(err))
 		}
 	}
 }
@@ -119,6 +213,23 @@ func chain(handler HandlerFunc, middlewares ...MiddlewareFunc) HandlerFunc {
 	return handler
 }
 
+// Compose menggabungkan dua fungsi unary: Compose(f, g)(x) == f(g(x)),
+// jadi g dijalankan lebih dulu
+func Compose[A, B, C any](f func(B) C, g func(A) B) func(A) C {
+	return func(x A) C {
+		return f(g(x))
+	}
+}
+
+// Pipe menjalankan transform bertipe sama dari kiri ke kanan:
+// Pipe(f, g, h)(x) == h(g(f(x)))
+func Pipe[T any](fns ...func(T) T) func(T) T {
+	identity := func(x T) T { return x }
+	return Reduce(fns, identity, func(acc func(T) T, fn func(T) T) func(T) T {
+		return Compose(fn, acc)
+	})
+}
+
 // ============================================
 // 5. CLOSURE
 // Fungsi yang mengakses variabel dari scope luar (lexical scoping)
@@ -127,14 +238,51 @@ func chain(handler HandlerFunc, middlewares ...MiddlewareFunc) HandlerFunc {
 func makeWeatherHandler(fetchWeather func(string) (*WeatherData, error)) HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		region := getRegionOrDefault(r.URL.Query().Get("region"))
+		units, err := unitsFromRequest(r)
+		if err != nil {
+			writeAPIError(w, asAPIError(err))
+			return
+		}
 
 		data, err := fetchWeather(region)
 		if err != nil {
-			respondError(w, "Gagal mengambil data cuaca", http.StatusInternalServerError)
+			writeAPIError(w, weatherAPIError(err))
 			return
 		}
 
-		respondJSON(w, http.StatusOK, data)
+		// InUnits menyalin, jadi data milik cache tidak ikut berubah
+		response := data.InUnits(units)
+		if response.Region == "" {
+			response.Region = region
+		}
+		respondJSON(w, http.StatusOK, response)
+	}
+}
+
+// Debounce membungkus fn agar panggilan beruntun digabung: fn tidak dijalankan lagi
+// selama masih berjalan atau belum lewat d sejak selesai. Return false = panggilan diredam.
+func Debounce(d time.Duration, fn func()) func() bool {
+	var mu sync.Mutex
+	var running bool
+	var finishedAt time.Time
+
+	return func() bool {
+		mu.Lock()
+		if running || (!finishedAt.IsZero() && time.Since(finishedAt) < d) {
+			mu.Unlock()
+			return false
+		}
+		running = true
+		mu.Unlock()
+
+		defer func() {
+			mu.Lock()
+			running = false
+			finishedAt = time.Now()
+			mu.Unlock()
+		}()
+		fn()
+		return true
 	}
 }
 
@@ -161,6 +309,85 @@ func Filter[T any](slice []T, predicate func(T) bool) []T {
 	return result
 }
 
+// FlatMap memetakan tiap elemen ke slice lalu menggabungkannya menjadi satu slice.
+// fn boleh mengembalikan nil / slice kosong.
+func FlatMap[T, U any](slice []T, fn func(T) []U) []U {
+	result := []U{}
+	for _, v := range slice {
+		result = append(result, fn(v)...)
+	}
+	return result
+}
+
+// Pair pasangan dua nilai hasil Zip
+type Pair[A, B any] struct {
+	First  A `json:"first"`
+	Second B `json:"second"`
+}
+
+// Zip memasangkan elemen a[i] dengan b[i]; panjang hasil = slice terpendek
+func Zip[A, B any](a []A, b []B) []Pair[A, B] {
+	return ZipWith(a, b, func(x A, y B) Pair[A, B] {
+		return Pair[A, B]{First: x, Second: y}
+	})
+}
+
+// ZipWith seperti Zip tapi tiap pasangan langsung digabung dengan fn
+func ZipWith[A, B, C any](a []A, b []B, fn func(A, B) C) []C {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	result := make([]C, n)
+	for i := 0; i < n; i++ {
+		result[i] = fn(a[i], b[i])
+	}
+	return result
+}
+
+// Find mengembalikan elemen pertama yang memenuhi predicate
+func Find[T any](slice []T, predicate func(T) bool) Option[T] {
+	for _, item := range slice {
+		if predicate(item) {
+			return Some(item)
+		}
+	}
+	return None[T]()
+}
+
+// Partition memisahkan elemen yang memenuhi predicate dan yang tidak dalam satu kali iterasi
+func Partition[T any](slice []T, predicate func(T) bool) (matched, unmatched []T) {
+	matched, unmatched = []T{}, []T{}
+	for _, v := range slice {
+		if predicate(v) {
+			matched = append(matched, v)
+		} else {
+			unmatched = append(unmatched, v)
+		}
+	}
+	return matched, unmatched
+}
+
+// Count jumlah elemen yang memenuhi predicate
+func Count[T any](slice []T, predicate func(T) bool) int {
+	n := 0
+	for _, v := range slice {
+		if predicate(v) {
+			n++
+		}
+	}
+	return n
+}
+
+// CountBy jumlah elemen per key; input kosong menghasilkan map kosong (bukan nil)
+func CountBy[T any, K comparable](slice []T, key func(T) K) map[K]int {
+	counts := make(map[K]int)
+	for _, v := range slice {
+		counts[key(v)]++
+	}
+	return counts
+}
+
 func Reduce[T, U any](slice []T, initial U, fn func(U, T) U) U {
 	result := initial
 	for _, v := range slice {
@@ -169,6 +396,61 @@ func Reduce[T, U any](slice []T, initial U, fn func(U, T) U) U {
 	return result
 }
 
+// ReduceWhile seperti Reduce, tapi berhenti begitu fn mengembalikan false;
+// akumulator dari panggilan yang menghentikan iterasi tetap dipakai
+func ReduceWhile[T, U any](slice []T, initial U, fn func(U, T) (U, bool)) U {
+	result := initial
+	for _, v := range slice {
+		var next bool
+		result, next = fn(result, v)
+		if !next {
+			break
+		}
+	}
+	return result
+}
+
+// Distinct menghapus duplikat dengan mempertahankan urutan kemunculan pertama
+func Distinct[T comparable](slice []T) []T {
+	return DistinctBy(slice, func(v T) T { return v })
+}
+
+// DistinctBy menghapus elemen dengan key yang sama (kemunculan pertama dipertahankan)
+func DistinctBy[T any, K comparable](slice []T, key func(T) K) []T {
+	seen := make(map[K]struct{}, len(slice))
+	result := []T{}
+	for _, v := range slice {
+		k := key(v)
+		if _, exists := seen[k]; exists {
+			continue
+		}
+		seen[k] = struct{}{}
+		result = append(result, v)
+	}
+	return result
+}
+
+// Chunk memecah slice menjadi potongan berukuran size (potongan terakhir bisa lebih kecil).
+// size <= 0 mengembalikan seluruh slice sebagai satu potongan; input kosong menghasilkan nil.
+func Chunk[T any](slice []T, size int) [][]T {
+	if len(slice) == 0 {
+		return nil
+	}
+	if size <= 0 {
+		return [][]T{slice}
+	}
+
+	chunks := make([][]T, 0, (len(slice)+size-1)/size)
+	for start := 0; start < len(slice); start += size {
+		end := start + size
+		if end > len(slice) {
+			end = len(slice)
+		}
+		chunks = append(chunks, slice[start:end:end])
+	}
+	return chunks
+}
+
 // ============================================
 // 7. IMMUTABILITY
 // Data tidak dapat diubah setelah dibuat, selalu membuat copy baru
@@ -197,6 +479,35 @@ func (r Result[T]) OrElse(defaultValue T) T {
 	return r.Value
 }
 
+// Option nilai yang mungkin tidak ada, pengganti pasangan (T, bool) / error "not found"
+type Option[T any] struct {
+	value T
+	ok    bool
+}
+
+func Some[T any](value T) Option[T] {
+	return Option[T]{value: value, ok: true}
+}
+
+func None[T any]() Option[T] {
+	return Option[T]{}
+}
+
+func (o Option[T]) IsSome() bool {
+	return o.ok
+}
+
+func (o Option[T]) Get() (T, bool) {
+	return o.value, o.ok
+}
+
+func (o Option[T]) OrElse(defaultValue T) T {
+	if !o.ok {
+		return defaultValue
+	}
+	return o.value
+}
+
 // ============================================
 // 8. RECURSION
 // Fungsi yang memanggil dirinya sendiri
@@ -209,6 +520,33 @@ func Factorial(n int) int {
 	return n * Factorial(n-1)
 }
 
+var ErrFactorialOverflow = errors.New("factorial melebihi batas int")
+
+// FactorialChecked seperti Factorial tetapi mengembalikan error jika overflow
+func FactorialChecked(n int) (int, error) {
+	if n < 0 {
+		return 0, fmt.Errorf("factorial tidak terdefinisi untuk n negatif (%d)", n)
+	}
+	result := 1
+	for i := 2; i <= n; i++ {
+		if result > math.MaxInt/i {
+			return 0, ErrFactorialOverflow
+		}
+		result *= i
+	}
+	return result, nil
+}
+
+// FactorialBig untuk n besar tanpa overflow. Seperti Factorial, n <= 1 (termasuk negatif)
+// menghasilkan 1; pakai FactorialChecked jika n negatif harus ditolak.
+func FactorialBig(n int) *big.Int {
+	result := big.NewInt(1)
+	for i := 2; i <= n; i++ {
+		result.Mul(result, big.NewInt(int64(i)))
+	}
+	return result
+}
+
 func FactorialTailRecursive(n int) int {
 	return factorialHelper(n, 1)
 }
@@ -220,11 +558,17 @@ func factorialHelper(n, acc int) int {
 	return factorialHelper(n-1, n*acc)
 }
 
+// Fibonacci iteratif O(n). Input negatif menghasilkan 0.
+// Catatan: melebihi int64 untuk n > 92.
 func Fibonacci(n int) int {
-	if n <= 1 {
-		return n
+	if n <= 0 {
+		return 0
 	}
-	return Fibonacci(n-1) + Fibonacci(n-2)
+	prev, curr := 0, 1
+	for i := 1; i < n; i++ {
+		prev, curr = curr, prev+curr
+	}
+	return curr
 }
 
 func FibonacciMemoized(n int) int {
@@ -401,34 +745,38 @@ func ParallelFilter[T any](slice []T, predicate func(T) bool) []T {
 	return result
 }
 
+// ParallelReduce membagi slice ke beberapa worker lalu menggabungkan hasil parsialnya
+// dengan fn yang sama. Urutan hasil parsial tidak dijamin, jadi fn WAJIB asosiatif
+// dan komutatif (sum, min, max); operasi seperti pengurangan atau konkatenasi string
+// memberi hasil acak. workers <= 0 memakai runtime.NumCPU(), dan tidak lebih dari len(slice).
+// initial diterapkan tepat sekali, jadi hasilnya sama dengan Reduce sekuensial.
 func ParallelReduce[T any](slice []T, initial T, fn func(T, T) T, workers int) T {
 	if len(slice) == 0 {
 		return initial
 	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(slice) {
+		workers = len(slice)
+	}
 
 	chunkSize := (len(slice) + workers - 1) / workers
-	resultChan := make(chan T, workers)
+	chunks := Chunk(slice, chunkSize)
+	resultChan := make(chan T, len(chunks))
 	var wg sync.WaitGroup
 
-	for i := 0; i < workers; i++ {
-		start := i * chunkSize
-		end := start + chunkSize
-		if end > len(slice) {
-			end = len(slice)
-		}
-		if start >= len(slice) {
-			break
-		}
-
+	for _, chunk := range chunks {
 		wg.Add(1)
 		go func(chunk []T) {
 			defer wg.Done()
-			result := initial
-			for _, item := range chunk {
+			// Mulai dari elemen pertama chunk, bukan initial: initial hanya dilipat sekali di akhir
+			result := chunk[0]
+			for _, item := range chunk[1:] {
 				result = fn(result, item)
 			}
 			resultChan <- result
-		}(slice[start:end])
+		}(chunk)
 	}
 
 	go func() {
@@ -445,6 +793,10 @@ func ParallelReduce[T any](slice []T, initial T, fn func(T, T) T, workers int) T
 }
 
 func FetchMultipleRegionsWeather(regions []string) map[string]*WeatherData {
+	return FetchMultipleRegionsWeatherWithContext(context.Background(), regions)
+}
+
+func FetchMultipleRegionsWeatherWithContext(ctx context.Context, regions []string) map[string]*WeatherData {
 	results := make(map[string]*WeatherData)
 	var mu sync.Mutex
 	var wg sync.WaitGroup
@@ -453,7 +805,7 @@ func FetchMultipleRegionsWeather(regions []string) map[string]*WeatherData {
 		wg.Add(1)
 		go func(r string) {
 			defer wg.Done()
-			data, err := FetchWeather(r)
+			data, err := FetchWeatherWithContext(ctx, r)
 			if err != nil {
 				log.Printf("Failed to fetch weather for %s: %v", r, err)
 				return
@@ -469,6 +821,33 @@ func FetchMultipleRegionsWeather(regions []string) map[string]*WeatherData {
 	return results
 }
 
+// FetchMultipleRegionsForecast mengambil forecast tiap region secara concurrent;
+// region yang gagal tidak ada di map hasil
+func FetchMultipleRegionsForecast(ctx context.Context, regions []string) map[string][]WeatherData {
+	results := make(map[string][]WeatherData)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, region := range regions {
+		wg.Add(1)
+		go func(r string) {
+			defer wg.Done()
+			entries, err := FetchWeatherForecastWithContext(ctx, r)
+			if err != nil {
+				log.Printf("Failed to fetch forecast for %s: %v", r, err)
+				return
+			}
+
+			mu.Lock()
+			results[r] = entries
+			mu.Unlock()
+		}(region)
+	}
+
+	wg.Wait()
+	return results
+}
+
 func FetchMultiplePricesSources(sources []func() error) []error {
 	errorChan := make(chan error, len(sources))
 	var wg sync.WaitGroup
@@ -540,185 +919,1528 @@ func (wp *WorkerPool[T, U]) Results() <-chan U {
 	return wp.results
 }
 
-func RecommendationHandler(w http.ResponseWriter, r *http.Request) {
-	handler := chain(
-		func(w http.ResponseWriter, r *http.Request) {
-			region := getRegionOrDefault(r.URL.Query().Get("region"))
+// ============================================
+// 11. MEMOIZATION
+// Cache hasil fungsi murni berdasarkan input (generalisasi FibonacciMemoized)
+// ============================================
 
-			data, err := FetchWeather(region)
-			if err != nil {
-				respondError(w, "Gagal mengambil data cuaca", http.StatusInternalServerError)
-				return
-			}
+type memoEntry[V any] struct {
+	once  sync.Once
+	value V
+}
 
-			result := Recommend(data.Temp, data.Humidity, data.Rain)
-			response := buildRecommendationResponse(result, region, data.Temp, float64(data.Humidity), data.Rain)
+// Memoize membungkus fn sehingga setiap key hanya dihitung sekali.
+// Aman dipakai concurrent: pemanggil dengan key yang sama menunggu hasil yang sama.
+func Memoize[K comparable, V any](fn func(K) V) func(K) V {
+	var mu sync.Mutex
+	cache := make(map[K]*memoEntry[V])
+
+	return func(key K) V {
+		mu.Lock()
+		entry, ok := cache[key]
+		if !ok {
+			entry = &memoEntry[V]{}
+			cache[key] = entry
+		}
+		mu.Unlock()
 
-			respondJSON(w, http.StatusOK, response)
-		},
-		withJSONContentType,
-		withLogging,
-		withRecovery,
-	)
-	handler(w, r)
+		entry.once.Do(func() {
+			entry.value = fn(key)
+		})
+		return entry.value
+	}
 }
 
-func AdvancedRecommendationHandler(w http.ResponseWriter, r *http.Request) {
-	handler := chain(
-		func(w http.ResponseWriter, r *http.Request) {
-			region := getRegionOrDefault(r.URL.Query().Get("region"))
+type lruItem[K comparable, V any] struct {
+	key   K
+	value V
+}
 
-			data, err := FetchWeather(region)
-			if err != nil {
-				respondError(w, "Gagal mengambil data cuaca", http.StatusInternalServerError)
-				return
-			}
+// MemoizeLRU seperti Memoize tetapi hanya menyimpan `capacity` key terakhir dipakai
+func MemoizeLRU[K comparable, V any](capacity int, fn func(K) V) func(K) V {
+	if capacity <= 0 {
+		capacity = 1
+	}
 
-			result := GetAdvancedRecommendation(data.Temp, data.Humidity, data.Rain, region)
-			respondJSON(w, http.StatusOK, result)
-		},
-		withJSONContentType,
-		withLogging,
-		withRecovery,
-	)
-	handler(w, r)
-}
+	var mu sync.Mutex
+	order := list.New()
+	items := make(map[K]*list.Element)
+
+	return func(key K) V {
+		mu.Lock()
+		if el, ok := items[key]; ok {
+			order.MoveToFront(el)
+			value := el.Value.(lruItem[K, V]).value
+			mu.Unlock()
+			return value
+		}
+		mu.Unlock()
 
-func WeatherAPIHandler(w http.ResponseWriter, r *http.Request) {
-	handler := chain(
-		makeWeatherHandler(FetchWeather),
-		withJSONContentType,
-		withLogging,
-		withRecovery,
-	)
-	handler(w, r)
-}
+		// Hitung di luar lock agar key lain tidak ikut menunggu
+		value := fn(key)
 
-func MultiRegionWeatherHandler(w http.ResponseWriter, r *http.Request) {
-	handler := chain(
-		withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
-			regions := []string{"Jember", "Surabaya", "Malang", "Banyuwangi"}
-			results := FetchMultipleRegionsWeather(regions)
-			return respondJSON(w, http.StatusOK, results)
-		}),
-		withJSONContentType,
-		withLogging,
-		withRecovery,
-	)
-	handler(w, r)
+		mu.Lock()
+		defer mu.Unlock()
+		if el, ok := items[key]; ok {
+			order.MoveToFront(el)
+			return el.Value.(lruItem[K, V]).value
+		}
+		items[key] = order.PushFront(lruItem[K, V]{key: key, value: value})
+		if order.Len() > capacity {
+			oldest := order.Back()
+			order.Remove(oldest)
+			delete(items, oldest.Value.(lruItem[K, V]).key)
+		}
+		return value
+	}
 }
 
-func AddPriceHandler(w http.ResponseWriter, r *http.Request) {
-	handler := chain(
-		withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
-			var p Price
-			if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-				respondError(w, "Request body tidak valid", http.StatusBadRequest)
-				return nil
-			}
+// parseExplicitWeather membaca temp, humidity, dan rain dari query string.
+// ok=false jika salah satu tidak ada, artinya caller harus fetch ke OWM.
+func parseExplicitWeather(q url.Values, region string) (*WeatherData, bool, error) {
+	rawTemp, rawHumidity, rawRain := q.Get("temp"), q.Get("humidity"), q.Get("rain")
+	if rawTemp == "" || rawHumidity == "" || rawRain == "" {
+		return nil, false, nil
+	}
 
-			_, err := DB.Exec(`INSERT INTO prices (region, price, unit, source, recorded_at) VALUES (?, ?, ?, ?, ?)`,
-				p.Region, p.Price, p.Unit, p.Source, p.RecordedAt)
+	temp, err := strconv.ParseFloat(rawTemp, 64)
+	if err != nil {
+		return nil, true, fmt.Errorf("temp bukan angka: %q", rawTemp)
+	}
+	humidity, err := strconv.ParseFloat(rawHumidity, 64)
+	if err != nil {
+		return nil, true, fmt.Errorf("humidity bukan angka: %q", rawHumidity)
+	}
+	rain, err := strconv.ParseFloat(rawRain, 64)
+	if err != nil {
+		return nil, true, fmt.Errorf("rain bukan angka: %q", rawRain)
+	}
+	// Sensor bisa melapor °F/K (?units=); mesin rekomendasi memakai Celsius
+	units, err := ParseUnitSystem(q.Get("units"))
+	if err != nil {
+		return nil, true, err
+	}
+	temp = units.ToCelsius(temp)
 
-			if err != nil {
-				return err
-			}
+	in := RecommendationInput{Region: region, Temp: temp, Humidity: int(math.Round(humidity)), Rain: rain}
+	data := in.WeatherData()
+	if err := data.Validate(); err != nil {
+		return nil, true, err
+	}
 
-			response := buildStatusResponse("ok", "Data harga berhasil ditambahkan")
-			return respondJSON(w, http.StatusOK, response)
-		}),
-		withMethodValidation(http.MethodPost),
-		withJSONContentType,
-		withLogging,
-		withRecovery,
-	)
-	handler(w, r)
+	return &data, true, nil
 }
 
-func FetchPricesHandler(w http.ResponseWriter, r *http.Request) {
-	handler := chain(
-		withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
-			tryFetch := func() error {
-				if err := AutoFetchPricesFromScraper(); err != nil {
-					log.Printf("Scraping failed, fallback to simulation: %v", err)
-					return AutoFetchPrices()
-				}
-				return nil
-			}
+// simpleRecommendation respons /rekomendasi yang disimpan di recommendationCache
+type simpleRecommendation struct {
+	Response map[string]interface{}
+	Text     string
+}
 
-			if err := tryFetch(); err != nil {
-				return err
-			}
+// Themed salinan dengan simbol tema t; map respons disalin supaya entry cache tidak berubah
+func (rec simpleRecommendation) Themed(t Theme) simpleRecommendation {
+	response := make(map[string]interface{}, len(rec.Response))
+	for k, v := range rec.Response {
+		response[k] = v
+	}
+	if text, ok := response["recommendation"].(string); ok {
+		response["recommendation"] = t.Apply(text)
+	}
+	return simpleRecommendation{Response: response, Text: t.Apply(rec.Text)}
+}
 
-			response := buildStatusResponse("ok", "Berhasil fetch dan simpan harga (Web Scraping + Market Data)")
-			return respondJSON(w, http.StatusOK, response)
-		}),
-		withMethodValidation(http.MethodPost),
-		withJSONContentType,
-		withLogging,
-		withRecovery,
-	)
-	handler(w, r)
+func buildSimpleRecommendation(data *WeatherData, region string) simpleRecommendation {
+	result := Recommend(data.Temp, data.Humidity, data.Rain)
+	return simpleRecommendation{
+		Response: buildRecommendationResponse(result, region, data.Temp, float64(data.Humidity), data.Rain),
+		Text:     RecommendationText(region, data.Temp, data.Humidity, data.Rain),
+	}
 }
 
-func GetCurrentPriceHandler(w http.ResponseWriter, r *http.Request) {
-	handler := chain(
-		withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
-			region := getRegionOrDefault(r.URL.Query().Get("region"))
+// RecommendationHandler memakai input eksplisit dari query jika lengkap (tidak di-cache),
+// selain itu fetch ke OWM lewat recommendationCache
+func RecommendationHandler(w http.ResponseWriter, r *http.Request) {
+	region := getRegionOrDefault(r.URL.Query().Get("region"))
+
+	data, explicit, err := parseExplicitWeather(r.URL.Query(), region)
+	if err != nil {
+		writeAPIError(w, NewAPIError(http.StatusBadRequest, "invalid_weather_input", err.Error()))
+		return
+	}
+	theme, err := themeFromRequest(r)
+	if err != nil {
+		writeAPIError(w, asAPIError(err))
+		return
+	}
 
-			jsonData, err := GetLatestPriceJSON(region)
+	rec := simpleRecommendation{}
+	if explicit {
+		rec = buildSimpleRecommendation(data, region)
+	} else {
+		rec, err = cachedRecommendation(w, r, recommendationCacheKey("simple", region, ""), func() (simpleRecommendation, error) {
+			data, err := FetchWeatherWithContext(r.Context(), region)
 			if err != nil {
-				return err
+				return simpleRecommendation{}, err
 			}
+			return buildSimpleRecommendation(data, region), nil
+		})
+		if err != nil {
+			writeAPIError(w, weatherAPIError(err))
+			return
+		}
+	}
 
-			w.Write([]byte(jsonData))
-			return nil
-		}),
-		withJSONContentType,
-		withLogging,
-		withRecovery,
-	)
-	handler(w, r)
+	rec = rec.Themed(theme)
+	respondNegotiated(w, r, http.StatusOK, rec.Response, func() string { return rec.Text })
 }
 
-func PricesHandler(w http.ResponseWriter, r *http.Request) {
-	handler := chain(
-		withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
-			rows, err := DB.Query("SELECT id, region, price, unit, source, recorded_at, created_at FROM prices ORDER BY created_at DESC")
-			if err != nil {
-				log.Println("DB error:", err)
-				return err
-			}
-			defer rows.Close()
+// fetchAdvancedRecommendation mengambil cuaca saat ini & forecast secara concurrent,
+// jadi latensi = panggilan yang paling lambat, bukan jumlah keduanya
+func fetchAdvancedRecommendation(ctx context.Context, region string, cfg RecommendationConfig) (AdvancedRecommendation, error) {
+	type forecastResult struct {
+		entries []WeatherData
+		err     error
+	}
+	forecastCh := make(chan forecastResult, 1)
+	go func() {
+		entries, err := FetchWeatherForecastWithContext(ctx, region)
+		forecastCh <- forecastResult{entries, err}
+	}()
 
-			var data []Price
+	data, err := FetchWeatherWithContext(ctx, region)
+	forecast := <-forecastCh
+	if err != nil {
+		return AdvancedRecommendation{}, err
+	}
 
-			for rows.Next() {
-				var p Price
-				err := rows.Scan(&p.ID, &p.Region, &p.Price, &p.Unit, &p.Source, &p.RecordedAt, &p.CreatedAt)
-				if err != nil {
-					log.Println("Scan error:", err)
-					continue
-				}
-				data = append(data, p)
-			}
+	result := AdvancedRecommendation{RecommendationResult: RecommendForWeatherWithConfig(cfg, data, region)}
+	if forecast.err != nil {
+		log.Printf("Forecast %s gagal, rekomendasi hanya dari cuaca saat ini: %v", region, forecast.err)
+		return result, nil
+	}
 
-			if data == nil {
-				data = []Price{}
-			}
+	outlook := SummarizeForecast(forecast.entries)
+	result.RecommendationResult = result.withDryingNote(
+		forecastDryingNote(result.Humidity, outlook.RainMM, outlook.AvgWindSpeed, outlook.PressureChange))
+	result.ForecastAvailable = true
+	result.Forecast = &outlook
+	return result, nil
+}
+
+// maxAreaHa batas wajar luas lahan satu petani/kelompok tani untuk ?area_ha=
+const maxAreaHa = 10000
 
-			return respondJSON(w, http.StatusOK, data)
-		}),
-		withJSONContentType,
-		withLogging,
-		withRecovery,
-	)
-	handler(w, r)
+// parseAreaHa ?area_ha= untuk estimasi irigasi; kosong = 1 hektar
+func parseAreaHa(raw string) (float64, error) {
+	if raw == "" {
+		return 1, nil
+	}
+	area, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(area) || area < 0 || area > maxAreaHa {
+		return 0, fmt.Errorf("area_ha harus angka 0-%d, didapat %q", maxAreaHa, raw)
+	}
+	return area, nil
+}
+
+func respondAdvancedRecommendation(w http.ResponseWriter, r *http.Request, result AdvancedRecommendation, theme Theme) {
+	result = result.Themed(theme)
+	respondNegotiated(w, r, http.StatusOK, result, func() string { return theme.Apply(result.Text()) })
+}
+
+func AdvancedRecommendationHandler(w http.ResponseWriter, r *http.Request) {
+	region := getRegionOrDefault(r.URL.Query().Get("region"))
+
+	// Input eksplisit (sensor) tidak punya forecast
+	data, explicit, err := parseExplicitWeather(r.URL.Query(), region)
+	if err != nil {
+		writeAPIError(w, NewAPIError(http.StatusBadRequest, "invalid_weather_input", err.Error()))
+		return
+	}
+	explain := r.URL.Query().Get("explain") == "true"
+	cfg := RecommendationConfigFor(r.URL.Query().Get("variety"))
+	areaHa, err := parseAreaHa(r.URL.Query().Get("area_ha"))
+	if err != nil {
+		writeAPIError(w, NewAPIError(http.StatusBadRequest, "invalid_area", err.Error()))
+		return
+	}
+	theme, err := themeFromRequest(r)
+	if err != nil {
+		writeAPIError(w, asAPIError(err))
+		return
+	}
+	if explicit {
+		result := AdvancedRecommendation{RecommendationResult: RecommendForWeatherWithConfig(cfg, data, region)}
+		result.RecommendationResult = result.WithIrrigationArea(areaHa)
+		if explain {
+			explanation := ExplainRecommendation(cfg, data.Temp, data.Humidity, data.Rain)
+			result.Explanation = &explanation
+		}
+		respondAdvancedRecommendation(w, r, result, theme)
+		return
+	}
+
+	key := recommendationCacheKey("advanced", region, cfg.Variety)
+	result, err := cachedRecommendation(w, r, key, func() (AdvancedRecommendation, error) {
+		return fetchAdvancedRecommendation(r.Context(), region, cfg)
+	})
+	if err != nil {
+		writeAPIError(w, weatherAPIError(err))
+		return
+	}
+	result.RecommendationResult = result.WithIrrigationArea(areaHa)
+	if explain {
+		explanation := ExplainRecommendation(cfg, result.Temperature, result.Humidity, result.RainMM)
+		result.Explanation = &explanation
+	}
+	respondAdvancedRecommendation(w, r, result, theme)
+}
+
+// WeeklyRecommendationHandler rekomendasi per hari dari forecast 5 hari, untuk
+// perencanaan seminggu ke depan. Hari yang slot forecast-nya tidak lengkap
+// (biasanya hari ini & hari terakhir) ditandai confidence lebih rendah.
+func WeeklyRecommendationHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		region := getRegionOrDefault(r.URL.Query().Get("region"))
+		cfg := RecommendationConfigFor(r.URL.Query().Get("variety"))
+		theme, err := themeFromRequest(r)
+		if err != nil {
+			return err
+		}
+
+		key := recommendationCacheKey("week", region, cfg.Variety)
+		days, err := cachedRecommendation(w, r, key, func() ([]DailyRecommendation, error) {
+			entries, err := FetchWeatherForecastWithContext(r.Context(), region)
+			if err != nil {
+				return nil, err
+			}
+			return RecommendForecastDays(cfg, entries, region), nil
+		})
+		if err != nil {
+			return weatherAPIError(err)
+		}
+		if len(days) == 0 {
+			return NewAPIError(http.StatusBadGateway, "forecast_empty", "Forecast tidak berisi data untuk "+region)
+		}
+
+		days = Map(days, func(d DailyRecommendation) DailyRecommendation {
+			d.Recommendation = d.Recommendation.Themed(theme)
+			return d
+		})
+		return respondJSON(w, http.StatusOK, map[string]interface{}{
+			"region":  region,
+			"variety": cfg.Variety,
+			"days":    days,
+		})
+	})(w, r)
+}
+
+// RegionComparison hasil satu sisi perbandingan; Error terisi jika fetch cuaca gagal
+type RegionComparison struct {
+	Region       string                `json:"region"`
+	HarvestScore int                   `json:"harvest_score"`
+	Result       *RecommendationResult `json:"result,omitempty"`
+	Error        string                `json:"error,omitempty"`
+	err          error
+}
+
+func compareRegion(ctx context.Context, region string) RegionComparison {
+	data, err := FetchWeatherWithContext(ctx, region)
+	if err != nil {
+		log.Printf("Failed to fetch weather for %s: %v", region, err)
+		return RegionComparison{Region: region, Error: "Gagal mengambil data cuaca", err: err}
+	}
+	result := RecommendForWeather(data, region)
+	return RegionComparison{
+		Region:       region,
+		HarvestScore: HarvestScore(data.Temp, data.Humidity, data.Rain),
+		Result:       &result,
+	}
+}
+
+// harvestVerdict - pure function, membandingkan skor panen dua region
+func harvestVerdict(a, b RegionComparison) (better, verdict string) {
+	switch {
+	case a.HarvestScore > b.HarvestScore:
+		return a.Region, fmt.Sprintf("%s lebih cocok untuk panen hari ini (skor %d vs %d)", a.Region, a.HarvestScore, b.HarvestScore)
+	case b.HarvestScore > a.HarvestScore:
+		return b.Region, fmt.Sprintf("%s lebih cocok untuk panen hari ini (skor %d vs %d)", b.Region, b.HarvestScore, a.HarvestScore)
+	default:
+		return "", fmt.Sprintf("Kondisi panen %s dan %s setara (skor %d)", a.Region, b.Region, a.HarvestScore)
+	}
+}
+
+func CompareRecommendationHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		q := r.URL.Query()
+		regionA, regionB := NormalizeRegion(q.Get("a")), NormalizeRegion(q.Get("b"))
+		if regionA == "" || regionB == "" {
+			respondError(w, "Parameter a dan b wajib diisi", http.StatusBadRequest)
+			return nil
+		}
+		if regionA == regionB {
+			respondError(w, "Parameter a dan b harus region yang berbeda", http.StatusBadRequest)
+			return nil
+		}
+
+		var a, b RegionComparison
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() { defer wg.Done(); a = compareRegion(r.Context(), regionA) }()
+		go func() { defer wg.Done(); b = compareRegion(r.Context(), regionB) }()
+		wg.Wait()
+
+		response := map[string]interface{}{"a": a, "b": b}
+		switch {
+		case a.Error != "" && b.Error != "":
+			writeAPIError(w, weatherAPIError(a.err).WithDetails(map[string]string{
+				a.Region: a.Error,
+				b.Region: b.Error,
+			}))
+			return nil
+		case a.Error != "":
+			response["better"] = b.Region
+			response["note"] = fmt.Sprintf("Data cuaca %s tidak tersedia, hanya %s yang dievaluasi", a.Region, b.Region)
+		case b.Error != "":
+			response["better"] = a.Region
+			response["note"] = fmt.Sprintf("Data cuaca %s tidak tersedia, hanya %s yang dievaluasi", b.Region, a.Region)
+		default:
+			response["better"], response["verdict"] = harvestVerdict(a, b)
+		}
+
+		return respondJSON(w, http.StatusOK, response)
+	})(w, r)
+}
+
+const maxBestRegions = 20
+
+// rankRegions - pure function, urut skor panen menurun; skor sama diurutkan
+// berdasarkan nama region supaya hasil selalu sama untuk input yang sama
+func rankRegions(regions []RegionComparison) []RegionComparison {
+	ranked := append([]RegionComparison(nil), regions...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].HarvestScore != ranked[j].HarvestScore {
+			return ranked[i].HarvestScore > ranked[j].HarvestScore
+		}
+		return strings.ToLower(ranked[i].Region) < strings.ToLower(ranked[j].Region)
+	})
+	return ranked
+}
+
+// BestRegionHandler memilih region paling layak panen dari ?regions=a,b,c.
+// Region yang cuacanya gagal diambil tidak ikut diperingkat dan dicatat di "excluded"
+func BestRegionHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		regions := parseRegionList(r.URL.Query().Get("regions"))
+		if len(regions) == 0 {
+			return NewAPIError(http.StatusBadRequest, "invalid_regions", "parameter regions wajib diisi (mis. regions=Jember,Temanggung)")
+		}
+		if len(regions) > maxBestRegions {
+			return NewAPIError(http.StatusBadRequest, "too_many_regions",
+				fmt.Sprintf("maksimal %d region per request, didapat %d", maxBestRegions, len(regions)))
+		}
+
+		results := ParallelMap(regions, func(region string) RegionComparison {
+			return compareRegion(r.Context(), region)
+		})
+		ok, failed := Partition(results, func(c RegionComparison) bool { return c.Error == "" })
+		if len(ok) == 0 {
+			details := make(map[string]string, len(failed))
+			for _, c := range failed {
+				details[c.Region] = c.Error
+			}
+			writeAPIError(w, weatherAPIError(failed[0].err).WithDetails(details))
+			return nil
+		}
+
+		ranking := rankRegions(ok)
+		best := ranking[0]
+		reason := fmt.Sprintf("%s paling cocok untuk panen hari ini (skor %d)", best.Region, best.HarvestScore)
+		switch {
+		case len(ranking) > 1 && ranking[1].HarvestScore == best.HarvestScore:
+			reason = fmt.Sprintf("%s dan %s setara (skor %d); %s dipilih berdasarkan urutan nama",
+				best.Region, ranking[1].Region, best.HarvestScore, best.Region)
+		case len(ranking) > 1:
+			reason = fmt.Sprintf("%s paling cocok untuk panen hari ini (skor %d, berikutnya %s dengan skor %d)",
+				best.Region, best.HarvestScore, ranking[1].Region, ranking[1].HarvestScore)
+		}
+
+		response := map[string]interface{}{
+			"ranking": ranking,
+			"best":    best,
+			"reason":  reason,
+			"status_summary": CountBy(ranking, func(c RegionComparison) string {
+				return c.Result.Status
+			}),
+		}
+		if len(failed) > 0 {
+			response["excluded"] = failed
+			response["note"] = fmt.Sprintf("Data cuaca %s tidak tersedia, region tersebut tidak ikut diperingkat",
+				strings.Join(Map(failed, func(c RegionComparison) string { return c.Region }), ", "))
+		}
+		return respondJSON(w, http.StatusOK, response)
+	})(w, r)
+}
+
+// historicalTimeLayouts format ?at= tanpa zona waktu, dibaca sebagai WIB
+var historicalTimeLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04"}
+
+// parseHistoricalTime RFC3339 (dengan zona) atau waktu lokal WIB
+func parseHistoricalTime(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	for _, layout := range historicalTimeLayouts {
+		if t, err := time.ParseInLocation(layout, raw, reportTimezone); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("at harus RFC3339 (2025-01-02T08:00:00+07:00) atau WIB (2025-01-02 08:00), didapat %q", raw)
+}
+
+// HistoricalRecommendationHandler rekomendasi yang *akan* diberikan pada waktu lampau,
+// dihitung dari sampel weather_history terdekat (backtesting & pelatihan penyuluh)
+func HistoricalRecommendationHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		q := r.URL.Query()
+		region := getRegionOrDefault(q.Get("region"))
+		if q.Get("at") == "" {
+			return NewAPIError(http.StatusBadRequest, "invalid_at", "parameter at wajib diisi (mis. at=2025-01-02 08:00)")
+		}
+		at, err := parseHistoricalTime(q.Get("at"))
+		if err != nil {
+			return NewAPIError(http.StatusBadRequest, "invalid_at", err.Error())
+		}
+		theme, err := themeFromRequest(r)
+		if err != nil {
+			return err
+		}
+
+		weather, err := weatherStore.Nearest(region, at)
+		if errors.Is(err, sql.ErrNoRows) {
+			return NewAPIError(http.StatusNotFound, "weather_history_not_found",
+				fmt.Sprintf("Tidak ada data cuaca %s dalam ±%s dari %s", region, historicalWeatherMaxGap,
+					at.In(reportTimezone).Format("2006-01-02 15:04 MST")))
+		}
+		if err != nil {
+			return err
+		}
+
+		cfg := RecommendationConfigFor(q.Get("variety"))
+		result := RecommendForWeatherWithConfig(cfg, &weather.WeatherData, region).Themed(theme)
+		return respondJSON(w, http.StatusOK, map[string]interface{}{
+			"region":  region,
+			"at":      at.UTC().Format(time.RFC3339),
+			"weather": weather,
+			"result":  result,
+		})
+	})(w, r)
+}
+
+// BatchRecommendationItem hasil per item; Error terisi jika input tidak valid
+type BatchRecommendationItem struct {
+	Index  int                   `json:"index"`
+	Region string                `json:"region"`
+	Result *RecommendationResult `json:"result,omitempty"`
+	Error  string                `json:"error,omitempty"`
+	Field  string                `json:"field,omitempty"` // field yang tidak wajar jika Error terisi
+}
+
+// memoizedAdvancedRecommendation - input sensor sering identik antar request batch
+var memoizedAdvancedRecommendation = MemoizeLRU(256, func(in RecommendationInput) RecommendationResult {
+	return GetAdvancedRecommendationWithConfig(RecommendationConfigFor(in.Variety), in.Temp, in.Humidity, in.Rain, in.Region)
+})
+
+func evaluateRecommendationInput(index int, in RecommendationInput) BatchRecommendationItem {
+	item := BatchRecommendationItem{Index: index, Region: in.Region}
+	if err := in.WeatherData().Validate(); err != nil {
+		item.Error = err.Error()
+		var invalid *WeatherValidationError
+		if errors.As(err, &invalid) {
+			item.Field = invalid.Field
+		}
+		return item
+	}
+	result := memoizedAdvancedRecommendation(in)
+	item.Result = &result
+	return item
+}
+
+func BatchRecommendationHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		var inputs []RecommendationInput
+		if err := json.NewDecoder(r.Body).Decode(&inputs); err != nil {
+			if isBodyTooLarge(err) {
+				writeAPIError(w, errBodyTooLarge)
+				return nil
+			}
+			writeAPIError(w, errInvalidBody.WithDetails("body harus berupa array JSON"))
+			return nil
+		}
+
+		items := make([]BatchRecommendationItem, len(inputs))
+		for i, in := range inputs {
+			items[i] = evaluateRecommendationInput(i, in)
+		}
+
+		// Input tidak wajar dilaporkan per item (error + field, tanpa result) supaya satu sensor
+		// rusak tidak menggagalkan batch; 400 hanya jika tidak ada satu pun item yang valid
+		invalid := Filter(items, func(item BatchRecommendationItem) bool { return item.Error != "" })
+		if len(items) > 0 && len(invalid) == len(items) {
+			return NewAPIError(http.StatusBadRequest, "invalid_weather_input",
+				fmt.Sprintf("%d dari %d input berada di luar rentang wajar", len(invalid), len(items))).
+				WithDetails(invalid)
+		}
+
+		return respondJSON(w, http.StatusOK, items)
+	})(w, r)
+}
+
+// ============================================
+// WEBSOCKET - PUSH CUACA + REKOMENDASI
+// ============================================
+
+const defaultWSPushInterval = 60 * time.Second
+
+// wsAllowedOrigins diisi dari Config.CORSOrigins (env CORS_ORIGINS)
+var wsAllowedOrigins = []string{"*"}
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     checkWSOrigin,
+}
+
+// checkWSOrigin aturan origin yang sama dengan enableCORS. Browser selalu mengirim Origin
+// saat upgrade; tanpa header Origin berarti client non-browser (tidak terkena CSWSH).
+func checkWSOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	return origin == "" || allowedOrigin(wsAllowedOrigins, origin) != ""
+}
+
+// wsPushInterval diisi dari Config.WSPushInterval (env WS_PUSH_INTERVAL)
+var wsPushInterval = defaultWSPushInterval
+
+func buildWeatherRecommendation(region string) (RecommendationResult, error) {
+	data, err := FetchWeatherCached(region)
+	if err != nil {
+		return RecommendationResult{}, err
+	}
+	return RecommendForWeather(data, region), nil
+}
+
+func WeatherWebSocketHandler(w http.ResponseWriter, r *http.Request) {
+	region := getRegionOrDefault(r.URL.Query().Get("region"))
+
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade sudah menulis response error ke client
+		log.Printf("WebSocket upgrade gagal: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	// Read loop: wajib untuk memproses control frame (close/ping)
+	// dan mendeteksi client yang disconnect
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	push := func() error {
+		result, err := buildWeatherRecommendation(region)
+		if err != nil {
+			log.Printf("WebSocket: gagal mengambil cuaca %s: %v", region, err)
+			return conn.WriteJSON(buildStatusResponse("error", "Gagal mengambil data cuaca"))
+		}
+		return conn.WriteJSON(result)
+	}
+
+	if err := push(); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(wsPushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			log.Printf("WebSocket client disconnected: %s (%s)", r.RemoteAddr, region)
+			return
+		case <-ticker.C:
+			if err := push(); err != nil {
+				log.Printf("WebSocket write error: %v", err)
+				return
+			}
+		}
+	}
+}
+
+// WeatherAPIHandler lewat cache lalu singleflight: burst dashboard (bersamaan maupun
+// berselang beberapa ms) hanya memicu satu panggilan OWM, dan ikut batal bersama request
+func WeatherAPIHandler(w http.ResponseWriter, r *http.Request) {
+	makeWeatherHandler(func(region string) (*WeatherData, error) {
+		return FetchWeatherCachedWithContext(r.Context(), region)
+	})(w, r)
+}
+
+var defaultMultiRegions = []string{"Jember", "Surabaya", "Malang", "Banyuwangi"}
+
+func MultiRegionForecastHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		regions := defaultMultiRegions
+		if raw := r.URL.Query().Get("regions"); raw != "" {
+			regions = parseRegionList(raw)
+		}
+
+		units, err := unitsFromRequest(r)
+		if err != nil {
+			return err
+		}
+		forecasts := FetchMultipleRegionsForecast(r.Context(), regions)
+		_, failed := Partition(regions, func(region string) bool {
+			_, ok := forecasts[region]
+			return ok
+		})
+
+		// Satu list datar (urut per region sesuai query) agar mudah dipakai chart
+		entries := FlatMap(regions, func(region string) []WeatherData {
+			return Map(forecasts[region], func(e WeatherData) WeatherData { return e.InUnits(units) })
+		})
+
+		daily := make(map[string][]DailyRainProbability, len(forecasts))
+		for region, regionEntries := range forecasts {
+			daily[region] = SummarizeRainProbabilityByDay(regionEntries)
+		}
+
+		return respondJSON(w, http.StatusOK, map[string]interface{}{
+			"entries":                entries,
+			"daily_rain_probability": daily,
+			"failed_regions":         failed,
+		})
+	})(w, r)
+}
+
+func WeatherDailyHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		region := getRegionOrDefault(r.URL.Query().Get("region"))
+
+		days := defaultWeatherHistoryDays
+		if raw := r.URL.Query().Get("days"); raw != "" {
+			v, err := strconv.Atoi(raw)
+			if err != nil || v < 1 || v > maxWeatherHistoryDays {
+				return NewAPIError(http.StatusBadRequest, "invalid_days",
+					fmt.Sprintf("days harus 1-%d", maxWeatherHistoryDays))
+			}
+			days = v
+		}
+
+		daily, err := weatherStore.DailyHistory(region, days)
+		if err != nil {
+			return err
+		}
+		if daily == nil {
+			daily = []WeatherDailyAggregate{}
+		}
+
+		return respondJSON(w, http.StatusOK, map[string]interface{}{
+			"region":   region,
+			"days":     days,
+			"timezone": "Asia/Jakarta",
+			"daily":    daily,
+		})
+	})(w, r)
+}
+
+// parseRegionList memecah "a,b,c" dan menghapus duplikat (case-insensitive)
+func parseRegionList(raw string) []string {
+	return normalizeRegionList(strings.Split(raw, ","))
+}
+
+// normalizeRegionList menormalisasi nama region, membuang yang kosong dan duplikat
+func normalizeRegionList(names []string) []string {
+	regions := Filter(Map(names, NormalizeRegion), func(r string) bool {
+		return r != ""
+	})
+	return DistinctBy(regions, strings.ToLower)
+}
+
+// weatherMapInUnits salinan hasil per region dalam satuan u
+func weatherMapInUnits(results map[string]*WeatherData, u UnitSystem) map[string]WeatherData {
+	converted := make(map[string]WeatherData, len(results))
+	for region, data := range results {
+		converted[region] = data.InUnits(u)
+	}
+	return converted
+}
+
+func MultiRegionWeatherHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		regions := defaultMultiRegions
+		if raw := r.URL.Query().Get("regions"); raw != "" {
+			regions = parseRegionList(raw)
+		}
+		units, err := unitsFromRequest(r)
+		if err != nil {
+			return err
+		}
+		results := FetchMultipleRegionsWeatherWithContext(r.Context(), regions)
+		return respondJSON(w, http.StatusOK, weatherMapInUnits(results, units))
+	})(w, r)
+}
+
+const (
+	maxBatchWeatherRegions = 20
+	batchWeatherWorkers    = 5
+)
+
+type weatherBatchRequest struct {
+	Regions []string `json:"regions"`
+}
+
+type weatherBatchResult struct {
+	region string
+	data   *WeatherData
+	err    error
+}
+
+// fetchWeatherBatch mengambil cuaca (cache dulu) lewat worker pool terbatas agar
+// batch besar tidak membuka puluhan koneksi OWM sekaligus
+func fetchWeatherBatch(ctx context.Context, regions []string) (map[string]*WeatherData, map[string]string) {
+	pool := NewWorkerPool(batchWeatherWorkers, func(region string) weatherBatchResult {
+		data, err := FetchWeatherCachedWithContext(ctx, region)
+		return weatherBatchResult{region: region, data: data, err: err}
+	})
+	go func() {
+		for _, region := range regions {
+			pool.Submit(region)
+		}
+		pool.Close()
+	}()
+
+	results := make(map[string]*WeatherData)
+	failures := make(map[string]string)
+	for res := range pool.Results() {
+		if res.err != nil {
+			log.Printf("Failed to fetch weather for %s: %v", res.region, res.err)
+			failures[res.region] = weatherAPIError(res.err).Message
+			continue
+		}
+		results[res.region] = res.data
+	}
+	return results, failures
+}
+
+func BatchWeatherHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		var req weatherBatchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			if isBodyTooLarge(err) {
+				return errBodyTooLarge
+			}
+			return errInvalidBody.WithDetails(`body harus berupa {"regions": ["Jember", ...]}`)
+		}
+
+		regions := normalizeRegionList(req.Regions)
+		if len(regions) == 0 {
+			return NewAPIError(http.StatusBadRequest, "invalid_regions", "regions tidak boleh kosong")
+		}
+		if len(regions) > maxBatchWeatherRegions {
+			return NewAPIError(http.StatusBadRequest, "too_many_regions",
+				fmt.Sprintf("maksimal %d region per request, didapat %d", maxBatchWeatherRegions, len(regions)))
+		}
+
+		units, err := unitsFromRequest(r)
+		if err != nil {
+			return err
+		}
+		results, failures := fetchWeatherBatch(r.Context(), regions)
+		return respondJSON(w, http.StatusOK, map[string]interface{}{
+			"results": weatherMapInUnits(results, units),
+			"errors":  failures,
+		})
+	})(w, r)
+}
+
+var errUnsupportedMediaType = errors.New("content type tidak didukung")
+
+// decodePriceRequest membaca Price dari body JSON atau form-urlencoded.
+// Content-Type kosong dianggap JSON agar client lama tetap berjalan.
+func decodePriceRequest(r *http.Request) (Price, error) {
+	var p Price
+
+	mediaType := "application/json"
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		parsed, _, err := mime.ParseMediaType(ct)
+		if err != nil {
+			return p, errUnsupportedMediaType
+		}
+		mediaType = parsed
+	}
+
+	switch mediaType {
+	case "application/json":
+		err := json.NewDecoder(r.Body).Decode(&p)
+		return p, err
+
+	case "application/x-www-form-urlencoded":
+		if err := r.ParseForm(); err != nil {
+			return p, err
+		}
+		price, err := strconv.ParseFloat(strings.TrimSpace(r.PostForm.Get("price")), 64)
+		if err != nil {
+			return p, fmt.Errorf("field price bukan angka: %w", err)
+		}
+		p.Region = r.PostForm.Get("region")
+		p.Price = price
+		p.Unit = r.PostForm.Get("unit")
+		p.Source = r.PostForm.Get("source")
+		p.RecordedAt = r.PostForm.Get("recorded_at")
+		return p, nil
+
+	default:
+		return p, errUnsupportedMediaType
+	}
+}
+
+func AddPriceHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		p, err := decodePriceRequest(r)
+		if errors.Is(err, errUnsupportedMediaType) {
+			writeAPIError(w, NewAPIError(http.StatusUnsupportedMediaType, "unsupported_media_type",
+				"Content-Type harus application/json atau application/x-www-form-urlencoded"))
+			return nil
+		}
+		if isBodyTooLarge(err) {
+			writeAPIError(w, errBodyTooLarge)
+			return nil
+		}
+		if err != nil {
+			writeAPIError(w, errInvalidBody)
+			return nil
+		}
+		p = normalizePriceInput(p)
+		p.SourceType = sourceTypeManual
+
+		saved, err := priceStore.Add(p)
+		if err != nil {
+			return err
+		}
+
+		priceBroker.Publish(saved)
+
+		response := buildStatusResponse("ok", "Data harga berhasil ditambahkan")
+		return respondJSON(w, http.StatusOK, response)
+	})(w, r)
+}
+
+// respondFetchDryRun menjalankan alur scraping -> simulasi tanpa menulis ke database
+func respondFetchDryRun(w http.ResponseWriter, r *http.Request) error {
+	source := "scraper"
+	prices, err := PreviewScrapedPrices(r.Context())
+	if ctx := r.Context(); ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		log.Printf("Dry run: scraping failed, fallback to simulation: %v", err)
+		source = "simulation"
+		prices = PreviewSimulatedPrices()
+	}
+
+	return respondJSON(w, http.StatusOK, map[string]interface{}{
+		"status":       "ok",
+		"dry_run":      true,
+		"source":       source,
+		"would_insert": prices,
+	})
+}
+
+// fetchPricesDebounceWindow klik "fetch" beruntun dalam jendela ini hanya memicu satu scrape
+const fetchPricesDebounceWindow = 10 * time.Second
+
+// lastPriceFetch hasil scrape terakhir yang dijalankan lewat /harga/fetch
+var lastPriceFetch struct {
+	sync.Mutex
+	report PriceFetchReport
+	at     time.Time
+}
+
+// newPriceFetchDebounce scrape multi-sumber yang diredam selama window setelah selesai.
+// Scrape memakai context sendiri (bukan milik request) karena hasilnya juga dipakai
+// caller yang diredam atau ikut menunggu.
+func newPriceFetchDebounce(window time.Duration) func() bool {
+	return Debounce(window, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), scrapeRequestTimeout)
+		defer cancel()
+		report := FetchPricesFromSources(ctx, priceSources())
+
+		lastPriceFetch.Lock()
+		lastPriceFetch.report, lastPriceFetch.at = report, time.Now()
+		lastPriceFetch.Unlock()
+	})
+}
+
+// debouncedPriceFetch bisa diganti dengan jendela lain (mis. jendela pendek di test)
+var debouncedPriceFetch = newPriceFetchDebounce(fetchPricesDebounceWindow)
+
+// priceFetchFlight menggabungkan POST /harga/fetch yang bersamaan: hanya satu scrape
+// berjalan, request lain menunggu dan memakai hasil scrape yang sama
+var priceFetchFlight singleflight.Group
+
+const priceFetchFlightKey = "harga/fetch"
+
+// priceFetchResult hasil satu flight; Debounced = scrape diredam, Report milik scrape terakhir
+type priceFetchResult struct {
+	Report    PriceFetchReport
+	At        time.Time
+	Debounced bool
+}
+
+// fetchPricesCoalesced menjalankan atau ikut menunggu fetch multi-sumber yang sedang berjalan.
+// shared=true berarti hasilnya dibagi dengan request lain.
+func fetchPricesCoalesced(ctx context.Context) (result priceFetchResult, shared bool, err error) {
+	ch := priceFetchFlight.DoChan(priceFetchFlightKey, func() (interface{}, error) {
+		ran := debouncedPriceFetch()
+
+		lastPriceFetch.Lock()
+		defer lastPriceFetch.Unlock()
+		return priceFetchResult{Report: lastPriceFetch.report, At: lastPriceFetch.at, Debounced: !ran}, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return result, false, ctx.Err()
+	case res := <-ch:
+		return res.Val.(priceFetchResult), res.Shared, res.Err
+	}
+}
+
+// scheduledPriceFetch job scheduler scrape harga. Memakai flight yang sama dengan
+// POST /harga/fetch sehingga scrape terjadwal dan manual tidak pernah berjalan bersamaan.
+func scheduledPriceFetch(ctx context.Context) error {
+	result, _, err := fetchPricesCoalesced(ctx)
+	if err != nil {
+		return err
+	}
+	if result.Debounced {
+		log.Printf("Scrape terjadwal dilewati: fetch terakhir %s", result.At.Format(time.RFC3339))
+		return nil
+	}
+	if result.Report.Succeeded == 0 {
+		return fmt.Errorf("semua %d sumber harga gagal", result.Report.Sources)
+	}
+	return nil
+}
+
+func FetchPricesHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		if r.URL.Query().Get("dry_run") == "true" {
+			return respondFetchDryRun(w, r)
+		}
+
+		result, shared, err := fetchPricesCoalesced(r.Context())
+		if err != nil {
+			return err
+		}
+		// Klik beruntun setelah scrape selesai: pakai hasil terakhir tanpa scrape ulang
+		if result.Debounced {
+			return respondJSON(w, http.StatusOK, map[string]interface{}{
+				"status":        "ok",
+				"message":       "Fetch harga baru saja dijalankan, memakai hasil terakhir",
+				"debounced":     true,
+				"last_fetch_at": result.At.Format(time.RFC3339),
+			})
+		}
+
+		report := result.Report
+		if report.Succeeded == 0 {
+			return NewAPIError(http.StatusBadGateway, "price_fetch_failed", "Semua sumber harga gagal").
+				WithDetails(report)
+		}
+
+		status := "ok"
+		if len(report.Failed) > 0 {
+			status = "partial"
+		}
+		return respondJSON(w, http.StatusOK, map[string]interface{}{
+			"status": status,
+			"message": fmt.Sprintf("Berhasil fetch dan simpan harga dari %d/%d sumber (Web Scraping + Market Data)",
+				report.Succeeded, report.Sources),
+			"coalesced": shared,
+			"sources":   report,
+		})
+	})(w, r)
+}
+
+func ScrapeStatusHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		sources, lastRun := scrapeStatus.Snapshot()
+		return respondJSON(w, http.StatusOK, map[string]interface{}{
+			"sources":  sources,
+			"last_run": lastRun,
+			"breakers": []BreakerSnapshot{bappebtiBreaker.Snapshot()},
+		})
+	})(w, r)
+}
+
+// HealthHandler liveness probe: proses hidup dan bisa melayani request
+func HealthHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		build := currentBuildInfo()
+		response := buildStatusResponse("ok", "Server berjalan")
+		response["version"] = build.Version
+		response["commit"] = build.Commit
+		response["build_time"] = build.BuildTime
+		return respondJSON(w, http.StatusOK, response)
+	})(w, r)
+}
+
+// ReadinessHandler readiness probe: DB & schema wajib sehat (503 jika tidak), OWM non-kritis
+// sehingga masalah cuaca hanya menjadi warning dengan status 200
+func ReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		checks := map[string]interface{}{}
+		var warnings []string
+
+		if err := priceStore.Ping(r.Context()); err != nil {
+			log.Printf("Readiness: database tidak sehat: %v", err)
+			return NewAPIError(http.StatusServiceUnavailable, "not_ready", "Database tidak tersedia").
+				WithDetails(map[string]string{"database": err.Error()})
+		}
+		checks["database"] = "ok"
+
+		// Store in-memory (PRICE_STORE=memory) tidak punya schema untuk diperiksa
+		checks["schema"] = "n/a"
+		if DB != nil {
+			if err := VerifySchema(DB); err != nil {
+				log.Printf("Readiness: %v", err)
+				var drift *SchemaDriftError
+				if errors.As(err, &drift) {
+					return NewAPIError(http.StatusServiceUnavailable, "schema_drift", err.Error()).WithDetails(drift)
+				}
+				return NewAPIError(http.StatusServiceUnavailable, "not_ready", "Gagal memeriksa schema database").
+					WithDetails(map[string]string{"schema": err.Error()})
+			}
+			checks["schema"] = "ok"
+		}
+
+		owm := weatherHealth.Snapshot(weatherConfig.APIKey != "")
+		checks["weather_provider"] = owm
+		switch owm.Status {
+		case ProviderDegraded:
+			warnings = append(warnings, "OpenWeatherMap gagal pada fetch terakhir: "+owm.LastError)
+		case ProviderUnconfigured:
+			warnings = append(warnings, "OWM_API_KEY belum diset, endpoint cuaca tidak berfungsi")
+		}
+
+		breaker := owmBreaker.Snapshot()
+		checks["weather_rate_limit_breaker"] = breaker
+		if breaker.State != BreakerClosed {
+			warnings = append(warnings, "Circuit breaker OWM "+string(breaker.State)+" karena rate limit (HTTP 429)")
+		}
+
+		status := "ready"
+		if len(warnings) > 0 {
+			status = "degraded"
+		}
+		return respondJSON(w, http.StatusOK, map[string]interface{}{
+			"status":   status,
+			"checks":   checks,
+			"warnings": warnings,
+		})
+	})(w, r)
+}
+
+func WeatherStatsHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		return respondJSON(w, http.StatusOK, map[string]interface{}{
+			"window_size": latencyWindowSize,
+			"regions":     weatherLatency.Snapshot(),
+		})
+	})(w, r)
+}
+
+// PriceWithWeather response gabungan /harga/current?with_weather=true.
+// Weather null + Warning terisi jika cuaca gagal diambil.
+type PriceWithWeather struct {
+	Region               string       `json:"region"`
+	Price                Price        `json:"price"`
+	Weather              *WeatherData `json:"weather"`
+	RecommendationStatus string       `json:"recommendation_status,omitempty"`
+	Warning              string       `json:"warning,omitempty"`
+}
+
+// latestPriceWithWeather membaca harga dari DB sambil mengambil cuaca (cache) secara concurrent
+func latestPriceWithWeather(region string) (PriceWithWeather, error) {
+	type weatherResult struct {
+		data *WeatherData
+		err  error
+	}
+	weatherCh := make(chan weatherResult, 1)
+	go func() {
+		data, err := FetchWeatherCached(region)
+		weatherCh <- weatherResult{data, err}
+	}()
+
+	price, err := priceStore.GetLatest(region)
+	weather := <-weatherCh
+	if err != nil {
+		return PriceWithWeather{}, err
+	}
+
+	response := PriceWithWeather{Region: region, Price: price}
+	if weather.err != nil {
+		log.Printf("Failed to fetch weather for %s: %v", region, weather.err)
+		response.Warning = "Data cuaca tidak tersedia: " + weather.err.Error()
+		return response, nil
+	}
+
+	response.Weather = weather.data
+	response.RecommendationStatus = RecommendForWeather(weather.data, region).Status
+	return response, nil
+}
+
+func errPriceNotFound(region string) *APIError {
+	return NewAPIError(http.StatusNotFound, "price_not_found", "Belum ada data harga untuk region "+region)
+}
+
+// persistPriceFallback diisi dari PRICE_FALLBACK_PERSIST (lihat applyConfig)
+var persistPriceFallback = false
+
+// Asal harga fallback /harga/current
+const (
+	priceOriginLiveScrape = "live_scrape"
+	priceOriginResearch   = "research_fallback"
+)
+
+// FallbackPrice harga yang diambil langsung dari scraper karena DB belum punya data region
+type FallbackPrice struct {
+	Price
+	Fallback  bool   `json:"fallback"`
+	Origin    string `json:"origin"`
+	Persisted bool   `json:"persisted"`
+}
+
+// fallbackCurrentPrice scrape harga satu region (BAPPEBTI, lalu data riset manual)
+func fallbackCurrentPrice(ctx context.Context, region string) (FallbackPrice, error) {
+	scraped, fromFallback, err := NewScraperManager().ScrapeRegion(ctx, region)
+	if err != nil {
+		return FallbackPrice{}, err
+	}
+
+	result := FallbackPrice{Price: scrapedToPrice(scraped), Fallback: true, Origin: priceOriginLiveScrape}
+	if fromFallback {
+		result.Origin = priceOriginResearch
+	}
+
+	if persistPriceFallback {
+		if err := SaveScrapedPrice(scraped); err != nil {
+			log.Printf("Failed to persist fallback price for %s: %v", region, err)
+		} else {
+			result.Persisted = true
+		}
+	}
+	return result, nil
+}
+
+const maxBatchPriceRegions = 50
+
+// BatchCurrentPriceHandler harga terbaru untuk ?regions=a,b,c; region tanpa data masuk "missing"
+func BatchCurrentPriceHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		regions := parseRegionList(r.URL.Query().Get("regions"))
+		if len(regions) == 0 {
+			return NewAPIError(http.StatusBadRequest, "invalid_regions", "parameter regions wajib diisi (mis. regions=Jember,Temanggung)")
+		}
+		if len(regions) > maxBatchPriceRegions {
+			return NewAPIError(http.StatusBadRequest, "too_many_regions",
+				fmt.Sprintf("maksimal %d region per request, didapat %d", maxBatchPriceRegions, len(regions)))
+		}
+
+		prices, err := priceStore.GetLatestMany(regions)
+		if err != nil {
+			return err
+		}
+		missing := Filter(regions, func(region string) bool {
+			_, ok := prices[region]
+			return !ok
+		})
+		if missing == nil {
+			missing = []string{}
+		}
+
+		return respondJSON(w, http.StatusOK, map[string]interface{}{
+			"prices":  prices,
+			"missing": missing,
+		})
+	})(w, r)
+}
+
+// GetCurrentPriceHandler harga terakhir dari DB; deployment baru yang DB-nya masih
+// kosong mendapat harga hasil scraping (ditandai fallback: true) alih-alih error
+func GetCurrentPriceHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		region := getRegionOrDefault(r.URL.Query().Get("region"))
+
+		if r.URL.Query().Get("with_weather") == "true" {
+			response, err := latestPriceWithWeather(region)
+			if errors.Is(err, sql.ErrNoRows) {
+				return errPriceNotFound(region)
+			}
+			if err != nil {
+				return err
+			}
+			return respondJSON(w, http.StatusOK, response)
+		}
+
+		jsonData, err := GetLatestPriceJSON(region)
+		if errors.Is(err, sql.ErrNoRows) {
+			fallback, err := fallbackCurrentPrice(r.Context(), region)
+			if ctxErr := r.Context().Err(); ctxErr != nil {
+				return ctxErr
+			}
+			if err != nil {
+				log.Printf("Price fallback for %s failed: %v", region, err)
+				return errPriceNotFound(region)
+			}
+			return respondJSON(w, http.StatusOK, fallback)
+		}
+		if err != nil {
+			return err
+		}
+
+		w.Write([]byte(jsonData))
+		return nil
+	})(w, r)
+}
+
+func PriceStatsHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		stats, err := priceStore.Stats(r.URL.Query().Get("region"))
+		if err != nil {
+			return err
+		}
+		if stats == nil {
+			stats = []RegionPriceStats{}
+		}
+
+		// ?threshold=: region dipisah berdasarkan rata-rata harga all-time
+		raw := r.URL.Query().Get("threshold")
+		if raw == "" {
+			return respondJSON(w, http.StatusOK, stats)
+		}
+		threshold, err := strconv.ParseFloat(raw, 64)
+		if err != nil || threshold < 0 {
+			return NewAPIError(http.StatusBadRequest, "invalid_threshold", "threshold harus angka >= 0")
+		}
+
+		above, below := Partition(stats, func(s RegionPriceStats) bool {
+			return s.Avg >= threshold
+		})
+		regionName := func(s RegionPriceStats) string { return s.Region }
+		return respondJSON(w, http.StatusOK, map[string]interface{}{
+			"threshold":       threshold,
+			"regions":         stats,
+			"above_threshold": Map(above, regionName),
+			"below_threshold": Map(below, regionName),
+		})
+	})(w, r)
+}
+
+const (
+	defaultTrendWindow = 7
+	defaultTrendPoints = 90
+)
+
+func PriceTrendHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		q := r.URL.Query()
+		region := getRegionOrDefault(q.Get("region"))
+
+		window := defaultTrendWindow
+		if raw := q.Get("window"); raw != "" {
+			v, err := strconv.Atoi(raw)
+			if err != nil || v < 1 || v > maxPageLimit {
+				return NewAPIError(http.StatusBadRequest, "invalid_window", fmt.Sprintf("window harus 1-%d", maxPageLimit))
+			}
+			window = v
+		}
+
+		series, err := priceStore.ByRegion(region, defaultTrendPoints)
+		if err != nil {
+			return err
+		}
+		timestamps := Map(series, func(p Price) string { return p.RecordedAt })
+		prices := Map(series, func(p Price) float64 { return p.Price })
+
+		return respondJSON(w, http.StatusOK, map[string]interface{}{
+			"region": region,
+			"window": window,
+			"points": BuildPriceTrend(timestamps, prices, window),
+		})
+	})(w, r)
+}
+
+// parseDateParam memvalidasi parameter tanggal opsional berformat YYYY-MM-DD
+func parseDateParam(q url.Values, key string) (string, error) {
+	raw := strings.TrimSpace(q.Get(key))
+	if raw == "" {
+		return "", nil
+	}
+	if _, err := time.Parse("2006-01-02", raw); err != nil {
+		return "", fmt.Errorf("%s harus berformat YYYY-MM-DD, didapat %q", key, raw)
+	}
+	return raw, nil
+}
+
+func PriceSourcesHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		q := r.URL.Query()
+		from, err := parseDateParam(q, "from")
+		if err != nil {
+			return NewAPIError(http.StatusBadRequest, "invalid_date", err.Error())
+		}
+		to, err := parseDateParam(q, "to")
+		if err != nil {
+			return NewAPIError(http.StatusBadRequest, "invalid_date", err.Error())
+		}
+		if from != "" && to != "" && from > to {
+			return NewAPIError(http.StatusBadRequest, "invalid_date", "from tidak boleh setelah to")
+		}
+
+		breakdown, err := priceStore.SourceBreakdown(from, to)
+		if err != nil {
+			return err
+		}
+		return respondJSON(w, http.StatusOK, breakdown)
+	})(w, r)
+}
+
+// Paginated pembungkus generik untuk endpoint list; Total = jumlah seluruh data
+// setelah filter (bukan jumlah item di halaman ini)
+type Paginated[T any] struct {
+	Data    []T `json:"data"`
+	Total   int `json:"total"`
+	Limit   int `json:"limit"`
+	Offset  int `json:"offset"`
+	Skipped int `json:"skipped,omitempty"` // baris yang gagal dibaca dari DB (lihat log)
+}
+
+func NewPaginated[T any](data []T, total, limit, offset int) Paginated[T] {
+	if data == nil {
+		data = []T{}
+	}
+	return Paginated[T]{Data: data, Total: total, Limit: limit, Offset: offset}
+}
+
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 500
+)
+
+// parsePagination membaca ?limit=&offset= dengan default & batas atas
+func parsePagination(q url.Values) (limit, offset int, err error) {
+	limit, offset = defaultPageLimit, 0
+	if raw := q.Get("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return 0, 0, fmt.Errorf("limit harus 1-%d, didapat %q", maxPageLimit, raw)
+		}
+	}
+	if raw := q.Get("offset"); raw != "" {
+		offset, err = strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("offset harus bilangan bulat >= 0, didapat %q", raw)
+		}
+	}
+	return limit, offset, nil
+}
+
+func PricesHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		q := r.URL.Query()
+		limit, offset, err := parsePagination(q)
+		if err != nil {
+			return NewAPIError(http.StatusBadRequest, "invalid_pagination", err.Error())
+		}
+
+		result, err := priceStore.GetAll(PriceQuery{Region: q.Get("region"), Limit: limit, Offset: offset})
+		if err != nil {
+			log.Println("DB error:", err)
+			return err
+		}
+
+		// Baris rusak dilewati, tapi dihitung & dilaporkan ke client
+		page := NewPaginated(result.Prices, result.Total, limit, offset)
+		page.Skipped = result.Skipped
+		if result.Skipped > 0 {
+			w.Header().Set("X-Skipped-Rows", strconv.Itoa(result.Skipped))
+		}
+
+		// ?raw=true: array polos seperti response lama (backward compatibility)
+		if q.Get("raw") == "true" {
+			return respondJSON(w, http.StatusOK, page.Data)
+		}
+		return respondJSON(w, http.StatusOK, page)
+	})(w, r)
+}
+
+// sseKeepAliveInterval interval komentar keep-alive agar proxy tidak memutus koneksi
+const sseKeepAliveInterval = 15 * time.Second
+
+func PriceStreamHandler(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondError(w, "Streaming tidak didukung", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	events := priceBroker.Subscribe()
+	defer priceBroker.Unsubscribe(events)
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			log.Printf("SSE client disconnected: %s", r.RemoteAddr)
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case p, ok := <-events:
+			if !ok {
+				return
+			}
+			payload, err := json.Marshal(p)
+			if err != nil {
+				log.Printf("SSE marshal error: %v", err)
+				continue
+			}
+			fmt.Fprintf(w, "event: price\ndata: %s\n\n", payload)
+			flusher.Flush()
+		}
+	}
 }
 
 func FilterPricesByRegion(prices []Price, region string) []Price {
+	region = NormalizeRegion(region)
 	return Filter(prices, func(p Price) bool {
-		return p.Region == region
+		return NormalizeRegion(p.Region) == region
 	})
 }
 
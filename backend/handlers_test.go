@@ -0,0 +1,1730 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// failingPriceStore PriceStore palsu: baca selalu gagal, sisanya diteruskan ke PriceStore tertanam
+type failingPriceStore struct {
+	PriceStore
+	err error
+}
+
+func (s failingPriceStore) GetAll(PriceQuery) (PricePage, error)     { return PricePage{}, s.err }
+func (s failingPriceStore) Stats(string) ([]RegionPriceStats, error) { return nil, s.err }
+func (s failingPriceStore) Each(string, func(Price) error) error     { return s.err }
+func (s failingPriceStore) Add(p Price) (Price, error)               { return p, s.err }
+func (s failingPriceStore) AddBatch([]Price) ([]Price, error)        { return nil, s.err }
+func (s failingPriceStore) SourceBreakdown(string, string) (PriceSourceBreakdown, error) {
+	return PriceSourceBreakdown{}, s.err
+}
+
+// serve memanggil handler langsung (tanpa middleware route)
+func serve(handler http.HandlerFunc, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func decodeBody(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("body bukan JSON (%v): %s", err, rec.Body.String())
+	}
+}
+
+func TestPriceHandlersReadFromStore(t *testing.T) {
+	store := NewMemoryPriceStore()
+	useStores(t, store, NewMemoryWeatherStore())
+	mustAdd(t, store,
+		testPrice("Jember", 40000, "2026-01-01"),
+		testPrice("Jember", 42000, "2026-01-02"),
+		testPrice("Jember", 44000, "2026-01-03"),
+		testPrice("Bondowoso", 38000, "2026-01-01"))
+
+	t.Run("list", func(t *testing.T) {
+		rec := serve(PricesHandler, http.MethodGet, "/harga?region=jember&limit=2", "")
+		var page Paginated[Price]
+		decodeBody(t, rec, &page)
+		if rec.Code != http.StatusOK || page.Total != 3 || len(page.Data) != 2 || page.Data[0].Price != 44000 {
+			t.Fatalf("GET /harga = %d %+v", rec.Code, page)
+		}
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		rec := serve(PriceStatsHandler, http.MethodGet, "/harga/stats", "")
+		var stats []RegionPriceStats
+		decodeBody(t, rec, &stats)
+		if rec.Code != http.StatusOK || len(stats) != 2 || stats[1].Region != "Jember" || stats[1].Avg != 42000 {
+			t.Fatalf("GET /harga/stats = %d %+v", rec.Code, stats)
+		}
+	})
+
+	t.Run("export", func(t *testing.T) {
+		rec := serve(ExportPricesHandler, http.MethodGet, "/harga/export?region=Bondowoso&locale=en", "")
+		lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+		if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") || len(lines) != 2 {
+			t.Fatalf("GET /harga/export = %d %q", rec.Code, rec.Body.String())
+		}
+		if !strings.HasPrefix(lines[1], "Bondowoso,38000.00") {
+			t.Fatalf("baris CSV = %q", lines[1])
+		}
+	})
+
+	t.Run("sources", func(t *testing.T) {
+		rec := serve(PriceSourcesHandler, http.MethodGet, "/harga/sources?from=2026-01-02", "")
+		var breakdown PriceSourceBreakdown
+		decodeBody(t, rec, &breakdown)
+		if rec.Code != http.StatusOK || breakdown.Total != 2 {
+			t.Fatalf("GET /harga/sources = %d %+v", rec.Code, breakdown)
+		}
+	})
+
+	t.Run("add", func(t *testing.T) {
+		rec := serve(AddPriceHandler, http.MethodPost, "/harga/add", `{"region":"situbondo","price":39000}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("POST /harga/add = %d %s", rec.Code, rec.Body.String())
+		}
+		if p, err := store.GetLatest("Situbondo"); err != nil || p.Price != 39000 || p.SourceType != sourceTypeManual {
+			t.Fatalf("harga tersimpan = %+v, %v", p, err)
+		}
+	})
+}
+
+func TestPriceHandlersStoreError(t *testing.T) {
+	useStores(t, failingPriceStore{PriceStore: NewMemoryPriceStore(), err: errors.New("disk penuh")}, NewMemoryWeatherStore())
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		target  string
+		body    string
+	}{
+		{"list", PricesHandler, http.MethodGet, "/harga", ""},
+		{"stats", PriceStatsHandler, http.MethodGet, "/harga/stats", ""},
+		// Error sebelum baris pertama tetap dijawab JSON, bukan CSV terpotong
+		{"export", ExportPricesHandler, http.MethodGet, "/harga/export", ""},
+		{"sources", PriceSourcesHandler, http.MethodGet, "/harga/sources", ""},
+		{"add", AddPriceHandler, http.MethodPost, "/harga/add", `{"region":"Jember","price":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(tt.handler, tt.method, tt.target, tt.body)
+			var env struct{ Error APIError }
+			decodeBody(t, rec, &env)
+			if rec.Code != http.StatusInternalServerError || env.Error.Code != "internal_error" ||
+				rec.Header().Get("Content-Type") != "application/json" {
+				t.Fatalf("%s %s = %d %s", tt.method, tt.target, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestWeatherDailyHistoryHandlerReadsStore(t *testing.T) {
+	weather := NewMemoryWeatherStore()
+	useStores(t, NewMemoryPriceStore(), weather)
+	weather.Add(WeatherHistoryRow{Region: "Jember", TempC: floatPtr(27), Humidity: intPtr(70), FetchedAt: formatFetchedAt(time.Now())})
+
+	rec := serve(WeatherDailyHistoryHandler, http.MethodGet, "/weather/history/daily?region=jember&days=3", "")
+	var body struct {
+		Region string
+		Days   int
+		Daily  []WeatherDailyAggregate
+	}
+	decodeBody(t, rec, &body)
+	if rec.Code != http.StatusOK || body.Region != "Jember" || body.Days != 3 || len(body.Daily) != 1 || body.Daily[0].Samples != 1 {
+		t.Fatalf("GET /weather/history/daily = %d %+v", rec.Code, body)
+	}
+
+	for _, days := range []string{"0", "abc", strconv.Itoa(maxWeatherHistoryDays + 1)} {
+		if rec := serve(WeatherDailyHistoryHandler, http.MethodGet, "/weather/history/daily?days="+days, ""); rec.Code != http.StatusBadRequest {
+			t.Fatalf("days=%s = %d, ingin 400", days, rec.Code)
+		}
+	}
+}
+
+// countingScraper scraper palsu yang menghitung panggilan; Scrape menunggu release (jika ada)
+type countingScraper struct {
+	name    string
+	calls   atomic.Int32
+	release chan struct{}
+	prices  []ScrapedPrice
+	err     error
+}
+
+func (s *countingScraper) GetName() string { return s.name }
+
+func (s *countingScraper) Scrape(ctx context.Context) ([]ScrapedPrice, error) {
+	s.calls.Add(1)
+	if s.release != nil {
+		select {
+		case <-s.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return s.prices, s.err
+}
+
+// usePriceSources memasang sumber palsu untuk POST /harga/fetch dan mengosongkan status debounce
+func usePriceSources(t *testing.T, sources ...PriceSource) {
+	t.Helper()
+	prev, prevDebounce := priceSources, debouncedPriceFetch
+	priceSources = func() []PriceSource { return sources }
+	debouncedPriceFetch = newPriceFetchDebounce(fetchPricesDebounceWindow)
+	t.Cleanup(func() {
+		priceSources, debouncedPriceFetch = prev, prevDebounce
+	})
+}
+
+func TestFetchPricesHandlerCoalescesConcurrentRequests(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+	scraper := &countingScraper{
+		name:    "Palsu",
+		release: make(chan struct{}),
+		prices:  []ScrapedPrice{{Region: "Jember", Price: 41000, Source: "Palsu", ScrapedAt: time.Now()}},
+	}
+	usePriceSources(t, scraperPriceSource(0, scraper))
+
+	const requests = 8
+	var wg sync.WaitGroup
+	codes := make([]int, requests)
+	bodies := make([]map[string]interface{}, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rec := serve(FetchPricesHandler, http.MethodPost, "/harga/fetch", "")
+			codes[i] = rec.Code
+			json.Unmarshal(rec.Body.Bytes(), &bodies[i])
+		}(i)
+	}
+	// Beri waktu semua request bergabung ke fetch yang sedang berjalan; yang terlambat
+	// kena debounce - keduanya tidak boleh memicu scrape kedua
+	time.Sleep(50 * time.Millisecond)
+	close(scraper.release)
+	wg.Wait()
+
+	if n := scraper.calls.Load(); n != 1 {
+		t.Fatalf("scrape dijalankan %d kali, ingin 1", n)
+	}
+	// singleflight menandai semua pemanggil (termasuk pemicu) shared jika hasil dibagi
+	for i, code := range codes {
+		if code != http.StatusOK || (bodies[i]["coalesced"] != true && bodies[i]["debounced"] != true) {
+			t.Fatalf("request %d = %d %v", i, code, bodies[i])
+		}
+	}
+	if page, _ := priceStore.GetAll(PriceQuery{Limit: 10}); page.Total != 1 {
+		t.Fatalf("%d harga tersimpan, ingin 1", page.Total)
+	}
+}
+
+func TestScheduledPriceFetchSharesFlightWithHandler(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+	scraper := &countingScraper{
+		name:    "Palsu",
+		release: make(chan struct{}),
+		prices:  []ScrapedPrice{{Region: "Jember", Price: 41000, Source: "Palsu", ScrapedAt: time.Now()}},
+	}
+	usePriceSources(t, scraperPriceSource(0, scraper))
+
+	scheduled := make(chan error)
+	go func() { scheduled <- scheduledPriceFetch(context.Background()) }()
+	for scraper.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// POST saat scrape terjadwal berjalan ikut menunggu hasilnya
+	handled := make(chan *httptest.ResponseRecorder)
+	go func() { handled <- serve(FetchPricesHandler, http.MethodPost, "/harga/fetch", "") }()
+	time.Sleep(20 * time.Millisecond)
+	close(scraper.release)
+
+	if err := <-scheduled; err != nil {
+		t.Fatalf("scheduledPriceFetch = %v", err)
+	}
+	rec := <-handled
+	var body map[string]interface{}
+	decodeBody(t, rec, &body)
+	if rec.Code != http.StatusOK || body["coalesced"] != true || scraper.calls.Load() != 1 {
+		t.Fatalf("POST /harga/fetch = %d %v, scrape %d kali; ingin 1 scrape bersama", rec.Code, body, scraper.calls.Load())
+	}
+
+	// Tick berikutnya dalam jendela debounce tidak scrape ulang
+	if err := scheduledPriceFetch(context.Background()); err != nil || scraper.calls.Load() != 1 {
+		t.Fatalf("tick dalam jendela = %v, scrape %d kali", err, scraper.calls.Load())
+	}
+}
+
+func TestFetchPricesHandlerDebouncesRecentFetch(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+	scraper := &countingScraper{
+		name:   "Palsu",
+		prices: []ScrapedPrice{{Region: "Jember", Price: 41000, Source: "Palsu", ScrapedAt: time.Now()}},
+	}
+	usePriceSources(t, scraperPriceSource(0, scraper))
+
+	fetch := func() map[string]interface{} {
+		t.Helper()
+		rec := serve(FetchPricesHandler, http.MethodPost, "/harga/fetch", "")
+		var body map[string]interface{}
+		decodeBody(t, rec, &body)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("POST /harga/fetch = %d %v", rec.Code, body)
+		}
+		return body
+	}
+
+	if body := fetch(); body["debounced"] != nil {
+		t.Fatalf("fetch pertama = %v", body)
+	}
+	// Klik ulang dalam jendela debounce: 200 dengan pesan jelas, tanpa scrape
+	body := fetch()
+	if body["debounced"] != true || body["message"] == "" || body["last_fetch_at"] == nil || scraper.calls.Load() != 1 {
+		t.Fatalf("fetch kedua = %v, scrape %d kali", body, scraper.calls.Load())
+	}
+
+	// Jendela lewat: scrape lagi
+	debouncedPriceFetch = newPriceFetchDebounce(20 * time.Millisecond)
+	if body := fetch(); body["debounced"] != nil || scraper.calls.Load() != 2 {
+		t.Fatalf("fetch dengan debounce baru = %v, scrape %d kali", body, scraper.calls.Load())
+	}
+	if body := fetch(); body["debounced"] != true || scraper.calls.Load() != 2 {
+		t.Fatalf("fetch dalam jendela 20ms = %v, scrape %d kali", body, scraper.calls.Load())
+	}
+	time.Sleep(30 * time.Millisecond)
+	if body := fetch(); body["debounced"] != nil || scraper.calls.Load() != 3 {
+		t.Fatalf("fetch setelah jendela = %v, scrape %d kali", body, scraper.calls.Load())
+	}
+}
+
+func TestBatchRecommendationHandlerReportsInvalidItems(t *testing.T) {
+	t.Run("sebagian tidak wajar", func(t *testing.T) {
+		rec := serve(BatchRecommendationHandler, http.MethodPost, "/rekomendasi/batch",
+			`[{"region":"Jember","temp":26,"humidity":70,"rain":2},{"region":"Bondowoso","temp":26,"humidity":250,"rain":2}]`)
+		var items []BatchRecommendationItem
+		decodeBody(t, rec, &items)
+		if rec.Code != http.StatusOK || len(items) != 2 {
+			t.Fatalf("POST /rekomendasi/batch = %d %s", rec.Code, rec.Body.String())
+		}
+		if items[0].Result == nil || items[0].Error != "" {
+			t.Fatalf("item valid = %+v", items[0])
+		}
+		if items[1].Result != nil || items[1].Field != "humidity" || items[1].Index != 1 {
+			t.Fatalf("item tidak wajar = %+v", items[1])
+		}
+	})
+
+	t.Run("semua tidak wajar", func(t *testing.T) {
+		rec := serve(BatchRecommendationHandler, http.MethodPost, "/rekomendasi/batch",
+			`[{"temp":-999,"humidity":70,"rain":2},{"temp":26,"humidity":70,"rain":-1}]`)
+		var env struct {
+			Error struct {
+				Code    string
+				Details []BatchRecommendationItem
+			}
+		}
+		decodeBody(t, rec, &env)
+		if rec.Code != http.StatusBadRequest || env.Error.Code != "invalid_weather_input" || len(env.Error.Details) != 2 {
+			t.Fatalf("POST /rekomendasi/batch = %d %s", rec.Code, rec.Body.String())
+		}
+		if env.Error.Details[0].Field != "temp" || env.Error.Details[1].Field != "rain" {
+			t.Fatalf("field = %+v", env.Error.Details)
+		}
+	})
+
+	t.Run("kosong", func(t *testing.T) {
+		rec := serve(BatchRecommendationHandler, http.MethodPost, "/rekomendasi/batch", `[]`)
+		if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != "[]" {
+			t.Fatalf("POST /rekomendasi/batch [] = %d %s", rec.Code, rec.Body.String())
+		}
+	})
+}
+
+func TestRecommendationHandlersRejectImplausibleExplicitInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		target  string
+	}{
+		{"simple humidity", RecommendationHandler, "/rekomendasi?temp=26&humidity=250&rain=2"},
+		{"simple temp", RecommendationHandler, "/rekomendasi?temp=-999&humidity=70&rain=2"},
+		{"advanced rain", AdvancedRecommendationHandler, "/rekomendasi/advanced?temp=26&humidity=70&rain=-3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(tt.handler, http.MethodGet, tt.target, "")
+			var env struct{ Error APIError }
+			decodeBody(t, rec, &env)
+			if rec.Code != http.StatusBadRequest || env.Error.Code != "invalid_weather_input" {
+				t.Fatalf("GET %s = %d %s", tt.target, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCheckWSOriginFollowsCORSOrigins(t *testing.T) {
+	tests := []struct {
+		name    string
+		origins []string
+		origin  string
+		want    bool
+	}{
+		{"wildcard", []string{"*"}, "https://mana.saja", true},
+		{"origin terdaftar", []string{"https://dashboard.tembakau.id"}, "https://dashboard.tembakau.id", true},
+		{"origin asing", []string{"https://dashboard.tembakau.id"}, "https://jahat.example", false},
+		{"beda skema", []string{"https://dashboard.tembakau.id"}, "http://dashboard.tembakau.id", false},
+		{"client non-browser", []string{"https://dashboard.tembakau.id"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := wsAllowedOrigins
+			wsAllowedOrigins = tt.origins
+			t.Cleanup(func() { wsAllowedOrigins = prev })
+
+			req := httptest.NewRequest(http.MethodGet, "/ws/weather", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if got := checkWSOrigin(req); got != tt.want {
+				t.Fatalf("checkWSOrigin(%q) = %v, ingin %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFibonacci(t *testing.T) {
+	tests := map[int]int{-5: 0, 0: 0, 1: 1, 2: 1, 10: 55, 50: 12586269025,
+		// Nilai terbesar yang masih muat di int64
+		92: 7540113804746346429}
+	for n, want := range tests {
+		if got := Fibonacci(n); got != want {
+			t.Fatalf("Fibonacci(%d) = %d, ingin %d", n, got, want)
+		}
+	}
+	// n > 92 overflow (lihat doc Fibonacci): pastikan tetap cepat & tidak panic
+	if Fibonacci(93) >= 0 {
+		t.Fatal("Fibonacci(93) diharapkan overflow ke nilai negatif")
+	}
+}
+
+func TestFactorialChecked(t *testing.T) {
+	if got, err := FactorialChecked(20); err != nil || got != 2432902008176640000 {
+		t.Fatalf("FactorialChecked(20) = %d, %v", got, err)
+	}
+	if got, err := FactorialChecked(0); err != nil || got != 1 {
+		t.Fatalf("FactorialChecked(0) = %d, %v", got, err)
+	}
+	if _, err := FactorialChecked(21); !errors.Is(err, ErrFactorialOverflow) {
+		t.Fatalf("FactorialChecked(21) err = %v, ingin ErrFactorialOverflow", err)
+	}
+	if _, err := FactorialChecked(-1); err == nil || errors.Is(err, ErrFactorialOverflow) {
+		t.Fatalf("FactorialChecked(-1) err = %v, ingin error n negatif", err)
+	}
+}
+
+func TestFactorialBig(t *testing.T) {
+	tests := map[int]string{-3: "1", 0: "1", 20: "2432902008176640000", 25: "15511210043330985984000000"}
+	for n, want := range tests {
+		if got := FactorialBig(n).String(); got != want {
+			t.Fatalf("FactorialBig(%d) = %s, ingin %s", n, got, want)
+		}
+	}
+}
+
+// fibonacciNaive implementasi rekursif lama, hanya sebagai pembanding benchmark
+func fibonacciNaive(n int) int {
+	if n <= 1 {
+		return n
+	}
+	return fibonacciNaive(n-1) + fibonacciNaive(n-2)
+}
+
+func BenchmarkFibonacci(b *testing.B) {
+	b.Run("iteratif/n=30", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			Fibonacci(30)
+		}
+	})
+	b.Run("memoized/n=30", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			FibonacciMemoized(30)
+		}
+	})
+	b.Run("naive/n=30", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			fibonacciNaive(30)
+		}
+	})
+}
+
+func BenchmarkFactorial(b *testing.B) {
+	b.Run("int/n=20", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			Factorial(20)
+		}
+	})
+	b.Run("checked/n=20", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			FactorialChecked(20)
+		}
+	})
+	b.Run("big/n=20", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			FactorialBig(20)
+		}
+	})
+	b.Run("big/n=200", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			FactorialBig(200)
+		}
+	})
+}
+
+// postRaw POST dengan Content-Type apa adanya (kosong = tanpa header)
+func postRaw(handler http.HandlerFunc, target, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestAddPriceHandlerEncodings(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantCode    int
+		wantPrice   float64
+		wantErrCode string
+	}{
+		{"json", "application/json", `{"region":"jember","price":41000,"unit":"kg"}`, http.StatusOK, 41000, ""},
+		{"json tanpa Content-Type", "", `{"region":"jember","price":41000}`, http.StatusOK, 41000, ""},
+		{"json dengan charset", "application/json; charset=utf-8", `{"region":"jember","price":41000}`, http.StatusOK, 41000, ""},
+		{"form", "application/x-www-form-urlencoded", "region=jember&price=41500.5&unit=kg", http.StatusOK, 41500.5, ""},
+		{"form harga berspasi", "application/x-www-form-urlencoded", "region=jember&price=+42000+", http.StatusOK, 42000, ""},
+		{"form harga bukan angka", "application/x-www-form-urlencoded", "region=jember&price=empat+puluh+ribu", http.StatusBadRequest, 0, "invalid_body"},
+		{"form tanpa harga", "application/x-www-form-urlencoded", "region=jember", http.StatusBadRequest, 0, "invalid_body"},
+		{"json rusak", "application/json", `{"region":`, http.StatusBadRequest, 0, "invalid_body"},
+		{"content type lain", "text/plain", "jember 41000", http.StatusUnsupportedMediaType, 0, "unsupported_media_type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryPriceStore()
+			useStores(t, store, NewMemoryWeatherStore())
+
+			rec := postRaw(AddPriceHandler, "/harga/add", tt.contentType, tt.body)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("POST /harga/add = %d %s, ingin %d", rec.Code, rec.Body.String(), tt.wantCode)
+			}
+			if tt.wantErrCode != "" {
+				var env struct{ Error APIError }
+				decodeBody(t, rec, &env)
+				if env.Error.Code != tt.wantErrCode {
+					t.Fatalf("kode error = %q, ingin %q", env.Error.Code, tt.wantErrCode)
+				}
+				if page, _ := store.GetAll(PriceQuery{Limit: 10}); page.Total != 0 {
+					t.Fatalf("request gagal tetap menyimpan %d harga", page.Total)
+				}
+				return
+			}
+			p, err := store.GetLatest("Jember")
+			if err != nil || p.Price != tt.wantPrice || p.Region != "Jember" {
+				t.Fatalf("harga tersimpan = %+v, %v; ingin Jember %v", p, err, tt.wantPrice)
+			}
+		})
+	}
+}
+
+func TestBodyLimitRejectsOversizedBodies(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+	const limit = 64
+	small := `{"region":"Jember","price":41000}`
+	large := `{"region":"Jember","price":41000,"source":"` + strings.Repeat("x", 2*limit) + `"}`
+
+	tests := []struct {
+		name     string
+		handler  HandlerFunc
+		body     string
+		chunked  bool // tanpa Content-Length: batas ditegakkan saat body dibaca
+		wantCode int
+	}{
+		{"add dalam batas", AddPriceHandler, small, false, http.StatusOK},
+		{"add Content-Length besar", AddPriceHandler, large, false, http.StatusRequestEntityTooLarge},
+		{"add chunked besar", AddPriceHandler, large, true, http.StatusRequestEntityTooLarge},
+		{"batch chunked besar", BatchRecommendationHandler, "[" + strings.Repeat(`{"temp":26,"humidity":70,"rain":1},`, 10) + "{}]", true, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/harga/add", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			withBodyLimit(limit)(tt.handler)(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d %s, ingin %d", rec.Code, rec.Body.String(), tt.wantCode)
+			}
+			if tt.wantCode == http.StatusRequestEntityTooLarge {
+				var env struct{ Error APIError }
+				decodeBody(t, rec, &env)
+				if env.Error.Code != errBodyTooLarge.Code {
+					t.Fatalf("kode error = %q, ingin %q", env.Error.Code, errBodyTooLarge.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestDebounce(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	debounced := Debounce(20*time.Millisecond, func() {
+		calls.Add(1)
+		<-release
+	})
+
+	started := make(chan bool)
+	go func() { started <- debounced() }()
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	// Masih berjalan: diredam tanpa menunggu
+	if debounced() {
+		t.Fatal("panggilan saat fn berjalan tidak diredam")
+	}
+	close(release)
+	if !<-started {
+		t.Fatal("panggilan pertama diredam")
+	}
+
+	// Baru selesai: diredam selama jendela
+	if debounced() || calls.Load() != 1 {
+		t.Fatalf("panggilan dalam jendela: fn %d kali, ingin 1", calls.Load())
+	}
+	time.Sleep(30 * time.Millisecond)
+	if !debounced() || calls.Load() != 2 {
+		t.Fatalf("panggilan setelah jendela: fn %d kali, ingin 2", calls.Load())
+	}
+}
+
+func TestMemoizeCallsOncePerKey(t *testing.T) {
+	calls := map[int]int{}
+	var mu sync.Mutex
+	square := Memoize(func(n int) int {
+		mu.Lock()
+		calls[n]++
+		mu.Unlock()
+		time.Sleep(time.Millisecond) // perlebar jendela race antar pemanggil concurrent
+		return n * n
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if got := square(i % 5); got != (i%5)*(i%5) {
+				t.Errorf("square(%d) = %d", i%5, got)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for n := 0; n < 5; n++ {
+		if calls[n] != 1 {
+			t.Fatalf("fn(%d) dipanggil %d kali, ingin 1", n, calls[n])
+		}
+	}
+}
+
+func TestMemoizeLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	var calls []string
+	upper := MemoizeLRU(2, func(s string) string {
+		calls = append(calls, s)
+		return strings.ToUpper(s)
+	})
+
+	upper("a")
+	upper("b")
+	upper("a") // a jadi yang terbaru, b yang tertua
+	upper("c") // b dibuang
+	upper("a")
+	if got := upper("b"); got != "B" {
+		t.Fatalf("upper(b) = %q", got)
+	}
+	if want := []string{"a", "b", "c", "b"}; strings.Join(calls, ",") != strings.Join(want, ",") {
+		t.Fatalf("fn dipanggil untuk %v, ingin %v", calls, want)
+	}
+}
+
+func TestChunk(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []int
+		size  int
+		want  [][]int
+	}{
+		{"kelipatan pas", []int{1, 2, 3, 4, 5, 6}, 3, [][]int{{1, 2, 3}, {4, 5, 6}}},
+		{"sisa", []int{1, 2, 3, 4, 5}, 2, [][]int{{1, 2}, {3, 4}, {5}}},
+		{"size lebih besar", []int{1, 2}, 10, [][]int{{1, 2}}},
+		{"size 1", []int{1, 2}, 1, [][]int{{1}, {2}}},
+		{"size nol", []int{1, 2, 3}, 0, [][]int{{1, 2, 3}}},
+		{"kosong", []int{}, 3, nil},
+		{"nil", nil, 3, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Chunk(tt.input, tt.size); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Chunk(%v, %d) = %v, ingin %v", tt.input, tt.size, got, tt.want)
+			}
+		})
+	}
+
+	// Potongan dibatasi kapasitasnya: append pada satu potongan tidak menimpa potongan berikutnya
+	data := []int{1, 2, 3, 4}
+	chunks := Chunk(data, 2)
+	_ = append(chunks[0], 99)
+	if data[2] != 3 || chunks[1][0] != 3 {
+		t.Fatalf("append ke potongan pertama menimpa data: %v", data)
+	}
+}
+
+func TestDistinct(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []string
+		want  []string
+	}{
+		{"urutan kemunculan pertama", []string{"Jember", "Bondowoso", "Jember", "Malang", "Bondowoso"}, []string{"Jember", "Bondowoso", "Malang"}},
+		{"tanpa duplikat", []string{"c", "a", "b"}, []string{"c", "a", "b"}},
+		{"semua sama", []string{"x", "x", "x"}, []string{"x"}},
+		{"kosong", []string{}, []string{}},
+		{"nil", nil, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Distinct(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Distinct(%v) = %v, ingin %v", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	// DistinctBy mempertahankan elemen pertama per key
+	prices := []Price{{Region: "Jember", Price: 1}, {Region: "Malang", Price: 2}, {Region: "Jember", Price: 3}}
+	got := DistinctBy(prices, func(p Price) string { return p.Region })
+	if len(got) != 2 || got[0].Price != 1 || got[1].Price != 2 {
+		t.Fatalf("DistinctBy = %+v", got)
+	}
+}
+
+func TestPricesHandlerPagination(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ps PriceStore, ws WeatherStore) {
+		for day := 1; day <= 7; day++ {
+			mustAdd(t, ps, testPrice("Jember", float64(40000+day), fmt.Sprintf("2026-01-%02d", day)))
+		}
+		mustAdd(t, ps, testPrice("Malang", 38000, "2026-01-01"), testPrice("Malang", 38500, "2026-01-02"))
+
+		tests := []struct {
+			target     string
+			total      int
+			limit      int
+			offset     int
+			firstPrice float64 // terbaru dulu; 0 = tidak dicek
+			pageLen    int
+		}{
+			{"/harga", 9, defaultPageLimit, 0, 0, 9}, // urutan antar region tidak diuji
+			{"/harga?region=jember&limit=3", 7, 3, 0, 40007, 3},
+			{"/harga?region=jember&limit=3&offset=3", 7, 3, 3, 40004, 3},
+			{"/harga?region=jember&limit=3&offset=6", 7, 3, 6, 40001, 1},
+			// Offset melewati data: halaman kosong ([] bukan null), Total tetap jumlah penuh
+			{"/harga?region=jember&limit=3&offset=30", 7, 3, 30, 0, 0},
+			{"/harga?region=malang&limit=1", 2, 1, 0, 38500, 1},
+		}
+		for _, tt := range tests {
+			rec := serve(PricesHandler, http.MethodGet, tt.target, "")
+			var page Paginated[Price]
+			decodeBody(t, rec, &page)
+			if rec.Code != http.StatusOK || page.Total != tt.total || page.Limit != tt.limit ||
+				page.Offset != tt.offset || len(page.Data) != tt.pageLen || page.Data == nil {
+				t.Fatalf("GET %s = %d total=%d limit=%d offset=%d len=%d", tt.target, rec.Code, page.Total, page.Limit, page.Offset, len(page.Data))
+			}
+			if tt.firstPrice != 0 && page.Data[0].Price != tt.firstPrice {
+				t.Fatalf("GET %s data[0] = %v, ingin %v", tt.target, page.Data[0].Price, tt.firstPrice)
+			}
+		}
+
+		// ?raw=true: array polos dengan isi halaman yang sama
+		rec := serve(PricesHandler, http.MethodGet, "/harga?region=jember&limit=2&raw=true", "")
+		var raw []Price
+		decodeBody(t, rec, &raw)
+		if len(raw) != 2 || raw[0].Price != 40007 {
+			t.Fatalf("GET ?raw=true = %s", rec.Body.String())
+		}
+	})
+}
+
+func TestParsePaginationRejectsInvalid(t *testing.T) {
+	for _, target := range []string{"/harga?limit=0", "/harga?limit=501", "/harga?limit=abc", "/harga?offset=-1"} {
+		rec := serve(PricesHandler, http.MethodGet, target, "")
+		var env struct{ Error APIError }
+		decodeBody(t, rec, &env)
+		if rec.Code != http.StatusBadRequest || env.Error.Code != "invalid_pagination" {
+			t.Fatalf("GET %s = %d %s", target, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestPriceStatsHandlerWindowBoundaries(t *testing.T) {
+	now := time.Now()
+	at := func(d time.Duration) string { return now.Add(d).Format(sqliteUTCLayout) }
+	day := 24 * time.Hour
+
+	forEachStore(t, func(t *testing.T, ps PriceStore, ws WeatherStore) {
+		mustAdd(t, ps,
+			// Satu jam di dalam / di luar batas 7 hari, beda hari kalender
+			testPrice("Jember", 70, at(-7*day+time.Hour)),
+			testPrice("Jember", 75, at(-7*day-time.Hour)),
+			testPrice("Jember", 300, at(-30*day+time.Hour)),
+			testPrice("Jember", 310, at(-30*day-time.Hour)),
+			testPrice("Jember", 10, at(-time.Hour)),
+			// Data pertama 8 hari lalu: 7d lengkap, 30d partial
+			testPrice("Bondowoso", 20, at(-8*day)),
+			testPrice("Bondowoso", 40, at(-day)))
+
+		rec := serve(PriceStatsHandler, http.MethodGet, "/harga/stats", "")
+		var stats []RegionPriceStats
+		decodeBody(t, rec, &stats)
+		if rec.Code != http.StatusOK || len(stats) != 2 {
+			t.Fatalf("GET /harga/stats = %d %s", rec.Code, rec.Body.String())
+		}
+
+		bondowoso, jember := stats[0], stats[1]
+		if jember.Count != 5 || jember.Min != 10 || jember.Max != 310 {
+			t.Errorf("Jember all-time = %+v", jember)
+		}
+		assertWindow(t, "Jember 7d", jember.Windows["7d"], 2, 10, 70, false)
+		assertWindow(t, "Jember 30d", jember.Windows["30d"], 4, 10, 300, false)
+		assertWindow(t, "Bondowoso 7d", bondowoso.Windows["7d"], 1, 40, 40, false)
+		assertWindow(t, "Bondowoso 30d", bondowoso.Windows["30d"], 2, 20, 40, true)
+		if w := jember.Windows["30d"]; w.Days != 30 || *w.Avg != 113.75 {
+			t.Errorf("Jember 30d = %+v", w)
+		}
+	})
+}
+
+func TestWeatherHandlerIncludesConditionAndRegion(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+	useWeatherCache(t)
+	useOWMServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(owmTestBody))
+	})
+
+	for _, path := range []string{"/cuaca", "/weather"} {
+		t.Run(path, func(t *testing.T) {
+			rec := serve(WeatherAPIHandler, http.MethodGet, path+"?region=jember", "")
+			var body map[string]interface{}
+			decodeBody(t, rec, &body)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("GET %s = %d %s", path, rec.Code, rec.Body.String())
+			}
+			// Tag JSON harus tetap stabil untuk frontend
+			want := map[string]interface{}{"condition": "Clouds", "description": "berawan", "region": "Jember", "temp": 27.5}
+			for key, value := range want {
+				if body[key] != value {
+					t.Errorf("%s = %v, ingin %v", key, body[key], value)
+				}
+			}
+		})
+	}
+}
+
+func TestMakeWeatherHandlerEchoesRequestedRegion(t *testing.T) {
+	// Provider yang tidak mengisi Region: handler mengisi region dari request
+	handler := makeWeatherHandler(func(region string) (*WeatherData, error) {
+		return &WeatherData{Temp: 25, Humidity: 60, Condition: "Clear", Description: "cerah"}, nil
+	})
+	rec := serve(http.HandlerFunc(handler), http.MethodGet, "/cuaca?region=Bondowoso", "")
+	var data WeatherData
+	decodeBody(t, rec, &data)
+	if data.Region != "Bondowoso" || data.Condition != "Clear" || data.Description != "cerah" {
+		t.Fatalf("response = %+v", data)
+	}
+}
+
+// owmByRegion server OWM palsu yang menjawab /weather per region (?q=); region
+// yang tidak ada di bodies mendapat 404
+func owmByRegion(bodies map[string]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, ok := bodies[r.URL.Query().Get("q")]
+		if !ok {
+			http.Error(w, `{"cod":"404","message":"city not found"}`, http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(body))
+	}
+}
+
+func TestBestRegionHandlerRanksRegions(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+	sunny := `{"main":{"temp":28,"humidity":60},"weather":[{"main":"Clear","description":"cerah"}]}`
+	useOWMServer(t, owmByRegion(map[string]string{
+		"Surabaya": sunny,
+		"Jember":   sunny,
+		"Malang":   `{"main":{"temp":28,"humidity":85},"rain":{"1h":6},"weather":[{"main":"Rain","description":"hujan"}]}`,
+	}))
+
+	rec := serve(BestRegionHandler, http.MethodGet, "/rekomendasi/best?regions=Malang,Surabaya,Merauke,Jember", "")
+	var body struct {
+		Ranking  []RegionComparison `json:"ranking"`
+		Best     RegionComparison   `json:"best"`
+		Reason   string             `json:"reason"`
+		Excluded []RegionComparison `json:"excluded"`
+		Note     string             `json:"note"`
+	}
+	decodeBody(t, rec, &body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /rekomendasi/best = %d %s", rec.Code, rec.Body.String())
+	}
+
+	// Skor 100 seri: Jember menang karena urutan nama, bukan urutan request
+	got := Map(body.Ranking, func(c RegionComparison) string { return fmt.Sprintf("%s:%d", c.Region, c.HarvestScore) })
+	if want := []string{"Jember:100", "Surabaya:100", "Malang:35"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("ranking = %v, ingin %v", got, want)
+	}
+	if body.Best.Region != "Jember" || !strings.Contains(body.Reason, "setara") {
+		t.Fatalf("best = %s, reason %q", body.Best.Region, body.Reason)
+	}
+	if len(body.Excluded) != 1 || body.Excluded[0].Region != "Merauke" || !strings.Contains(body.Note, "Merauke") {
+		t.Fatalf("excluded = %+v, note %q; ingin Merauke", body.Excluded, body.Note)
+	}
+
+	rec = serve(BestRegionHandler, http.MethodGet, "/rekomendasi/best?regions=Malang,Jember", "")
+	decodeBody(t, rec, &body)
+	if body.Best.Region != "Jember" || !strings.Contains(body.Reason, "berikutnya Malang dengan skor 35") {
+		t.Fatalf("best = %s, reason %q", body.Best.Region, body.Reason)
+	}
+}
+
+func TestBestRegionHandlerAllRegionsFail(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+	useOWMServer(t, owmByRegion(nil))
+
+	rec := serve(BestRegionHandler, http.MethodGet, "/rekomendasi/best?regions=Merauke,Sorong", "")
+	var env struct{ Error APIError }
+	decodeBody(t, rec, &env)
+	if rec.Code != http.StatusInternalServerError || env.Error.Code != errWeatherUnavailable.Code {
+		t.Fatalf("semua region gagal = %d %s", rec.Code, rec.Body.String())
+	}
+	details, _ := env.Error.Details.(map[string]interface{})
+	if len(details) != 2 {
+		t.Fatalf("details = %v, ingin error per region", env.Error.Details)
+	}
+
+	rec = serve(BestRegionHandler, http.MethodGet, "/rekomendasi/best", "")
+	decodeBody(t, rec, &env)
+	if rec.Code != http.StatusBadRequest || env.Error.Code != "invalid_regions" {
+		t.Fatalf("tanpa regions = %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestFetchAdvancedRecommendationConcurrent(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+	const delay = 150 * time.Millisecond
+	useOWMServer(t, owmFake(t, delay, http.StatusOK))
+
+	start := time.Now()
+	result, err := fetchAdvancedRecommendation(context.Background(), "Jember", RecommendationConfigFor(""))
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Cuaca & forecast paralel: total mendekati satu delay, bukan dua
+	if elapsed >= 2*delay-20*time.Millisecond {
+		t.Fatalf("fetch berjalan %v, ingin < %v (concurrent)", elapsed, 2*delay)
+	}
+	if !result.ForecastAvailable || result.Forecast == nil || result.Forecast.RainMM != 1.5 {
+		t.Fatalf("forecast = %v %+v", result.ForecastAvailable, result.Forecast)
+	}
+	if result.Temperature != 27.5 || result.Humidity != 72 {
+		t.Fatalf("cuaca saat ini = %v°C %d%%", result.Temperature, result.Humidity)
+	}
+}
+
+func TestFetchAdvancedRecommendationForecastFails(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+	useOWMServer(t, owmFake(t, 0, http.StatusInternalServerError))
+
+	result, err := fetchAdvancedRecommendation(context.Background(), "Jember", RecommendationConfigFor(""))
+	if err != nil {
+		t.Fatalf("forecast gagal tidak boleh menggagalkan rekomendasi: %v", err)
+	}
+	if result.ForecastAvailable || result.Forecast != nil || result.Temperature != 27.5 {
+		t.Fatalf("result = %+v, ingin hanya dari cuaca saat ini", result)
+	}
+}
+
+func TestPartition(t *testing.T) {
+	even := func(n int) bool { return n%2 == 0 }
+	tests := []struct {
+		name               string
+		input              []int
+		matched, unmatched []int
+	}{
+		{"campuran urut", []int{1, 2, 3, 4, 5}, []int{2, 4}, []int{1, 3, 5}},
+		{"semua cocok", []int{2, 4}, []int{2, 4}, []int{}},
+		{"tidak ada yang cocok", []int{1, 3}, []int{}, []int{1, 3}},
+		{"kosong", []int{}, []int{}, []int{}},
+		{"nil", nil, []int{}, []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched, unmatched := Partition(tt.input, even)
+			if !reflect.DeepEqual(matched, tt.matched) || !reflect.DeepEqual(unmatched, tt.unmatched) {
+				t.Fatalf("Partition(%v) = %v, %v; ingin %v, %v", tt.input, matched, unmatched, tt.matched, tt.unmatched)
+			}
+		})
+	}
+}
+
+func TestRecommendationHandlersPlain(t *testing.T) {
+	const weather = "region=Jember&temp=34&humidity=92&rain=12"
+	handlers := []struct {
+		name    string
+		handler http.HandlerFunc
+		path    string
+		advice  string
+	}{
+		{"rekomendasi", RecommendationHandler, "/rekomendasi?", "Hujan lebat, tunda pemanenan"},
+		{"advanced", AdvancedRecommendationHandler, "/rekomendasi/advanced?", "Suhu hangat (30-35°C) - perlu irigasi ekstra"},
+	}
+	for _, h := range handlers {
+		t.Run(h.name, func(t *testing.T) {
+			emoji := serve(h.handler, http.MethodGet, h.path+weather, "")
+			plain := serve(h.handler, http.MethodGet, h.path+weather+"&plain=true", "")
+			if emoji.Code != http.StatusOK || plain.Code != http.StatusOK {
+				t.Fatalf("status = %d, %d", emoji.Code, plain.Code)
+			}
+			if strings.IndexFunc(emoji.Body.String(), isDecorativeRune) < 0 {
+				t.Fatalf("respons default tanpa emoji, test tidak bermakna: %s", emoji.Body.String())
+			}
+			if i := strings.IndexFunc(plain.Body.String(), isDecorativeRune); i >= 0 {
+				t.Fatalf("?plain=true masih berisi %q: %s", plain.Body.String()[i:i+4], plain.Body.String())
+			}
+			// Teks saran tetap utuh tanpa emoji-nya
+			if !strings.Contains(plain.Body.String(), h.advice) || !strings.Contains(plain.Body.String(), `"region":"Jember"`) {
+				t.Fatalf("?plain=true kehilangan isi saran: %s", plain.Body.String())
+			}
+		})
+	}
+}
+
+func TestParallelReduceWorkers(t *testing.T) {
+	sum := func(a, b int) int { return a + b }
+	tests := []struct {
+		name    string
+		input   []int
+		workers int
+		want    int
+	}{
+		{"workers 0 memakai NumCPU", []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 0, 55},
+		{"workers negatif", []int{1, 2, 3}, -4, 6},
+		{"workers lebih dari elemen", []int{1, 2, 3}, 16, 6},
+		{"satu elemen, workers 0", []int{42}, 0, 42},
+		{"satu elemen, banyak worker", []int{42}, 8, 42},
+		{"kosong", []int{}, 0, 0},
+		{"nil", nil, 3, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParallelReduce(tt.input, 0, sum, tt.workers); got != tt.want {
+				t.Fatalf("ParallelReduce(%v, workers=%d) = %d, ingin %d", tt.input, tt.workers, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParallelReduceAppliesInitialOnce(t *testing.T) {
+	input := make([]int, 101)
+	for i := range input {
+		input[i] = i + 1
+	}
+	sum := func(a, b int) int { return a + b }
+	max := func(a, b int) int {
+		if a > b {
+			return a
+		}
+		return b
+	}
+
+	for _, workers := range []int{1, 2, 3, 4, 7, 101} {
+		// 10 + 5151, bukan 10 per worker
+		if got, want := ParallelReduce(input, 10, sum, workers), Reduce(input, 10, sum); got != want {
+			t.Fatalf("sum workers=%d = %d, ingin %d (Reduce sekuensial)", workers, got, want)
+		}
+		// initial lebih besar dari semua elemen tetap menang
+		if got, want := ParallelReduce(input, 500, max, workers), Reduce(input, 500, max); got != want {
+			t.Fatalf("max workers=%d = %d, ingin %d", workers, got, want)
+		}
+	}
+}
+
+func TestReduceWhile(t *testing.T) {
+	input := []int{3, 4, 5, 6, 7}
+	visited := 0
+	// Jumlahkan sampai total >= 10: berhenti di elemen ketiga, elemen sisanya tidak disentuh
+	got := ReduceWhile(input, 0, func(acc, n int) (int, bool) {
+		visited++
+		acc += n
+		return acc, acc < 10
+	})
+	if got != 12 || visited != 3 {
+		t.Fatalf("ReduceWhile berhenti dini = %d setelah %d elemen, ingin 12 setelah 3", got, visited)
+	}
+
+	visited = 0
+	sum := func(acc, n int) int { return acc + n }
+	got = ReduceWhile(input, 100, func(acc, n int) (int, bool) {
+		visited++
+		return sum(acc, n), true
+	})
+	if want := Reduce(input, 100, sum); got != want || visited != len(input) {
+		t.Fatalf("ReduceWhile penuh = %d setelah %d elemen, ingin %d (Reduce) setelah %d", got, visited, want, len(input))
+	}
+
+	if got := ReduceWhile(nil, 7, func(acc, n int) (int, bool) { return acc + n, true }); got != 7 {
+		t.Fatalf("ReduceWhile(nil) = %d, ingin initial 7", got)
+	}
+}
+
+func TestHistoricalRecommendationHandler(t *testing.T) {
+	forEachStore(t, func(t *testing.T, _ PriceStore, ws WeatherStore) {
+		ws.Add(WeatherHistoryRow{Region: "Jember", TempC: floatPtr(29), Humidity: intPtr(65), RainMM: floatPtr(0), FetchedAt: "2026-03-10 01:00:00"})
+		ws.Add(WeatherHistoryRow{Region: "Jember", TempC: floatPtr(24), Humidity: intPtr(92), RainMM: floatPtr(12), FetchedAt: "2026-03-10 05:00:00"})
+		ws.Add(WeatherHistoryRow{Region: "Malang", TempC: floatPtr(20), Humidity: intPtr(80), RainMM: floatPtr(1), FetchedAt: "2026-03-10 01:10:00"})
+		flushWrites(t)
+
+		tests := []struct {
+			name      string
+			at        string
+			fetchedAt string
+			gap       int
+			temp      float64
+			humidity  int
+			rain      float64
+		}{
+			// Waktu tanpa zona = WIB: 08:30 WIB = 01:30 UTC
+			{"WIB pagi", "2026-03-10%2008:30", "2026-03-10 01:00:00", 30, 29, 65, 0},
+			{"RFC3339 UTC", "2026-03-10T05:20:00Z", "2026-03-10 05:00:00", 20, 24, 92, 12},
+			// 04:00 berjarak 3 jam dari sampel pertama, 1 jam dari sampel kedua
+			{"lebih dekat ke sampel kedua", "2026-03-10T04:00:00%2B00:00", "2026-03-10 05:00:00", 60, 24, 92, 12},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rec := serve(HistoricalRecommendationHandler, http.MethodGet, "/rekomendasi/historical?region=jember&at="+tt.at, "")
+				var body struct {
+					Region  string               `json:"region"`
+					Weather HistoricalWeather    `json:"weather"`
+					Result  RecommendationResult `json:"result"`
+				}
+				decodeBody(t, rec, &body)
+				if rec.Code != http.StatusOK {
+					t.Fatalf("GET historical = %d %s", rec.Code, rec.Body.String())
+				}
+				if body.Region != "Jember" || body.Weather.FetchedAt != tt.fetchedAt || body.Weather.GapMinutes != tt.gap {
+					t.Fatalf("weather = %s %s gap %d, ingin Jember %s gap %d",
+						body.Region, body.Weather.FetchedAt, body.Weather.GapMinutes, tt.fetchedAt, tt.gap)
+				}
+				want := RecommendForWeather(&WeatherData{Temp: tt.temp, Humidity: tt.humidity, Rain: tt.rain}, "Jember")
+				if body.Result.Status != want.Status || body.Result.Temperature != tt.temp || body.Result.RainMM != tt.rain {
+					t.Fatalf("result = %s %.1f°C %.1fmm, ingin %s %.1f°C %.1fmm",
+						body.Result.Status, body.Result.Temperature, body.Result.RainMM, want.Status, tt.temp, tt.rain)
+				}
+			})
+		}
+
+		// Di luar historicalWeatherMaxGap dari sampel mana pun = 404
+		rec := serve(HistoricalRecommendationHandler, http.MethodGet, "/rekomendasi/historical?region=Jember&at=2026-03-11T05:00:00Z", "")
+		var env struct{ Error APIError }
+		decodeBody(t, rec, &env)
+		if rec.Code != http.StatusNotFound || env.Error.Code != "weather_history_not_found" {
+			t.Fatalf("tanpa data = %d %s", rec.Code, rec.Body.String())
+		}
+		rec = serve(HistoricalRecommendationHandler, http.MethodGet, "/rekomendasi/historical?region=Jember&at=kemarin", "")
+		decodeBody(t, rec, &env)
+		if rec.Code != http.StatusBadRequest || env.Error.Code != "invalid_at" {
+			t.Fatalf("at tidak valid = %d %s", rec.Code, rec.Body.String())
+		}
+	})
+}
+
+func TestWeeklyRecommendationHandlerForecastFixture(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+	useRecommendationCache(t, useWeatherCache(t))
+	useOWMServer(t, owmFake(t, 0, http.StatusOK))
+
+	rec := serve(WeeklyRecommendationHandler, http.MethodGet, "/rekomendasi/week?region=Jember", "")
+	var body struct {
+		Region string                `json:"region"`
+		Days   []DailyRecommendation `json:"days"`
+	}
+	decodeBody(t, rec, &body)
+	if rec.Code != http.StatusOK || body.Region != "Jember" {
+		t.Fatalf("GET /rekomendasi/week = %d %s", rec.Code, rec.Body.String())
+	}
+
+	want := []struct {
+		date       string
+		confidence string
+		entries    int
+		temp       float64
+		humidity   int
+		rainMM     float64
+		peak       float64
+	}{
+		{"2026-03-10", confidenceHigh, 8, 26.4, 70, 1.5, 0.3},
+		{"2026-03-11", confidenceHigh, 8, 24.9, 86, 12, 1},
+		// Hari terakhir forecast hanya 2 slot
+		{"2026-03-12", confidenceLow, 2, 22.8, 81, 0, 0},
+	}
+	if len(body.Days) != len(want) {
+		t.Fatalf("%d hari, ingin %d", len(body.Days), len(want))
+	}
+	cfg := RecommendationConfigFor("")
+	for i, w := range want {
+		day := body.Days[i]
+		f := day.Forecast
+		if day.Date != w.date || day.Confidence != w.confidence || f.Entries != w.entries ||
+			f.AvgTemp != w.temp || f.AvgHumidity != w.humidity || f.RainMM != w.rainMM || f.PeakRainMMPerHour != w.peak {
+			t.Fatalf("hari %d = %s %s %+v, ingin %+v", i, day.Date, day.Confidence, f, w)
+		}
+		// Rekomendasi per hari dari agregat hari itu (hujan = intensitas slot terberat)
+		if status := GetAdvancedRecommendationWithConfig(cfg, w.temp, w.humidity, w.peak, "Jember").Status; day.Recommendation.Status != status {
+			t.Fatalf("status %s = %s, ingin %s", w.date, day.Recommendation.Status, status)
+		}
+	}
+}
+
+func TestWeeklyRecommendationHandlerForecastFails(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+	useRecommendationCache(t, useWeatherCache(t))
+	useOWMServer(t, owmFake(t, 0, http.StatusNotFound))
+
+	rec := serve(WeeklyRecommendationHandler, http.MethodGet, "/rekomendasi/week?region=Jember", "")
+	var env struct{ Error APIError }
+	decodeBody(t, rec, &env)
+	if rec.Code != http.StatusInternalServerError || env.Error.Code != errWeatherUnavailable.Code {
+		t.Fatalf("forecast gagal = %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestCount(t *testing.T) {
+	even := func(n int) bool { return n%2 == 0 }
+	tests := []struct {
+		name  string
+		input []int
+		want  int
+	}{
+		{"campuran", []int{1, 2, 3, 4, 6}, 3},
+		{"tidak ada yang cocok", []int{1, 3}, 0},
+		{"kosong", []int{}, 0},
+		{"nil", nil, 0},
+	}
+	for _, tt := range tests {
+		if got := Count(tt.input, even); got != tt.want {
+			t.Errorf("Count(%s) = %d, ingin %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCountBy(t *testing.T) {
+	statuses := []string{"optimal", "good", "optimal", "poor", "optimal"}
+	got := CountBy(statuses, func(s string) string { return s })
+	if want := map[string]int{"optimal": 3, "good": 1, "poor": 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("CountBy = %v, ingin %v", got, want)
+	}
+
+	byLength := CountBy([]string{"Jember", "Malang", "Bondowoso"}, func(s string) int { return len(s) })
+	if want := map[int]int{6: 2, 9: 1}; !reflect.DeepEqual(byLength, want) {
+		t.Fatalf("CountBy panjang = %v, ingin %v", byLength, want)
+	}
+
+	// Input kosong tetap map (JSON {}), bukan nil (JSON null)
+	for _, input := range [][]string{nil, {}} {
+		empty := CountBy(input, func(s string) string { return s })
+		if empty == nil || len(empty) != 0 {
+			t.Fatalf("CountBy(%#v) = %#v, ingin map kosong", input, empty)
+		}
+		if encoded, _ := json.Marshal(empty); string(encoded) != "{}" {
+			t.Fatalf("JSON CountBy kosong = %s, ingin {}", encoded)
+		}
+	}
+}
+
+func TestPriceStatsHandlerThreshold(t *testing.T) {
+	ps := NewMemoryPriceStore()
+	useStores(t, ps, NewMemoryWeatherStore())
+	mustAdd(t, ps,
+		testPrice("Jember", 50000, "2026-03-01"),
+		testPrice("Bondowoso", 40000, "2026-03-01"),
+		testPrice("Malang", 30000, "2026-03-01"))
+
+	rec := serve(PriceStatsHandler, http.MethodGet, "/harga/stats?threshold=40000", "")
+	var body struct {
+		Above []string `json:"above_threshold"`
+		Below []string `json:"below_threshold"`
+	}
+	decodeBody(t, rec, &body)
+	// Rata-rata tepat di threshold masuk ke atas
+	if !reflect.DeepEqual(body.Above, []string{"Bondowoso", "Jember"}) || !reflect.DeepEqual(body.Below, []string{"Malang"}) {
+		t.Fatalf("threshold 40000 = %+v", body)
+	}
+
+	if rec := serve(PriceStatsHandler, http.MethodGet, "/harga/stats?threshold=-1", ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("threshold negatif = %d, ingin 400", rec.Code)
+	}
+}
+
+func TestFlatMap(t *testing.T) {
+	repeat := func(n int) []int {
+		if n == 0 {
+			return nil
+		}
+		out := []int{}
+		for i := 0; i < n; i++ {
+			out = append(out, n)
+		}
+		return out
+	}
+	tests := []struct {
+		name  string
+		input []int
+		want  []int
+	}{
+		{"urut sesuai input", []int{1, 2, 3}, []int{1, 2, 2, 3, 3, 3}},
+		{"fn mengembalikan nil", []int{0, 2, 0}, []int{2, 2}},
+		{"semua nil", []int{0, 0}, []int{}},
+		{"kosong", nil, []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FlatMap(tt.input, repeat); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("FlatMap(%v) = %v, ingin %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMultiRegionForecastHandlerFlattensEntries(t *testing.T) {
+	fake := owmFake(t, 0, http.StatusOK)
+	useOWMServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("q") == "Malang" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fake(w, r)
+	})
+
+	rec := serve(MultiRegionForecastHandler, http.MethodGet, "/forecast/multi?regions=Jember,Malang,Bondowoso", "")
+	var body struct {
+		Entries []WeatherData                     `json:"entries"`
+		Daily   map[string][]DailyRainProbability `json:"daily_rain_probability"`
+		Failed  []string                          `json:"failed_regions"`
+	}
+	decodeBody(t, rec, &body)
+	if rec.Code != http.StatusOK || len(body.Entries) != 36 {
+		t.Fatalf("GET forecast multi = %d, %d entri", rec.Code, len(body.Entries))
+	}
+	// Satu list datar, urut per region sesuai query
+	if body.Entries[0].Region != "Jember" || body.Entries[17].Region != "Jember" || body.Entries[18].Region != "Bondowoso" {
+		t.Fatalf("urutan region = %s, %s, %s", body.Entries[0].Region, body.Entries[17].Region, body.Entries[18].Region)
+	}
+	if !reflect.DeepEqual(body.Failed, []string{"Malang"}) || len(body.Daily) != 2 {
+		t.Fatalf("failed = %v, daily = %d region", body.Failed, len(body.Daily))
+	}
+}
+
+func TestZip(t *testing.T) {
+	tests := []struct {
+		name string
+		a    []string
+		b    []int
+		want []Pair[string, int]
+	}{
+		{"sama panjang", []string{"a", "b"}, []int{1, 2}, []Pair[string, int]{{"a", 1}, {"b", 2}}},
+		{"a lebih panjang", []string{"a", "b", "c"}, []int{1}, []Pair[string, int]{{"a", 1}}},
+		{"b lebih panjang", []string{"a"}, []int{1, 2, 3}, []Pair[string, int]{{"a", 1}}},
+		{"a kosong", nil, []int{1, 2}, []Pair[string, int]{}},
+		{"keduanya kosong", []string{}, []int{}, []Pair[string, int]{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Zip(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Zip(%v, %v) = %v, ingin %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+
+	sums := ZipWith([]int{1, 2, 3}, []int{10, 20}, func(x, y int) int { return x + y })
+	if !reflect.DeepEqual(sums, []int{11, 22}) {
+		t.Fatalf("ZipWith = %v, ingin [11 22]", sums)
+	}
+}
+
+func TestComposeAndPipeOrder(t *testing.T) {
+	double := func(x int) int { return x * 2 }
+	inc := func(x int) int { return x + 1 }
+
+	// Compose(f, g)(x) == f(g(x)): g jalan lebih dulu
+	if got := Compose(double, inc)(3); got != 8 {
+		t.Fatalf("Compose(double, inc)(3) = %d, ingin 8", got)
+	}
+	if got := Compose(inc, double)(3); got != 7 {
+		t.Fatalf("Compose(inc, double)(3) = %d, ingin 7", got)
+	}
+	label := Compose(func(n int) string { return fmt.Sprintf("n=%d", n) }, func(s string) int { return len(s) })
+	if got := label("tembakau"); got != "n=8" {
+		t.Fatalf("Compose beda tipe = %q", got)
+	}
+
+	// Pipe kiri ke kanan
+	var calls []string
+	step := func(name string, fn func(int) int) func(int) int {
+		return func(x int) int {
+			calls = append(calls, name)
+			return fn(x)
+		}
+	}
+	if got := Pipe(step("inc", inc), step("double", double))(3); got != 8 {
+		t.Fatalf("Pipe(inc, double)(3) = %d, ingin 8", got)
+	}
+	if !reflect.DeepEqual(calls, []string{"inc", "double"}) {
+		t.Fatalf("urutan Pipe = %v", calls)
+	}
+	if got := Pipe[int]()(5); got != 5 {
+		t.Fatalf("Pipe kosong = %d, ingin identitas", got)
+	}
+}
+
+func TestGetCurrentPriceHandlerFallsBackOnEmptyStore(t *testing.T) {
+	tests := []struct {
+		name    string
+		page    roundTripFunc
+		region  string
+		persist bool
+		origin  string
+	}{
+		{"live scrape", bappebtiPage(http.StatusOK, bappebtiTestPage), "Jember", false, priceOriginLiveScrape},
+		{"BAPPEBTI down, data riset", bappebtiPage(http.StatusServiceUnavailable, ""), "Temanggung", false, priceOriginResearch},
+		{"disimpan", bappebtiPage(http.StatusOK, bappebtiTestPage), "Jember", true, priceOriginLiveScrape},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps := NewMemoryPriceStore()
+			useStores(t, ps, NewMemoryWeatherStore())
+			useBAPPEBTITransport(t, tt.page)
+			prev := persistPriceFallback
+			persistPriceFallback = tt.persist
+			t.Cleanup(func() { persistPriceFallback = prev })
+
+			rec := serve(GetCurrentPriceHandler, http.MethodGet, "/harga/current?region="+tt.region, "")
+			var got FallbackPrice
+			decodeBody(t, rec, &got)
+			if rec.Code != http.StatusOK || !got.Fallback || got.Origin != tt.origin || got.Region != tt.region {
+				t.Fatalf("GET /harga/current = %d %s", rec.Code, rec.Body.String())
+			}
+			if got.Persisted != tt.persist {
+				t.Fatalf("persisted = %v, ingin %v", got.Persisted, tt.persist)
+			}
+
+			_, err := ps.GetLatest(tt.region)
+			if stored := err == nil; stored != tt.persist {
+				t.Fatalf("harga tersimpan = %v, ingin %v", stored, tt.persist)
+			}
+		})
+	}
+}
+
+func TestGetCurrentPriceHandlerUnknownRegion(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+	useBAPPEBTITransport(t, bappebtiPage(http.StatusOK, bappebtiTestPage))
+
+	rec := serve(GetCurrentPriceHandler, http.MethodGet, "/harga/current?region=Merauke", "")
+	var env struct{ Error APIError }
+	decodeBody(t, rec, &env)
+	if rec.Code != http.StatusNotFound || env.Error.Code != "price_not_found" {
+		t.Fatalf("GET /harga/current region tanpa data = %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestBatchCurrentPriceHandler(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ps PriceStore, _ WeatherStore) {
+		mustAdd(t, ps,
+			testPrice("Jember", 41000, "2026-03-01"),
+			testPrice("Jember", 42000, "2026-03-02"),
+			testPrice("Temanggung", 95000, "2026-03-02"),
+		)
+		flushWrites(t)
+
+		// Region dinormalisasi & duplikat dibuang sebelum query
+		rec := serve(BatchCurrentPriceHandler, http.MethodGet, "/harga/current/batch?regions=jember,Merauke,Temanggung,JEMBER,,Boyolali", "")
+		var body struct {
+			Prices  map[string]Price `json:"prices"`
+			Missing []string         `json:"missing"`
+		}
+		decodeBody(t, rec, &body)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET /harga/current/batch = %d %s", rec.Code, rec.Body.String())
+		}
+		if len(body.Prices) != 2 || body.Prices["Jember"].Price != 42000 || body.Prices["Temanggung"].Price != 95000 {
+			t.Fatalf("prices = %+v, ingin Jember 42000 & Temanggung 95000", body.Prices)
+		}
+		if !reflect.DeepEqual(body.Missing, []string{"Merauke", "Boyolali"}) {
+			t.Fatalf("missing = %v, ingin [Merauke Boyolali]", body.Missing)
+		}
+
+		// Semua region punya data: missing tetap array kosong, bukan null
+		rec = serve(BatchCurrentPriceHandler, http.MethodGet, "/harga/current/batch?regions=Jember", "")
+		if !strings.Contains(rec.Body.String(), `"missing":[]`) {
+			t.Fatalf("body = %s, ingin missing []", rec.Body.String())
+		}
+	})
+}
+
+func TestBatchCurrentPriceHandlerRejectsInvalidRegions(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+	names := make([]string, maxBatchPriceRegions+1)
+	for i := range names {
+		names[i] = "Region" + strconv.Itoa(i)
+	}
+	tooMany := strings.Join(names, ",")
+
+	tests := []struct {
+		name  string
+		query string
+		code  string
+	}{
+		{"tanpa regions", "", "invalid_regions"},
+		{"hanya koma", "regions=,%20,", "invalid_regions"},
+		{"terlalu banyak", "regions=" + tooMany, "too_many_regions"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(BatchCurrentPriceHandler, http.MethodGet, "/harga/current/batch?"+tt.query, "")
+			var env struct{ Error APIError }
+			decodeBody(t, rec, &env)
+			if rec.Code != http.StatusBadRequest || env.Error.Code != tt.code {
+				t.Fatalf("status = %d %s, ingin 400 %s", rec.Code, rec.Body.String(), tt.code)
+			}
+		})
+	}
+}
+
+func TestPricesHandlerReportsSkippedRows(t *testing.T) {
+	openTestDB(t)
+	useStores(t, SQLitePriceStore{}, SQLiteWeatherStore{})
+	mustAdd(t, priceStore, testPrice("Jember", 41000, "2026-03-01"), testPrice("Jember", 42000, "2026-03-02"))
+	// price bertipe teks tidak bisa di-scan ke float64
+	if _, err := DB.Exec(`INSERT INTO prices (region, price, unit, source, source_type, recorded_at)
+		VALUES ('Jember', 'tidak tahu', 'kg', 'manual', 'manual', '2026-03-03')`); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := serve(PricesHandler, http.MethodGet, "/harga?region=Jember", "")
+	var page Paginated[Price]
+	decodeBody(t, rec, &page)
+	if rec.Code != http.StatusOK || page.Total != 3 || len(page.Data) != 2 || page.Skipped != 1 {
+		t.Fatalf("GET /harga = %d %+v", rec.Code, page)
+	}
+	if got := rec.Header().Get("X-Skipped-Rows"); got != "1" {
+		t.Fatalf("X-Skipped-Rows = %q, ingin 1", got)
+	}
+
+	// Tanpa baris rusak: field & header tidak muncul
+	rec = serve(PricesHandler, http.MethodGet, "/harga?region=Bondowoso", "")
+	if strings.Contains(rec.Body.String(), "skipped") || rec.Header().Get("X-Skipped-Rows") != "" {
+		t.Fatalf("response tanpa baris rusak = %s", rec.Body.String())
+	}
+}
+
+func TestAdvancedRecommendationHandlerExplain(t *testing.T) {
+	rec := serve(AdvancedRecommendationHandler, http.MethodGet, "/rekomendasi/advanced?region=Jember&temp=25&humidity=95&rain=2&explain=true", "")
+	var body AdvancedRecommendation
+	decodeBody(t, rec, &body)
+	if rec.Code != http.StatusOK || body.Explanation == nil {
+		t.Fatalf("GET ?explain=true = %d %s", rec.Code, rec.Body.String())
+	}
+	if body.Explanation.LimitingFactor != "humidity" || body.Explanation.Status != body.Status {
+		t.Fatalf("explanation = %+v, status %s", body.Explanation, body.Status)
+	}
+
+	rec = serve(AdvancedRecommendationHandler, http.MethodGet, "/rekomendasi/advanced?region=Jember&temp=25&humidity=95&rain=2", "")
+	if strings.Contains(rec.Body.String(), `"explanation"`) {
+		t.Fatalf("explanation muncul tanpa ?explain=true: %s", rec.Body.String())
+	}
+}
+
+func TestFind(t *testing.T) {
+	prices := []Price{{Region: "Jember", Price: 1}, {Region: "Malang", Price: 2}, {Region: "Jember", Price: 3}}
+
+	got, ok := Find(prices, func(p Price) bool { return p.Region == "Jember" }).Get()
+	if !ok || got.Price != 1 {
+		t.Fatalf("Find Jember = %+v, %v; ingin elemen pertama", got, ok)
+	}
+
+	missing := Find(prices, func(p Price) bool { return p.Region == "Lumajang" })
+	if missing.IsSome() || missing.OrElse(Price{Price: -1}).Price != -1 {
+		t.Fatalf("Find Lumajang = %+v, ingin None", missing)
+	}
+	if Find([]int(nil), func(int) bool { return true }).IsSome() {
+		t.Fatal("Find pada slice nil harus None")
+	}
+}
+
+func TestBatchWeatherHandlerPartialResults(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+	cache := useWeatherCache(t)
+	cache.Set("Bondowoso", &WeatherData{Temp: 31, Humidity: 55, Region: "Bondowoso"})
+
+	var mu sync.Mutex
+	requested := map[string]int{}
+	useOWMServer(t, func(w http.ResponseWriter, r *http.Request) {
+		region := r.URL.Query().Get("q")
+		mu.Lock()
+		requested[region]++
+		mu.Unlock()
+		switch region {
+		case "Atlantis":
+			w.WriteHeader(http.StatusNotFound)
+		case "Malang":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Write([]byte(owmTestBody))
+		}
+	})
+
+	rec := serve(BatchWeatherHandler, http.MethodPost, "/weather/batch", `{"regions":["jember","Atlantis","Bondowoso","Malang","Jember"]}`)
+	var body struct {
+		Results map[string]WeatherData
+		Errors  map[string]string
+	}
+	decodeBody(t, rec, &body)
+	if rec.Code != http.StatusOK || len(body.Results) != 2 || len(body.Errors) != 2 {
+		t.Fatalf("POST /weather/batch = %d %s", rec.Code, rec.Body.String())
+	}
+	if body.Results["Jember"].Temp != 27.5 || body.Results["Bondowoso"].Temp != 31 {
+		t.Fatalf("results = %+v", body.Results)
+	}
+	if body.Errors["Atlantis"] != errWeatherUnavailable.Message || body.Errors["Malang"] != errWeatherUnavailable.Message {
+		t.Fatalf("errors = %+v", body.Errors)
+	}
+
+	// Bondowoso dari cache, Jember duplikat hanya diambil sekali
+	mu.Lock()
+	defer mu.Unlock()
+	if requested["Bondowoso"] != 0 || requested["Jember"] != 1 {
+		t.Fatalf("request ke OWM = %v", requested)
+	}
+}
+
+func TestBatchWeatherHandlerRejectsInvalidRequests(t *testing.T) {
+	tooMany := make([]string, maxBatchWeatherRegions+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("Region%d", i)
+	}
+	body, _ := json.Marshal(map[string][]string{"regions": tooMany})
+
+	tests := []struct {
+		name, body, code string
+	}{
+		{"bukan JSON", "regions=Jember", "invalid_body"},
+		{"kosong", `{"regions":[]}`, "invalid_regions"},
+		{"terlalu banyak", string(body), "too_many_regions"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(BatchWeatherHandler, http.MethodPost, "/weather/batch", tt.body)
+			var env struct{ Error APIError }
+			decodeBody(t, rec, &env)
+			if rec.Code != http.StatusBadRequest || env.Error.Code != tt.code {
+				t.Fatalf("POST /weather/batch = %d %s", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestMethodValidationAllowHeader(t *testing.T) {
+	var called atomic.Int32
+	handler := withMethodValidation(http.MethodGet, http.MethodPost)(func(w http.ResponseWriter, r *http.Request) { called.Add(1) })
+
+	rec := serve(http.HandlerFunc(handler), http.MethodDelete, "/ganda", "")
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "GET, POST, OPTIONS" {
+		t.Fatalf("DELETE = %d Allow %q, ingin 405 \"GET, POST, OPTIONS\"", rec.Code, rec.Header().Get("Allow"))
+	}
+
+	rec = serve(http.HandlerFunc(handler), http.MethodOptions, "/ganda", "")
+	if rec.Code != http.StatusNoContent || rec.Body.Len() != 0 {
+		t.Fatalf("OPTIONS = %d %q, ingin 204 tanpa body", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("Allow") != "GET, POST, OPTIONS" || rec.Header().Get("Access-Control-Allow-Methods") != "GET, POST, OPTIONS" {
+		t.Fatalf("OPTIONS header = %v", rec.Header())
+	}
+	if called.Load() != 0 {
+		t.Fatalf("handler dipanggil %d kali untuk DELETE/OPTIONS", called.Load())
+	}
+
+	// Header Allow hanya untuk respons yang ditolak
+	if rec = serve(http.HandlerFunc(handler), http.MethodPost, "/ganda", ""); rec.Header().Get("Allow") != "" || called.Load() != 1 {
+		t.Fatalf("POST: Allow %q, handler %d kali", rec.Header().Get("Allow"), called.Load())
+	}
+}
+
+func TestRegisteredRoutePreflightUsesRouteMethods(t *testing.T) {
+	prev := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(prev) })
+	mux := http.NewServeMux()
+	registerRoutes(mux, []Route{{Pattern: "/harga/add", Handler: AddPriceHandler, Methods: []string{http.MethodPost}}}, []string{"http://localhost:3000"})
+
+	req := httptest.NewRequest(http.MethodOptions, "/harga/add", nil)
+	req.Header.Set("Origin", "http://localhost:3000")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("preflight = %d %s, ingin 204", rec.Code, rec.Body.String())
+	}
+	// Allow-Methods generik dari CORS ditimpa method milik route
+	for header, want := range map[string]string{
+		"Allow":                        "POST, OPTIONS",
+		"Access-Control-Allow-Methods": "POST, OPTIONS",
+		"Access-Control-Allow-Origin":  "http://localhost:3000",
+	} {
+		if got := rec.Header().Get(header); got != want {
+			t.Fatalf("%s = %q, ingin %q", header, got, want)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ============================================
+// HEALTH SCORE (GET /status)
+// Satu angka 0-100 untuk ops, gabungan berbobot dari: DB bisa dijangkau, kesegaran
+// sukses OWM & scraper terakhir, dan rasio 5xx dalam requestErrorWindow terakhir.
+// Faktor yang belum punya data (mis. OWM belum pernah dipanggil sejak start)
+// tidak ikut dihitung, bobotnya dibagi ke faktor lain.
+// ============================================
+
+const (
+	requestErrorWindow = 5 * time.Minute
+	requestErrorBucket = time.Minute
+
+	// Sukses terakhir dalam *Fresh = skor penuh, turun linear sampai 0 di *Stale
+	weatherFreshFor   = 30 * time.Minute
+	weatherStaleAfter = 3 * time.Hour
+	scraperFreshFor   = 24 * time.Hour
+	scraperStaleAfter = 72 * time.Hour
+	// maxToleratedErrorRate rasio 5xx yang membuat faktor error_rate bernilai 0
+	maxToleratedErrorRate = 0.2
+
+	healthyScore  = 80
+	degradedScore = 50
+)
+
+// healthWeights bobot tiap faktor; DB paling berat karena tanpa DB hampir semua endpoint gagal
+var healthWeights = map[string]float64{
+	"database":   0.4,
+	"weather":    0.2,
+	"scraper":    0.2,
+	"error_rate": 0.2,
+}
+
+// ============================================
+// REQUEST ERROR RATE
+// ============================================
+
+type requestBucket struct {
+	start  time.Time
+	total  int
+	errors int
+}
+
+// RequestErrorTracker hitungan request & respons 5xx per bucket (ring buffer seukuran window)
+type RequestErrorTracker struct {
+	mu      sync.Mutex
+	bucket  time.Duration
+	window  time.Duration
+	buckets []requestBucket
+}
+
+func NewRequestErrorTracker(window, bucket time.Duration) *RequestErrorTracker {
+	return &RequestErrorTracker{
+		bucket:  bucket,
+		window:  window,
+		buckets: make([]requestBucket, int(window/bucket)),
+	}
+}
+
+func (t *RequestErrorTracker) Record(status int, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	start := now.Truncate(t.bucket)
+	b := &t.buckets[int(start.UnixNano()/int64(t.bucket))%len(t.buckets)]
+	if !b.start.Equal(start) {
+		*b = requestBucket{start: start}
+	}
+	b.total++
+	if status >= http.StatusInternalServerError {
+		b.errors++
+	}
+}
+
+// Snapshot jumlah request & 5xx yang bucket-nya masih di dalam window
+func (t *RequestErrorTracker) Snapshot(now time.Time) (total, errors int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, b := range t.buckets {
+		if !b.start.IsZero() && now.Sub(b.start) < t.window {
+			total += b.total
+			errors += b.errors
+		}
+	}
+	return total, errors
+}
+
+var requestErrors = NewRequestErrorTracker(requestErrorWindow, requestErrorBucket)
+
+// statusRecorder menangkap status respons; tanpa WriteHeader eksplisit = 200
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// withRequestMetrics mencatat status ke requestErrors. Tidak dipasang pada route streaming
+// (SSE/websocket butuh Flusher/Hijacker dari ResponseWriter asli) maupun route Quiet.
+func withRequestMetrics(next HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+		requestErrors.Record(rec.status, time.Now())
+	}
+}
+
+// ============================================
+// SCORE
+// ============================================
+
+// HealthSignals sinyal mentah; waktu nol = belum pernah
+type HealthSignals struct {
+	DBErr              error
+	WeatherConfigured  bool
+	WeatherLastSuccess time.Time
+	WeatherAttempted   bool
+	ScraperLastSuccess time.Time
+	ScraperAttempted   bool
+	Requests           int
+	ServerErrors       int
+}
+
+// HealthFactor Score nil = belum ada data (tidak ikut dihitung)
+type HealthFactor struct {
+	Name   string   `json:"name"`
+	Weight float64  `json:"weight"`
+	Score  *float64 `json:"score"` // 0-1
+	Detail string   `json:"detail"`
+}
+
+type HealthReport struct {
+	Score   float64        `json:"score"` // 0-100
+	Status  string         `json:"status"`
+	Factors []HealthFactor `json:"factors"`
+}
+
+// recencyScore 1 jika sukses terakhir dalam fresh, 0 jika lebih tua dari stale, linear di antaranya
+func recencyScore(last, now time.Time, fresh, stale time.Duration) float64 {
+	age := now.Sub(last)
+	switch {
+	case age <= fresh:
+		return 1
+	case age >= stale:
+		return 0
+	}
+	return 1 - float64(age-fresh)/float64(stale-fresh)
+}
+
+func recencyFactor(name string, last time.Time, attempted bool, now time.Time, fresh, stale time.Duration) HealthFactor {
+	f := HealthFactor{Name: name}
+	switch {
+	case !last.IsZero():
+		score := recencyScore(last, now, fresh, stale)
+		f.Score = &score
+		f.Detail = "sukses terakhir " + now.Sub(last).Round(time.Second).String() + " lalu"
+	case attempted:
+		score := 0.0
+		f.Score = &score
+		f.Detail = "belum pernah berhasil sejak start"
+	default:
+		f.Detail = "belum ada percobaan sejak start"
+	}
+	return f
+}
+
+// ComputeHealthScore fungsi murni dari sinyal ke skor; dipisah dari pengumpulan sinyal
+func ComputeHealthScore(s HealthSignals, now time.Time) HealthReport {
+	zero, one := 0.0, 1.0
+
+	db := HealthFactor{Name: "database", Score: &one, Detail: "ok"}
+	if s.DBErr != nil {
+		db.Score, db.Detail = &zero, s.DBErr.Error()
+	}
+
+	weather := recencyFactor("weather", s.WeatherLastSuccess, s.WeatherAttempted, now, weatherFreshFor, weatherStaleAfter)
+	if !s.WeatherConfigured {
+		weather.Score, weather.Detail = &zero, "OWM_API_KEY belum diset"
+	}
+
+	scraper := recencyFactor("scraper", s.ScraperLastSuccess, s.ScraperAttempted, now, scraperFreshFor, scraperStaleAfter)
+
+	errorRate := HealthFactor{Name: "error_rate", Detail: "belum ada request dalam " + requestErrorWindow.String() + " terakhir"}
+	if s.Requests > 0 {
+		rate := float64(s.ServerErrors) / float64(s.Requests)
+		score := math.Max(0, 1-rate/maxToleratedErrorRate)
+		errorRate.Score = &score
+		errorRate.Detail = fmt.Sprintf("%d/%d request 5xx dalam %s terakhir", s.ServerErrors, s.Requests, requestErrorWindow)
+	}
+
+	factors := Map([]HealthFactor{db, weather, scraper, errorRate}, func(f HealthFactor) HealthFactor {
+		f.Weight = healthWeights[f.Name]
+		return f
+	})
+	known := Filter(factors, func(f HealthFactor) bool { return f.Score != nil })
+	totalWeight := Reduce(known, 0.0, func(acc float64, f HealthFactor) float64 { return acc + f.Weight })
+	weighted := Reduce(known, 0.0, func(acc float64, f HealthFactor) float64 { return acc + f.Weight*(*f.Score) })
+
+	report := HealthReport{Score: 100, Factors: factors}
+	if totalWeight > 0 {
+		report.Score = math.Round(weighted/totalWeight*1000) / 10
+	}
+	switch {
+	case report.Score >= healthyScore:
+		report.Status = "healthy"
+	case report.Score >= degradedScore:
+		report.Status = "degraded"
+	default:
+		report.Status = "unhealthy"
+	}
+	return report
+}
+
+// collectHealthSignals membaca sinyal dari priceStore, weatherHealth, scrapeStatus, dan requestErrors
+func collectHealthSignals(r *http.Request, now time.Time) HealthSignals {
+	owm := weatherHealth.Snapshot(weatherConfig.APIKey != "")
+	s := HealthSignals{
+		DBErr:             priceStore.Ping(r.Context()),
+		WeatherConfigured: owm.Status != ProviderUnconfigured,
+		WeatherAttempted:  owm.LastSuccess != nil || owm.LastFailure != nil,
+	}
+	if owm.LastSuccess != nil {
+		s.WeatherLastSuccess = *owm.LastSuccess
+	}
+
+	// Scraper dianggap segar jika salah satu sumber berhasil baru-baru ini
+	sources, _ := scrapeStatus.Snapshot()
+	for _, src := range sources {
+		s.ScraperAttempted = s.ScraperAttempted || src.LastAttempt != nil
+		if src.LastSuccess != nil && src.LastSuccess.After(s.ScraperLastSuccess) {
+			s.ScraperLastSuccess = *src.LastSuccess
+		}
+	}
+
+	s.Requests, s.ServerErrors = requestErrors.Snapshot(now)
+	return s
+}
+
+func StatusHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		now := time.Now()
+		return respondJSON(w, http.StatusOK, ComputeHealthScore(collectHealthSignals(r, now), now))
+	})(w, r)
+}
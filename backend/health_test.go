@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// useRequestErrors mengganti tracker global dengan yang kosong selama test
+func useRequestErrors(t *testing.T) *RequestErrorTracker {
+	t.Helper()
+	prev := requestErrors
+	requestErrors = NewRequestErrorTracker(requestErrorWindow, requestErrorBucket)
+	t.Cleanup(func() { requestErrors = prev })
+	return requestErrors
+}
+
+func TestRouteHandlerRecordsTimeoutResponses(t *testing.T) {
+	tracker := useRequestErrors(t)
+	slow := Route{
+		Pattern:    "/lambat",
+		Methods:    []string{http.MethodGet},
+		Middleware: []MiddlewareFunc{withTimeout(20 * time.Millisecond)},
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		},
+	}
+
+	rec := httptest.NewRecorder()
+	routeHandler(slow)(rec, httptest.NewRequest(http.MethodGet, "/lambat", nil))
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, ingin 504", rec.Code)
+	}
+	if total, errs := tracker.Snapshot(time.Now()); total != 1 || errs != 1 {
+		t.Fatalf("tercatat %d request, %d 5xx; ingin 1, 1", total, errs)
+	}
+}
+
+func TestRequestErrorTrackerWindow(t *testing.T) {
+	tracker := NewRequestErrorTracker(5*time.Minute, time.Minute)
+	start := time.Date(2026, 1, 1, 10, 0, 30, 0, time.UTC)
+	tracker.Record(http.StatusInternalServerError, start)
+	tracker.Record(http.StatusOK, start.Add(2*time.Minute))
+	tracker.Record(http.StatusBadGateway, start.Add(6*time.Minute))
+
+	// Bucket 10:00 sudah keluar window pada 10:06:30
+	if total, errs := tracker.Snapshot(start.Add(6 * time.Minute)); total != 2 || errs != 1 {
+		t.Fatalf("Snapshot = %d, %d; ingin 2, 1", total, errs)
+	}
+}
+
+func TestComputeHealthScoreFactors(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	healthy := HealthSignals{
+		WeatherConfigured:  true,
+		WeatherLastSuccess: now.Add(-time.Minute),
+		WeatherAttempted:   true,
+		ScraperLastSuccess: now.Add(-time.Hour),
+		ScraperAttempted:   true,
+		Requests:           100,
+	}
+
+	tests := []struct {
+		name        string
+		change      func(s *HealthSignals)
+		factor      string
+		factorScore *float64 // nil = faktor tidak diketahui
+		score       float64
+		status      string
+	}{
+		{"semua sehat", func(*HealthSignals) {}, "database", floatPtr(1), 100, "healthy"},
+		{"database down", func(s *HealthSignals) { s.DBErr = errors.New("database is locked") }, "database", floatPtr(0), 60, "degraded"},
+		{"weather basi", func(s *HealthSignals) { s.WeatherLastSuccess = now.Add(-weatherStaleAfter) }, "weather", floatPtr(0), 80, "healthy"},
+		// Tengah-tengah antara fresh (30m) dan stale (3h)
+		{"weather setengah", func(s *HealthSignals) { s.WeatherLastSuccess = now.Add(-105 * time.Minute) }, "weather", floatPtr(0.5), 90, "healthy"},
+		{"weather tanpa API key", func(s *HealthSignals) { s.WeatherConfigured = false }, "weather", floatPtr(0), 80, "healthy"},
+		{"weather belum pernah berhasil", func(s *HealthSignals) { s.WeatherLastSuccess = time.Time{} }, "weather", floatPtr(0), 80, "healthy"},
+		{"scraper basi", func(s *HealthSignals) { s.ScraperLastSuccess = now.Add(-scraperStaleAfter) }, "scraper", floatPtr(0), 80, "healthy"},
+		{"scraper belum dicoba", func(s *HealthSignals) { s.ScraperLastSuccess, s.ScraperAttempted = time.Time{}, false }, "scraper", nil, 100, "healthy"},
+		{"error rate 10%", func(s *HealthSignals) { s.ServerErrors = 10 }, "error_rate", floatPtr(0.5), 90, "healthy"},
+		{"error rate di batas", func(s *HealthSignals) { s.ServerErrors = 20 }, "error_rate", floatPtr(0), 80, "healthy"},
+		{"tanpa request", func(s *HealthSignals) { s.Requests = 0 }, "error_rate", nil, 100, "healthy"},
+		{"semua down", func(s *HealthSignals) {
+			s.DBErr = errors.New("down")
+			s.WeatherConfigured = false
+			s.ScraperLastSuccess = time.Time{}
+			s.ServerErrors = 100
+		}, "scraper", floatPtr(0), 0, "unhealthy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := healthy
+			tt.change(&s)
+			report := ComputeHealthScore(s, now)
+			if report.Score != tt.score || report.Status != tt.status {
+				t.Fatalf("score = %v %s, ingin %v %s (%+v)", report.Score, report.Status, tt.score, tt.status, report.Factors)
+			}
+
+			factors := Filter(report.Factors, func(f HealthFactor) bool { return f.Name == tt.factor })
+			if len(factors) != 1 {
+				t.Fatalf("faktor %s tidak ada", tt.factor)
+			}
+			got := factors[0].Score
+			if (got == nil) != (tt.factorScore == nil) || (got != nil && *got != *tt.factorScore) {
+				t.Fatalf("skor %s = %v, ingin %v", tt.factor, got, tt.factorScore)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// ============================================
+// SHARED HTTP CLIENT
+// Satu transport untuk semua panggilan keluar (OWM, scraper) supaya koneksi
+// keep-alive dipakai ulang dan TLS handshake tidak diulang tiap request.
+// ============================================
+
+// HTTPClientConfig tuning transport keluar. Pertimbangan default:
+//   - Timeout 15s: batas keseluruhan request; OWM memakai WEATHER_TIMEOUT sendiri
+//   - DialTimeout/TLSHandshakeTimeout 5s: host yang tidak bisa dijangkau cepat ketahuan,
+//     sisa waktu dipakai untuk retry
+//   - MaxIdleConnsPerHost 10: default Go (2) terlalu kecil untuk /weather/batch &
+//     dashboard yang memanggil OWM paralel; upstream kita hanya segelintir host
+//   - IdleConnTimeout 90s: lebih lama dari interval scheduler cuaca terpendek yang umum
+//     tanpa menahan koneksi mati terlalu lama
+type HTTPClientConfig struct {
+	Timeout             time.Duration
+	DialTimeout         time.Duration
+	TLSHandshakeTimeout time.Duration
+	KeepAlive           time.Duration
+	IdleConnTimeout     time.Duration
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+}
+
+func DefaultHTTPClientConfig() HTTPClientConfig {
+	return HTTPClientConfig{
+		Timeout:             15 * time.Second,
+		DialTimeout:         5 * time.Second,
+		TLSHandshakeTimeout: 5 * time.Second,
+		KeepAlive:           30 * time.Second,
+		IdleConnTimeout:     90 * time.Second,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+	}
+}
+
+func NewHTTPClient(cfg HTTPClientConfig) *http.Client {
+	dialer := &net.Dialer{Timeout: cfg.DialTimeout, KeepAlive: cfg.KeepAlive}
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		ExpectContinueTimeout: time.Second,
+	}
+	return &http.Client{Transport: transport, Timeout: cfg.Timeout}
+}
+
+// withClientTimeout salinan client (transport tetap sama) dengan timeout berbeda
+func withClientTimeout(client *http.Client, timeout time.Duration) *http.Client {
+	copied := *client
+	copied.Timeout = timeout
+	return &copied
+}
+
+// userAgentTransport mengisi User-Agent pada request yang belum menyetelnya sendiri
+type userAgentTransport struct {
+	base      http.RoundTripper
+	userAgent string
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		// RoundTripper tidak boleh mengubah request milik pemanggil
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// withUserAgent salinan client (transport dasar tetap sama) yang mengirim userAgent
+func withUserAgent(client *http.Client, userAgent string) *http.Client {
+	copied := *client
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	copied.Transport = &userAgentTransport{base: base, userAgent: userAgent}
+	return &copied
+}
+
+// sharedHTTPClient diganti saat startup lewat applyConfig; test bisa menyuntikkan
+// client dengan Transport palsu lewat ConfigureWeather / field Client scraper
+var sharedHTTPClient = NewHTTPClient(DefaultHTTPClientConfig())
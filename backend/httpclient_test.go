@@ -0,0 +1,65 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPClientAppliesConfig(t *testing.T) {
+	cfg := DefaultHTTPClientConfig()
+	cfg.Timeout, cfg.MaxIdleConnsPerHost, cfg.IdleConnTimeout = 7*time.Second, 4, time.Minute
+
+	client := NewHTTPClient(cfg)
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Transport = %T, ingin *http.Transport", client.Transport)
+	}
+	if client.Timeout != 7*time.Second || transport.MaxIdleConnsPerHost != 4 || transport.IdleConnTimeout != time.Minute {
+		t.Fatalf("client timeout %v, MaxIdleConnsPerHost %d, IdleConnTimeout %v",
+			client.Timeout, transport.MaxIdleConnsPerHost, transport.IdleConnTimeout)
+	}
+	if transport.DisableKeepAlives {
+		t.Fatal("keep-alive dimatikan, koneksi tidak akan dipakai ulang")
+	}
+
+	// Timeout per pemakai tidak boleh memecah pool koneksi
+	weather := withClientTimeout(client, time.Second)
+	if weather.Transport != client.Transport || weather.Timeout != time.Second || client.Timeout != 7*time.Second {
+		t.Fatalf("withClientTimeout: transport sama %v, timeout %v / %v",
+			weather.Transport == client.Transport, weather.Timeout, client.Timeout)
+	}
+}
+
+func TestConfigureWeatherUsesInjectedTransport(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+	prevCfg, prevClient, prevBreaker := weatherConfig, weatherHTTPClient, owmBreaker
+	t.Cleanup(func() { weatherConfig, weatherHTTPClient, owmBreaker = prevCfg, prevClient, prevBreaker })
+
+	var calls atomic.Int32
+	fake := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		if r.URL.Host != "owm.invalid" {
+			t.Errorf("request ke %s, ingin owm.invalid", r.URL.Host)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(owmTestBody)), Request: r}, nil
+	})}
+
+	cfg := weatherConfig
+	cfg.APIKey, cfg.BaseURL, cfg.Timeout = "test-key", "http://owm.invalid/data/2.5", 3*time.Second
+	ConfigureWeather(cfg, fake)
+
+	data, err := FetchWeather("Jember")
+	if err != nil || data.Temp != 27.5 {
+		t.Fatalf("FetchWeather = %+v, %v", data, err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("transport palsu dipanggil %d kali, ingin 1", calls.Load())
+	}
+	if weatherHTTPClient.Timeout != 3*time.Second || fake.Timeout != 0 {
+		t.Fatalf("timeout weather %v, client asal %v; ingin 3s & tidak berubah", weatherHTTPClient.Timeout, fake.Timeout)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ============================================
+// IDEMPOTENCY KEY
+// Request dengan header Idempotency-Key yang sama tidak diproses ulang;
+// response pertama disimpan dan diputar ulang selama TTL
+// ============================================
+
+const (
+	idempotencyTTL = 24 * time.Hour
+	// idempotencyCapacity batas jumlah key; key paling lama tidak dipakai dibuang lebih dulu
+	idempotencyCapacity = 10000
+)
+
+type idempotentResponse struct {
+	key         string
+	status      int
+	contentType string
+	body        []byte
+	done        bool
+	expiresAt   time.Time
+}
+
+// IdempotencyStore LRU in-memory untuk hasil request per key, dibatasi TTL dan kapasitas
+type IdempotencyStore struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	capacity int
+	order    *list.List // depan = paling baru dipakai
+	entries  map[string]*list.Element
+}
+
+func NewIdempotencyStore(ttl time.Duration, capacity int) *IdempotencyStore {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &IdempotencyStore{
+		ttl:      ttl,
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// begin mengklaim key. Jika key sudah ada, kembalikan entry lama (bisa masih in-flight).
+func (s *IdempotencyStore) begin(key string) (*idempotentResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, el := range s.entries {
+		if e := el.Value.(*idempotentResponse); e.done && now.After(e.expiresAt) {
+			s.remove(el)
+		}
+	}
+
+	if el, ok := s.entries[key]; ok {
+		s.order.MoveToFront(el)
+		copied := *el.Value.(*idempotentResponse)
+		return &copied, true
+	}
+
+	s.entries[key] = s.order.PushFront(&idempotentResponse{key: key})
+	s.evict()
+	return nil, false
+}
+
+func (s *IdempotencyStore) complete(key string, status int, contentType string, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry := &idempotentResponse{
+		key:         key,
+		status:      status,
+		contentType: contentType,
+		body:        body,
+		done:        true,
+		expiresAt:   time.Now().Add(s.ttl),
+	}
+	if el, ok := s.entries[key]; ok {
+		el.Value = entry
+		s.order.MoveToFront(el)
+		return
+	}
+	s.entries[key] = s.order.PushFront(entry)
+	s.evict()
+}
+
+// release melepas key agar request gagal bisa dicoba ulang
+func (s *IdempotencyStore) release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.entries[key]; ok {
+		s.remove(el)
+	}
+}
+
+// evict membuang entry selesai yang paling lama tidak dipakai sampai muat kapasitas.
+// Entry in-flight tidak dibuang; jumlahnya dibatasi request yang sedang berjalan.
+func (s *IdempotencyStore) evict() {
+	for el := s.order.Back(); el != nil && s.order.Len() > s.capacity; {
+		prev := el.Prev()
+		if el.Value.(*idempotentResponse).done {
+			s.remove(el)
+		}
+		el = prev
+	}
+}
+
+func (s *IdempotencyStore) remove(el *list.Element) {
+	s.order.Remove(el)
+	delete(s.entries, el.Value.(*idempotentResponse).key)
+}
+
+var idempotencyStore = NewIdempotencyStore(idempotencyTTL, idempotencyCapacity)
+
+// recordingWriter meneruskan response ke client sekaligus menyimpan salinannya
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(status int) {
+	rw.status = status
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+// idempotencyStoreKey key dipisah per API key (di-hash, tidak disimpan mentah) supaya
+// dua client yang kebetulan memakai Idempotency-Key sama tidak saling menerima response
+func idempotencyStoreKey(r *http.Request, header string) string {
+	owner := sha256.Sum256([]byte(extractAPIKey(r)))
+	return hex.EncodeToString(owner[:8]) + "|" + r.URL.Path + "|" + header
+}
+
+func withIdempotency(store *IdempotencyStore) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Idempotency-Key")
+			if header == "" {
+				next(w, r)
+				return
+			}
+
+			key := idempotencyStoreKey(r, header)
+			existing, found := store.begin(key)
+			if found {
+				if !existing.done {
+					writeAPIError(w, NewAPIError(http.StatusConflict, "idempotency_in_progress", "Request dengan Idempotency-Key ini masih diproses"))
+					return
+				}
+				w.Header().Set("Content-Type", existing.contentType)
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(existing.status)
+				w.Write(existing.body)
+				return
+			}
+
+			rec := &recordingWriter{ResponseWriter: w}
+			defer func() {
+				// Hanya response sukses yang disimpan; error boleh dicoba ulang
+				if rec.status >= 200 && rec.status < 300 {
+					store.complete(key, rec.status, w.Header().Get("Content-Type"), rec.body.Bytes())
+				} else {
+					store.release(key)
+				}
+			}()
+
+			next(rec, r)
+		}
+	}
+}
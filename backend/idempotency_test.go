@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// idempotentRequest POST dengan header Idempotency-Key ke handler yang sudah dibungkus middleware
+func idempotentRequest(handler HandlerFunc, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/harga/add", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", key)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestIdempotencyReplaysAddPrice(t *testing.T) {
+	store := NewMemoryPriceStore()
+	useStores(t, store, NewMemoryWeatherStore())
+	handler := withIdempotency(NewIdempotencyStore(time.Minute, idempotencyCapacity))(AddPriceHandler)
+	body := `{"region":"Jember","price":41000}`
+
+	first := idempotentRequest(handler, "abc-123", body)
+	second := idempotentRequest(handler, "abc-123", body)
+	if first.Code != http.StatusOK || second.Code != http.StatusOK {
+		t.Fatalf("status = %d, %d; ingin 200, 200", first.Code, second.Code)
+	}
+	if first.Header().Get("Idempotent-Replayed") != "" || second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("Idempotent-Replayed = %q, %q", first.Header().Get("Idempotent-Replayed"), second.Header().Get("Idempotent-Replayed"))
+	}
+	if second.Body.String() != first.Body.String() || second.Header().Get("Content-Type") != first.Header().Get("Content-Type") {
+		t.Fatalf("replay = %q, ingin %q", second.Body.String(), first.Body.String())
+	}
+	if page, _ := store.GetAll(PriceQuery{Limit: 10}); page.Total != 1 {
+		t.Fatalf("%d baris tersimpan, ingin 1", page.Total)
+	}
+
+	// Key lain = request baru
+	if rec := idempotentRequest(handler, "def-456", body); rec.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatal("key berbeda ikut diputar ulang")
+	}
+	if page, _ := store.GetAll(PriceQuery{Limit: 10}); page.Total != 2 {
+		t.Fatalf("%d baris tersimpan, ingin 2", page.Total)
+	}
+}
+
+func TestIdempotencyReleasesFailedRequests(t *testing.T) {
+	store := NewMemoryPriceStore()
+	useStores(t, store, NewMemoryWeatherStore())
+	handler := withIdempotency(NewIdempotencyStore(time.Minute, idempotencyCapacity))(AddPriceHandler)
+
+	if rec := idempotentRequest(handler, "retry-me", `{"region":"Jember","price":`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("body terpotong = %d, ingin 400", rec.Code)
+	}
+	// Error tidak disimpan: key yang sama boleh dipakai ulang dengan body yang sudah diperbaiki
+	rec := idempotentRequest(handler, "retry-me", `{"region":"Jember","price":41000}`)
+	if rec.Code != http.StatusOK || rec.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("percobaan ulang = %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestIdempotencyConflictWhileInFlight(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	calls := 0
+	handler := withIdempotency(NewIdempotencyStore(time.Minute, idempotencyCapacity))(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		close(started)
+		<-release
+		respondJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- idempotentRequest(handler, "lambat", "{}") }()
+	<-started
+
+	conflict := idempotentRequest(handler, "lambat", "{}")
+	var env struct{ Error APIError }
+	decodeBody(t, conflict, &env)
+	if conflict.Code != http.StatusConflict || env.Error.Code != "idempotency_in_progress" {
+		t.Fatalf("request kedua = %d %s, ingin 409", conflict.Code, conflict.Body.String())
+	}
+
+	close(release)
+	if first := <-done; first.Code != http.StatusOK {
+		t.Fatalf("request pertama = %d", first.Code)
+	}
+	if replay := idempotentRequest(handler, "lambat", "{}"); replay.Header().Get("Idempotent-Replayed") != "true" || calls != 1 {
+		t.Fatalf("replay setelah selesai: header %q, handler dipanggil %d kali",
+			replay.Header().Get("Idempotent-Replayed"), calls)
+	}
+}
+
+func TestIdempotencyStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	store := NewIdempotencyStore(time.Minute, 2)
+	for _, key := range []string{"a", "b"} {
+		store.begin(key)
+		store.complete(key, http.StatusOK, "application/json", []byte(key))
+	}
+	store.begin("a") // a dipakai lagi: b jadi paling lama
+
+	store.begin("c")
+	store.complete("c", http.StatusOK, "application/json", []byte("c"))
+	if e, found := store.begin("a"); !found || string(e.body) != "a" {
+		t.Fatalf("a = %+v, %v; ingin tetap tersimpan", e, found)
+	}
+	if _, found := store.begin("b"); found {
+		t.Fatal("b masih disimpan setelah kapasitas penuh")
+	}
+
+	// Key in-flight tidak dibuang walau melewati kapasitas
+	inFlight := NewIdempotencyStore(time.Minute, 1)
+	inFlight.begin("x")
+	inFlight.begin("y")
+	if e, found := inFlight.begin("x"); !found || e.done {
+		t.Fatalf("x in-flight = %+v, %v; ingin masih diklaim", e, found)
+	}
+}
+
+func TestIdempotencyKeyScopedPerAPIKey(t *testing.T) {
+	store := NewMemoryPriceStore()
+	useStores(t, store, NewMemoryWeatherStore())
+	prev := apiKeys
+	apiKeys = []string{"kunci-koperasi-a", "kunci-koperasi-b"}
+	t.Cleanup(func() { apiKeys = prev })
+	handler := chain(AddPriceHandler, withAPIKey, withIdempotency(NewIdempotencyStore(time.Minute, idempotencyCapacity)))
+
+	send := func(apiKey string, price int) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/harga/add", strings.NewReader(fmt.Sprintf(`{"region":"Jember","price":%d}`, price)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		req.Header.Set("Idempotency-Key", "order-1")
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	if rec := send("kunci-koperasi-a", 41000); rec.Code != http.StatusOK {
+		t.Fatalf("client A = %d %s", rec.Code, rec.Body.String())
+	}
+	// Client lain dengan Idempotency-Key sama tidak menerima response milik A
+	if rec := send("kunci-koperasi-b", 42000); rec.Code != http.StatusOK || rec.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("client B = %d replayed %q", rec.Code, rec.Header().Get("Idempotent-Replayed"))
+	}
+	if rec := send("kunci-koperasi-a", 41000); rec.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatal("ulangan client A tidak diputar ulang")
+	}
+	if page, _ := store.GetAll(PriceQuery{Limit: 10}); page.Total != 2 {
+		t.Fatalf("%d baris tersimpan, ingin 2", page.Total)
+	}
+}
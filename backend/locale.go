@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ============================================
+// LOCALE FORMAT ANGKA
+// Indonesia memakai titik untuk ribuan dan koma untuk desimal ("Rp 85.000,50");
+// tool lain (Excel en-US, pandas) mengharapkan sebaliknya
+// ============================================
+
+type Locale struct {
+	Name          string `json:"name"`
+	Grouping      string `json:"grouping"` // pemisah ribuan
+	Decimal       string `json:"decimal"`  // pemisah desimal
+	ListSeparator rune   `json:"-"`        // pemisah kolom CSV yang dipakai spreadsheet locale ini
+}
+
+var (
+	localeID = Locale{Name: "id", Grouping: ".", Decimal: ",", ListSeparator: ';'}
+	localeEN = Locale{Name: "en", Grouping: ",", Decimal: ".", ListSeparator: ','}
+
+	defaultLocale = localeID
+)
+
+var locales = map[string]Locale{
+	"id": localeID,
+	"en": localeEN,
+}
+
+// ParseLocale menerima tag bahasa seperti "id", "id-ID", "en_US" (hanya bagian bahasa yang dipakai)
+func ParseLocale(raw string) (Locale, bool) {
+	tag := strings.ToLower(strings.TrimSpace(raw))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	l, ok := locales[tag]
+	return l, ok
+}
+
+// localeFromRequest ?locale= diutamakan (harus valid), lalu Accept-Language, lalu default Indonesia
+func localeFromRequest(r *http.Request) (Locale, error) {
+	if raw := r.URL.Query().Get("locale"); raw != "" {
+		l, ok := ParseLocale(raw)
+		if !ok {
+			return Locale{}, fmt.Errorf("locale %q tidak didukung (pilih id atau en)", raw)
+		}
+		return l, nil
+	}
+
+	// Accept-Language: "en-US,en;q=0.9,id;q=0.8" - urutan dianggap sudah sesuai preferensi
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, _, _ := strings.Cut(part, ";")
+		if l, ok := ParseLocale(tag); ok {
+			return l, nil
+		}
+	}
+	return defaultLocale, nil
+}
+
+// FormatNumber memformat v dengan `decimals` angka di belakang koma, lengkap dengan pemisah ribuan
+func (l Locale) FormatNumber(v float64, decimals int) string {
+	return l.format(v, decimals, true)
+}
+
+// FormatPlain seperti FormatNumber tapi tanpa pemisah ribuan (untuk kolom numerik CSV)
+func (l Locale) FormatPlain(v float64, decimals int) string {
+	return l.format(v, decimals, false)
+}
+
+func (l Locale) format(v float64, decimals int, grouped bool) string {
+	raw := strconv.FormatFloat(math.Abs(v), 'f', decimals, 64)
+	intPart, fracPart, _ := strings.Cut(raw, ".")
+
+	if grouped {
+		var b strings.Builder
+		for i, digit := range intPart {
+			if i > 0 && (len(intPart)-i)%3 == 0 {
+				b.WriteString(l.Grouping)
+			}
+			b.WriteRune(digit)
+		}
+		intPart = b.String()
+	}
+
+	result := intPart
+	if fracPart != "" {
+		result += l.Decimal + fracPart
+	}
+	if v < 0 && strings.Trim(raw, "0.") != "" {
+		result = "-" + result
+	}
+	return result
+}
+
+// FormatRupiah "Rp 85.000" (id) / "Rp 85,000" (en); desimal hanya ditampilkan jika ada sen
+func FormatRupiah(amount float64, l Locale) string {
+	decimals := 0
+	if math.Round(amount) != math.Round(amount*100)/100 {
+		decimals = 2
+	}
+	return "Rp " + l.FormatNumber(amount, decimals)
+}
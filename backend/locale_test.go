@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLocaleFormatting(t *testing.T) {
+	tests := []struct {
+		value    float64
+		decimals int
+		id, en   string
+	}{
+		{85000, 0, "85.000", "85,000"},
+		{1234567.891, 2, "1.234.567,89", "1,234,567.89"},
+		{999, 0, "999", "999"},
+		{-42000.5, 1, "-42.000,5", "-42,000.5"},
+		{-0.001, 2, "0,00", "0.00"},
+	}
+	for _, tt := range tests {
+		if got := localeID.FormatNumber(tt.value, tt.decimals); got != tt.id {
+			t.Errorf("id FormatNumber(%v) = %q, ingin %q", tt.value, got, tt.id)
+		}
+		if got := localeEN.FormatNumber(tt.value, tt.decimals); got != tt.en {
+			t.Errorf("en FormatNumber(%v) = %q, ingin %q", tt.value, got, tt.en)
+		}
+	}
+
+	if got := localeID.FormatPlain(1234567.5, 2); got != "1234567,50" {
+		t.Errorf("id FormatPlain = %q", got)
+	}
+	if got := FormatRupiah(85000, localeID); got != "Rp 85.000" {
+		t.Errorf("FormatRupiah id = %q", got)
+	}
+	if got := FormatRupiah(85000.5, localeEN); got != "Rp 85,000.50" {
+		t.Errorf("FormatRupiah en = %q", got)
+	}
+}
+
+func TestLocaleFromRequest(t *testing.T) {
+	tests := []struct {
+		name, query, acceptLanguage string
+		want                        string
+		wantErr                     bool
+	}{
+		{"default Indonesia", "", "", "id", false},
+		{"query", "?locale=en_US", "id", "en", false},
+		{"query tidak didukung", "?locale=fr", "", "", true},
+		{"Accept-Language", "", "fr-FR,en-US;q=0.9,id;q=0.8", "en", false},
+		{"Accept-Language tidak dikenal", "", "fr-FR", "id", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/harga/export"+tt.query, nil)
+			r.Header.Set("Accept-Language", tt.acceptLanguage)
+			got, err := localeFromRequest(r)
+			if (err != nil) != tt.wantErr || got.Name != tt.want {
+				t.Fatalf("localeFromRequest = %q, %v; ingin %q (error %v)", got.Name, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
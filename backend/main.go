@@ -1,10 +1,16 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -13,20 +19,35 @@ import (
 // FUNCTIONAL MIDDLEWARE - CORS
 // ============================================
 
+// allowedOrigin menentukan nilai Access-Control-Allow-Origin untuk request ini
+func allowedOrigin(origins []string, requestOrigin string) string {
+	for _, o := range origins {
+		if o == "*" {
+			return "*"
+		}
+		if requestOrigin != "" && o == requestOrigin {
+			return requestOrigin
+		}
+	}
+	return ""
+}
+
 // CORS Middleware - Higher Order Function
-func enableCORS(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		
-		// Handle preflight request
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
+func enableCORS(origins []string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if origin := allowedOrigin(origins, r.Header.Get("Origin")); origin != "" {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				if origin != "*" {
+					w.Header().Add("Vary", "Origin")
+				}
+			}
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, Idempotency-Key")
+
+			// Preflight OPTIONS dijawab withMethodValidation (204 + Allow sesuai route)
+			next(w, r)
 		}
-		
-		next(w, r)
 	}
 }
 
@@ -42,72 +63,207 @@ func loadEnvironment() error {
 		return err
 	}
 	log.Println("✓ .env berhasil di-load")
-	log.Println("✓ OWM_API_KEY =", os.Getenv("OWM_API_KEY"))
 	return nil
 }
 
+// applyConfig meneruskan subset konfigurasi ke masing-masing komponen
+func applyConfig(cfg Config) {
+	logLevel = cfg.LogLevel
+	sharedHTTPClient = NewHTTPClient(cfg.HTTP)
+	ConfigureWeather(cfg.Weather, sharedHTTPClient)
+	scraperHTTPClient = withUserAgent(sharedHTTPClient, cfg.ScraperUserAgent)
+	scraperPoliteDelay = cfg.ScraperPoliteDelay
+	if cfg.Weather.APIKey == "" {
+		log.Println("⚠️  OWM_API_KEY kosong - endpoint cuaca tidak akan berfungsi")
+	}
+
+	apiKeys = cfg.APIKeys
+	if len(apiKeys) == 0 {
+		log.Println("⚠️  API_KEYS kosong - autentikasi endpoint write NONAKTIF")
+	} else {
+		log.Printf("✓ API key auth aktif (%d key)", len(apiKeys))
+	}
+
+	maxBodyBytes = cfg.MaxBodyBytes
+	regionAliases = NewRegionAliases(cfg.RegionAliases)
+	wsPushInterval = cfg.WSPushInterval
+	wsAllowedOrigins = cfg.CORSOrigins
+	priceSimulationConfig = cfg.PriceSim
+	scrapeMode = cfg.ScrapeMode
+	bappebtiBreaker = NewCircuitBreaker("bappebti", cfg.ScrapeBreaker)
+	bappebtiCommodities = cfg.BAPPEBTICommodities
+	persistPriceFallback = cfg.PersistPriceFallback
+	priceStore, weatherStore = newStores(cfg.PriceStore)
+	if cfg.PriceStore == priceStoreMemory {
+		log.Println("⚠️  PRICE_STORE=memory - harga & weather history tidak disimpan permanen")
+	}
+}
+
 // ============================================
 // FUNCTIONAL ROUTER SETUP
 // ============================================
 
-// Route definition type
+// Route definition type. Methods ditegakkan oleh registerRoutes (405 + header Allow),
+// handler tidak perlu memasang withMethodValidation sendiri.
 type Route struct {
 	Pattern string
 	Handler http.HandlerFunc
-	Method  string
+	Methods []string
+	// Middleware khusus route (auth, body limit, timeout), dipasang di luar middleware default
+	Middleware []MiddlewareFunc
+	NonJSON    bool // response bukan JSON (SSE, WebSocket, CSV): tanpa withJSONContentType
+	Quiet      bool // probe yang dipanggil tiap beberapa detik: tanpa withLogging
+}
+
+// routeHandler urutan luar -> dalam: withRequestMetrics, validasi method, Route.Middleware,
+// lalu withJSONContentType, withLogging, withRecovery (recovery selalu dipasang).
+// Metrics paling luar supaya respons dari Route.Middleware (mis. 504 withTimeout) ikut tercatat.
+func routeHandler(route Route) HandlerFunc {
+	var middlewares []MiddlewareFunc
+	if !route.Quiet && !route.NonJSON {
+		middlewares = append(middlewares, withRequestMetrics)
+	}
+	middlewares = append(middlewares, withMethodValidation(route.Methods...))
+	middlewares = append(middlewares, route.Middleware...)
+	if !route.NonJSON {
+		middlewares = append(middlewares, withJSONContentType)
+	}
+	if !route.Quiet {
+		middlewares = append(middlewares, withLogging)
+	}
+	middlewares = append(middlewares, withRecovery)
+	return chain(HandlerFunc(route.Handler), middlewares...)
 }
 
 // Register routes functionally
-func registerRoutes(mux *http.ServeMux, routes []Route) {
+func registerRoutes(mux *http.ServeMux, routes []Route, corsOrigins []string) {
+	cors := enableCORS(corsOrigins)
 	for _, route := range routes {
-		// Apply CORS to all handlers
-		mux.HandleFunc(route.Pattern, enableCORS(route.Handler))
-		log.Printf("✓ Registered: %-8s %s", route.Method, route.Pattern)
+		// CORS paling luar supaya preflight OPTIONS tidak ditolak validasi method
+		mux.HandleFunc(route.Pattern, cors(http.HandlerFunc(routeHandler(route))))
+		log.Printf("✓ Registered: %-8s %s", strings.Join(route.Methods, ","), route.Pattern)
 	}
 }
 
 // Define all routes in a declarative way
 func getRoutes() []Route {
 	return []Route{
+		// Health endpoints
+		{Pattern: "/health", Handler: http.HandlerFunc(HealthHandler), Methods: []string{"GET"}, Quiet: true},
+		{Pattern: "/ready", Handler: http.HandlerFunc(ReadinessHandler), Methods: []string{"GET"}, Quiet: true},
+		{Pattern: "/version", Handler: http.HandlerFunc(VersionHandler), Methods: []string{"GET"}, Quiet: true},
+		{Pattern: "/status", Handler: http.HandlerFunc(StatusHandler), Methods: []string{"GET"}, Quiet: true},
+
 		// Price endpoints
-		{Pattern: "/harga", Handler: http.HandlerFunc(PricesHandler), Method: "GET"},
-		{Pattern: "/harga/add", Handler: http.HandlerFunc(AddPriceHandler), Method: "POST"},
-		{Pattern: "/harga/fetch", Handler: http.HandlerFunc(FetchPricesHandler), Method: "POST"},
-		{Pattern: "/harga/current", Handler: http.HandlerFunc(GetCurrentPriceHandler), Method: "GET"},
+		{Pattern: "/harga", Handler: http.HandlerFunc(PricesHandler), Methods: []string{"GET"}},
+		{Pattern: "/harga/add", Handler: http.HandlerFunc(AddPriceHandler), Methods: []string{"POST"},
+			Middleware: []MiddlewareFunc{withAPIKey, withBodyLimit(maxBodyBytes), withIdempotency(idempotencyStore)}},
+		{Pattern: "/harga/fetch", Handler: http.HandlerFunc(FetchPricesHandler), Methods: []string{"POST"}, Middleware: []MiddlewareFunc{withAPIKey, withBodyLimit(maxBodyBytes), withTimeout(scrapeRequestTimeout)}},
+		{Pattern: "/harga/import", Handler: http.HandlerFunc(ImportPricesHandler), Methods: []string{"POST"},
+			Middleware: []MiddlewareFunc{withAPIKey, withBodyLimit(maxImportBytes), withIdempotency(idempotencyStore)}},
+		{Pattern: "/harga/import/stream", Handler: http.HandlerFunc(StreamImportPricesHandler), Methods: []string{"POST"}, Middleware: []MiddlewareFunc{withAPIKey, withBodyLimit(maxStreamImportBytes)}},
+		{Pattern: "/harga/stats", Handler: http.HandlerFunc(PriceStatsHandler), Methods: []string{"GET"}},
+		{Pattern: "/harga/trend", Handler: http.HandlerFunc(PriceTrendHandler), Methods: []string{"GET"}},
+		{Pattern: "/harga/anomalies", Handler: http.HandlerFunc(PriceAnomaliesHandler), Methods: []string{"GET"}},
+		{Pattern: "/harga/export", Handler: http.HandlerFunc(ExportPricesHandler), Methods: []string{"GET"}, NonJSON: true},
+		{Pattern: "/harga/sources", Handler: http.HandlerFunc(PriceSourcesHandler), Methods: []string{"GET"}},
+		{Pattern: "/harga/current", Handler: http.HandlerFunc(GetCurrentPriceHandler), Methods: []string{"GET"},
+			Middleware: []MiddlewareFunc{withTimeout(scrapeRequestTimeout)}},
+		{Pattern: "/harga/current/batch", Handler: http.HandlerFunc(BatchCurrentPriceHandler), Methods: []string{"GET"}},
+		{Pattern: "/harga/stream", Handler: http.HandlerFunc(PriceStreamHandler), Methods: []string{"GET"}, NonJSON: true},
+		{Pattern: "/harga/scrape/status", Handler: http.HandlerFunc(ScrapeStatusHandler), Methods: []string{"GET"}},
 		
 		// Weather endpoints
-		{Pattern: "/cuaca", Handler: http.HandlerFunc(WeatherAPIHandler), Method: "GET"},
-		{Pattern: "/weather", Handler: http.HandlerFunc(WeatherAPIHandler), Method: "GET"},
-		{Pattern: "/weather/multi", Handler: http.HandlerFunc(MultiRegionWeatherHandler), Method: "GET"},
+		{Pattern: "/cuaca", Handler: http.HandlerFunc(WeatherAPIHandler), Methods: []string{"GET"},
+			Middleware: []MiddlewareFunc{withTimeout(weatherRequestTimeout)}},
+		{Pattern: "/weather", Handler: http.HandlerFunc(WeatherAPIHandler), Methods: []string{"GET"},
+			Middleware: []MiddlewareFunc{withTimeout(weatherRequestTimeout)}},
+		{Pattern: "/weather/multi", Handler: http.HandlerFunc(MultiRegionWeatherHandler), Methods: []string{"GET"},
+			Middleware: []MiddlewareFunc{withTimeout(weatherRequestTimeout)}},
+		{Pattern: "/weather/forecast/multi", Handler: http.HandlerFunc(MultiRegionForecastHandler), Methods: []string{"GET"}, Middleware: []MiddlewareFunc{withTimeout(weatherRequestTimeout)}},
+		{Pattern: "/weather/history/daily", Handler: http.HandlerFunc(WeatherDailyHistoryHandler), Methods: []string{"GET"}},
+		{Pattern: "/weather/batch", Handler: http.HandlerFunc(BatchWeatherHandler), Methods: []string{"POST"},
+			Middleware: []MiddlewareFunc{withBodyLimit(maxBodyBytes), withTimeout(weatherRequestTimeout)}},
+		{Pattern: "/ws/weather", Handler: http.HandlerFunc(WeatherWebSocketHandler), Methods: []string{"GET"}, NonJSON: true},
+		{Pattern: "/debug/weather-stats", Handler: http.HandlerFunc(WeatherStatsHandler), Methods: []string{"GET"}},
+		{Pattern: "/admin/export", Handler: http.HandlerFunc(ExportSnapshotHandler), Methods: []string{"GET"},
+			Middleware: []MiddlewareFunc{withAPIKey}},
+		{Pattern: "/admin/import", Handler: http.HandlerFunc(ImportSnapshotHandler), Methods: []string{"POST"}, Middleware: []MiddlewareFunc{withAPIKey, withBodyLimit(maxSnapshotBytes)}},
+		{Pattern: "/admin/reset", Handler: http.HandlerFunc(ResetDatabaseHandler), Methods: []string{"POST"},
+			Middleware: []MiddlewareFunc{withAPIKey, withBodyLimit(maxBodyBytes)}},
 		
 		// Recommendation endpoints
-		{Pattern: "/rekomendasi", Handler: http.HandlerFunc(RecommendationHandler), Method: "GET"},
-		{Pattern: "/rekomendasi/advanced", Handler: http.HandlerFunc(AdvancedRecommendationHandler), Method: "GET"},
+		{Pattern: "/rekomendasi", Handler: http.HandlerFunc(RecommendationHandler), Methods: []string{"GET"}},
+		{Pattern: "/rekomendasi/advanced", Handler: http.HandlerFunc(AdvancedRecommendationHandler), Methods: []string{"GET"},
+			Middleware: []MiddlewareFunc{withTimeout(weatherRequestTimeout)}},
+		{Pattern: "/rekomendasi/week", Handler: http.HandlerFunc(WeeklyRecommendationHandler), Methods: []string{"GET"}, Middleware: []MiddlewareFunc{withTimeout(weatherRequestTimeout)}},
+		{Pattern: "/rekomendasi/compare", Handler: http.HandlerFunc(CompareRecommendationHandler), Methods: []string{"GET"},
+			Middleware: []MiddlewareFunc{withTimeout(weatherRequestTimeout)}},
+		{Pattern: "/rekomendasi/best", Handler: http.HandlerFunc(BestRegionHandler), Methods: []string{"GET"}, Middleware: []MiddlewareFunc{withTimeout(weatherRequestTimeout)}},
+		{Pattern: "/rekomendasi/historical", Handler: http.HandlerFunc(HistoricalRecommendationHandler), Methods: []string{"GET"}},
+		{Pattern: "/rekomendasi/batch", Handler: http.HandlerFunc(BatchRecommendationHandler), Methods: []string{"POST"},
+			Middleware: []MiddlewareFunc{withBodyLimit(maxBodyBytes)}},
+		{Pattern: "/rekomendasi/report", Handler: http.HandlerFunc(RecommendationReportHandler), Methods: []string{"GET"}, Middleware: []MiddlewareFunc{withTimeout(weatherRequestTimeout)}},
 	}
 }
 
 // Print available endpoints
-func printEndpoints() {
+func printEndpoints(port string, tlsEnabled bool) {
 	separator := "============================================================"
+	scheme, mode := "http", "HTTP (tanpa TLS)"
+	if tlsEnabled {
+		scheme, mode = "https", "HTTPS (TLS aktif)"
+	}
 	
 	fmt.Println("\n" + separator)
-	fmt.Println("🚀 Server berjalan di http://localhost:8080")
+	fmt.Println("🚀 Server berjalan di " + scheme + "://localhost:" + port)
+	fmt.Println("🔒 Mode: " + mode)
+	build := currentBuildInfo()
+	fmt.Printf("🏷️  Versi: %s (%s, build %s)\n", build.Version, build.Commit, build.BuildTime)
 	fmt.Println(separator)
-	fmt.Println("\n📋 Endpoints tersedia:\n")
+	fmt.Print("\n📋 Endpoints tersedia:\n\n")
 	
 	endpoints := []struct {
 		method      string
 		path        string
 		description string
 	}{
-		{"GET", "/harga", "Lihat semua harga"},
+		{"GET", "/health", "Liveness probe"},
+		{"GET", "/ready", "Readiness probe (DB + status OWM)"},
+		{"GET", "/status", "Skor kesehatan 0-100 (DB, OWM, scraper, rasio 5xx)"},
+		{"GET", "/version", "Versi, commit & waktu build"},
+		{"GET", "/harga?limit=&offset=&region=", "Lihat harga (paginated, ?raw=true = array polos)"},
 		{"POST", "/harga/add", "Tambah harga manual"},
 		{"POST", "/harga/fetch", "Fetch harga otomatis (scraping)"},
-		{"GET", "/harga/current", "Lihat harga terkini by region"},
+		{"POST", "/harga/import", "Import harga dari CSV (multipart, field: file)"},
+		{"POST", "/harga/import/stream", "Import CSV besar (body CSV mentah, disimpan per batch)"},
+		{"GET", "/harga/stats?threshold=", "Statistik harga per region (all-time, 7 & 30 hari)"},
+		{"GET", "/harga/trend?region=&window=", "Tren harga + moving average"},
+		{"GET", "/harga/anomalies?region=&threshold=", "Harga yang dicurigai anomali (median/MAD)"},
+		{"GET", "/harga/export?region=&locale=id|en", "Export harga ke CSV (format angka sesuai locale)"},
+		{"GET", "/harga/sources?from=&to=", "Jumlah data harga per sumber (BAPPEBTI/riset/simulasi/manual)"},
+		{"GET", "/harga/current", "Lihat harga terkini by region (?with_weather=true)"},
+		{"GET", "/harga/current/batch?regions=", "Harga terkini banyak region + daftar region tanpa data"},
+		{"GET", "/harga/stream", "Stream harga baru (Server-Sent Events)"},
+		{"GET", "/harga/scrape/status", "Status & kesegaran tiap scraper"},
 		{"GET", "/cuaca", "Data cuaca single region"},
 		{"GET", "/weather/multi", "🆕 Data cuaca multiple regions (concurrent)"},
+		{"GET", "/weather/forecast/multi", "Forecast beberapa region dalam satu list (?regions=a,b)"},
+		{"GET", "/weather/history/daily?region=&days=", "Agregat cuaca harian (WIB) dari weather_history"},
+		{"POST", "/weather/batch", "Cuaca banyak region sekaligus + daftar region yang gagal"},
+		{"GET", "/ws/weather", "WebSocket cuaca + rekomendasi (push berkala)"},
+		{"GET", "/debug/weather-stats", "Latensi & hasil panggilan OWM per region"},
+		{"GET", "/admin/export", "Snapshot JSON seluruh database (butuh API key)"},
+		{"POST", "/admin/import?confirm=true", "Restore snapshot, MENGGANTI semua data (butuh API key)"},
+		{"POST", "/admin/reset", "Kosongkan data (+ seed demo), body {\"confirm\": true} (butuh API key)"},
 		{"GET", "/rekomendasi", "Rekomendasi sederhana"},
 		{"GET", "/rekomendasi/advanced", "Rekomendasi detail"},
+		{"GET", "/rekomendasi/week?region=", "Rekomendasi per hari dari forecast 5 hari"},
+		{"GET", "/rekomendasi/compare?a=X&b=Y", "Bandingkan kelayakan panen dua region"},
+		{"GET", "/rekomendasi/best?regions=a,b,c", "Peringkat region paling layak panen"},
+		{"GET", "/rekomendasi/historical?region=&at=", "Rekomendasi dari cuaca historis terdekat (backtest)"},
+		{"POST", "/rekomendasi/batch", "Rekomendasi dari data cuaca eksplisit (offline)"},
+		{"GET", "/rekomendasi/report?region=&format=pdf|html", "Laporan rekomendasi siap cetak"},
 	}
 	
 	for _, ep := range endpoints {
@@ -128,6 +284,43 @@ func printEndpoints() {
 	fmt.Println(separator + "\n")
 }
 
+// ============================================
+// SERVER LIFECYCLE
+// ============================================
+
+const shutdownTimeout = 15 * time.Second
+
+// runServer menjalankan server (HTTP atau HTTPS) sampai SIGINT/SIGTERM,
+// lalu mematikannya dengan graceful agar request yang berjalan sempat selesai
+func runServer(srv *http.Server, tlsCfg TLSConfig) error {
+	errCh := make(chan error, 1)
+	go func() {
+		if tlsCfg.Enabled() {
+			errCh <- srv.ListenAndServeTLS(tlsCfg.CertFile, tlsCfg.KeyFile)
+		} else {
+			errCh <- srv.ListenAndServe()
+		}
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	select {
+	case err := <-errCh:
+		return err
+	case sig := <-stop:
+		log.Printf("🛑 Sinyal %s diterima, mematikan server...", sig)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("⚠️  Warning - shutdown tidak bersih: %v", err)
+	}
+	return nil
+}
+
 // ============================================
 // MAIN FUNCTION - COMPOSITION
 // ============================================
@@ -136,21 +329,57 @@ func main() {
 	// 1. Load environment (side effect)
 	loadEnvironment()
 	
-	// 2. Initialize database (side effect)
-	InitDB()
-	defer DB.Close()
-	log.Println("✓ Database initialized")
+	// 1b. Load & validasi konfigurasi (fail fast)
+	cfg, err := LoadConfig(os.Getenv)
+	if err != nil {
+		log.Fatal(err)
+	}
+	applyConfig(cfg)
+
+	// 2. Initialize database (side effect); PRICE_STORE=memory tidak membuka SQLite sama sekali
+	if cfg.PriceStore == priceStoreSQLite {
+		InitDB(cfg.DB)
+		defer DB.Close()
+		defer dbWriter.Close()
+		log.Println("✓ Database initialized")
+	}
+
+	// 2b. Scheduler scraping periodik (opsional, SCRAPE_INTERVAL)
+	if cfg.ScrapeInterval > 0 {
+		priceScheduler := NewScheduler("scrape harga", cfg.ScrapeInterval, scheduledPriceFetch)
+		priceScheduler.Start()
+		defer priceScheduler.Stop()
+	}
+
+	// 2c. Scheduler sampel cuaca untuk weather_history (opsional, WEATHER_HISTORY_INTERVAL)
+	if cfg.WeatherHistory.Interval > 0 {
+		weatherScheduler := NewScheduler("weather history", cfg.WeatherHistory.Interval,
+			collectWeatherHistory(cfg.WeatherHistory.Regions))
+		weatherScheduler.Start()
+		defer weatherScheduler.Stop()
+	}
+
+	// 2d. Scheduler retention data lama (opsional, RETENTION_INTERVAL)
+	if cfg.Retention.Interval > 0 {
+		retentionScheduler := NewScheduler("retention", cfg.Retention.Interval, retentionJob(cfg.Retention))
+		retentionScheduler.Start()
+		defer retentionScheduler.Stop()
+	}
 	
 	// 3. Setup router
 	mux := http.NewServeMux()
 	
 	// 4. Register routes functionally
 	routes := getRoutes()
-	registerRoutes(mux, routes)
+	registerRoutes(mux, routes, cfg.CORSOrigins)
 	
 	// 5. Print server info
-	printEndpoints()
+	printEndpoints(cfg.Port, cfg.TLS.Enabled())
 	
-	// 6. Start server
-	log.Fatal(http.ListenAndServe(":8080", mux))
+	// 6. Start server (HTTPS jika TLS_CERT & TLS_KEY diset)
+	srv := &http.Server{Addr: ":" + cfg.Port, Handler: mux}
+	if err := runServer(srv, cfg.TLS); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+	log.Println("✓ Server berhenti")
 }
\ No newline at end of file
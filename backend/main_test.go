@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// routedMux mux dari getRoutes dengan handler diganti fn; log pendaftaran dibuang
+func routedMux(t *testing.T, fn func(Route) http.HandlerFunc) *http.ServeMux {
+	t.Helper()
+	prev := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(prev) })
+
+	routes := getRoutes()
+	for i := range routes {
+		routes[i].Handler = fn(routes[i])
+	}
+	mux := http.NewServeMux()
+	registerRoutes(mux, routes, nil)
+	return mux
+}
+
+func TestRegisteredRoutesRejectWrongMethod(t *testing.T) {
+	useRequestErrors(t)
+	var called atomic.Int32
+	mux := routedMux(t, func(Route) http.HandlerFunc {
+		return func(http.ResponseWriter, *http.Request) { called.Add(1) }
+	})
+
+	for _, route := range getRoutes() {
+		allowed := strings.Join(route.Methods, ",")
+		for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+			if strings.Contains(","+allowed+",", ","+method+",") {
+				continue
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(method, route.Pattern, nil))
+			var env struct{ Error APIError }
+			decodeBody(t, rec, &env)
+			if rec.Code != http.StatusMethodNotAllowed || env.Error.Code != "method_not_allowed" {
+				t.Fatalf("%s %s = %d %s, ingin 405 method_not_allowed", method, route.Pattern, rec.Code, rec.Body.String())
+			}
+			if want := allowed + ",OPTIONS"; strings.ReplaceAll(rec.Header().Get("Allow"), " ", "") != want {
+				t.Fatalf("%s %s: Allow = %q, ingin %s", method, route.Pattern, rec.Header().Get("Allow"), want)
+			}
+		}
+	}
+	if called.Load() != 0 {
+		t.Fatalf("handler dipanggil %d kali untuk method yang ditolak", called.Load())
+	}
+}
+
+func TestRouteHandlerMultipleMethods(t *testing.T) {
+	handler := routeHandler(Route{
+		Pattern: "/ganda",
+		Handler: func(w http.ResponseWriter, r *http.Request) { respondJSON(w, http.StatusOK, r.Method) },
+		Methods: []string{http.MethodGet, http.MethodPost},
+		Quiet:   true,
+	})
+	for method, want := range map[string]int{
+		http.MethodGet:    http.StatusOK,
+		http.MethodPost:   http.StatusOK,
+		http.MethodPut:    http.StatusMethodNotAllowed,
+		http.MethodDelete: http.StatusMethodNotAllowed,
+	} {
+		rec := serve(http.HandlerFunc(handler), method, "/ganda", "")
+		if rec.Code != want {
+			t.Fatalf("%s /ganda = %d, ingin %d", method, rec.Code, want)
+		}
+	}
+}
+
+func TestRegisteredRoutesRecoverAndLog(t *testing.T) {
+	useRequestErrors(t)
+	prevKeys := apiKeys
+	apiKeys = nil
+	t.Cleanup(func() { apiKeys = prevKeys })
+	mux := routedMux(t, func(route Route) http.HandlerFunc {
+		return func(http.ResponseWriter, *http.Request) { panic("gagal di " + route.Pattern) }
+	})
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+
+	for _, route := range getRoutes() {
+		logs.Reset()
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(route.Methods[0], route.Pattern+"?region=Jember", nil))
+		var env struct{ Error APIError }
+		decodeBody(t, rec, &env)
+		if rec.Code != http.StatusInternalServerError || env.Error.Code != "internal_server_error" {
+			t.Fatalf("%s %s panic = %d %s, ingin 500", route.Methods[0], route.Pattern, rec.Code, rec.Body.String())
+		}
+		if !strings.Contains(logs.String(), "Panic recovered: gagal di "+route.Pattern) {
+			t.Fatalf("%s: panic tidak tercatat di log: %q", route.Pattern, logs.String())
+		}
+		// Probe (Quiet) sengaja tidak dicatat tiap request
+		request := "[" + route.Methods[0] + "] " + route.Pattern + " region=Jember"
+		if logged := strings.Contains(logs.String(), request); logged == route.Quiet {
+			t.Fatalf("%s: log request %v, Quiet %v: %q", route.Pattern, logged, route.Quiet, logs.String())
+		}
+	}
+}
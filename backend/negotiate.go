@@ -0,0 +1,69 @@
+package main
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ============================================
+// CONTENT NEGOTIATION
+// Integrator SMS/USSD meminta ringkasan teks lewat header Accept;
+// klien lain tetap mendapat JSON
+// ============================================
+
+const (
+	mediaTypeJSON      = "application/json"
+	mediaTypePlainText = "text/plain"
+)
+
+// negotiateContentType memilih media type dari offers dengan q-value tertinggi di
+// header Accept. Tanpa Accept, atau tidak ada yang cocok, offers[0] (default) dipakai.
+func negotiateContentType(r *http.Request, offers ...string) string {
+	best, bestQ := offers[0], 0.0
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(raw, 64); err != nil {
+				continue
+			}
+		}
+
+		for _, offer := range offers {
+			if q > bestQ && acceptMatches(mediaType, offer) {
+				best, bestQ = offer, q
+			}
+		}
+	}
+	return best
+}
+
+// acceptMatches mendukung wildcard "*/*" dan "text/*"
+func acceptMatches(accepted, offer string) bool {
+	if accepted == "*/*" || accepted == offer {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(accepted, "/*")
+	return ok && strings.HasPrefix(offer, prefix+"/")
+}
+
+func respondText(w http.ResponseWriter, statusCode int, text string) error {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(statusCode)
+	_, err := w.Write([]byte(text + "\n"))
+	return err
+}
+
+// respondNegotiated mengirim text jika klien meminta text/plain, selain itu JSON
+func respondNegotiated(w http.ResponseWriter, r *http.Request, statusCode int, data interface{}, text func() string) error {
+	w.Header().Add("Vary", "Accept")
+	if negotiateContentType(r, mediaTypeJSON, mediaTypePlainText) == mediaTypePlainText {
+		return respondText(w, statusCode, text())
+	}
+	return respondJSON(w, statusCode, data)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateContentType(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", mediaTypeJSON},
+		{"application/json", mediaTypeJSON},
+		{"text/plain", mediaTypePlainText},
+		{"text/*", mediaTypePlainText},
+		{"*/*", mediaTypeJSON},
+		{"application/json;q=0.5, text/plain", mediaTypePlainText},
+		{"text/plain;q=0.2, application/json", mediaTypeJSON},
+		{"image/png", mediaTypeJSON},
+		{"text/plain;q=abc", mediaTypeJSON},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", tt.accept)
+		if got := negotiateContentType(r, mediaTypeJSON, mediaTypePlainText); got != tt.want {
+			t.Errorf("Accept %q = %s, ingin %s", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestRecommendationHandlersNegotiateAccept(t *testing.T) {
+	handlers := []struct {
+		name     string
+		handler  HandlerFunc
+		target   string
+		textHead string
+	}{
+		{"simple", RecommendationHandler, "/rekomendasi?region=Jember&temp=26&humidity=70&rain=2", "Jember 26.0°C, RH 70%, hujan 2.0mm: "},
+		{"advanced", AdvancedRecommendationHandler, "/rekomendasi/advanced?region=Jember&temp=26&humidity=70&rain=2", "[OPTIMAL] Jember 26.0°C"},
+	}
+	// Route memasang withJSONContentType; respons teks harus menimpanya
+	for _, h := range handlers {
+		t.Run(h.name+" text/plain", func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, h.target, nil)
+			req.Header.Set("Accept", "text/plain")
+			withJSONContentType(h.handler)(rec, req)
+			if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, mediaTypePlainText) {
+				t.Fatalf("Content-Type = %q, ingin text/plain", ct)
+			}
+			if body := rec.Body.String(); !strings.HasPrefix(body, h.textHead) || strings.Count(body, "\n") != 1 {
+				t.Fatalf("body = %q, ingin diawali %q", body, h.textHead)
+			}
+			if rec.Header().Get("Vary") != "Accept" {
+				t.Fatalf("Vary = %q", rec.Header().Get("Vary"))
+			}
+		})
+		t.Run(h.name+" application/json", func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, h.target, nil)
+			req.Header.Set("Accept", "application/json")
+			withJSONContentType(h.handler)(rec, req)
+			var body map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("body bukan JSON: %s", rec.Body.String())
+			}
+			if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, mediaTypeJSON) || body["region"] != "Jember" {
+				t.Fatalf("Content-Type = %q, body = %v", ct, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// ============================================
+// EXPORT HARGA KE CSV
+// Angka diformat sesuai locale (?locale= atau Accept-Language) agar langsung
+// terbaca benar oleh spreadsheet; default Indonesia (desimal koma, kolom ";")
+// ============================================
+
+var priceExportHeader = []string{"region", "price", "price_min", "price_max", "price_formatted", "unit", "source", "recorded_at"}
+
+func priceExportRecord(p Price, l Locale) []string {
+	optional := func(v *float64) string {
+		if v == nil {
+			return ""
+		}
+		return l.FormatPlain(*v, 2)
+	}
+	return []string{
+		p.Region,
+		l.FormatPlain(p.Price, 2),
+		optional(p.PriceMin),
+		optional(p.PriceMax),
+		FormatRupiah(p.Price, l),
+		p.Unit,
+		p.Source,
+		p.RecordedAt,
+	}
+}
+
+// writePriceCSV header HTTP & baris judul CSV baru dikirim saat harga pertama datang
+// (atau setelah store selesai tanpa data), sehingga error store sebelum itu tetap
+// bisa dijawab sebagai JSON 500; setelah itu error hanya bisa di-log
+func writePriceCSV(w http.ResponseWriter, region string, locale Locale) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = locale.ListSeparator
+
+	started := false
+	start := func() error {
+		started = true
+		filename := fmt.Sprintf("harga-%s-%s.csv", locale.Name, time.Now().Format("20060102"))
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		w.Header().Set("Content-Language", locale.Name)
+		return writer.Write(priceExportHeader)
+	}
+
+	err := priceStore.Each(region, func(p Price) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		return writer.Write(priceExportRecord(p, locale))
+	})
+	if err != nil {
+		if !started {
+			return err
+		}
+		log.Printf("Export CSV terputus: %v", err)
+		return nil
+	}
+	if !started {
+		if err := start(); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		log.Printf("Export CSV terputus: %v", err)
+	}
+	return nil
+}
+
+func ExportPricesHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		locale, err := localeFromRequest(r)
+		if err != nil {
+			return NewAPIError(http.StatusBadRequest, "invalid_locale", err.Error())
+		}
+		return writePriceCSV(w, r.URL.Query().Get("region"), locale)
+	})(w, r)
+}
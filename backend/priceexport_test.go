@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestExportPricesHandlerLocales(t *testing.T) {
+	ps := NewMemoryPriceStore()
+	useStores(t, ps, NewMemoryWeatherStore())
+	p := testPrice("Jember", 85000.5, "2026-03-01")
+	p.PriceMin, p.PriceMax = floatPtr(80000), floatPtr(91000)
+	mustAdd(t, ps, p)
+
+	tests := []struct {
+		name, query, header, record string
+	}{
+		{"default Indonesia", "", "region;price;price_min", `Jember;85000,50;80000,00;91000,00;Rp 85.000,50;kg`},
+		{"en", "&locale=en", "region,price,price_min", `Jember,85000.50,80000.00,91000.00,"Rp 85,000.50",kg`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(ExportPricesHandler, http.MethodGet, "/harga/export?region=Jember"+tt.query, "")
+			lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+			if rec.Code != http.StatusOK || len(lines) != 2 {
+				t.Fatalf("GET /harga/export = %d %q", rec.Code, rec.Body.String())
+			}
+			if !strings.HasPrefix(lines[0], tt.header) || !strings.HasPrefix(lines[1], tt.record) {
+				t.Fatalf("CSV = %q, ingin diawali %q / %q", lines, tt.header, tt.record)
+			}
+		})
+	}
+
+	if rec := serve(ExportPricesHandler, http.MethodGet, "/harga/export?locale=fr", ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("locale tidak didukung = %d, ingin 400", rec.Code)
+	}
+}
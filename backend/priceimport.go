@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ============================================
+// IMPORT HARGA DARI CSV
+// Backfill data historis koperasi: baris valid disimpan dalam satu transaksi,
+// baris tidak valid dilaporkan per nomor baris
+// ============================================
+
+const (
+	maxImportBytes       int64 = 10 << 20 // 10MB
+	importFormField            = "file"
+	importDefaultUnit          = "kg"
+	importDefaultSource        = "CSV Import"
+	importDateLayout           = "2006-01-02"
+	importDateTimeLayout       = "2006-01-02 15:04:05"
+)
+
+// importHeaderAliases nama kolom yang dikenali (lowercase) -> field Price
+var importHeaderAliases = map[string]string{
+	"region":      "region",
+	"wilayah":     "region",
+	"daerah":      "region",
+	"price":       "price",
+	"harga":       "price",
+	"unit":        "unit",
+	"satuan":      "unit",
+	"source":      "source",
+	"sumber":      "source",
+	"recorded_at": "recorded_at",
+	"tanggal":     "recorded_at",
+	"date":        "recorded_at",
+}
+
+// ImportRowError laporan untuk satu baris CSV yang ditolak (Row = nomor baris di file, header = 1)
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+type ImportReport struct {
+	Imported int              `json:"imported"`
+	Rejected int              `json:"rejected"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+// mapImportHeader mengembalikan field -> indeks kolom; region & price wajib ada
+func mapImportHeader(header []string) (map[string]int, error) {
+	columns := make(map[string]int)
+	for i, name := range header {
+		key := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if field, ok := importHeaderAliases[key]; ok {
+			if _, dup := columns[field]; !dup {
+				columns[field] = i
+			}
+		}
+	}
+
+	var missing []string
+	for _, field := range []string{"region", "price"} {
+		if _, ok := columns[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("kolom wajib tidak ditemukan: %s", strings.Join(missing, ", "))
+	}
+	return columns, nil
+}
+
+// parseImportRow mengubah satu baris CSV menjadi Price. Harga boleh memakai format
+// Indonesia ("Rp 85.000") atau rentang ("80.000 - 90.000").
+func parseImportRow(record []string, columns map[string]int) (Price, error) {
+	get := func(field string) string {
+		if i, ok := columns[field]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	p := Price{
+		Region:     get("region"),
+		Unit:       get("unit"),
+		Source:     get("source"),
+		SourceType: sourceTypeImport,
+		RecordedAt: get("recorded_at"),
+	}
+	if NormalizeRegion(p.Region) == "" {
+		return p, errors.New("region kosong")
+	}
+
+	raw := get("price")
+	pr, ok := extractPriceRange(raw)
+	if !ok || pr.Mid <= 0 {
+		return p, fmt.Errorf("harga tidak valid: %q", raw)
+	}
+	p.Price = pr.Mid
+	if pr.IsRange() {
+		p.PriceMin, p.PriceMax = &pr.Min, &pr.Max
+	}
+
+	if p.Unit == "" {
+		p.Unit = importDefaultUnit
+	}
+	if p.Source == "" {
+		p.Source = importDefaultSource
+	}
+	if p.RecordedAt == "" {
+		p.RecordedAt = time.Now().Format(importDateTimeLayout)
+	} else {
+		recordedAt, err := parseImportDate(p.RecordedAt)
+		if err != nil {
+			return p, err
+		}
+		p.RecordedAt = recordedAt
+	}
+	return normalizePriceInput(p), nil
+}
+
+// parseImportDate memvalidasi recorded_at dari CSV dan menormalkannya ke format store:
+// tanggal saja tetap YYYY-MM-DD, waktu menjadi YYYY-MM-DD HH:MM:SS (RFC3339 ke waktu lokal).
+// Format lain (12/03/2021, 2021-3-1) ditolak karena merusak urutan & window datetime().
+func parseImportDate(raw string) (string, error) {
+	if t, err := time.Parse(importDateLayout, raw); err == nil {
+		return t.Format(importDateLayout), nil
+	}
+	if t, err := time.Parse(importDateTimeLayout, raw); err == nil {
+		return t.Format(importDateTimeLayout), nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t.Local().Format(importDateTimeLayout), nil
+	}
+	return "", fmt.Errorf("tanggal tidak valid: %q (pakai YYYY-MM-DD, YYYY-MM-DD HH:MM:SS, atau RFC3339)", raw)
+}
+
+// newImportReader membaca & memetakan header; reader siap dipakai untuk baris data
+func newImportReader(r io.Reader) (*csv.Reader, map[string]int, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, errors.New("file CSV kosong")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("header CSV tidak valid: %w", err)
+	}
+	columns, err := mapImportHeader(header)
+	if err != nil {
+		return nil, nil, err
+	}
+	return reader, columns, nil
+}
+
+// ParsePriceCSV membaca seluruh CSV; baris yang gagal dicatat tanpa menghentikan parsing
+func ParsePriceCSV(r io.Reader) ([]Price, []ImportRowError, error) {
+	reader, columns, err := newImportReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var prices []Price
+	var rowErrors []ImportRowError
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rowErrors = append(rowErrors, ImportRowError{Row: parseErr.StartLine, Error: parseErr.Err.Error()})
+				continue
+			}
+			return nil, nil, err
+		}
+
+		row, _ := reader.FieldPos(0)
+		p, err := parseImportRow(record, columns)
+		if err != nil {
+			rowErrors = append(rowErrors, ImportRowError{Row: row, Error: err.Error()})
+			continue
+		}
+		prices = append(prices, p)
+	}
+	return prices, rowErrors, nil
+}
+
+// insertPricesTx menyimpan semua harga secara atomik (semua atau tidak sama sekali)
+// lalu mengirimnya ke subscriber SSE
+func insertPricesTx(prices []Price) error {
+	saved, err := priceStore.AddBatch(prices)
+	if err != nil {
+		return err
+	}
+	for _, p := range saved {
+		priceBroker.Publish(p)
+	}
+	return nil
+}
+
+func ImportPricesHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		file, _, err := r.FormFile(importFormField)
+		if isBodyTooLarge(err) {
+			return errBodyTooLarge
+		}
+		if err != nil {
+			return errInvalidBody.WithDetails("upload multipart/form-data dengan field \"" + importFormField + "\" berisi file CSV")
+		}
+		defer file.Close()
+
+		prices, rowErrors, err := ParsePriceCSV(file)
+		if err != nil {
+			return NewAPIError(http.StatusBadRequest, "invalid_csv", err.Error())
+		}
+
+		report := ImportReport{Imported: len(prices), Rejected: len(rowErrors), Errors: rowErrors}
+		if report.Errors == nil {
+			report.Errors = []ImportRowError{}
+		}
+		if len(prices) == 0 {
+			return NewAPIError(http.StatusUnprocessableEntity, "no_valid_rows", "Tidak ada baris valid untuk diimpor").
+				WithDetails(report)
+		}
+
+		if err := insertPricesTx(prices); err != nil {
+			return err
+		}
+		return respondJSON(w, http.StatusOK, report)
+	})(w, r)
+}
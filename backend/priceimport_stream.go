@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+)
+
+// ============================================
+// IMPORT HARGA STREAMING
+// Untuk CSV puluhan ribu baris: body dibaca baris per baris lewat pipeline
+// (baca -> PipeMap parse -> PipeFilter validasi) dan disimpan per batch,
+// jadi memori hanya sebesar satu batch. Berbeda dengan /harga/import, tiap batch
+// di-commit sendiri: jika gagal di tengah, batch sebelumnya tetap tersimpan.
+// ============================================
+
+const (
+	maxStreamImportBytes    int64 = 500 << 20 // 500MB
+	streamImportBatchSize         = 500
+	maxReportedImportErrors       = 100
+)
+
+type StreamImportReport struct {
+	Imported int              `json:"imported"`
+	Rejected int              `json:"rejected"`
+	Batches  int              `json:"batches"`
+	Errors   []ImportRowError `json:"errors"` // hanya maxReportedImportErrors pertama
+	// ErrorsTruncated true jika baris ditolak lebih banyak dari yang dilaporkan di Errors
+	ErrorsTruncated bool `json:"errors_truncated"`
+}
+
+// importRow satu baris yang mengalir di pipeline; fatal = pembacaan body gagal (bukan baris rusak)
+type importRow struct {
+	row    int
+	record []string
+	price  Price
+	err    error
+	fatal  bool
+}
+
+// readImportRows sumber pipeline: berhenti di EOF, error fatal, atau ctx dibatalkan
+func readImportRows(ctx context.Context, reader *csv.Reader) chan importRow {
+	out := make(chan importRow)
+	go func() {
+		defer close(out)
+		for {
+			record, err := reader.Read()
+			if err == io.EOF {
+				return
+			}
+
+			var item importRow
+			var parseErr *csv.ParseError
+			switch {
+			case err == nil:
+				item.row, _ = reader.FieldPos(0)
+				item.record = record
+			case errors.As(err, &parseErr):
+				item.row, item.err = parseErr.StartLine, parseErr.Err
+			default:
+				item.err, item.fatal = err, true
+			}
+
+			select {
+			case out <- item:
+			case <-ctx.Done():
+				return
+			}
+			if item.fatal {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// StreamImportPrices menjalankan pipeline import. onBatch dipanggil setelah tiap batch
+// tersimpan (progress). Error fatal/insert menghentikan import; report tetap berisi
+// jumlah yang sudah tersimpan.
+func StreamImportPrices(ctx context.Context, reader *csv.Reader, columns map[string]int, batchSize int, onBatch func(StreamImportReport)) (StreamImportReport, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	parsed := PipeMap(readImportRows(ctx, reader), func(item importRow) importRow {
+		if item.err == nil {
+			item.price, item.err = parseImportRow(item.record, columns)
+		}
+		return item
+	})
+
+	// Dipakai hanya oleh goroutine PipeFilter; dibaca setelah channel valid ditutup
+	var rejected []ImportRowError
+	rejectedCount := 0
+	var fatalErr error
+	valid := PipeFilter(parsed, func(item importRow) bool {
+		switch {
+		case item.fatal:
+			fatalErr = item.err
+			return false
+		case item.err != nil:
+			rejectedCount++
+			if len(rejected) < maxReportedImportErrors {
+				rejected = append(rejected, ImportRowError{Row: item.row, Error: item.err.Error()})
+			}
+			return false
+		}
+		return true
+	})
+
+	report := StreamImportReport{}
+	batch := make([]Price, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := insertPricesTx(batch); err != nil {
+			return err
+		}
+		report.Imported += len(batch)
+		report.Batches++
+		batch = batch[:0]
+		if onBatch != nil {
+			onBatch(report)
+		}
+		return nil
+	}
+
+	var insertErr error
+	for item := range valid {
+		// Setelah gagal, sisa pipeline hanya dikuras agar goroutine tidak menggantung
+		if insertErr != nil {
+			continue
+		}
+		batch = append(batch, item.price)
+		if len(batch) == batchSize {
+			if insertErr = flush(); insertErr != nil {
+				cancel()
+			}
+		}
+	}
+
+	report.Rejected = rejectedCount
+	report.Errors = rejected
+	if report.Errors == nil {
+		report.Errors = []ImportRowError{}
+	}
+	report.ErrorsTruncated = rejectedCount > len(rejected)
+
+	switch {
+	case fatalErr != nil:
+		return report, fatalErr
+	case insertErr != nil:
+		return report, insertErr
+	}
+	return report, flush()
+}
+
+// StreamImportPricesHandler body = CSV mentah (bukan multipart), kolom sama dengan /harga/import
+func StreamImportPricesHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		reader, columns, err := newImportReader(r.Body)
+		if isBodyTooLarge(err) {
+			return errBodyTooLarge
+		}
+		if err != nil {
+			return NewAPIError(http.StatusBadRequest, "invalid_csv", err.Error())
+		}
+
+		report, err := StreamImportPrices(r.Context(), reader, columns, streamImportBatchSize, func(progress StreamImportReport) {
+			log.Printf("📥 Import stream: %d baris tersimpan (%d batch)", progress.Imported, progress.Batches)
+		})
+		log.Printf("✓ Import stream selesai: %d tersimpan, %d ditolak", report.Imported, report.Rejected)
+		switch {
+		case isBodyTooLarge(err):
+			return errBodyTooLarge.WithDetails(report)
+		case err != nil:
+			return err
+		case report.Imported == 0:
+			return NewAPIError(http.StatusUnprocessableEntity, "no_valid_rows", "Tidak ada baris valid untuk diimpor").
+				WithDetails(report)
+		}
+		return respondJSON(w, http.StatusOK, report)
+	})(w, r)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// generatedPriceCSV CSV dengan rows baris data yang ditulis bertahap lewat pipe (tidak pernah
+// utuh di memori); tiap baris ke-invalidEvery (1-based) berisi harga rusak
+func generatedPriceCSV(rows, invalidEvery int) io.Reader {
+	pr, pw := io.Pipe()
+	regions := []string{"Jember", "Temanggung", "Boyolali", "Pamekasan"}
+	go func() {
+		fmt.Fprintln(pw, "region,price,unit,recorded_at")
+		for i := 1; i <= rows; i++ {
+			price := fmt.Sprintf("%d", 80000+i%5000)
+			if invalidEvery > 0 && i%invalidEvery == 0 {
+				price = "tidak tahu"
+			}
+			fmt.Fprintf(pw, "%s,%s,kg,2026-03-%02d\n", regions[i%len(regions)], price, 1+i%28)
+		}
+		pw.Close()
+	}()
+	return pr
+}
+
+func TestStreamImportPricesLargeCSV(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ps PriceStore, _ WeatherStore) {
+		reader, columns, err := newImportReader(generatedPriceCSV(20000, 1000))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var progress []int
+		report, err := StreamImportPrices(context.Background(), reader, columns, 500, func(r StreamImportReport) {
+			progress = append(progress, r.Imported)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 20 baris rusak; 19.980 baris valid = 39 batch penuh + 1 batch sisa 480
+		if report.Imported != 19980 || report.Rejected != 20 || report.Batches != 40 || report.ErrorsTruncated {
+			t.Fatalf("report = %+v", report)
+		}
+		if len(progress) != 40 || progress[0] != 500 || progress[38] != 19500 || progress[39] != 19980 {
+			t.Fatalf("progress = %d callback, awal %v akhir %v", len(progress), progress[:1], progress[len(progress)-1:])
+		}
+		// Nomor baris file: header baris 1, data ke-1000 di baris 1001
+		if first := report.Errors[0]; first.Row != 1001 || !strings.Contains(first.Error, "harga tidak valid") {
+			t.Fatalf("error pertama = %+v", first)
+		}
+
+		page, err := ps.GetAll(PriceQuery{Limit: 1})
+		if err != nil || page.Total != 19980 {
+			t.Fatalf("tersimpan %d harga, %v; ingin 19980", page.Total, err)
+		}
+	})
+}
+
+func TestStreamImportPricesTruncatesErrors(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+	reader, columns, err := newImportReader(generatedPriceCSV(300, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := StreamImportPrices(context.Background(), reader, columns, 100, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Imported != 150 || report.Rejected != 150 || len(report.Errors) != maxReportedImportErrors || !report.ErrorsTruncated {
+		t.Fatalf("report = imported %d rejected %d errors %d truncated %v",
+			report.Imported, report.Rejected, len(report.Errors), report.ErrorsTruncated)
+	}
+}
+
+func TestStreamImportPricesHandler(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+
+	req := httptest.NewRequest(http.MethodPost, "/harga/import/stream", generatedPriceCSV(1200, 0))
+	rec := httptest.NewRecorder()
+	StreamImportPricesHandler(rec, req)
+	var report StreamImportReport
+	decodeBody(t, rec, &report)
+	if rec.Code != http.StatusOK || report.Imported != 1200 || report.Batches != 3 || len(report.Errors) != 0 {
+		t.Fatalf("POST /harga/import/stream = %d %+v", rec.Code, report)
+	}
+
+	rec = serve(StreamImportPricesHandler, http.MethodPost, "/harga/import/stream", "region,price\nJember,gratis\n")
+	var env struct{ Error APIError }
+	decodeBody(t, rec, &env)
+	if rec.Code != http.StatusUnprocessableEntity || env.Error.Code != "no_valid_rows" {
+		t.Fatalf("tanpa baris valid = %d %s", rec.Code, rec.Body.String())
+	}
+}
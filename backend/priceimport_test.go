@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// uploadCSV POST multipart dengan content sebagai field file
+func uploadCSV(t *testing.T, handler http.HandlerFunc, field string, content []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile(field, "harga.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(content)
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/harga/import", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestImportPricesHandlerFixture(t *testing.T) {
+	fixture, err := os.ReadFile(filepath.Join("testdata", "import_harga.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	forEachStore(t, func(t *testing.T, ps PriceStore, ws WeatherStore) {
+		rec := uploadCSV(t, ImportPricesHandler, importFormField, fixture)
+		var report ImportReport
+		decodeBody(t, rec, &report)
+		if rec.Code != http.StatusOK || report.Imported != 3 || report.Rejected != 3 {
+			t.Fatalf("POST /harga/import = %d %+v", rec.Code, report)
+		}
+		// Nomor baris mengikuti file (header = baris 1)
+		if report.Errors[0].Row != 4 || !strings.Contains(report.Errors[0].Error, "harga tidak valid") ||
+			report.Errors[1].Row != 5 || report.Errors[1].Error != "region kosong" ||
+			report.Errors[2].Row != 7 || !strings.Contains(report.Errors[2].Error, "tanggal tidak valid") {
+			t.Fatalf("errors = %+v", report.Errors)
+		}
+
+		page, err := ps.GetAll(PriceQuery{Region: "Jember", Limit: 10})
+		if err != nil || page.Total != 2 {
+			t.Fatalf("harga Jember = %+v, %v", page, err)
+		}
+		ranged := page.Prices[0] // terbaru: baris rentang 2025-08-02
+		if ranged.Price != 85000 || ranged.PriceMin == nil || *ranged.PriceMin != 80000 || *ranged.PriceMax != 90000 ||
+			ranged.SourceType != sourceTypeImport || ranged.Unit != importDefaultUnit {
+			t.Fatalf("baris rentang = %+v", ranged)
+		}
+		if p, err := ps.GetLatest("Boyolali"); err != nil || p.Price != 120000.5 {
+			t.Fatalf("harga Boyolali = %+v, %v", p, err)
+		}
+	})
+}
+
+func TestParseImportDate(t *testing.T) {
+	valid := map[string]string{
+		"2021-03-12":          "2021-03-12",
+		"2021-03-12 08:30:00": "2021-03-12 08:30:00",
+		// RFC3339 disimpan sebagai jam dinding lokal, sama seperti recorded_at default
+		"2021-03-12T01:30:00Z": time.Date(2021, 3, 12, 1, 30, 0, 0, time.UTC).Local().Format(importDateTimeLayout),
+	}
+	for raw, want := range valid {
+		if got, err := parseImportDate(raw); err != nil || got != want {
+			t.Fatalf("parseImportDate(%q) = %q, %v; ingin %q", raw, got, err, want)
+		}
+	}
+	for _, raw := range []string{"12/03/2021", "kemarin", "2021-3-1", "2021-02-30"} {
+		if got, err := parseImportDate(raw); err == nil {
+			t.Fatalf("parseImportDate(%q) = %q, ingin error", raw, got)
+		}
+	}
+}
+
+func TestImportPricesHandlerRejects(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+	tests := []struct {
+		name    string
+		field   string
+		content string
+		status  int
+		code    string
+	}{
+		{"field salah", "data", "region,price\nJember,1000\n", http.StatusBadRequest, "invalid_body"},
+		{"kolom wajib hilang", importFormField, "region,tanggal\nJember,2025-01-01\n", http.StatusBadRequest, "invalid_csv"},
+		{"file kosong", importFormField, "", http.StatusBadRequest, "invalid_csv"},
+		{"tanpa baris valid", importFormField, "region,price\nJember,gratis\n", http.StatusUnprocessableEntity, "no_valid_rows"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := uploadCSV(t, ImportPricesHandler, tt.field, []byte(tt.content))
+			var env struct{ Error APIError }
+			decodeBody(t, rec, &env)
+			if rec.Code != tt.status || env.Error.Code != tt.code {
+				t.Fatalf("POST /harga/import = %d %s", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
@@ -1,60 +1,180 @@
 package main
 
 import (
+    "database/sql"
     "encoding/json"
-    "fmt"
     "log"
+    "math"
     "math/rand"
+    "strings"
     "time"
 )
 
 type Price struct {
-    ID         int     `json:"id"`
-    Region     string  `json:"region"`
-    Price      float64 `json:"price"`
-    Unit       string  `json:"unit"`
-    Source     string  `json:"source"`
-    RecordedAt string  `json:"recorded_at"`
-    CreatedAt  string  `json:"created_at"`
+    ID         int      `json:"id"`
+    Region     string   `json:"region"`
+    Price      float64  `json:"price"`
+    PriceMin   *float64 `json:"price_min"`
+    PriceMax   *float64 `json:"price_max"`
+    Unit       string   `json:"unit"`
+    Source     string   `json:"source"`
+    SourceType string   `json:"source_type"` // asal data ternormalisasi, lihat sourceType*
+    // Metadata scraper; nil untuk harga manual/import/simulasi
+    Quality    *string  `json:"quality,omitempty"`
+    SourceName *string  `json:"source_name,omitempty"`
+    SourceURL  *string  `json:"source_url,omitempty"`
+    ScrapedAt  *string  `json:"scraped_at,omitempty"`
+    RecordedAt string   `json:"recorded_at"`
+    CreatedAt  string   `json:"created_at"`
 }
 
-// AutoFetchPrices simulates fetching prices and saves to database
+// PriceSimulationConfig mengatur region dan rentang harga simulasi.
+// Harga dibangkitkan dalam rentang BasePrice ± Variance (Rp/kg).
+type PriceSimulationConfig struct {
+    Regions   []string
+    BasePrice float64
+    Variance  float64
+    // SeedFromHistory: region yang punya harga non-simulasi di DB memakai harga
+    // terakhirnya sebagai basis (variance diskalakan proporsional); region lain tetap BasePrice
+    SeedFromHistory bool
+}
+
+const simulatedPriceSource = "Simulated Market Data (simulasi, bukan harga riil)"
+
+// Nilai kolom prices.source_type. Kolom source berisi teks bebas (nama sumber +
+// tanggal cek + kualitas), jadi pengelompokan memakai kolom ini.
+const (
+    sourceTypeBAPPEBTI   = "bappebti"
+    sourceTypeNews       = "news"
+    sourceTypeResearch   = "research"
+    sourceTypeSimulation = "simulation"
+    sourceTypeManual     = "manual"
+    sourceTypeImport     = "csv_import"
+    sourceTypeUnknown    = "unknown"
+)
+
+// DefaultPriceSimulationConfig - rentang realistis harga tembakau rakyat (puluhan ribu/kg)
+func DefaultPriceSimulationConfig() PriceSimulationConfig {
+    return PriceSimulationConfig{
+        Regions:         []string{"Jember", "Malang", "Surabaya", "Bondowoso"},
+        BasePrice:       85000,
+        Variance:        10000,
+        SeedFromHistory: true,
+    }
+}
+
+var priceSimulationConfig = DefaultPriceSimulationConfig()
+
+// simulatePrice menghasilkan harga acak di dalam [base-variance, base+variance]
+func simulatePrice(base, variance float64, rng *rand.Rand) float64 {
+    offset := (rng.Float64()*2 - 1) * variance
+    return math.Round(base + offset)
+}
+
+// simulationBasis basis & variance untuk satu region: harga seed jika ada, selain itu
+// BasePrice. Variance seed diskalakan agar persentase variasi hariannya sama.
+func simulationBasis(cfg PriceSimulationConfig, seeds map[string]float64, region string) (base, variance float64) {
+    if seed, ok := seeds[region]; ok && seed > 0 {
+        return seed, seed * cfg.Variance / cfg.BasePrice
+    }
+    return cfg.BasePrice, cfg.Variance
+}
+
+// simulatePrices menghitung harga simulasi untuk semua region tanpa menyentuh database.
+// seeds (region -> harga riil terakhir) boleh nil.
+func simulatePrices(cfg PriceSimulationConfig, seeds map[string]float64, rng *rand.Rand) []Price {
+    recordedAt := time.Now().Format("2006-01-02 15:04:05")
+    return Map(cfg.Regions, func(region string) Price {
+        base, variance := simulationBasis(cfg, seeds, region)
+        return Price{
+            Region:     region,
+            Price:      simulatePrice(base, variance, rng),
+            Unit:       "per kg",
+            Source:     simulatedPriceSource,
+            SourceType: sourceTypeSimulation,
+            RecordedAt: recordedAt,
+        }
+    })
+}
+
+// PreviewSimulatedPrices - varian dry-run dari AutoFetchPrices (tidak ada insert)
+func PreviewSimulatedPrices() []Price {
+    cfg := priceSimulationConfig
+    var seeds map[string]float64
+    if cfg.SeedFromHistory {
+        var err error
+        if seeds, err = priceStore.LatestReal(cfg.Regions); err != nil {
+            log.Printf("⚠️  Seed simulasi dari histori gagal, memakai SIM_PRICE_BASE: %v", err)
+        }
+    }
+
+    rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+    return simulatePrices(cfg, seeds, rng)
+}
+
+// AutoFetchPrices simulates fetching prices and saves to database (satu transaksi)
 func AutoFetchPrices() error {
-    regions := []string{"Jember", "Malang", "Surabaya", "Bondowoso"}
-    source := "Market Data API"
-    
-    for _, region := range regions {
-        // Simulate price data (5000-8000 per kg)
-        price := 5000 + rand.Intn(3000)
-        recordedAt := time.Now().Format("2006-01-02 15:04:05")
-        
-        _, err := DB.Exec(`INSERT INTO prices (region, price, unit, source, recorded_at) VALUES (?, ?, ?, ?, ?)`,
-            region, price, "per kg", source, recordedAt)
+    prices := PreviewSimulatedPrices()
+    if err := insertPricesTx(prices); err != nil {
+        log.Printf("Failed to insert simulated prices: %v", err)
+        return err
+    }
+
+    for _, p := range prices {
+        log.Printf("Inserted simulated price for %s: Rp %.0f/kg", p.Region, p.Price)
+    }
+    return nil
+}
+
+// insertedPrice melengkapi ID & CreatedAt harga yang baru di-INSERT
+func insertedPrice(res sql.Result, p Price) Price {
+    if id, err := res.LastInsertId(); err == nil {
+        p.ID = int(id)
+    }
+    // Samakan dengan default datetime('now') SQLite (UTC)
+    p.CreatedAt = time.Now().UTC().Format("2006-01-02 15:04:05")
+    return p
+}
+
+// priceColumns urutan kolom yang diharapkan scanPrice/scanPrices
+const priceColumns = "id, region, price, price_min, price_max, unit, source, source_type, quality, source_name, source_url, scraped_at, recorded_at, created_at"
+
+// rowScanner dipenuhi *sql.Row maupun *sql.Rows
+type rowScanner interface {
+    Scan(dest ...interface{}) error
+}
+
+func scanPrice(row rowScanner) (Price, error) {
+    var p Price
+    err := row.Scan(&p.ID, &p.Region, &p.Price, &p.PriceMin, &p.PriceMax, &p.Unit, &p.Source, &p.SourceType,
+        &p.Quality, &p.SourceName, &p.SourceURL, &p.ScrapedAt, &p.RecordedAt, &p.CreatedAt)
+    return p, err
+}
+
+// scanPrices membaca semua baris hasil SELECT priceColumns. Baris yang gagal di-scan
+// dilewati tapi dihitung (skipped) dan di-log; error driver di tengah iterasi
+// (rows.Err) dikembalikan agar hasil yang terpotong tidak terlihat lengkap.
+func scanPrices(rows *sql.Rows) (prices []Price, skipped int, err error) {
+    for rows.Next() {
+        p, err := scanPrice(rows)
         if err != nil {
-            log.Printf("Failed to insert price for %s: %v", region, err)
-            return err
+            skipped++
+            log.Printf("⚠️  Baris prices dilewati (scan error): %v", err)
+            continue
         }
-        
-        log.Printf("Inserted price for %s: Rp %d/kg", region, price)
+        prices = append(prices, p)
     }
-    
-    return nil
+    if err := rows.Err(); err != nil {
+        return nil, skipped, err
+    }
+    return prices, skipped, nil
 }
 
 // GetLatestPriceJSON returns the latest price for a region as JSON string
 func GetLatestPriceJSON(region string) (string, error) {
-    var p Price
-    
-    err := DB.QueryRow(`
-        SELECT id, region, price, unit, source, recorded_at, created_at 
-        FROM prices 
-        WHERE region = ? 
-        ORDER BY created_at DESC 
-        LIMIT 1
-    `, region).Scan(&p.ID, &p.Region, &p.Price, &p.Unit, &p.Source, &p.RecordedAt, &p.CreatedAt)
-    
+    p, err := priceStore.GetLatest(region)
     if err != nil {
-        return "", fmt.Errorf("no price data found for region %s: %v", region, err)
+        return "", err
     }
     
     jsonData, err := json.Marshal(p)
@@ -63,4 +183,164 @@ func GetLatestPriceJSON(region string) (string, error) {
     }
     
     return string(jsonData), nil
-}
\ No newline at end of file
+}
+// ============================================
+// STATISTIK HARGA (all-time + window 7/30 hari)
+// ============================================
+
+// priceStatsWindows window yang dihitung di /harga/stats (key JSON -> jumlah hari)
+var priceStatsWindows = []struct {
+    Key  string
+    Days int
+}{
+    {"7d", 7},
+    {"30d", 30},
+}
+
+// PriceWindowStats ringkasan harga dalam N hari terakhir berdasarkan recorded_at.
+// Partial = data region belum mencakup seluruh window (data pertama lebih baru dari awal window).
+type PriceWindowStats struct {
+    Days    int      `json:"days"`
+    Count   int      `json:"count"`
+    Min     *float64 `json:"min"`
+    Max     *float64 `json:"max"`
+    Avg     *float64 `json:"avg"`
+    Partial bool     `json:"partial"`
+}
+
+type RegionPriceStats struct {
+    Region    string                       `json:"region"`
+    Count     int                          `json:"count"`
+    Min       float64                      `json:"min"`
+    Max       float64                      `json:"max"`
+    Avg       float64                      `json:"avg"`
+    FirstSeen string                       `json:"first_recorded_at"`
+    Windows   map[string]*PriceWindowStats `json:"windows"`
+}
+
+// recordedAtIsDate - hanya recorded_at berformat YYYY-MM-DD... yang dihitung di window
+// (julianday() menganggap string angka seperti "2026" sebagai julian day number)
+const recordedAtIsDate = `recorded_at GLOB '[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9]*'`
+
+// newPriceWindows semua window priceStatsWindows dalam keadaan kosong (Partial)
+func newPriceWindows() map[string]*PriceWindowStats {
+    windows := make(map[string]*PriceWindowStats, len(priceStatsWindows))
+    for _, win := range priceStatsWindows {
+        windows[win.Key] = &PriceWindowStats{Days: win.Days, Partial: true}
+    }
+    return windows
+}
+
+// ============================================
+// BREAKDOWN SUMBER HARGA
+// ============================================
+
+type PriceSourceCount struct {
+    Source     string `json:"source"`
+    SourceType string `json:"source_type"`
+    Count      int    `json:"count"`
+}
+
+type PriceSourceBreakdown struct {
+    Total   int                `json:"total"`
+    ByType  map[string]int     `json:"by_type"`
+    Sources []PriceSourceCount `json:"sources"`
+}
+
+// newPriceSourceBreakdown melengkapi Total & ByType dari hitungan per source
+func newPriceSourceBreakdown(counts []PriceSourceCount) PriceSourceBreakdown {
+    breakdown := PriceSourceBreakdown{ByType: map[string]int{}, Sources: counts}
+    if breakdown.Sources == nil {
+        breakdown.Sources = []PriceSourceCount{}
+    }
+    breakdown.Total = Reduce(counts, 0, func(total int, c PriceSourceCount) int {
+        breakdown.ByType[c.SourceType] += c.Count
+        return total + c.Count
+    })
+    return breakdown
+}
+
+// ============================================
+// TREN HARGA (time series + moving average)
+// ============================================
+
+type TrendPoint struct {
+    RecordedAt    string  `json:"recorded_at"`
+    Price         float64 `json:"price"`
+    MovingAverage float64 `json:"moving_avg"`
+}
+
+// movingAverage rata-rata bergerak trailing; titik awal memakai data yang tersedia
+func movingAverage(values []float64, window int) []float64 {
+    result := make([]float64, len(values))
+    sum := 0.0
+    for i, v := range values {
+        sum += v
+        if i >= window {
+            sum -= values[i-window]
+        }
+        n := i + 1
+        if n > window {
+            n = window
+        }
+        result[i] = math.Round(sum/float64(n)*100) / 100
+    }
+    return result
+}
+
+// BuildPriceTrend memasangkan timestamp, harga, dan moving average menjadi satu seri
+func BuildPriceTrend(timestamps []string, prices []float64, window int) []TrendPoint {
+    return ZipWith(Zip(timestamps, prices), movingAverage(prices, window),
+        func(point Pair[string, float64], avg float64) TrendPoint {
+            return TrendPoint{RecordedAt: point.First, Price: point.Second, MovingAverage: avg}
+        })
+}
+
+// ============================================
+// NORMALISASI INPUT HARGA
+// Harga dari user/CSV bisa ditulis per kwintal atau per ton;
+// disimpan seragam per kg dengan region kanonik
+// ============================================
+
+// kgPerUnit faktor konversi satuan (lowercase, tanpa awalan "per ") ke kg
+var kgPerUnit = map[string]float64{
+    "kg":      1,
+    "kilo":    1,
+    "ku":      100,
+    "kw":      100,
+    "kwintal": 100,
+    "kuintal": 100,
+    "ton":     1000,
+}
+
+func normalizePriceRegion(p Price) Price {
+    p.Region = NormalizeRegion(p.Region)
+    return p
+}
+
+// convertPriceToKg mengubah harga per kwintal/ton menjadi per kg.
+// Satuan yang tidak dikenal (atau kosong) dibiarkan apa adanya.
+func convertPriceToKg(p Price) Price {
+    unit := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(p.Unit)), "per ")
+    factor, ok := kgPerUnit[unit]
+    if !ok || factor == 1 {
+        return p
+    }
+
+    perKg := func(v float64) float64 { return math.Round(v/factor*100) / 100 }
+    p.Price = perKg(p.Price)
+    // Pointer baru agar nilai milik caller tidak ikut berubah
+    if p.PriceMin != nil {
+        lo := perKg(*p.PriceMin)
+        p.PriceMin = &lo
+    }
+    if p.PriceMax != nil {
+        hi := perKg(*p.PriceMax)
+        p.PriceMax = &hi
+    }
+    p.Unit = "kg"
+    return p
+}
+
+// normalizePriceInput region kanonik lalu konversi satuan, dipakai semua jalur input manual
+var normalizePriceInput = Pipe(normalizePriceRegion, convertPriceToKg)
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ============================================
+// FETCH HARGA MULTI-SUMBER (POST /harga/fetch)
+// BAPPEBTI, riset manual, dan simulasi berjalan bersamaan lewat FetchMultiplePricesSources;
+// satu sumber gagal tidak menggagalkan yang lain. Semua insert lewat priceStore
+// (SQLite: antrean dbWriter tunggal), jadi goroutine sumber tidak berebut koneksi write.
+// ============================================
+
+// PriceSource satu sumber harga yang mengambil sekaligus menyimpan hasilnya
+type PriceSource struct {
+	Name  string
+	Fetch func(ctx context.Context) error
+}
+
+type PriceSourceError struct {
+	Source string `json:"source"`
+	Error  string `json:"error"`
+}
+
+// PriceFetchReport Failed diurutkan menurut nama sumber
+type PriceFetchReport struct {
+	Sources   int                `json:"sources"`
+	Succeeded int                `json:"succeeded"`
+	Failed    []PriceSourceError `json:"failed"`
+}
+
+// priceSourceError membawa nama sumber melewati FetchMultiplePricesSources ([]error)
+type priceSourceError struct {
+	source string
+	err    error
+}
+
+func (e *priceSourceError) Error() string { return e.source + ": " + e.err.Error() }
+
+func (e *priceSourceError) Unwrap() error { return e.err }
+
+// scraperPriceSource scrape lalu simpan semua hasilnya dalam satu transaksi;
+// hasil scrape ikut tercatat di /harga/scrape/status
+func scraperPriceSource(priority int, scraper TobaccoScraper) PriceSource {
+	return PriceSource{
+		Name: scraper.GetName(),
+		Fetch: func(ctx context.Context) error {
+			prices, err := scraper.Scrape(ctx)
+			scrapeStatus.RecordAttempt(scraper.GetName(), priority, len(prices), err)
+			if err != nil {
+				return err
+			}
+			if len(prices) == 0 {
+				return errors.New("tidak ada data harga")
+			}
+			// Satu transaksi per sumber: gagal di tengah tidak meninggalkan sebagian harga
+			// tersimpan sementara sumbernya dilaporkan gagal
+			if err := insertPricesTx(Map(prices, scrapedToPrice)); err != nil {
+				return fmt.Errorf("simpan %d harga: %w", len(prices), err)
+			}
+			return nil
+		},
+	}
+}
+
+// priceSources pembuat daftar sumber untuk POST /harga/fetch (bisa diganti, mis. sumber palsu di test)
+var priceSources = defaultPriceSources
+
+// defaultPriceSources dibuat ulang tiap fetch supaya konfigurasi terbaru (komoditas, breaker) terpakai
+func defaultPriceSources() []PriceSource {
+	return []PriceSource{
+		scraperPriceSource(0, &BreakerScraper{TobaccoScraper: NewBAPPEBTIScraper(), Breaker: bappebtiBreaker}),
+		scraperPriceSource(1, NewMockScraperWithRealData()),
+		{Name: "Simulasi Harga", Fetch: func(context.Context) error { return AutoFetchPrices() }},
+	}
+}
+
+// FetchPricesFromSources menjalankan semua sumber bersamaan dan menunggu semuanya selesai
+func FetchPricesFromSources(ctx context.Context, sources []PriceSource) PriceFetchReport {
+	errs := FetchMultiplePricesSources(Map(sources, func(s PriceSource) func() error {
+		return func() error {
+			if err := s.Fetch(ctx); err != nil {
+				return &priceSourceError{source: s.Name, err: err}
+			}
+			return nil
+		}
+	}))
+
+	failed := Map(errs, func(err error) PriceSourceError {
+		var srcErr *priceSourceError
+		if errors.As(err, &srcErr) {
+			return PriceSourceError{Source: srcErr.source, Error: srcErr.err.Error()}
+		}
+		return PriceSourceError{Error: err.Error()}
+	})
+	sort.Slice(failed, func(i, j int) bool { return failed[i].Source < failed[j].Source })
+
+	return PriceFetchReport{Sources: len(sources), Succeeded: len(sources) - len(errs), Failed: failed}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetchPricesFromSourcesAggregatesErrors(t *testing.T) {
+	ran := make(chan string, 4)
+	source := func(name string, err error) PriceSource {
+		return PriceSource{Name: name, Fetch: func(context.Context) error {
+			ran <- name
+			return err
+		}}
+	}
+
+	report := FetchPricesFromSources(context.Background(), []PriceSource{
+		source("Sumber B", errors.New("timeout")),
+		source("Sumber C", nil),
+		source("Sumber A", errors.New("404")),
+		source("Sumber D", nil),
+	})
+	close(ran)
+
+	if len(ran) != 4 {
+		t.Fatalf("%d sumber dijalankan, ingin 4", len(ran))
+	}
+	if report.Sources != 4 || report.Succeeded != 2 || len(report.Failed) != 2 {
+		t.Fatalf("report = %+v", report)
+	}
+	// Diurutkan menurut nama sumber, pesan error asli tanpa prefix nama
+	if report.Failed[0] != (PriceSourceError{Source: "Sumber A", Error: "404"}) ||
+		report.Failed[1] != (PriceSourceError{Source: "Sumber B", Error: "timeout"}) {
+		t.Fatalf("failed = %+v", report.Failed)
+	}
+}
+
+func TestScraperPriceSourceSavesInOneBatch(t *testing.T) {
+	scraped := []ScrapedPrice{
+		{Region: "Jember", Price: 41000, Source: "Palsu", SourceType: sourceTypeResearch, ScrapedAt: time.Now()},
+		{Region: "Bondowoso", Price: 39000, Source: "Palsu", SourceType: sourceTypeResearch, ScrapedAt: time.Now()},
+	}
+
+	t.Run("tersimpan", func(t *testing.T) {
+		store := NewMemoryPriceStore()
+		useStores(t, store, NewMemoryWeatherStore())
+		report := FetchPricesFromSources(context.Background(), []PriceSource{
+			scraperPriceSource(0, &countingScraper{name: "Palsu", prices: scraped}),
+			scraperPriceSource(1, &countingScraper{name: "Kosong"}),
+			scraperPriceSource(2, &countingScraper{name: "Rusak", err: errors.New("HTML berubah")}),
+		})
+		if report.Succeeded != 1 || len(report.Failed) != 2 ||
+			report.Failed[0].Source != "Kosong" || report.Failed[1].Source != "Rusak" {
+			t.Fatalf("report = %+v", report)
+		}
+		if page, _ := store.GetAll(PriceQuery{Limit: 10}); page.Total != 2 {
+			t.Fatalf("%d harga tersimpan, ingin 2", page.Total)
+		}
+	})
+
+	t.Run("simpan gagal", func(t *testing.T) {
+		store := NewMemoryPriceStore()
+		useStores(t, failingPriceStore{PriceStore: store, err: errors.New("database is locked")}, NewMemoryWeatherStore())
+		report := FetchPricesFromSources(context.Background(), []PriceSource{
+			scraperPriceSource(0, &countingScraper{name: "Palsu", prices: scraped}),
+		})
+		if report.Succeeded != 0 || len(report.Failed) != 1 || !strings.Contains(report.Failed[0].Error, "simpan 2 harga") {
+			t.Fatalf("report = %+v", report)
+		}
+		if page, _ := store.GetAll(PriceQuery{Limit: 10}); page.Total != 0 {
+			t.Fatalf("%d harga tersimpan sebagian, ingin 0", page.Total)
+		}
+	})
+}
@@ -1,11 +1,13 @@
 package main
 
 import (
+    "fmt"
+    "math"
     "strings"
 )
 
 type RecommendationResult struct {
-    Status           string   `json:"status"`            // "optimal", "good", "caution", "not_recommended"
+    Status           string   `json:"status"`            // "optimal", "good", "caution", "not_recommended", "invalid_input"
     MainAdvice       string   `json:"main_advice"`
     DetailedAdvice   []string `json:"detailed_advice"`
     PlantingAdvice   string   `json:"planting_advice"`
@@ -13,155 +15,304 @@ type RecommendationResult struct {
     DryingAdvice     string   `json:"drying_advice"`
     PestWarning      string   `json:"pest_warning"`
     IrrigationAdvice string   `json:"irrigation_advice"`
+    IrrigationLiters float64  `json:"irrigation_liters"`  // estimasi liter/hari untuk IrrigationAreaHa, lihat CalculateIrrigationNeed
+    IrrigationAreaHa float64  `json:"irrigation_area_ha"`
     Temperature      float64  `json:"temperature"`
     Humidity         int      `json:"humidity"`
     RainMM           float64  `json:"rain_mm"`
     Region           string   `json:"region"`
+    Variety          string   `json:"variety"`           // profil varietas yang dipakai, lihat RecommendationConfigFor
+}
+
+// RecommendationInput data cuaca eksplisit (mis. dari sensor) tanpa panggilan OWM
+type RecommendationInput struct {
+    Region   string  `json:"region"`
+    Temp     float64 `json:"temp"`
+    Humidity int     `json:"humidity"`
+    Rain     float64 `json:"rain"`
+    Variety  string  `json:"variety,omitempty"`
+}
+
+// RecommendationConfig rentang kondisi optimal satu varietas tembakau.
+// Di luar rentang optimal, batas ekstrem (<15°C, >35°C, <40% & >90% RH) sama untuk semua varietas.
+type RecommendationConfig struct {
+    Variety     string  `json:"variety"`
+    TempMin     float64 `json:"temp_min"`
+    TempMax     float64 `json:"temp_max"`
+    HumidityMin int     `json:"humidity_min"`
+    HumidityMax int     `json:"humidity_max"`
+    RainMin     float64 `json:"rain_min"`
+    RainMax     float64 `json:"rain_max"` // eksklusif
+}
+
+// defaultRecommendationConfig profil umum (rentang lama sebelum ada varietas)
+var defaultRecommendationConfig = RecommendationConfig{
+    Variety: "umum", TempMin: 20, TempMax: 30, HumidityMin: 60, HumidityMax: 80, RainMin: 1, RainMax: 5,
+}
+
+// recommendationVarieties profil bawaan per varietas (key lowercase)
+var recommendationVarieties = map[string]RecommendationConfig{
+    // Virginia (flue-cured): sensitif kelembaban tinggi saat pematangan daun
+    "virginia": {Variety: "virginia", TempMin: 21, TempMax: 30, HumidityMin: 60, HumidityMax: 75, RainMin: 1, RainMax: 5},
+    // Burley (air-cured): toleran udara lebih lembab dan sejuk
+    "burley": {Variety: "burley", TempMin: 18, TempMax: 28, HumidityMin: 65, HumidityMax: 85, RainMin: 1, RainMax: 6},
+    // Kasturi (Jember/Bondowoso): tembakau lahan kering, suka panas & hujan sedikit
+    "kasturi": {Variety: "kasturi", TempMin: 22, TempMax: 32, HumidityMin: 55, HumidityMax: 75, RainMin: 0.5, RainMax: 4},
+    // Rajangan (Temanggung): dataran tinggi, suhu lebih rendah
+    "rajangan": {Variety: "rajangan", TempMin: 18, TempMax: 27, HumidityMin: 60, HumidityMax: 80, RainMin: 1, RainMax: 5},
+}
+
+// RecommendationConfigFor profil varietas; kosong atau tidak dikenal = profil umum
+func RecommendationConfigFor(variety string) RecommendationConfig {
+    if cfg, ok := recommendationVarieties[strings.ToLower(strings.TrimSpace(variety))]; ok {
+        return cfg
+    }
+    return defaultRecommendationConfig
+}
+
+// Batas nilai yang masih masuk akal untuk data cuaca
+const (
+    minPlausibleTemp = -40.0
+    maxPlausibleTemp = 60.0
+)
+
+// statusInvalidInput data cuaca di luar rentang wajar (sensor rusak / parse salah);
+// tidak ada saran yang diberikan agar tidak terlihat meyakinkan padahal salah
+const statusInvalidInput = "invalid_input"
+
+// ValidateRecommendationInput memastikan nilai cuaca berada dalam rentang wajar (WeatherData.Validate)
+func ValidateRecommendationInput(in RecommendationInput) error {
+    return in.WeatherData().Validate()
+}
+
+// WeatherData input sensor dalam bentuk WeatherData (rain selalu dianggap terukur)
+func (in RecommendationInput) WeatherData() WeatherData {
+    return WeatherData{Temp: in.Temp, Humidity: in.Humidity, Rain: in.Rain, RainAvailable: true, Region: in.Region}
 }
 
 // Recommend memberikan rekomendasi berdasarkan data cuaca
 func Recommend(temp float64, humidity int, rain float64) string {
+    if err := ValidateRecommendationInput(RecommendationInput{Temp: temp, Humidity: humidity, Rain: rain}); err != nil {
+        return withSymbol(symWarn, "Data cuaca tidak valid, rekomendasi tidak dapat diberikan: "+err.Error())
+    }
+
     var recommendations []string
 
     // Analisis Suhu
     if temp >= 20 && temp <= 30 {
-        recommendations = append(recommendations, "✅ Suhu optimal untuk pertumbuhan tembakau (20-30°C)")
+        recommendations = append(recommendations, withSymbol(symOK, "Suhu optimal untuk pertumbuhan tembakau (20-30°C)"))
     } else if temp < 20 {
-        recommendations = append(recommendations, "⚠️ Suhu terlalu dingin, pertumbuhan mungkin terhambat")
+        recommendations = append(recommendations, withSymbol(symWarn, "Suhu terlalu dingin, pertumbuhan mungkin terhambat"))
     } else {
-        recommendations = append(recommendations, "⚠️ Suhu terlalu panas, tingkatkan irigasi")
+        recommendations = append(recommendations, withSymbol(symWarn, "Suhu terlalu panas, tingkatkan irigasi"))
     }
 
     // Analisis Kelembaban
     if humidity >= 60 && humidity <= 80 {
-        recommendations = append(recommendations, "✅ Kelembaban ideal untuk tembakau (60-80%)")
+        recommendations = append(recommendations, withSymbol(symOK, "Kelembaban ideal untuk tembakau (60-80%)"))
     } else if humidity < 60 {
-        recommendations = append(recommendations, "⚠️ Kelembaban rendah, tingkatkan irigasi")
+        recommendations = append(recommendations, withSymbol(symWarn, "Kelembaban rendah, tingkatkan irigasi"))
     } else {
-        recommendations = append(recommendations, "⚠️ Kelembaban tinggi, risiko penyakit jamur meningkat")
+        recommendations = append(recommendations, withSymbol(symWarn, "Kelembaban tinggi, risiko penyakit jamur meningkat"))
     }
 
     // Analisis Curah Hujan
     if rain < 1 {
-        recommendations = append(recommendations, "☀️ Cuaca kering, cocok untuk pengeringan daun tembakau")
+        recommendations = append(recommendations, withSymbol(symDry, "Cuaca kering, cocok untuk pengeringan daun tembakau"))
     } else if rain >= 1 && rain < 5 {
-        recommendations = append(recommendations, "🌦️ Hujan ringan, cocok untuk pertumbuhan")
+        recommendations = append(recommendations, withSymbol(symLightRain, "Hujan ringan, cocok untuk pertumbuhan"))
     } else if rain >= 5 && rain < 10 {
-        recommendations = append(recommendations, "🌧️ Hujan sedang, pastikan drainase baik")
+        recommendations = append(recommendations, withSymbol(symRain, "Hujan sedang, pastikan drainase baik"))
     } else {
-        recommendations = append(recommendations, "⛈️ Hujan lebat, tunda pemanenan, risiko busuk tinggi")
+        recommendations = append(recommendations, withSymbol(symStorm, "Hujan lebat, tunda pemanenan, risiko busuk tinggi"))
     }
 
     return strings.Join(recommendations, " | ")
 }
 
-// GetAdvancedRecommendation memberikan rekomendasi detail
+// RecommendationText ringkasan satu baris untuk integrator SMS/USSD (Accept: text/plain)
+func RecommendationText(region string, temp float64, humidity int, rain float64) string {
+    return fmt.Sprintf("%s %.1f°C, RH %d%%, hujan %.1fmm: %s",
+        region, temp, humidity, rain, Recommend(temp, humidity, rain))
+}
+
+// isDecorativeRune emoji & simbol hiasan di teks saran; huruf, angka, tanda baca, dan "°" bukan
+func isDecorativeRune(r rune) bool {
+    switch {
+    case r == '\u200d' || r == '\u20e3' || (r >= '\ufe00' && r <= '\ufe0f'): // ZWJ, keycap, variation selector
+        return true
+    case r >= 0x2190 && r <= 0x2bff: // panah, simbol teknis & dingbat (⚠ ✅ ❌ ⛈)
+        return true
+    case r >= 0x1f000: // emoji & piktograf
+        return true
+    }
+    return false
+}
+
+// StripEmoji membuang emoji untuk terminal, SMS, dan CSV; spasi ganda sisa emoji dirapikan
+func StripEmoji(s string) string {
+    cleaned := strings.Map(func(r rune) rune {
+        if isDecorativeRune(r) {
+            return -1
+        }
+        return r
+    }, s)
+    return strings.Join(strings.Fields(cleaned), " ")
+}
+
+// Themed salinan hasil dengan semua teks saran memakai simbol tema t (?theme=)
+func (r RecommendationResult) Themed(t Theme) RecommendationResult {
+    r.MainAdvice = t.Apply(r.MainAdvice)
+    r.DetailedAdvice = Map(r.DetailedAdvice, t.Apply)
+    r.PlantingAdvice = t.Apply(r.PlantingAdvice)
+    r.HarvestAdvice = t.Apply(r.HarvestAdvice)
+    r.DryingAdvice = t.Apply(r.DryingAdvice)
+    r.PestWarning = t.Apply(r.PestWarning)
+    r.IrrigationAdvice = t.Apply(r.IrrigationAdvice)
+    return r
+}
+
+// Themed seperti RecommendationResult.Themed, termasuk saran prakiraan
+func (a AdvancedRecommendation) Themed(t Theme) AdvancedRecommendation {
+    a.RecommendationResult = a.RecommendationResult.Themed(t)
+    if a.Forecast != nil {
+        forecast := *a.Forecast
+        forecast.Advice = t.Apply(forecast.Advice)
+        a.Forecast = &forecast
+    }
+    return a
+}
+
+// Text versi plain-text AdvancedRecommendation: status + ringkasan + prakiraan (jika ada)
+func (a AdvancedRecommendation) Text() string {
+    text := fmt.Sprintf("[%s] %s", strings.ToUpper(a.Status),
+        RecommendationText(a.Region, a.Temperature, a.Humidity, a.RainMM))
+    if a.Forecast != nil {
+        text += " | " + a.Forecast.Advice
+    }
+    return text
+}
+
+// GetAdvancedRecommendation memberikan rekomendasi detail (profil umum)
 func GetAdvancedRecommendation(temp float64, humidity int, rain float64, region string) RecommendationResult {
+    return GetAdvancedRecommendationWithConfig(defaultRecommendationConfig, temp, humidity, rain, region)
+}
+
+// GetAdvancedRecommendationWithConfig rekomendasi detail dengan rentang optimal dari cfg
+func GetAdvancedRecommendationWithConfig(cfg RecommendationConfig, temp float64, humidity int, rain float64, region string) RecommendationResult {
     result := RecommendationResult{
         Temperature: temp,
         Humidity:    humidity,
         RainMM:      rain,
         Region:      region,
+        Variety:     cfg.Variety,
+    }
+
+    if err := ValidateRecommendationInput(RecommendationInput{Region: region, Temp: temp, Humidity: humidity, Rain: rain}); err != nil {
+        result.Status = statusInvalidInput
+        result.MainAdvice = withSymbol(symWarn, "Data cuaca tidak valid, rekomendasi tidak dapat diberikan: "+err.Error())
+        result.DetailedAdvice = []string{}
+        return result
     }
 
     var advice []string
     
     // Determine overall status
-    optimalTemp := temp >= 20 && temp <= 30
-    optimalHumidity := humidity >= 60 && humidity <= 80
-    optimalRain := rain >= 1 && rain < 5
+    optimalTemp := temp >= cfg.TempMin && temp <= cfg.TempMax
+    optimalHumidity := humidity >= cfg.HumidityMin && humidity <= cfg.HumidityMax
+    optimalRain := rain >= cfg.RainMin && rain < cfg.RainMax
 
     if optimalTemp && optimalHumidity && optimalRain {
         result.Status = "optimal"
-        result.MainAdvice = "🌟 Kondisi OPTIMAL untuk budidaya tembakau!"
+        result.MainAdvice = withSymbol(symOptimal, "Kondisi OPTIMAL untuk budidaya tembakau!")
     } else if optimalTemp || optimalHumidity {
         result.Status = "good"
-        result.MainAdvice = "✅ Kondisi BAIK untuk budidaya tembakau"
+        result.MainAdvice = withSymbol(symOK, "Kondisi BAIK untuk budidaya tembakau")
     } else if temp > 35 || humidity > 90 || rain > 15 {
         result.Status = "not_recommended"
-        result.MainAdvice = "❌ Kondisi TIDAK DISARANKAN untuk aktivitas pertanian"
+        result.MainAdvice = withSymbol(symBad, "Kondisi TIDAK DISARANKAN untuk aktivitas pertanian")
     } else {
         result.Status = "caution"
-        result.MainAdvice = "⚠️ Kondisi CUKUP - perhatikan faktor risiko"
+        result.MainAdvice = withSymbol(symWarn, "Kondisi CUKUP - perhatikan faktor risiko")
     }
 
     // Temperature Analysis
-    if temp < 15 {
+    if optimalTemp {
+        advice = append(advice, fmt.Sprintf("Suhu optimal (%.0f-%.0f°C) - pertumbuhan ideal", cfg.TempMin, cfg.TempMax))
+        result.PlantingAdvice = withSymbol(symOK, "SANGAT COCOK untuk penanaman bibit baru")
+    } else if temp < 15 {
         advice = append(advice, "Suhu terlalu dingin (<15°C) - pertumbuhan sangat terhambat")
-        result.PlantingAdvice = "❌ TIDAK disarankan menanam. Tunggu suhu naik minimal 18°C"
-    } else if temp >= 15 && temp < 20 {
-        advice = append(advice, "Suhu sejuk (15-20°C) - pertumbuhan lambat")
-        result.PlantingAdvice = "⚠️ Penanaman dimungkinkan tapi pertumbuhan akan lambat"
-    } else if temp >= 20 && temp <= 30 {
-        advice = append(advice, "Suhu optimal (20-30°C) - pertumbuhan ideal")
-        result.PlantingAdvice = "✅ SANGAT COCOK untuk penanaman bibit baru"
-    } else if temp > 30 && temp <= 35 {
-        advice = append(advice, "Suhu hangat (30-35°C) - perlu irigasi ekstra")
-        result.PlantingAdvice = "⚠️ Bisa menanam tapi pastikan irigasi mencukupi"
+        result.PlantingAdvice = withSymbol(symBad, "TIDAK disarankan menanam. Tunggu suhu naik minimal 18°C")
+    } else if temp < cfg.TempMin {
+        advice = append(advice, fmt.Sprintf("Suhu sejuk (15-%.0f°C) - pertumbuhan lambat", cfg.TempMin))
+        result.PlantingAdvice = withSymbol(symWarn, "Penanaman dimungkinkan tapi pertumbuhan akan lambat")
+    } else if temp <= 35 {
+        advice = append(advice, fmt.Sprintf("Suhu hangat (%.0f-35°C) - perlu irigasi ekstra", cfg.TempMax))
+        result.PlantingAdvice = withSymbol(symWarn, "Bisa menanam tapi pastikan irigasi mencukupi")
     } else {
         advice = append(advice, "Suhu sangat panas (>35°C) - stres tanaman tinggi")
-        result.PlantingAdvice = "❌ TIDAK disarankan menanam. Tanaman akan stres"
+        result.PlantingAdvice = withSymbol(symBad, "TIDAK disarankan menanam. Tanaman akan stres")
     }
 
     // Humidity Analysis
-    if humidity < 40 {
+    if optimalHumidity {
+        advice = append(advice, fmt.Sprintf("Kelembaban ideal (%d-%d%%) - kondisi sempurna", cfg.HumidityMin, cfg.HumidityMax))
+        result.IrrigationAdvice = withSymbol(symOK, "Irigasi normal sesuai jadwal standar")
+    } else if humidity < 40 {
         advice = append(advice, "Kelembaban sangat rendah (<40%) - tanaman bisa layu")
-        result.IrrigationAdvice = "💧 PENTING: Tingkatkan irigasi 2-3x sehari, gunakan mulsa"
-    } else if humidity >= 40 && humidity < 60 {
-        advice = append(advice, "Kelembaban rendah (40-60%) - perlu irigasi rutin")
-        result.IrrigationAdvice = "💧 Irigasi 1-2x sehari, pantau kondisi tanah"
-    } else if humidity >= 60 && humidity <= 80 {
-        advice = append(advice, "Kelembaban ideal (60-80%) - kondisi sempurna")
-        result.IrrigationAdvice = "✅ Irigasi normal sesuai jadwal standar"
-    } else if humidity > 80 && humidity <= 90 {
-        advice = append(advice, "Kelembaban tinggi (80-90%) - risiko penyakit jamur")
-        result.IrrigationAdvice = "⚠️ Kurangi irigasi, pastikan drainase baik"
-        result.PestWarning = "⚠️ PERINGATAN: Risiko penyakit jamur tinggi! Semprot fungisida preventif, tingkatkan sirkulasi udara"
+        result.IrrigationAdvice = withSymbol(symWater, "PENTING: Tingkatkan irigasi 2-3x sehari, gunakan mulsa")
+    } else if humidity < cfg.HumidityMin {
+        advice = append(advice, fmt.Sprintf("Kelembaban rendah (40-%d%%) - perlu irigasi rutin", cfg.HumidityMin))
+        result.IrrigationAdvice = withSymbol(symWater, "Irigasi 1-2x sehari, pantau kondisi tanah")
+    } else if humidity <= 90 {
+        advice = append(advice, fmt.Sprintf("Kelembaban tinggi (%d-90%%) - risiko penyakit jamur", cfg.HumidityMax))
+        result.IrrigationAdvice = withSymbol(symWarn, "Kurangi irigasi, pastikan drainase baik")
+        result.PestWarning = withSymbol(symWarn, "PERINGATAN: Risiko penyakit jamur tinggi! Semprot fungisida preventif, tingkatkan sirkulasi udara")
     } else {
         advice = append(advice, "Kelembaban sangat tinggi (>90%) - bahaya penyakit")
-        result.IrrigationAdvice = "❌ STOP irigasi, perbaiki drainase segera"
-        result.PestWarning = "🚨 BAHAYA: Risiko penyakit jamur sangat tinggi! Aplikasi fungisida darurat, cek tanaman busuk"
+        result.IrrigationAdvice = withSymbol(symBad, "STOP irigasi, perbaiki drainase segera")
+        result.PestWarning = withSymbol(symDanger, "BAHAYA: Risiko penyakit jamur sangat tinggi! Aplikasi fungisida darurat, cek tanaman busuk")
     }
 
     // Rain Analysis
     if rain < 0.5 {
         advice = append(advice, "Cuaca kering - ideal untuk pengeringan")
-        result.HarvestAdvice = "✅ SANGAT COCOK untuk panen dan pengeringan daun"
-        result.DryingAdvice = "☀️ Kondisi SEMPURNA untuk penjemuran tembakau. Maksimalkan pengeringan hari ini!"
+        result.HarvestAdvice = withSymbol(symOK, "SANGAT COCOK untuk panen dan pengeringan daun")
+        result.DryingAdvice = withSymbol(symDry, "Kondisi SEMPURNA untuk penjemuran tembakau. Maksimalkan pengeringan hari ini!")
     } else if rain >= 0.5 && rain < 2 {
         advice = append(advice, "Hujan ringan - aman untuk pertumbuhan")
-        result.HarvestAdvice = "✅ Bisa panen pagi hari sebelum hujan"
-        result.DryingAdvice = "⚠️ Penjemuran bisa dilakukan dengan pengawasan ketat"
+        result.HarvestAdvice = withSymbol(symOK, "Bisa panen pagi hari sebelum hujan")
+        result.DryingAdvice = withSymbol(symWarn, "Penjemuran bisa dilakukan dengan pengawasan ketat")
     } else if rain >= 2 && rain < 5 {
         advice = append(advice, "Hujan sedang - baik untuk vegetatif")
-        result.HarvestAdvice = "⚠️ Tunda panen jika memungkinkan, atau panen cepat sebelum hujan lebat"
-        result.DryingAdvice = "❌ Tidak disarankan menjemur hari ini. Gunakan pengering mekanis jika mendesak"
+        result.HarvestAdvice = withSymbol(symWarn, "Tunda panen jika memungkinkan, atau panen cepat sebelum hujan lebat")
+        result.DryingAdvice = withSymbol(symBad, "Tidak disarankan menjemur hari ini. Gunakan pengering mekanis jika mendesak")
     } else if rain >= 5 && rain < 10 {
         advice = append(advice, "Hujan lebat - pastikan drainase baik")
-        result.HarvestAdvice = "❌ TUNDA panen! Daun basah tidak layak dipanen"
-        result.DryingAdvice = "❌ STOP penjemuran. Pindahkan tembakau ke tempat kering"
+        result.HarvestAdvice = withSymbol(symBad, "TUNDA panen! Daun basah tidak layak dipanen")
+        result.DryingAdvice = withSymbol(symBad, "STOP penjemuran. Pindahkan tembakau ke tempat kering")
     } else {
         advice = append(advice, "Hujan sangat lebat - risiko genangan")
-        result.HarvestAdvice = "❌ JANGAN panen. Cek kondisi tanaman setelah hujan reda"
-        result.DryingAdvice = "❌ Penjemuran tidak memungkinkan. Pastikan gudang kering dan ventilasi baik"
+        result.HarvestAdvice = withSymbol(symBad, "JANGAN panen. Cek kondisi tanaman setelah hujan reda")
+        result.DryingAdvice = withSymbol(symBad, "Penjemuran tidak memungkinkan. Pastikan gudang kering dan ventilasi baik")
         if result.PestWarning == "" {
-            result.PestWarning = "⚠️ Cek tanaman setelah hujan reda - risiko busuk batang dan akar tinggi"
+            result.PestWarning = withSymbol(symWarn, "Cek tanaman setelah hujan reda - risiko busuk batang dan akar tinggi")
         }
     }
 
     // Combined Analysis for Harvesting
     if temp >= 25 && temp <= 32 && rain < 1 && humidity < 75 {
-        result.HarvestAdvice = "🌟 KONDISI PANEN SEMPURNA! Suhu, kelembaban, dan cuaca mendukung"
+        result.HarvestAdvice = withSymbol(symOptimal, "KONDISI PANEN SEMPURNA! Suhu, kelembaban, dan cuaca mendukung")
     }
 
     // Pest and Disease Warnings
     if humidity > 80 && temp > 25 {
         if result.PestWarning == "" {
-            result.PestWarning = "🚨 Kombinasi panas + lembab: Risiko tinggi embun tepung, busuk daun, dan serangan ulat"
+            result.PestWarning = withSymbol(symDanger, "Kombinasi panas + lembab: Risiko tinggi embun tepung, busuk daun, dan serangan ulat")
         }
     } else if temp < 18 && rain > 5 {
         if result.PestWarning == "" {
-            result.PestWarning = "⚠️ Kondisi dingin + basah: Waspadai penyakit busuk akar dan batang"
+            result.PestWarning = withSymbol(symWarn, "Kondisi dingin + basah: Waspadai penyakit busuk akar dan batang")
         }
     }
 
@@ -179,14 +330,511 @@ func GetAdvancedRecommendation(temp float64, humidity int, rain float64, region
         result.IrrigationAdvice = "Lakukan irigasi sesuai kebutuhan tanaman"
     }
     if result.PestWarning == "" {
-        result.PestWarning = "✅ Risiko hama dan penyakit dalam batas normal. Lakukan monitoring rutin"
+        result.PestWarning = withSymbol(symOK, "Risiko hama dan penyakit dalam batas normal. Lakukan monitoring rutin")
     }
 
     result.DetailedAdvice = advice
+    result.IrrigationAreaHa = 1
+    result.IrrigationLiters = CalculateIrrigationNeed(temp, float64(humidity), rain, result.IrrigationAreaHa)
+
+    return result
+}
+
+// ============================================
+// KEBUTUHAN IRIGASI
+// Heuristik evapotranspirasi sederhana karena OWM tidak memberi radiasi matahari
+// maupun angin yang dibutuhkan Penman-Monteith:
+//   ET0 (mm/hari)   = 0.2 x suhu (°C)                -> 20°C = 4mm, 30°C = 6mm (kisaran dataran rendah tropis)
+//   faktor RH       = 1 + (60 - RH)/100, dibatasi 0.6-1.4 (udara kering menguap lebih cepat)
+//   ETc             = ET0 x faktor RH x Kc tembakau (1.0, fase pertumbuhan vegetatif)
+//   hujan efektif   = 80% curah hujan (sisanya limpasan/perkolasi)
+//   kebutuhan (L)   = max(0, ETc - hujan efektif) x 10.000 m²/ha x luas (1mm di 1m² = 1 liter)
+// Asumsi: curah hujan terukur dianggap total hujan hari itu, tanah pada kapasitas lapang,
+// dan efisiensi alat irigasi tidak diperhitungkan. Angka ini perkiraan kasar, bukan resep.
+// ============================================
+
+const (
+    irrigationETPerDegree  = 0.2
+    irrigationReferenceRH  = 60.0
+    tobaccoCropCoefficient = 1.0
+    effectiveRainFraction  = 0.8
+    litersPerMMPerHectare  = 10000.0
+)
+
+// WithIrrigationArea salinan hasil dengan IrrigationLiters dihitung ulang untuk luas lain
+func (r RecommendationResult) WithIrrigationArea(areaHa float64) RecommendationResult {
+    r.IrrigationAreaHa = areaHa
+    r.IrrigationLiters = CalculateIrrigationNeed(r.Temperature, float64(r.Humidity), r.RainMM, areaHa)
+    return r
+}
+
+// CalculateIrrigationNeed estimasi liter air per hari untuk areaHa hektar; 0 jika luas <= 0
+// atau hujan sudah mencukupi
+func CalculateIrrigationNeed(temp, humidity, rain float64, areaHa float64) float64 {
+    if areaHa <= 0 {
+        return 0
+    }
+
+    et0 := math.Max(0, irrigationETPerDegree*temp)
+    humidityFactor := math.Min(1.4, math.Max(0.6, 1+(irrigationReferenceRH-humidity)/100))
+    cropET := et0 * humidityFactor * tobaccoCropCoefficient
+    needMM := math.Max(0, cropET-effectiveRainFraction*math.Max(0, rain))
+
+    return math.Round(needMM * litersPerMMPerHectare * areaHa)
+}
+
+// ============================================
+// EXPLAIN MODE
+// Penalaran GetAdvancedRecommendationWithConfig per faktor sebagai data
+// (rentang optimal dari profil varietas, batas ekstrem sama untuk semua varietas)
+// ============================================
+
+// Sub-status per faktor, urut dari baik ke buruk
+const (
+    factorOptimal    = "optimal"
+    factorAcceptable = "acceptable"
+    factorPoor       = "poor"
+)
+
+var factorSeverity = map[string]int{factorOptimal: 0, factorAcceptable: 1, factorPoor: 2}
+
+type FactorExplanation struct {
+    Factor     string  `json:"factor"` // "temperature", "humidity", "rain"
+    Value      float64 `json:"value"`
+    Status     string  `json:"status"`
+    Reason     string  `json:"reason"`
+    OptimalMin float64 `json:"optimal_min"`
+    OptimalMax float64 `json:"optimal_max"`
+}
+
+// deviation jarak nilai dari rentang optimal, relatif terhadap lebar rentang
+// (agar 5°C dan 5mm bisa dibandingkan)
+func (f FactorExplanation) deviation() float64 {
+    width := f.OptimalMax - f.OptimalMin
+    switch {
+    case f.Value < f.OptimalMin:
+        return (f.OptimalMin - f.Value) / width
+    case f.Value > f.OptimalMax:
+        return (f.Value - f.OptimalMax) / width
+    }
+    return 0
+}
+
+type RecommendationExplanation struct {
+    Status         string              `json:"status"`
+    Factors        []FactorExplanation `json:"factors"`
+    LimitingFactor string              `json:"limiting_factor,omitempty"` // kosong jika semua optimal
+}
+
+func explainTemperature(cfg RecommendationConfig, temp float64) FactorExplanation {
+    f := FactorExplanation{Factor: "temperature", Value: temp, OptimalMin: cfg.TempMin, OptimalMax: cfg.TempMax}
+    switch {
+    case temp < 15:
+        f.Status, f.Reason = factorPoor, "Suhu terlalu dingin (<15°C) - pertumbuhan sangat terhambat"
+    case temp < cfg.TempMin:
+        f.Status, f.Reason = factorAcceptable, fmt.Sprintf("Suhu sejuk (15-%.0f°C) - pertumbuhan lambat", cfg.TempMin)
+    case temp <= cfg.TempMax:
+        f.Status, f.Reason = factorOptimal, fmt.Sprintf("Suhu optimal (%.0f-%.0f°C)", cfg.TempMin, cfg.TempMax)
+    case temp <= 35:
+        f.Status, f.Reason = factorAcceptable, fmt.Sprintf("Suhu hangat (%.0f-35°C) - perlu irigasi ekstra", cfg.TempMax)
+    default:
+        f.Status, f.Reason = factorPoor, "Suhu sangat panas (>35°C) - stres tanaman tinggi"
+    }
+    return f
+}
+
+func explainHumidity(cfg RecommendationConfig, humidity int) FactorExplanation {
+    f := FactorExplanation{Factor: "humidity", Value: float64(humidity),
+        OptimalMin: float64(cfg.HumidityMin), OptimalMax: float64(cfg.HumidityMax)}
+    switch {
+    case humidity < 40:
+        f.Status, f.Reason = factorPoor, "Kelembaban sangat rendah (<40%) - tanaman bisa layu"
+    case humidity < cfg.HumidityMin:
+        f.Status, f.Reason = factorAcceptable, fmt.Sprintf("Kelembaban rendah (40-%d%%) - perlu irigasi rutin", cfg.HumidityMin)
+    case humidity <= cfg.HumidityMax:
+        f.Status, f.Reason = factorOptimal, fmt.Sprintf("Kelembaban ideal (%d-%d%%)", cfg.HumidityMin, cfg.HumidityMax)
+    case humidity <= 90:
+        f.Status, f.Reason = factorAcceptable, fmt.Sprintf("Kelembaban tinggi (%d-90%%) - risiko penyakit jamur", cfg.HumidityMax)
+    default:
+        f.Status, f.Reason = factorPoor, "Kelembaban sangat tinggi (>90%) - bahaya penyakit"
+    }
+    return f
+}
+
+// explainRain RainMax eksklusif seperti di GetAdvancedRecommendationWithConfig;
+// >15mm membuat status keseluruhan not_recommended
+func explainRain(cfg RecommendationConfig, rain float64) FactorExplanation {
+    f := FactorExplanation{Factor: "rain", Value: rain, OptimalMin: cfg.RainMin, OptimalMax: cfg.RainMax}
+    switch {
+    case rain < cfg.RainMin:
+        f.Status, f.Reason = factorAcceptable, "Cuaca kering - baik untuk pengeringan, kurang air untuk pertumbuhan"
+    case rain < cfg.RainMax:
+        f.Status, f.Reason = factorOptimal, fmt.Sprintf("Hujan ringan-sedang (%g-%gmm) - baik untuk pertumbuhan", cfg.RainMin, cfg.RainMax)
+    case rain <= 15:
+        f.Status, f.Reason = factorAcceptable, fmt.Sprintf("Hujan lebat (%g-15mm) - tunda panen, pastikan drainase", cfg.RainMax)
+    default:
+        f.Status, f.Reason = factorPoor, "Hujan sangat lebat (>15mm) - aktivitas pertanian tidak disarankan"
+    }
+    return f
+}
+
+// ExplainRecommendation sub-status tiap faktor + faktor pembatas: faktor dengan
+// sub-status terburuk, jika seri dipilih yang paling jauh dari rentang optimalnya
+func ExplainRecommendation(cfg RecommendationConfig, temp float64, humidity int, rain float64) RecommendationExplanation {
+    factors := []FactorExplanation{explainTemperature(cfg, temp), explainHumidity(cfg, humidity), explainRain(cfg, rain)}
+    explanation := RecommendationExplanation{
+        Status:  GetAdvancedRecommendationWithConfig(cfg, temp, humidity, rain, "").Status,
+        Factors: factors,
+    }
+
+    limiting := Reduce(factors[1:], factors[0], func(worst, f FactorExplanation) FactorExplanation {
+        fs, ws := factorSeverity[f.Status], factorSeverity[worst.Status]
+        if fs > ws || (fs == ws && f.deviation() > worst.deviation()) {
+            return f
+        }
+        return worst
+    })
+    if limiting.Status != factorOptimal {
+        explanation.LimitingFactor = limiting.Factor
+    }
+    return explanation
+}
+
+// HarvestScore skor 0-100 kelayakan panen & penjemuran hari ini (makin tinggi makin baik)
+func HarvestScore(temp float64, humidity int, rain float64) int {
+    score := 100
 
+    // Hujan paling menentukan: daun basah tidak layak dipanen
+    switch {
+    case rain >= 10:
+        score -= 60
+    case rain >= 5:
+        score -= 45
+    case rain >= 2:
+        score -= 30
+    case rain >= 0.5:
+        score -= 10
+    }
+
+    switch {
+    case humidity > 90:
+        score -= 30
+    case humidity > 80:
+        score -= 20
+    case humidity >= 75:
+        score -= 5
+    case humidity < 40:
+        score -= 10
+    }
+
+    switch {
+    case temp < 15 || temp > 35:
+        score -= 30
+    case temp < 25 || temp > 32:
+        score -= 10
+    }
+
+    if score < 0 {
+        return 0
+    }
+    return score
+}
+
+// RecommendForWeather - GetAdvancedRecommendation dari WeatherData; jika data hujan
+// tidak tersedia dari provider, saran pengeringan diberi catatan
+func RecommendForWeather(data *WeatherData, region string) RecommendationResult {
+    return RecommendForWeatherWithConfig(defaultRecommendationConfig, data, region)
+}
+
+func RecommendForWeatherWithConfig(cfg RecommendationConfig, data *WeatherData, region string) RecommendationResult {
+    result := GetAdvancedRecommendationWithConfig(cfg, data.Temp, data.Humidity, data.Rain, region)
+    if data.RainAvailable || result.Status == statusInvalidInput {
+        return result
+    }
+
+    result.DetailedAdvice = append(append([]string(nil), result.DetailedAdvice...),
+        "Data curah hujan tidak tersedia dari provider - analisis hujan mengasumsikan 0mm")
+    result.DryingAdvice += " (" + withSymbol(symWarn, "data hujan tidak tersedia, cek langit sebelum menjemur") + ")"
     return result
 }
 
+// forecastLookaheadEntries entri forecast OWM per 3 jam -> 8 entri = 24 jam ke depan
+const forecastLookaheadEntries = 8
+
+// ForecastOutlook ringkasan hujan 24 jam ke depan untuk melengkapi rekomendasi
+// highRainProbability peluang hujan (pop) di atas ini dianggap "kemungkinan besar hujan"
+const highRainProbability = 0.7
+
+// dryingRainThresholdMM akumulasi hujan prakiraan yang dianggap membasahi daun jemuran
+const dryingRainThresholdMM = 1.0
+
+type ForecastOutlook struct {
+    RainMM             float64 `json:"rain_mm_24h"`
+    RainyHours         int     `json:"rainy_hours_24h"`
+    MaxRainProbability float64 `json:"max_rain_probability_24h"`
+    DryWindowHours     int     `json:"dry_window_hours"` // jam sebelum hujan kumulatif >= dryingRainThresholdMM
+    // AvgWindSpeed (m/s) & PressureChange (hPa, akhir - awal); nil jika OWM tidak mengirim datanya
+    AvgWindSpeed   *float64 `json:"avg_wind_speed_24h,omitempty"`
+    PressureChange *float64 `json:"pressure_change_24h,omitempty"`
+    Advice         string   `json:"advice"`
+}
+
+// ============================================
+// ANGIN & TEKANAN UNTUK PENJEMURAN
+// Daun cepat kering jika udara kering dan ada angin sedang; udara diam + lembab
+// memperlambat pengeringan (risiko jamur), angin kencang merusak daun di para-para.
+// Tekanan yang turun tajam menandakan cuaca akan memburuk.
+// ============================================
+
+const (
+    dryingWindMin          = 2.0  // m/s, di bawah ini udara dianggap diam
+    dryingWindMax          = 8.0  // m/s, di atas ini daun jemuran perlu diikat/dilindungi
+    dryingHumidityMax      = 70   // %, batas udara "kering" untuk penjemuran
+    stillAirHumidityMin    = 80   // %, udara diam di atas ini membuat daun lembab
+    pressureFallWarningHPa = -3.0 // hPa dalam periode prakiraan
+)
+
+// averageWindSpeed rata-rata entri yang punya data angin; nil jika tidak ada sama sekali
+func averageWindSpeed(entries []WeatherData) *float64 {
+    withWind := Filter(entries, func(e WeatherData) bool { return e.WindSpeed != nil })
+    if len(withWind) == 0 {
+        return nil
+    }
+    total := 0.0
+    for _, e := range withWind {
+        total += *e.WindSpeed
+    }
+    avg := math.Round(total/float64(len(withWind))*10) / 10
+    return &avg
+}
+
+// pressureChange selisih tekanan entri terakhir dan pertama yang punya data; nil jika < 2 entri
+func pressureChange(entries []WeatherData) *float64 {
+    withPressure := Filter(entries, func(e WeatherData) bool { return e.Pressure != nil })
+    if len(withPressure) < 2 {
+        return nil
+    }
+    change := math.Round((*withPressure[len(withPressure)-1].Pressure-*withPressure[0].Pressure)*10) / 10
+    return &change
+}
+
+// forecastDryingNote catatan penjemuran dari angin & tekanan; kosong jika datanya tidak ada
+// atau tidak ada yang perlu ditambahkan. rainMM = akumulasi hujan prakiraan periode yang sama.
+func forecastDryingNote(humidity int, rainMM float64, wind, pressure *float64) string {
+    var notes []string
+    if wind != nil {
+        switch {
+        case *wind > dryingWindMax:
+            notes = append(notes, withSymbol(symWarn, fmt.Sprintf("Angin kencang (%.1f m/s) - ikat atau lindungi daun di para-para", *wind)))
+        case *wind >= dryingWindMin && humidity <= dryingHumidityMax && rainMM < dryingRainThresholdMM:
+            notes = append(notes, withSymbol(symDry, fmt.Sprintf("Angin sedang (%.1f m/s) + udara kering - penjemuran sangat efektif", *wind)))
+        case *wind < dryingWindMin && humidity >= stillAirHumidityMin:
+            notes = append(notes, withSymbol(symWarn, fmt.Sprintf("Udara diam (%.1f m/s) & lembab - daun lambat kering, gunakan rak berventilasi", *wind)))
+        }
+    }
+    if pressure != nil && *pressure <= pressureFallWarningHPa {
+        notes = append(notes, withSymbol(symWarn, fmt.Sprintf("Tekanan udara turun %.1f hPa - cuaca cenderung memburuk", -*pressure)))
+    }
+    return strings.Join(notes, " | ")
+}
+
+// withDryingNote menambahkan catatan angin/tekanan ke DryingAdvice
+func (r RecommendationResult) withDryingNote(note string) RecommendationResult {
+    if note != "" {
+        r.DryingAdvice += " | " + note
+    }
+    return r
+}
+
+// dryWindowHours menjumlah hujan tiap slot 3 jam sampai melewati ambang; slot yang
+// melewati ambang tidak dihitung sebagai jendela kering
+func dryWindowHours(entries []WeatherData) int {
+    type acc struct {
+        rain  float64
+        hours int
+    }
+    window := ReduceWhile(entries, acc{}, func(a acc, e WeatherData) (acc, bool) {
+        a.rain += e.Rain
+        if a.rain >= dryingRainThresholdMM {
+            return a, false
+        }
+        a.hours += 3
+        return a, true
+    })
+    return window.hours
+}
+
+func SummarizeForecast(entries []WeatherData) ForecastOutlook {
+    if len(entries) > forecastLookaheadEntries {
+        entries = entries[:forecastLookaheadEntries]
+    }
+
+    outlook := ForecastOutlook{
+        DryWindowHours: dryWindowHours(entries),
+        AvgWindSpeed:   averageWindSpeed(entries),
+        PressureChange: pressureChange(entries),
+    }
+    for _, e := range entries {
+        outlook.RainMM += e.Rain
+        if e.Rain > 0 {
+            outlook.RainyHours += 3
+        }
+        outlook.MaxRainProbability = math.Max(outlook.MaxRainProbability, e.RainProbability)
+    }
+
+    switch {
+    case outlook.RainMM >= 5:
+        outlook.Advice = withSymbol(symRain, fmt.Sprintf("Hujan diperkirakan %.1fmm dalam 24 jam - percepat panen & pengeringan hari ini", outlook.RainMM))
+        if outlook.DryWindowHours > 0 {
+            outlook.Advice += fmt.Sprintf(" (sisa waktu kering ±%d jam)", outlook.DryWindowHours)
+        }
+    case outlook.MaxRainProbability >= highRainProbability:
+        outlook.Advice = withSymbol(symRain, fmt.Sprintf("Peluang hujan hingga %.0f%% dalam 24 jam - panen pagi ini & siapkan penutup untuk daun yang dijemur", outlook.MaxRainProbability*100))
+    case outlook.RainMM > 0:
+        outlook.Advice = withSymbol(symLightRain, fmt.Sprintf("Hujan ringan diperkirakan (%.1fmm) - siapkan penutup untuk daun yang dijemur", outlook.RainMM))
+    default:
+        outlook.Advice = withSymbol(symDry, "Tidak ada hujan diperkirakan 24 jam ke depan - jendela pengeringan aman")
+    }
+    return outlook
+}
+
+// DailyRainProbability ringkasan peluang hujan per tanggal (UTC, dari dt_txt forecast)
+type DailyRainProbability struct {
+    Date string  `json:"date"`
+    Max  float64 `json:"max"`
+    Avg  float64 `json:"avg"`
+}
+
+// groupForecastByDay mengelompokkan entri forecast per tanggal dt_txt, urut kronologis.
+// Entri tanpa ForecastTime dilewati.
+func groupForecastByDay(entries []WeatherData) []Pair[string, []WeatherData] {
+    days := []Pair[string, []WeatherData]{}
+    for _, e := range entries {
+        date, _, _ := strings.Cut(e.ForecastTime, " ")
+        if date == "" {
+            continue
+        }
+        last := len(days) - 1
+        if last < 0 || days[last].First != date {
+            days = append(days, Pair[string, []WeatherData]{First: date})
+            last++
+        }
+        days[last].Second = append(days[last].Second, e)
+    }
+    return days
+}
+
+// SummarizeRainProbabilityByDay mengelompokkan entri forecast per tanggal, urut kronologis
+func SummarizeRainProbabilityByDay(entries []WeatherData) []DailyRainProbability {
+    return Map(groupForecastByDay(entries), func(day Pair[string, []WeatherData]) DailyRainProbability {
+        summary := DailyRainProbability{Date: day.First}
+        for _, e := range day.Second {
+            summary.Max = math.Max(summary.Max, e.RainProbability)
+            summary.Avg += e.RainProbability
+        }
+        summary.Avg = math.Round(summary.Avg/float64(len(day.Second))*100) / 100
+        return summary
+    })
+}
+
+// ============================================
+// REKOMENDASI PER HARI PRAKIRAAN (/rekomendasi/week)
+// ============================================
+
+// forecastEntriesPerDay slot 3 jam dalam satu hari penuh
+const forecastEntriesPerDay = 8
+
+const (
+    confidenceHigh   = "high"   // hari lengkap (8 slot)
+    confidenceMedium = "medium" // >= setengah hari
+    confidenceLow    = "low"    // < setengah hari, biasanya hari ini/hari terakhir forecast
+)
+
+// DailyForecast agregat entri forecast satu tanggal (UTC, dari dt_txt)
+type DailyForecast struct {
+    Date               string   `json:"date"`
+    Entries            int      `json:"entries"` // jumlah slot 3 jam, forecastEntriesPerDay = lengkap
+    AvgTemp            float64  `json:"avg_temp"`
+    AvgHumidity        int      `json:"avg_humidity"`
+    RainMM             float64  `json:"rain_mm"` // total hujan hari itu
+    PeakRainMMPerHour  float64  `json:"peak_rain_mm_per_hour"`
+    MaxRainProbability float64  `json:"max_rain_probability"`
+    AvgWindSpeed       *float64 `json:"avg_wind_speed,omitempty"`
+    PressureChange     *float64 `json:"pressure_change,omitempty"`
+}
+
+// Confidence makin sedikit slot, makin besar kemungkinan cuaca siang/malam tidak terwakili
+func (d DailyForecast) Confidence() string {
+    switch {
+    case d.Entries >= forecastEntriesPerDay:
+        return confidenceHigh
+    case d.Entries*2 >= forecastEntriesPerDay:
+        return confidenceMedium
+    default:
+        return confidenceLow
+    }
+}
+
+// SummarizeForecastByDay memakai pengelompokan yang sama dengan SummarizeRainProbabilityByDay
+func SummarizeForecastByDay(entries []WeatherData) []DailyForecast {
+    return Map(groupForecastByDay(entries), func(day Pair[string, []WeatherData]) DailyForecast {
+        summary := DailyForecast{
+            Date:           day.First,
+            Entries:        len(day.Second),
+            AvgWindSpeed:   averageWindSpeed(day.Second),
+            PressureChange: pressureChange(day.Second),
+        }
+        humidity := 0
+        for _, e := range day.Second {
+            summary.AvgTemp += e.Temp
+            humidity += e.Humidity
+            summary.RainMM += e.Rain
+            // Rain entri forecast = akumulasi 3 jam
+            summary.PeakRainMMPerHour = math.Max(summary.PeakRainMMPerHour, e.Rain/3)
+            summary.MaxRainProbability = math.Max(summary.MaxRainProbability, e.RainProbability)
+        }
+        n := float64(len(day.Second))
+        summary.AvgTemp = math.Round(summary.AvgTemp/n*10) / 10
+        summary.AvgHumidity = int(math.Round(float64(humidity) / n))
+        summary.RainMM = math.Round(summary.RainMM*100) / 100
+        summary.PeakRainMMPerHour = math.Round(summary.PeakRainMMPerHour*100) / 100
+        return summary
+    })
+}
+
+// DailyRecommendation rekomendasi untuk satu hari prakiraan
+type DailyRecommendation struct {
+    Date           string               `json:"date"`
+    Confidence     string               `json:"confidence"`
+    Forecast       DailyForecast        `json:"forecast"`
+    Recommendation RecommendationResult `json:"recommendation"`
+}
+
+// RecommendForecastDays menjalankan mesin rekomendasi per hari. Input hujan = intensitas
+// slot terberat (mm/jam, satuan yang sama dengan cuaca saat ini): satu hujan lebat
+// sudah cukup menggagalkan penjemuran, walau rata-rata harian kecil.
+func RecommendForecastDays(cfg RecommendationConfig, entries []WeatherData, region string) []DailyRecommendation {
+    return Map(SummarizeForecastByDay(entries), func(day DailyForecast) DailyRecommendation {
+        result := GetAdvancedRecommendationWithConfig(cfg, day.AvgTemp, day.AvgHumidity, day.PeakRainMMPerHour, region).
+            withDryingNote(forecastDryingNote(day.AvgHumidity, day.RainMM, day.AvgWindSpeed, day.PressureChange))
+        return DailyRecommendation{
+            Date:           day.Date,
+            Confidence:     day.Confidence(),
+            Forecast:       day,
+            Recommendation: result,
+        }
+    })
+}
+
+// AdvancedRecommendation rekomendasi detail + prakiraan; ForecastAvailable=false
+// jika forecast gagal diambil (rekomendasi hanya dari cuaca saat ini)
+type AdvancedRecommendation struct {
+    RecommendationResult
+    ForecastAvailable bool                       `json:"forecast_available"`
+    Forecast          *ForecastOutlook           `json:"forecast,omitempty"`
+    Explanation       *RecommendationExplanation `json:"explanation,omitempty"` // hanya jika ?explain=true
+}
+
 // GetRecommendationSummary untuk backward compatibility
 func GetRecommendationSummary(temp float64, humidity int, rain float64) string {
     return Recommend(temp, humidity, rain)
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================
+// RECOMMENDATION CACHE
+// Rekomendasi region hanya berubah saat cuaca berubah (~10 menit), jadi hasilnya
+// disimpan per region+varietas dan dibuang begitu weatherCache region itu diperbarui
+// ============================================
+
+const recommendationCacheTTL = weatherCacheTTL
+
+type recommendationCacheEntry struct {
+	value    interface{}
+	storedAt time.Time
+}
+
+type RecommendationCache struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	entries map[string]recommendationCacheEntry
+}
+
+func NewRecommendationCache(ttl time.Duration) *RecommendationCache {
+	return &RecommendationCache{
+		ttl:     ttl,
+		entries: make(map[string]recommendationCacheEntry),
+	}
+}
+
+// recommendationCacheKey region di depan supaya InvalidateRegion cukup cek prefix
+func recommendationCacheKey(kind, region, variety string) string {
+	return weatherCacheKey(region) + "|" + kind + "|" + strings.ToLower(variety)
+}
+
+// Get mengembalikan nilai yang belum kedaluwarsa beserta sisa TTL-nya
+func (c *RecommendationCache) Get(key string) (interface{}, time.Duration, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, 0, false
+	}
+	remaining := c.ttl - time.Since(entry.storedAt)
+	if remaining <= 0 {
+		return nil, 0, false
+	}
+	return entry.value, remaining, true
+}
+
+func (c *RecommendationCache) Set(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = recommendationCacheEntry{value: value, storedAt: time.Now()}
+}
+
+// InvalidateRegion membuang semua varian rekomendasi untuk region
+func (c *RecommendationCache) InvalidateRegion(region string) {
+	prefix := weatherCacheKey(region) + "|"
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+var recommendationCache = func() *RecommendationCache {
+	c := NewRecommendationCache(recommendationCacheTTL)
+	weatherCache.OnRefresh(c.InvalidateRegion)
+	return c
+}()
+
+// cachedRecommendation mengambil dari cache atau menghitung ulang lewat compute.
+// ?fresh=true melewati cache (hasil barunya tetap disimpan). Header Cache-Control
+// mengikuti sisa TTL dan X-Cache menandai HIT/MISS.
+func cachedRecommendation[V any](w http.ResponseWriter, r *http.Request, key string, compute func() (V, error)) (V, error) {
+	if r.URL.Query().Get("fresh") != "true" {
+		if cached, remaining, ok := recommendationCache.Get(key); ok {
+			if value, ok := cached.(V); ok {
+				setRecommendationCacheHeaders(w, "HIT", remaining)
+				return value, nil
+			}
+		}
+	}
+
+	value, err := compute()
+	if err != nil {
+		return value, err
+	}
+	recommendationCache.Set(key, value)
+	setRecommendationCacheHeaders(w, "MISS", recommendationCache.ttl)
+	return value, nil
+}
+
+func setRecommendationCacheHeaders(w http.ResponseWriter, status string, maxAge time.Duration) {
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+	w.Header().Set("X-Cache", status)
+}
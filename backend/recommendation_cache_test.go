@@ -0,0 +1,90 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// useRecommendationCache cache rekomendasi kosong selama test, di-invalidate oleh wc
+func useRecommendationCache(t *testing.T, wc *WeatherCache) *RecommendationCache {
+	t.Helper()
+	prev := recommendationCache
+	recommendationCache = NewRecommendationCache(recommendationCacheTTL)
+	wc.OnRefresh(recommendationCache.InvalidateRegion)
+	t.Cleanup(func() { recommendationCache = prev })
+	return recommendationCache
+}
+
+func TestRecommendationHandlerCachesWithinTTL(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+	useRecommendationCache(t, useWeatherCache(t))
+	var calls atomic.Int32
+	useOWMServer(t, owmResponses(&calls, []int{http.StatusOK}, nil))
+
+	first := serve(RecommendationHandler, http.MethodGet, "/rekomendasi?region=Jember", "")
+	second := serve(RecommendationHandler, http.MethodGet, "/rekomendasi?region=jember", "")
+	if first.Code != http.StatusOK || second.Code != http.StatusOK {
+		t.Fatalf("GET /rekomendasi = %d, %d", first.Code, second.Code)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("upstream dipanggil %d kali, ingin 1 (panggilan kedua dari cache)", calls.Load())
+	}
+	if first.Header().Get("X-Cache") != "MISS" || second.Header().Get("X-Cache") != "HIT" {
+		t.Fatalf("X-Cache = %s, %s; ingin MISS, HIT", first.Header().Get("X-Cache"), second.Header().Get("X-Cache"))
+	}
+	if first.Body.String() != second.Body.String() {
+		t.Fatalf("body HIT %s berbeda dari MISS %s", second.Body.String(), first.Body.String())
+	}
+
+	// max-age mengikuti sisa TTL, tidak pernah melebihi TTL
+	for _, rec := range []*httptest.ResponseRecorder{first, second} {
+		maxAge, err := strconv.Atoi(strings.TrimPrefix(rec.Header().Get("Cache-Control"), "public, max-age="))
+		if err != nil || maxAge <= 0 || maxAge > int(recommendationCacheTTL.Seconds()) {
+			t.Fatalf("Cache-Control = %q", rec.Header().Get("Cache-Control"))
+		}
+	}
+
+	// ?fresh=true melewati cache
+	fresh := serve(RecommendationHandler, http.MethodGet, "/rekomendasi?region=Jember&fresh=true", "")
+	if calls.Load() != 2 || fresh.Header().Get("X-Cache") != "MISS" {
+		t.Fatalf("fresh=true: upstream %d kali, X-Cache %s; ingin 2, MISS", calls.Load(), fresh.Header().Get("X-Cache"))
+	}
+}
+
+func TestRecommendationCacheInvalidatedByWeatherRefresh(t *testing.T) {
+	wc := NewWeatherCache(weatherCacheTTL)
+	rc := NewRecommendationCache(time.Minute)
+	wc.OnRefresh(rc.InvalidateRegion)
+
+	rc.Set(recommendationCacheKey("simple", "Jember", ""), "lama")
+	rc.Set(recommendationCacheKey("advanced", "Jember", "Kasturi"), "lama")
+	rc.Set(recommendationCacheKey("simple", "Malang", ""), "lama")
+
+	wc.Set("jember", &WeatherData{Temp: 27, Humidity: 70})
+	for _, key := range []string{recommendationCacheKey("simple", "Jember", ""), recommendationCacheKey("advanced", "Jember", "kasturi")} {
+		if _, _, ok := rc.Get(key); ok {
+			t.Fatalf("%s masih di cache setelah cuaca Jember diperbarui", key)
+		}
+	}
+	if _, _, ok := rc.Get(recommendationCacheKey("simple", "Malang", "")); !ok {
+		t.Fatal("cache Malang ikut terbuang")
+	}
+}
+
+func TestRecommendationCacheExpires(t *testing.T) {
+	rc := NewRecommendationCache(20 * time.Millisecond)
+	key := recommendationCacheKey("simple", "Jember", "")
+	rc.Set(key, "nilai")
+	if _, remaining, ok := rc.Get(key); !ok || remaining > 20*time.Millisecond {
+		t.Fatalf("Get langsung = ok %v, sisa %v", ok, remaining)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, _, ok := rc.Get(key); ok {
+		t.Fatal("entry masih ada setelah TTL lewat")
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExplainRecommendationUsesVarietyBands(t *testing.T) {
+	tests := []struct {
+		name         string
+		variety      string
+		temp         float64
+		humidity     int
+		rain         float64
+		wantStatus   string
+		wantLimiting string
+	}{
+		// 31°C di atas rentang umum (20-30) tapi masih optimal untuk kasturi (22-32)
+		{"umum panas", "", 31, 70, 2, "good", "temperature"},
+		{"kasturi panas", "kasturi", 31, 70, 2, "optimal", ""},
+		// Sejuk & lembab: burley (18-28°C, 65-85%, 1-6mm) optimal, profil umum tidak
+		{"umum sejuk lembab", "", 19, 82, 5.5, "caution", "rain"},
+		{"burley sejuk lembab", "burley", 19, 82, 5.5, "optimal", ""},
+		// 78% di atas batas virginia (75%) tapi ideal untuk profil umum (60-80%)
+		{"umum lembab", "", 25, 78, 2, "optimal", ""},
+		{"virginia lembab", "virginia", 25, 78, 2, "good", "humidity"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := RecommendationConfigFor(tt.variety)
+			got := ExplainRecommendation(cfg, tt.temp, tt.humidity, tt.rain)
+			if got.Status != tt.wantStatus || got.LimitingFactor != tt.wantLimiting {
+				t.Fatalf("ExplainRecommendation(%s) = status %q limiting %q, ingin %q %q",
+					cfg.Variety, got.Status, got.LimitingFactor, tt.wantStatus, tt.wantLimiting)
+			}
+
+			// Status penjelasan harus sama dengan rekomendasi yang dikirim ke client
+			if want := GetAdvancedRecommendationWithConfig(cfg, tt.temp, tt.humidity, tt.rain, "").Status; got.Status != want {
+				t.Fatalf("status explain %q != status rekomendasi %q", got.Status, want)
+			}
+			temp := got.Factors[0]
+			if temp.OptimalMin != cfg.TempMin || temp.OptimalMax != cfg.TempMax {
+				t.Fatalf("rentang suhu = %v-%v, ingin %v-%v", temp.OptimalMin, temp.OptimalMax, cfg.TempMin, cfg.TempMax)
+			}
+		})
+	}
+}
+
+func TestExplainRecommendationLimitingFactor(t *testing.T) {
+	tests := []struct {
+		name     string
+		temp     float64
+		humidity int
+		rain     float64
+		limiting string
+	}{
+		{"semua optimal", 25, 70, 2, ""},
+		{"dingin", 10, 70, 2, "temperature"},
+		{"sangat lembab", 25, 95, 2, "humidity"},
+		{"hujan sangat lebat", 25, 70, 20, "rain"},
+		// Sub-status terburuk menang walau deviasinya lebih kecil
+		{"panas poor vs lembab acceptable", 36, 88, 2, "temperature"},
+		// Sama-sama acceptable: 88% (0.4 lebar rentang) lebih jauh dari 33°C (0.3)
+		{"seri, deviasi terbesar", 33, 88, 2, "humidity"},
+	}
+	cfg := RecommendationConfigFor("")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExplainRecommendation(cfg, tt.temp, tt.humidity, tt.rain)
+			if got.LimitingFactor != tt.limiting {
+				t.Fatalf("LimitingFactor = %q, ingin %q (%+v)", got.LimitingFactor, tt.limiting, got.Factors)
+			}
+			if len(got.Factors) != 3 {
+				t.Fatalf("Factors = %+v, ingin 3 faktor", got.Factors)
+			}
+			for _, f := range got.Factors {
+				if f.Reason == "" || f.Status == "" {
+					t.Fatalf("faktor %s tanpa status/alasan", f.Factor)
+				}
+			}
+		})
+	}
+}
+
+func TestSummarizeForecastRainProbabilityAdvice(t *testing.T) {
+	entries := forecastFixture(t)
+	tests := []struct {
+		name    string
+		entries []WeatherData
+		maxPop  float64
+		advice  string
+	}{
+		// Hujan 1.5mm, pop tertinggi 0.65 masih di bawah highRainProbability
+		{"hari pertama", entries[:8], 0.65, "Hujan ringan diperkirakan (1.5mm)"},
+		// Belum ada hujan terukur, tapi pop 0.7 cukup untuk peringatan
+		{"pop tinggi tanpa hujan", entries[8:10], 0.7, "Peluang hujan hingga 70%"},
+		// Akumulasi hujan lebat tetap diutamakan di atas peluang hujan
+		{"hujan lebat", entries[8:16], 0.9, "Hujan diperkirakan 12.0mm"},
+		{"cerah", entries[:1], 0, "Tidak ada hujan diperkirakan"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outlook := SummarizeForecast(tt.entries)
+			if outlook.MaxRainProbability != tt.maxPop || !strings.Contains(outlook.Advice, tt.advice) {
+				t.Fatalf("outlook = pop %v, advice %q; ingin pop %v, advice memuat %q",
+					outlook.MaxRainProbability, outlook.Advice, tt.maxPop, tt.advice)
+			}
+		})
+	}
+}
+
+func TestStripEmoji(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"✅ Kondisi optimal untuk panen", "Kondisi optimal untuk panen"},
+		{"🌧️ Hujan 12.5mm - tunda panen!", "Hujan 12.5mm - tunda panen!"},
+		// Emoji gabungan ZWJ & keycap hilang utuh, spasi sisa dirapikan
+		{"Petani 👨‍🌾 siap  ⚠️  cek 1️⃣ lahan", "Petani siap cek 1 lahan"},
+		// Huruf, angka, tanda baca & satuan tetap
+		{"Suhu 33.0°C (ideal 20-30°C), kelembaban 70%; Rp 85.000/kg?", "Suhu 33.0°C (ideal 20-30°C), kelembaban 70%; Rp 85.000/kg?"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := StripEmoji(tt.in); got != tt.want {
+			t.Errorf("StripEmoji(%q) = %q, ingin %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRecommendEmojiFreeKeepsMeaning(t *testing.T) {
+	result := GetAdvancedRecommendation(34, 92, 12, "Jember")
+	plain := result.Themed(ThemeNone)
+	pairs := [][2]string{
+		{result.MainAdvice, plain.MainAdvice},
+		{result.HarvestAdvice, plain.HarvestAdvice},
+		{result.DryingAdvice, plain.DryingAdvice},
+		{result.PestWarning, plain.PestWarning},
+	}
+	for i, advice := range result.DetailedAdvice {
+		pairs = append(pairs, [2]string{advice, plain.DetailedAdvice[i]})
+	}
+	for _, p := range pairs {
+		if strings.IndexFunc(p[1], isDecorativeRune) >= 0 {
+			t.Fatalf("teks plain masih berisi emoji: %q", p[1])
+		}
+		// Hanya emoji yang hilang: semua kata alfanumerik asli tetap ada
+		for _, word := range strings.Fields(p[0]) {
+			if strings.IndexFunc(word, isDecorativeRune) < 0 && !strings.Contains(p[1], word) {
+				t.Fatalf("kata %q hilang dari %q (asli %q)", word, p[1], p[0])
+			}
+		}
+	}
+}
+
+func TestDryWindowHoursStopsAtRainThreshold(t *testing.T) {
+	entries := forecastFixture(t)
+	tests := []struct {
+		name    string
+		entries []WeatherData
+		hours   int
+	}{
+		// 0.6mm di slot ke-7 belum melewati 1mm, 0.9mm berikutnya melewati
+		{"hujan ringan bertahap", entries[:8], 21},
+		{"hujan lebat di slot ketiga", entries[8:16], 6},
+		{"tanpa hujan, semua slot dihitung", entries[16:], 6},
+		{"kosong", nil, 0},
+	}
+	for _, tt := range tests {
+		if got := dryWindowHours(tt.entries); got != tt.hours {
+			t.Errorf("dryWindowHours(%s) = %d, ingin %d", tt.name, got, tt.hours)
+		}
+	}
+}
+
+func TestCalculateIrrigationNeed(t *testing.T) {
+	tests := []struct {
+		name     string
+		temp     float64
+		humidity float64
+		rain     float64
+		areaHa   float64
+		want     float64
+	}{
+		// ET0 7mm x faktor RH 1.2 = 8.4mm = 84.000 L/ha
+		{"panas kering", 35, 40, 0, 1, 84000},
+		{"panas kering 2.5 ha", 35, 40, 0, 2.5, 210000},
+		// 5mm x 0.9 - 80% x 1mm = 3.7mm
+		{"sedang, hujan ringan", 25, 70, 1, 0.5, 18500},
+		// Faktor RH dibatasi 1.4 walau udara sangat kering
+		{"udara sangat kering", 30, 0, 0, 1, 84000},
+		// ETc 2.8mm habis tertutup hujan efektif 4mm
+		{"sejuk basah", 20, 90, 5, 1, 0},
+		{"luas nol", 35, 40, 0, 0, 0},
+		{"luas negatif", 35, 40, 0, -1, 0},
+	}
+	for _, tt := range tests {
+		if got := CalculateIrrigationNeed(tt.temp, tt.humidity, tt.rain, tt.areaHa); got != tt.want {
+			t.Errorf("CalculateIrrigationNeed(%s) = %v, ingin %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAdvancedRecommendationIrrigationLiters(t *testing.T) {
+	hot := GetAdvancedRecommendation(35, 40, 0, "Jember")
+	wet := GetAdvancedRecommendation(20, 90, 5, "Jember")
+	if hot.IrrigationAreaHa <= 0 || hot.IrrigationLiters != 84000*hot.IrrigationAreaHa {
+		t.Fatalf("panas kering = %v L untuk %v ha, ingin %v", hot.IrrigationLiters, hot.IrrigationAreaHa, 84000*hot.IrrigationAreaHa)
+	}
+	if wet.IrrigationLiters != 0 {
+		t.Fatalf("sejuk basah = %v L, ingin 0", wet.IrrigationLiters)
+	}
+	if got := hot.WithIrrigationArea(0); got.IrrigationLiters != 0 || got.IrrigationAreaHa != 0 {
+		t.Fatalf("WithIrrigationArea(0) = %v L %v ha", got.IrrigationLiters, got.IrrigationAreaHa)
+	}
+}
+
+func TestForecastDryingNote(t *testing.T) {
+	tests := []struct {
+		name     string
+		humidity int
+		rainMM   float64
+		wind     *float64
+		pressure *float64
+		want     string // "" = tidak ada catatan
+	}{
+		{"berangin & kering", 60, 0, floatPtr(4), nil, "Angin sedang (4.0 m/s) + udara kering - penjemuran sangat efektif"},
+		{"diam & lembab", 85, 0, floatPtr(1), nil, "Udara diam (1.0 m/s) & lembab"},
+		{"angin kencang", 60, 0, floatPtr(10), nil, "Angin kencang (10.0 m/s)"},
+		// Angin sedang tidak membantu jika hujan membasahi jemuran
+		{"berangin tapi hujan", 60, 2, floatPtr(4), nil, ""},
+		{"tekanan turun", 60, 0, nil, floatPtr(-4), "Tekanan udara turun 4.0 hPa"},
+		{"tekanan turun sedikit", 60, 0, nil, floatPtr(-2), ""},
+		{"data angin & tekanan tidak ada", 85, 0, nil, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := forecastDryingNote(tt.humidity, tt.rainMM, tt.wind, tt.pressure)
+			if (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+				t.Fatalf("forecastDryingNote = %q, ingin %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForecastWindAndPressureFromFixture(t *testing.T) {
+	entries := forecastFixture(t)
+
+	outlook := SummarizeForecast(entries[:8])
+	if outlook.AvgWindSpeed == nil || *outlook.AvgWindSpeed != 2.7 || outlook.PressureChange == nil || *outlook.PressureChange != -2 {
+		t.Fatalf("angin %v, tekanan %v; ingin 2.7 m/s, -2 hPa", outlook.AvgWindSpeed, outlook.PressureChange)
+	}
+
+	// Siang hari pertama: berangin sedang, kering, tanpa hujan
+	windy := SummarizeForecastByDay(entries[2:6])[0]
+	if note := forecastDryingNote(windy.AvgHumidity, windy.RainMM, windy.AvgWindSpeed, windy.PressureChange); !strings.Contains(note, "penjemuran sangat efektif") {
+		t.Fatalf("catatan siang berangin = %q", note)
+	}
+
+	// Hari kedua: udara diam (1.3 m/s) & lembab (86%)
+	days := RecommendForecastDays(RecommendationConfigFor(""), entries, "Jember")
+	if !strings.Contains(days[1].Recommendation.DryingAdvice, "Udara diam (1.3 m/s)") {
+		t.Fatalf("drying advice hari kedua = %q", days[1].Recommendation.DryingAdvice)
+	}
+
+	// Entri tanpa wind/pressure (field tidak dikirim OWM) tidak dianggap 0
+	bare := []WeatherData{{Temp: 25, Humidity: 70, ForecastTime: "2026-03-10 00:00:00"}, {Temp: 26, Humidity: 68, ForecastTime: "2026-03-10 03:00:00"}}
+	if got := SummarizeForecast(bare); got.AvgWindSpeed != nil || got.PressureChange != nil {
+		t.Fatalf("tanpa data: angin %v, tekanan %v; ingin nil", got.AvgWindSpeed, got.PressureChange)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ============================================
+// NORMALISASI NAMA REGION
+// User, OWM, dan scraper menulis region dengan ejaan berbeda
+// ("jember", "Kab. Jember", "JEMBER"); semuanya dipetakan ke satu nama kanonik
+// ============================================
+
+// defaultRegionAliases alias (lowercase) -> nama kanonik
+var defaultRegionAliases = map[string]string{
+	"kab. jember":          "Jember",
+	"kab jember":           "Jember",
+	"kabupaten jember":     "Jember",
+	"kab. temanggung":      "Temanggung",
+	"kabupaten temanggung": "Temanggung",
+	"kab. bojonegoro":      "Bojonegoro",
+	"kabupaten bojonegoro": "Bojonegoro",
+	"kab. boyolali":        "Boyolali",
+	"kab. klaten":          "Klaten",
+	"kab. pamekasan":       "Pamekasan",
+	"kab. bondowoso":       "Bondowoso",
+	"kota malang":          "Malang",
+	"kab. malang":          "Malang",
+	"kota surabaya":        "Surabaya",
+	"lombok timur":         "Lombok",
+	"lombok tengah":        "Lombok",
+	"ntb":                  "Lombok",
+}
+
+// RegionAliases tabel alias; read-only setelah dibuat sehingga aman dipakai concurrent
+type RegionAliases struct {
+	aliases map[string]string
+}
+
+func NewRegionAliases(extra map[string]string) *RegionAliases {
+	ra := &RegionAliases{aliases: make(map[string]string, len(defaultRegionAliases)+len(extra))}
+	for alias, canonical := range defaultRegionAliases {
+		ra.aliases[regionAliasKey(alias)] = canonical
+	}
+	for alias, canonical := range extra {
+		ra.aliases[regionAliasKey(alias)] = canonical
+	}
+	return ra
+}
+
+// regionAliasKey lowercase + spasi dirapikan ("Kab.  Jember " -> "kab. jember")
+func regionAliasKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// Normalize mengembalikan nama kanonik. Nama tanpa alias cukup dirapikan
+// huruf besarnya ("jember" -> "Jember") agar cocok dengan data di DB.
+func (ra *RegionAliases) Normalize(name string) string {
+	key := regionAliasKey(name)
+	if key == "" {
+		return ""
+	}
+
+	if canonical, ok := ra.aliases[key]; ok {
+		return canonical
+	}
+
+	return strings.Join(Map(strings.Fields(key), func(word string) string {
+		return strings.ToUpper(word[:1]) + word[1:]
+	}), " ")
+}
+
+// parseRegionAliases membaca format "alias=Kanonik,alias2=Kanonik2"
+func parseRegionAliases(raw string) (map[string]string, error) {
+	aliases := make(map[string]string)
+	for _, pair := range splitList(raw) {
+		alias, canonical, ok := strings.Cut(pair, "=")
+		alias, canonical = strings.TrimSpace(alias), strings.TrimSpace(canonical)
+		if !ok || alias == "" || canonical == "" {
+			return nil, fmt.Errorf("alias harus berformat alias=Kanonik, didapat %q", pair)
+		}
+		aliases[alias] = canonical
+	}
+	return aliases, nil
+}
+
+var regionAliases = NewRegionAliases(nil)
+
+// NormalizeRegion memetakan alias ke nama region kanonik
+func NormalizeRegion(name string) string {
+	return regionAliases.Normalize(name)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRegionAliasesNormalize(t *testing.T) {
+	ra := NewRegionAliases(map[string]string{"Tembakau Na-Oogst": "Jember", "lotim": "Lombok"})
+	tests := map[string]string{
+		"jember":             "Jember",
+		"JEMBER":             "Jember",
+		"  Jember ":          "Jember",
+		"Kab. Jember":        "Jember",
+		"KABUPATEN   JEMBER": "Jember",
+		"kab jember":         "Jember",
+		"Kota Malang":        "Malang",
+		"Lombok Timur":       "Lombok",
+		"NTB":                "Lombok",
+		"lotim":              "Lombok", // alias tambahan dari konfigurasi
+		"tembakau na-oogst":  "Jember", // key alias tambahan ikut dinormalisasi
+		"situbondo":          "Situbondo",
+		"probolinggo   KOTA": "Probolinggo Kota",
+		"":                   "",
+	}
+	for in, want := range tests {
+		if got := ra.Normalize(in); got != want {
+			t.Fatalf("Normalize(%q) = %q, ingin %q", in, got, want)
+		}
+	}
+}
+
+func TestParseRegionAliases(t *testing.T) {
+	aliases, err := parseRegionAliases("lotim=Lombok, kab. situbondo = Situbondo")
+	if err != nil || len(aliases) != 2 || aliases["kab. situbondo"] != "Situbondo" {
+		t.Fatalf("parseRegionAliases = %v, %v", aliases, err)
+	}
+	for _, raw := range []string{"lotim", "=Lombok", "lotim="} {
+		if _, err := parseRegionAliases(raw); err == nil {
+			t.Fatalf("parseRegionAliases(%q) tidak error", raw)
+		}
+	}
+}
+
+func TestPriceQueriesNormalizeRegionAliases(t *testing.T) {
+	store := NewMemoryPriceStore()
+	useStores(t, store, NewMemoryWeatherStore())
+	mustAdd(t, store, testPrice("Jember", 40000, "2026-01-01"), testPrice("Malang", 38000, "2026-01-01"))
+
+	for _, region := range []string{"jember", "Kab.%20Jember", "KABUPATEN%20JEMBER"} {
+		rec := serve(PricesHandler, http.MethodGet, "/harga?region="+region, "")
+		var page Paginated[Price]
+		decodeBody(t, rec, &page)
+		if rec.Code != http.StatusOK || page.Total != 1 || page.Data[0].Region != "Jember" {
+			t.Fatalf("GET /harga?region=%s = %d %+v", region, rec.Code, page)
+		}
+	}
+	if got := getRegionOrDefault(""); got != "Jember" {
+		t.Fatalf("getRegionOrDefault(\"\") = %q", got)
+	}
+}
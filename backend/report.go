@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// ============================================
+// LAPORAN REKOMENDASI (PDF / HTML)
+// Versi cetak rekomendasi untuk penyuluh lapangan yang diserahkan ke petani
+// ============================================
+
+const (
+	reportFormatPDF  = "pdf"
+	reportFormatHTML = "html"
+)
+
+// reportTimezone waktu di laporan ditulis dalam WIB, sama dengan pengguna lapangan
+var reportTimezone = time.FixedZone("WIB", 7*60*60)
+
+type RecommendationReport struct {
+	Region      string
+	GeneratedAt time.Time
+	Weather     WeatherData
+	Result      RecommendationResult
+}
+
+func (rep RecommendationReport) filename(ext string) string {
+	region := strings.ToLower(strings.ReplaceAll(rep.Region, " ", "-"))
+	return fmt.Sprintf("rekomendasi-%s-%s.%s", region, rep.GeneratedAt.Format("20060102-1504"), ext)
+}
+
+// sections pasangan judul -> isi saran, urutan sama di PDF dan HTML
+func (rep RecommendationReport) sections() []Pair[string, string] {
+	return []Pair[string, string]{
+		{First: "Penanaman", Second: rep.Result.PlantingAdvice},
+		{First: "Panen", Second: rep.Result.HarvestAdvice},
+		{First: "Pengeringan", Second: rep.Result.DryingAdvice},
+		{First: "Hama & Penyakit", Second: rep.Result.PestWarning},
+		{First: "Irigasi", Second: rep.Result.IrrigationAdvice},
+	}
+}
+
+func (rep RecommendationReport) weatherLines() []string {
+	rain := fmt.Sprintf("%.1f mm", rep.Weather.Rain)
+	if !rep.Weather.RainAvailable {
+		rain += " (data hujan tidak tersedia)"
+	}
+	lines := []string{
+		fmt.Sprintf("Suhu: %.1f°C", rep.Weather.Temp),
+		fmt.Sprintf("Kelembaban: %d%%", rep.Weather.Humidity),
+		"Curah hujan: " + rain,
+	}
+	if rep.Weather.Description != "" {
+		lines = append(lines, "Kondisi: "+rep.Weather.Description)
+	}
+	return lines
+}
+
+func renderReportPDF(rep RecommendationReport) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle("Rekomendasi Budidaya Tembakau - "+rep.Region, true)
+	pdf.AddPage()
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	text := func(s string) string { return tr(StripEmoji(s)) }
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 10, text("Rekomendasi Budidaya Tembakau"), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 6, text(fmt.Sprintf("Wilayah: %s   |   Varietas: %s   |   Dibuat: %s",
+		rep.Region, rep.Result.Variety, rep.GeneratedAt.Format("02 Jan 2006 15:04 MST"))), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	heading := func(title string) {
+		pdf.SetFont("Helvetica", "B", 12)
+		pdf.CellFormat(0, 8, text(title), "B", 1, "L", false, 0, "")
+		pdf.Ln(1)
+		pdf.SetFont("Helvetica", "", 11)
+	}
+
+	heading("Cuaca Saat Ini")
+	for _, line := range rep.weatherLines() {
+		pdf.MultiCell(0, 6, text(line), "", "L", false)
+	}
+	pdf.Ln(3)
+
+	heading("Status: " + strings.ToUpper(rep.Result.Status))
+	pdf.MultiCell(0, 6, text(rep.Result.MainAdvice), "", "L", false)
+	for _, advice := range rep.Result.DetailedAdvice {
+		pdf.MultiCell(0, 6, text("- "+advice), "", "L", false)
+	}
+	pdf.Ln(3)
+
+	for _, section := range rep.sections() {
+		if section.Second == "" {
+			continue
+		}
+		heading(section.First)
+		pdf.MultiCell(0, 6, text(section.Second), "", "L", false)
+		pdf.Ln(2)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var reportHTMLTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="id">
+<head>
+<meta charset="utf-8">
+<title>Rekomendasi Budidaya Tembakau - {{.Region}}</title>
+<style>
+  body { font-family: sans-serif; max-width: 720px; margin: 2em auto; color: #222; }
+  h1 { font-size: 1.5em; margin-bottom: 0; }
+  h2 { font-size: 1.1em; border-bottom: 1px solid #999; padding-bottom: 2px; }
+  .meta { color: #555; }
+  @media print { body { margin: 0; } }
+</style>
+</head>
+<body>
+<h1>Rekomendasi Budidaya Tembakau</h1>
+<p class="meta">Wilayah: {{.Region}} &middot; Varietas: {{.Result.Variety}} &middot; Dibuat: {{.GeneratedAt.Format "02 Jan 2006 15:04 MST"}}</p>
+<h2>Cuaca Saat Ini</h2>
+<ul>{{range .WeatherLines}}<li>{{.}}</li>{{end}}</ul>
+<h2>Status: {{.Status}}</h2>
+<p>{{.Result.MainAdvice}}</p>
+<ul>{{range .Result.DetailedAdvice}}<li>{{.}}</li>{{end}}</ul>
+{{range .Sections}}{{if .Second}}<h2>{{.First}}</h2>
+<p>{{.Second}}</p>
+{{end}}{{end}}</body>
+</html>
+`))
+
+var reportErrorTemplate = template.Must(template.New("report-error").Parse(`<!DOCTYPE html>
+<html lang="id">
+<head><meta charset="utf-8"><title>Laporan tidak tersedia</title></head>
+<body style="font-family: sans-serif; max-width: 720px; margin: 2em auto;">
+<h1>Laporan tidak dapat dibuat</h1>
+<p>Data cuaca untuk <strong>{{.Region}}</strong> tidak tersedia: {{.Message}}</p>
+<p>Silakan coba lagi beberapa saat lagi.</p>
+</body>
+</html>
+`))
+
+func renderReportHTML(rep RecommendationReport) ([]byte, error) {
+	var buf bytes.Buffer
+	err := reportHTMLTemplate.Execute(&buf, map[string]interface{}{
+		"Region":       rep.Region,
+		"GeneratedAt":  rep.GeneratedAt,
+		"WeatherLines": rep.weatherLines(),
+		"Status":       strings.ToUpper(rep.Result.Status),
+		"Result":       rep.Result,
+		"Sections":     rep.sections(),
+	})
+	return buf.Bytes(), err
+}
+
+func writeReportError(w http.ResponseWriter, format, region string, apiErr *APIError) {
+	if format != reportFormatHTML {
+		writeAPIError(w, apiErr)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(apiErr.Status)
+	reportErrorTemplate.Execute(w, map[string]string{"Region": region, "Message": apiErr.Message})
+}
+
+func RecommendationReportHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		region := getRegionOrDefault(r.URL.Query().Get("region"))
+		format := strings.ToLower(r.URL.Query().Get("format"))
+		if format == "" {
+			format = reportFormatPDF
+		}
+		if format != reportFormatPDF && format != reportFormatHTML {
+			return NewAPIError(http.StatusBadRequest, "invalid_format", "format harus pdf atau html")
+		}
+
+		data, err := FetchWeatherCachedWithContext(r.Context(), region)
+		if err != nil {
+			writeReportError(w, format, region, weatherAPIError(err))
+			return nil
+		}
+
+		rep := RecommendationReport{
+			Region:      region,
+			GeneratedAt: time.Now().In(reportTimezone),
+			Weather:     *data,
+			Result:      RecommendForWeatherWithConfig(RecommendationConfigFor(r.URL.Query().Get("variety")), data, region),
+		}
+
+		render, contentType, ext := renderReportPDF, "application/pdf", "pdf"
+		if format == reportFormatHTML {
+			render, contentType, ext = renderReportHTML, "text/html; charset=utf-8", "html"
+		}
+		body, err := render(rep)
+		if err != nil {
+			return err
+		}
+
+		disposition := "attachment"
+		if format == reportFormatHTML {
+			disposition = "inline"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("%s; filename=%q", disposition, rep.filename(ext)))
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(body)
+		return err
+	})(w, r)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// ============================================
+// RESET DATABASE (DEMO)
+// POST /admin/reset mengosongkan tabel data lalu (opsional) mengisi ulang dengan
+// harga simulasi + beberapa sampel cuaca (lewat replaceAllData)
+// ============================================
+
+type resetRequest struct {
+	Confirm bool `json:"confirm"`
+	Reseed  bool `json:"reseed"`
+}
+
+// ResetResult jumlah baris per tabel yang dihapus dan diisi ulang
+type ResetResult struct {
+	Cleared map[string]int64 `json:"cleared"`
+	Seeded  map[string]int64 `json:"seeded"`
+}
+
+// demoWeatherSamples sampel cuaca per region simulasi: 3 titik per 3 jam terakhir
+const demoWeatherSamples = 3
+
+// demoWeatherHistory cuaca acak dalam rentang wajar dataran rendah Jawa Timur
+func demoWeatherHistory(regions []string, now time.Time, rng *rand.Rand) []WeatherHistoryRow {
+	var rows []WeatherHistoryRow
+	for _, region := range regions {
+		for i := 0; i < demoWeatherSamples; i++ {
+			temp := math.Round((24+rng.Float64()*7)*10) / 10
+			humidity := 60 + rng.Intn(21)
+			rain := math.Round(rng.Float64()*3*10) / 10
+			rows = append(rows, WeatherHistoryRow{
+				Region:    region,
+				TempC:     &temp,
+				Humidity:  &humidity,
+				RainMM:    &rain,
+				FetchedAt: formatFetchedAt(now.Add(-time.Duration(i*3) * time.Hour)),
+			})
+		}
+	}
+	return rows
+}
+
+// ResetDatabase mengosongkan data harga & cuaca; reseed=true mengisi harga simulasi
+// (basis SIM_PRICE_BASE, karena histori sudah kosong) dan sampel cuaca
+func ResetDatabase(reseed bool) (ResetResult, error) {
+	var prices []Price
+	var weather []WeatherHistoryRow
+	if reseed {
+		rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+		prices = simulatePrices(priceSimulationConfig, nil, rng)
+		weather = demoWeatherHistory(priceSimulationConfig.Regions, time.Now(), rng)
+	}
+
+	clearedPrices, clearedWeather, err := replaceAllData(prices, weather)
+	if err != nil {
+		return ResetResult{}, err
+	}
+	return ResetResult{
+		Cleared: map[string]int64{"prices": clearedPrices, "weather_history": clearedWeather},
+		Seeded:  map[string]int64{"prices": int64(len(prices)), "weather_history": int64(len(weather))},
+	}, nil
+}
+
+func ResetDatabaseHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		var req resetRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			if isBodyTooLarge(err) {
+				return errBodyTooLarge
+			}
+			return errInvalidBody.WithDetails(`body harus berupa {"confirm": true, "reseed": true}`)
+		}
+		if !req.Confirm {
+			return NewAPIError(http.StatusBadRequest, "confirmation_required",
+				`Reset akan MENGHAPUS semua data harga & cuaca; kirim ulang dengan {"confirm": true}`)
+		}
+
+		result, err := ResetDatabase(req.Reseed)
+		if err != nil {
+			return err
+		}
+		log.Printf("✓ Database direset: dihapus %v, diisi %v", result.Cleared, result.Seeded)
+
+		return respondJSON(w, http.StatusOK, map[string]interface{}{
+			"status":  "ok",
+			"cleared": result.Cleared,
+			"seeded":  result.Seeded,
+		})
+	})(w, r)
+}
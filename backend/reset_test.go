@@ -0,0 +1,90 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestResetDatabaseHandlerClearsThenReseeds(t *testing.T) {
+	prev := priceSimulationConfig
+	t.Cleanup(func() { priceSimulationConfig = prev })
+	priceSimulationConfig = PriceSimulationConfig{Regions: []string{"Jember", "Temanggung"}, BasePrice: 85000, Variance: 10000}
+
+	forEachStore(t, func(t *testing.T, ps PriceStore, ws WeatherStore) {
+		mustAdd(t, ps,
+			testPrice("Jember", 41000, "2026-03-01"),
+			testPrice("Jember", 42000, "2026-03-02"),
+			testPrice("Boyolali", 60000, "2026-03-02"),
+		)
+		ws.Add(WeatherHistoryRow{Region: "Jember", TempC: floatPtr(27), FetchedAt: "2026-03-02 06:00:00"})
+		ws.Add(WeatherHistoryRow{Region: "Boyolali", TempC: floatPtr(29), FetchedAt: "2026-03-02 07:00:00"})
+		flushWrites(t)
+
+		var result ResetResult
+		reset := func(body string, wantCleared, wantSeeded map[string]int64) {
+			t.Helper()
+			rec := serve(ResetDatabaseHandler, http.MethodPost, "/admin/reset", body)
+			decodeBody(t, rec, &result)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("POST /admin/reset %s = %d %s", body, rec.Code, rec.Body.String())
+			}
+			if !reflect.DeepEqual(result.Cleared, wantCleared) || !reflect.DeepEqual(result.Seeded, wantSeeded) {
+				t.Fatalf("reset %s = cleared %v seeded %v, ingin %v %v", body, result.Cleared, result.Seeded, wantCleared, wantSeeded)
+			}
+		}
+
+		reset(`{"confirm": true}`,
+			map[string]int64{"prices": 3, "weather_history": 2},
+			map[string]int64{"prices": 0, "weather_history": 0})
+		if snap := exportSnapshot(t); len(snap.Prices) != 0 || len(snap.WeatherHistory) != 0 {
+			t.Fatalf("setelah reset masih ada %d harga, %d cuaca", len(snap.Prices), len(snap.WeatherHistory))
+		}
+
+		reset(`{"confirm": true, "reseed": true}`,
+			map[string]int64{"prices": 0, "weather_history": 0},
+			map[string]int64{"prices": 2, "weather_history": 2 * demoWeatherSamples})
+		snap := exportSnapshot(t)
+		if len(snap.Prices) != 2 || len(snap.WeatherHistory) != 2*demoWeatherSamples {
+			t.Fatalf("setelah reseed %d harga, %d cuaca", len(snap.Prices), len(snap.WeatherHistory))
+		}
+		for _, p := range snap.Prices {
+			if p.SourceType != sourceTypeSimulation || p.Price < 75000 || p.Price > 95000 {
+				t.Fatalf("harga seed = %+v, ingin simulasi 85000±10000", p)
+			}
+		}
+		for _, row := range snap.WeatherHistory {
+			if row.TempC == nil || row.Humidity == nil || row.RainMM == nil {
+				t.Fatalf("sampel cuaca seed tidak lengkap: %+v", row)
+			}
+		}
+	})
+}
+
+func TestResetDatabaseHandlerRequiresConfirmation(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+	mustAdd(t, priceStore, testPrice("Jember", 41000, "2026-03-01"))
+
+	tests := []struct {
+		name string
+		body string
+		code string
+	}{
+		{"tanpa confirm", `{"reseed": true}`, "confirmation_required"},
+		{"confirm false", `{"confirm": false}`, "confirmation_required"},
+		{"bukan JSON", `confirm=true`, "invalid_body"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(ResetDatabaseHandler, http.MethodPost, "/admin/reset", tt.body)
+			var env struct{ Error APIError }
+			decodeBody(t, rec, &env)
+			if rec.Code != http.StatusBadRequest || env.Error.Code != tt.code {
+				t.Fatalf("status = %d %s, ingin 400 %s", rec.Code, rec.Body.String(), tt.code)
+			}
+		})
+	}
+	if page, _ := priceStore.GetAll(PriceQuery{Limit: 10}); page.Total != 1 {
+		t.Fatalf("reset ditolak tapi data berubah: %d harga", page.Total)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ============================================
+// DATA RETENTION
+// Job periodik yang menghapus weather_history (dan opsional prices) yang sudah tua.
+// Hapus per batch, satu transaksi per batch, supaya writer tunggal tidak tertahan
+// lama dan write lain (fetch cuaca, scraping) tetap bisa masuk di antaranya.
+// ============================================
+
+const (
+	defaultRetentionWeatherDays = 90
+	defaultRetentionBatchSize   = 500
+)
+
+// RetentionConfig Interval 0 = job nonaktif; PriceDays 0 = harga tidak pernah dihapus
+type RetentionConfig struct {
+	Interval    time.Duration
+	WeatherDays int
+	PriceDays   int
+	BatchSize   int
+}
+
+// retentionTarget satu tabel yang dibersihkan lewat store-nya
+type retentionTarget struct {
+	table  string
+	cutoff string
+	delete func(ctx context.Context, cutoff string, batchSize int) (int64, error)
+}
+
+// retentionTargets cutoff dihitung dari now. weather_history.fetched_at selalu UTC;
+// prices.recorded_at berasal dari banyak sumber (waktu lokal, tanggal saja dari CSV)
+// sehingga dibandingkan sebagai string dengan format yang sama - selisih zona waktu
+// tidak berarti untuk batas dalam hitungan hari
+func retentionTargets(cfg RetentionConfig, now time.Time) []retentionTarget {
+	targets := []retentionTarget{{
+		table:  "weather_history",
+		cutoff: formatFetchedAt(now.AddDate(0, 0, -cfg.WeatherDays)),
+		delete: weatherStore.DeleteFetchedBefore,
+	}}
+	if cfg.PriceDays > 0 {
+		targets = append(targets, retentionTarget{
+			table:  "prices",
+			cutoff: now.AddDate(0, 0, -cfg.PriceDays).Format(sqliteUTCLayout),
+			delete: priceStore.DeleteRecordedBefore,
+		})
+	}
+	return targets
+}
+
+// deleteOlderThan (SQLite) menghapus baris dengan column < cutoff per batchSize baris sampai habis
+func deleteOlderThan(ctx context.Context, table, column, cutoff string, batchSize int) (int64, error) {
+	query := fmt.Sprintf(`DELETE FROM %[1]s WHERE id IN (SELECT id FROM %[1]s WHERE %[2]s < ? LIMIT ?)`, table, column)
+
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		var affected int64
+		err := dbWriter.Tx(func(tx *sql.Tx) error {
+			res, err := tx.Exec(query, cutoff, batchSize)
+			if err != nil {
+				return err
+			}
+			affected, err = res.RowsAffected()
+			return err
+		})
+		if err != nil {
+			return total, err
+		}
+
+		total += affected
+		if affected < int64(batchSize) {
+			return total, nil
+		}
+	}
+}
+
+// RunRetention menjalankan satu putaran pembersihan untuk semua target
+func RunRetention(ctx context.Context, cfg RetentionConfig) (map[string]int64, error) {
+	removed := make(map[string]int64)
+	for _, t := range retentionTargets(cfg, time.Now()) {
+		n, err := t.delete(ctx, t.cutoff, cfg.BatchSize)
+		removed[t.table] = n
+		if err != nil {
+			return removed, fmt.Errorf("retention %s: %w", t.table, err)
+		}
+		if n > 0 {
+			log.Printf("🧹 Retention: %d baris %s sebelum %s dihapus", n, t.table, t.cutoff)
+		}
+	}
+	return removed, nil
+}
+
+// retentionJob membuat job scheduler dari cfg
+func retentionJob(cfg RetentionConfig) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		_, err := RunRetention(ctx, cfg)
+		return err
+	}
+}
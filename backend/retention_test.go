@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRunRetentionRemovesOnlyOldRows(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ps PriceStore, ws WeatherStore) {
+		now := time.Now()
+		// 5 sampel tua dengan batch 2: tiga putaran delete di SQLite
+		for i := 0; i < 5; i++ {
+			ws.Add(WeatherHistoryRow{Region: "Jember", TempC: floatPtr(25), FetchedAt: formatFetchedAt(now.AddDate(0, 0, -100-i))})
+		}
+		ws.Add(WeatherHistoryRow{Region: "Jember", TempC: floatPtr(27), FetchedAt: formatFetchedAt(now.AddDate(0, 0, -89))})
+		ws.Add(WeatherHistoryRow{Region: "Malang", TempC: floatPtr(28), FetchedAt: formatFetchedAt(now.Add(-time.Hour))})
+		mustAdd(t, ps,
+			testPrice("Jember", 30000, now.AddDate(0, 0, -400).Format("2006-01-02")),
+			testPrice("Jember", 41000, now.AddDate(0, 0, -100).Format("2006-01-02")),
+			testPrice("Jember", 42000, now.AddDate(0, 0, -1).Format("2006-01-02")),
+		)
+		flushWrites(t)
+
+		// PriceDays 0: harga tidak disentuh sama sekali
+		removed, err := RunRetention(context.Background(), RetentionConfig{WeatherDays: 90, BatchSize: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := map[string]int64{"weather_history": 5}; !reflect.DeepEqual(removed, want) {
+			t.Fatalf("RunRetention = %v, ingin %v", removed, want)
+		}
+		var kept []float64
+		ws.Each(func(r WeatherHistoryRow) error { kept = append(kept, *r.TempC); return nil })
+		if !reflect.DeepEqual(kept, []float64{27, 28}) {
+			t.Fatalf("sampel tersisa = %v, ingin hanya yang < 90 hari", kept)
+		}
+
+		removed, err = RunRetention(context.Background(), RetentionConfig{WeatherDays: 90, PriceDays: 365, BatchSize: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := map[string]int64{"weather_history": 0, "prices": 1}; !reflect.DeepEqual(removed, want) {
+			t.Fatalf("RunRetention dengan PriceDays = %v, ingin %v", removed, want)
+		}
+		page, _ := ps.GetAll(PriceQuery{Region: "Jember", Limit: 10})
+		if page.Total != 2 {
+			t.Fatalf("harga tersisa = %+v, ingin 2", page.Prices)
+		}
+		for _, p := range page.Prices {
+			if p.Price == 30000 {
+				t.Fatalf("harga 400 hari lalu tidak terhapus: %+v", p)
+			}
+		}
+	})
+}
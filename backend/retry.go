@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// ============================================
+// RETRY GENERIK
+// Satu implementasi retry untuk fetch cuaca, halaman scraper, write SQLite, dan pemanggil lain,
+// supaya aturan percobaan, jeda, dan pembatalan context tidak berbeda-beda.
+// ============================================
+
+// RetryWithResult memanggil fn sampai berhasil, percobaan habis (attempts total, 1 = tanpa
+// retry), atau error-nya tidak retryable (nil = semua error di-retry). backoff(n) = jeda
+// setelah percobaan ke-n gagal. Selalu mengembalikan nilai dari percobaan terakhir;
+// jika ctx selesai saat menunggu, error-nya ctx.Err().
+func RetryWithResult[T any](ctx context.Context, attempts int, backoff func(attempt int) time.Duration,
+	retryable func(error) bool, fn func() (T, error)) (T, error) {
+	for attempt := 1; ; attempt++ {
+		result, err := fn()
+		if err == nil || attempt >= attempts || (retryable != nil && !retryable(err)) {
+			return result, err
+		}
+		// fn gagal karena ctx sudah selesai: error aslinya lebih informatif dari ctx.Err()
+		if ctx.Err() != nil {
+			return result, err
+		}
+
+		if err := sleepContext(ctx, backoff(attempt)); err != nil {
+			return result, err
+		}
+	}
+}
+
+// sleepContext menunggu d atau sampai ctx selesai (mengembalikan ctx.Err())
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Retry RetryWithResult untuk fn tanpa nilai kembali
+func Retry(ctx context.Context, attempts int, backoff func(attempt int) time.Duration,
+	retryable func(error) bool, fn func() error) error {
+	_, err := RetryWithResult(ctx, attempts, backoff, retryable, func() (struct{}, error) {
+		return struct{}{}, fn()
+	})
+	return err
+}
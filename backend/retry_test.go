@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func noBackoff(int) time.Duration { return 0 }
+
+func TestRetryWithResultSucceedsAfterFailures(t *testing.T) {
+	calls := 0
+	got, err := RetryWithResult(context.Background(), 5, noBackoff, nil, func() (int, error) {
+		calls++
+		if calls < 3 {
+			return 0, errors.New("sementara")
+		}
+		return 42, nil
+	})
+	if err != nil || got != 42 || calls != 3 {
+		t.Fatalf("RetryWithResult = %d, %v setelah %d panggilan; ingin 42, nil, 3", got, err, calls)
+	}
+}
+
+func TestRetryWithResultExhaustsAttempts(t *testing.T) {
+	calls := 0
+	var delays []int
+	backoff := func(attempt int) time.Duration {
+		delays = append(delays, attempt)
+		return 0
+	}
+	_, err := RetryWithResult(context.Background(), 3, backoff, nil, func() (int, error) {
+		calls++
+		return calls, errors.New("gagal terus")
+	})
+	if err == nil || err.Error() != "gagal terus" || calls != 3 {
+		t.Fatalf("err = %v setelah %d panggilan; ingin error terakhir setelah 3", err, calls)
+	}
+	// Tidak ada jeda setelah percobaan terakhir
+	if len(delays) != 2 || delays[0] != 1 || delays[1] != 2 {
+		t.Fatalf("backoff dipanggil untuk %v, ingin [1 2]", delays)
+	}
+}
+
+func TestRetryStopsOnNonRetryableError(t *testing.T) {
+	errFatal := errors.New("404")
+	calls := 0
+	err := Retry(context.Background(), 5, noBackoff, func(err error) bool { return !errors.Is(err, errFatal) }, func() error {
+		calls++
+		return errFatal
+	})
+	if !errors.Is(err, errFatal) || calls != 1 {
+		t.Fatalf("err = %v setelah %d panggilan; ingin 404 setelah 1", err, calls)
+	}
+}
+
+func TestRetryCancelledDuringBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	done := make(chan error, 1)
+	go func() {
+		done <- Retry(ctx, 5, func(int) time.Duration { return time.Hour }, nil, func() error {
+			calls++
+			return errors.New("sementara")
+		})
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) || calls != 1 {
+			t.Fatalf("err = %v setelah %d panggilan; ingin context.Canceled setelah 1", err, calls)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Retry tidak berhenti saat context dibatalkan")
+	}
+}
+
+func TestRetryKeepsErrorWhenContextAlreadyDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	errFetch := errors.New("dial tcp: operation was canceled")
+	calls := 0
+	err := Retry(ctx, 5, noBackoff, nil, func() error {
+		calls++
+		return errFetch
+	})
+	if !errors.Is(err, errFetch) || calls != 1 {
+		t.Fatalf("err = %v setelah %d panggilan; ingin error fn setelah 1", err, calls)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// ============================================
+// BACKGROUND SCHEDULER
+// Menjalankan job periodik di satu goroutine. Run dijalankan berurutan
+// sehingga tidak pernah overlap; tick yang terlewat saat run lama dibuang.
+// ============================================
+
+type Scheduler struct {
+	name     string
+	interval time.Duration
+	job      func(ctx context.Context) error
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewScheduler(name string, interval time.Duration, job func(ctx context.Context) error) *Scheduler {
+	return &Scheduler{name: name, interval: interval, job: job}
+}
+
+// Start memulai loop ticker; run pertama terjadi setelah satu interval
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.runOnce(ctx)
+			}
+		}
+	}()
+
+	log.Printf("⏱️  Scheduler %s aktif (interval %s)", s.name, s.interval)
+}
+
+func (s *Scheduler) runOnce(ctx context.Context) {
+	start := time.Now()
+	err := s.job(ctx)
+	elapsed := time.Since(start)
+
+	switch {
+	case err != nil:
+		log.Printf("⚠️  Scheduler %s gagal setelah %s: %v", s.name, elapsed, err)
+	default:
+		log.Printf("✓ Scheduler %s selesai dalam %s", s.name, elapsed)
+	}
+	if elapsed > s.interval {
+		log.Printf("⚠️  Scheduler %s lebih lama dari interval (%s > %s), tick yang terlewat dilewati",
+			s.name, elapsed, s.interval)
+	}
+}
+
+// Stop membatalkan run yang sedang berjalan dan menunggu goroutine selesai
+func (s *Scheduler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+	log.Printf("✓ Scheduler %s berhenti", s.name)
+}
+
+// collectWeatherHistory membuat job yang mengambil cuaca tiap region secara berurutan
+// (hemat kuota OWM). FetchWeather sudah menyimpan ke weather_history secara async;
+// region yang masih ada di cache dilewati tanpa memanggil OWM.
+func collectWeatherHistory(regions []string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		failed := 0
+		for _, region := range regions {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if _, cached := weatherCache.Get(region); cached {
+				continue
+			}
+			data, err := FetchWeatherWithContext(ctx, region)
+			if err != nil {
+				failed++
+				log.Printf("⚠️  Weather history %s dilewati: %v", region, err)
+				continue
+			}
+			weatherCache.Set(region, data)
+		}
+		if failed == len(regions) {
+			return fmt.Errorf("semua %d region gagal diambil", failed)
+		}
+		return nil
+	}
+}
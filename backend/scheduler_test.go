@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSchedulerRunsPeriodicallyWithoutOverlap(t *testing.T) {
+	var runs, running, overlaps atomic.Int32
+	s := NewScheduler("uji", 5*time.Millisecond, func(ctx context.Context) error {
+		if running.Add(1) > 1 {
+			overlaps.Add(1)
+		}
+		defer running.Add(-1)
+		runs.Add(1)
+		time.Sleep(12 * time.Millisecond) // lebih lama dari interval
+		return errors.New("gagal juga tetap dijadwalkan ulang")
+	})
+	s.Start()
+
+	deadline := time.Now().Add(time.Second)
+	for runs.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	s.Stop()
+
+	if n := runs.Load(); n < 3 {
+		t.Fatalf("job berjalan %d kali dalam 1 detik, ingin >= 3", n)
+	}
+	if n := overlaps.Load(); n != 0 {
+		t.Fatalf("%d run overlap", n)
+	}
+}
+
+func TestSchedulerStopCancelsRunningJob(t *testing.T) {
+	started := make(chan struct{})
+	var cancelled atomic.Bool
+	s := NewScheduler("uji", time.Millisecond, func(ctx context.Context) error {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-ctx.Done()
+		cancelled.Store(true)
+		return ctx.Err()
+	})
+	s.Start()
+	<-started
+
+	done := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Stop tidak kembali saat job sedang berjalan")
+	}
+	if !cancelled.Load() {
+		t.Fatal("context job tidak dibatalkan oleh Stop")
+	}
+}
+
+func TestSchedulerStopWithoutStart(t *testing.T) {
+	NewScheduler("uji", time.Second, func(context.Context) error { return nil }).Stop()
+}
@@ -1,13 +1,20 @@
 package main
 
 import (
+    "context"
     "encoding/json"
+    "errors"
     "fmt"
+    "io"
     "log"
+    "math"
     "net/http"
+    neturl "net/url"
     "regexp"
+    "sort"
     "strconv"
     "strings"
+    "sync"
     "time"
 
     "github.com/PuerkitoBio/goquery"
@@ -16,53 +23,137 @@ import (
 // ScrapedPrice hasil scraping
 type ScrapedPrice struct {
     Region     string
-    Price      float64
+    Price      float64  // harga representatif (nilai tengah jika sumber berupa rentang)
+    PriceMin   *float64 // nil jika sumber hanya satu angka
+    PriceMax   *float64
     Quality    string
     Source     string
+    SourceType string   // nilai prices.source_type (sourceType*)
+    Commodity  string   // nama komoditas BAPPEBTI asal harga; kosong untuk sumber lain
     ScrapedAt  time.Time
     SourceURL  string
 }
 
-// TobaccoScraper interface untuk berbagai scraper
+// TobaccoScraper interface untuk berbagai scraper.
+// Scrape harus berhenti saat ctx dibatalkan (request timeout / client putus).
 type TobaccoScraper interface {
-    Scrape() ([]ScrapedPrice, error)
+    Scrape(ctx context.Context) ([]ScrapedPrice, error)
     GetName() string
 }
 
+// Identitas & laju request scraper. User-Agent jujur (bukan menyamar browser) supaya
+// pengelola situs sumber bisa menghubungi kita alih-alih langsung memblokir IP.
+const (
+    defaultScraperUserAgent   = "TobaccoTrack/1.0 (+https://github.com/anggaa990/FangPro)"
+    defaultScraperPoliteDelay = 2 * time.Second
+)
+
+// scraperHTTPClient client bersama semua scraper (sharedHTTPClient + SCRAPER_USER_AGENT),
+// diganti saat startup lewat applyConfig
+var scraperHTTPClient = withUserAgent(sharedHTTPClient, defaultScraperUserAgent)
+
+// scraperPoliteDelay jeda antar request ke situs yang sama (env SCRAPER_POLITE_DELAY)
+var scraperPoliteDelay = defaultScraperPoliteDelay
+
 // BAPPEBTIScraper - scrape dari BAPPEBTI Info Harga
 type BAPPEBTIScraper struct {
-    BaseURL string
+    BaseURL     string
+    Commodities []string // nama komoditas persis seperti di BAPPEBTI, mis. "TEMBAKAU BURLEY"
+    Client      *http.Client
+    Delay       time.Duration // jeda antar halaman komoditas
 }
 
+// defaultBAPPEBTICommodities varietas tembakau yang dipantau jika BAPPEBTI_COMMODITIES kosong
+var defaultBAPPEBTICommodities = []string{"TEMBAKAU BOYOLALI", "TEMBAKAU BURLEY", "TEMBAKAU KASTURI"}
+
+// bappebtiCommodities diisi dari Config.BAPPEBTICommodities (env BAPPEBTI_COMMODITIES)
+var bappebtiCommodities = defaultBAPPEBTICommodities
+
 func NewBAPPEBTIScraper() *BAPPEBTIScraper {
     return &BAPPEBTIScraper{
-        BaseURL: "https://infoharga.bappebti.go.id",
+        BaseURL:     "https://infoharga.bappebti.go.id",
+        Commodities: bappebtiCommodities,
+        Client:      scraperHTTPClient,
+        Delay:       scraperPoliteDelay,
     }
 }
 
-func (s *BAPPEBTIScraper) GetName() string {
-    return "BAPPEBTI Info Harga"
+// commodityURL URL halaman harga pedagang untuk satu komoditas. Spasi di-encode
+// sebagai %20 (bukan "+") mengikuti URL yang dipakai situs BAPPEBTI sendiri.
+func (s *BAPPEBTIScraper) commodityURL(commodity string) string {
+    escaped := strings.ReplaceAll(neturl.QueryEscape(commodity), "+", "%20")
+    return s.BaseURL + "/harga_komoditi_pedagang?komoditi=" + escaped
+}
+
+// scraperFetchAttempts percobaan per halaman (1 + 2 retry) untuk error jaringan, 5xx & 429
+const scraperFetchAttempts = 3
+
+// scraperStatusError status HTTP dari situs sumber yang layak dicoba ulang
+type scraperStatusError struct {
+    URL        string
+    StatusCode int
+}
+
+func (e *scraperStatusError) Error() string {
+    return fmt.Sprintf("GET %s: status %d", e.URL, e.StatusCode)
 }
 
-func (s *BAPPEBTIScraper) Scrape() ([]ScrapedPrice, error) {
-    // BAPPEBTI memiliki endpoint untuk tembakau
-    urls := []string{
-        s.BaseURL + "/harga_komoditi_pedagang?komoditi=TEMBAKAU%20BOYOLALI",
-        s.BaseURL + "/harga_komoditi_pedagang?komoditi=TEMBAKAU%20BURLEY",
-        s.BaseURL + "/harga_komoditi_pedagang?komoditi=TEMBAKAU%20KASTURI",
+// fetchPage GET satu halaman dengan retry. Jeda antar percobaan memakai s.Delay yang
+// digandakan tiap kali gagal, supaya situs yang sedang kewalahan tidak makin dibebani.
+// Status lain (mis. 404) dikembalikan apa adanya seperti sebelumnya.
+func (s *BAPPEBTIScraper) fetchPage(ctx context.Context, url string) (*http.Response, error) {
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+    if err != nil {
+        return nil, err
     }
 
+    var resp *http.Response
+    backoff := func(attempt int) time.Duration { return s.Delay << (attempt - 1) }
+    err = Retry(ctx, scraperFetchAttempts, backoff, nil, func() error {
+        r, err := s.Client.Do(req)
+        if err != nil {
+            return err
+        }
+        if r.StatusCode >= 500 || r.StatusCode == http.StatusTooManyRequests {
+            io.Copy(io.Discard, r.Body)
+            r.Body.Close()
+            return &scraperStatusError{URL: url, StatusCode: r.StatusCode}
+        }
+        resp = r
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    return resp, nil
+}
+
+func (s *BAPPEBTIScraper) GetName() string {
+    return "BAPPEBTI Info Harga"
+}
+
+func (s *BAPPEBTIScraper) Scrape(ctx context.Context) ([]ScrapedPrice, error) {
     var prices []ScrapedPrice
 
-    for _, url := range urls {
-        resp, err := http.Get(url)
+    // BAPPEBTI memiliki satu halaman per komoditas
+    for i, commodity := range s.Commodities {
+        if i > 0 {
+            if err := sleepContext(ctx, s.Delay); err != nil {
+                return nil, err
+            }
+        }
+        url := s.commodityURL(commodity)
+        resp, err := s.fetchPage(ctx, url)
+        if ctx.Err() != nil {
+            return nil, ctx.Err()
+        }
         if err != nil {
             log.Printf("Error fetching %s: %v", url, err)
             continue
         }
-        defer resp.Body.Close()
 
         doc, err := goquery.NewDocumentFromReader(resp.Body)
+        resp.Body.Close()
         if err != nil {
             log.Printf("Error parsing HTML: %v", err)
             continue
@@ -78,17 +169,23 @@ func (s *BAPPEBTIScraper) Scrape() ([]ScrapedPrice, error) {
             region := strings.TrimSpace(cols.Eq(1).Text())
             priceStr := strings.TrimSpace(cols.Eq(2).Text())
             
-            // Extract angka dari string harga
-            price := extractPrice(priceStr)
-            if price > 0 {
-                prices = append(prices, ScrapedPrice{
-                    Region:    region,
-                    Price:     price,
-                    Quality:   "Standard",
-                    Source:    s.GetName(),
-                    ScrapedAt: time.Now(),
-                    SourceURL: url,
-                })
+            // Extract angka dari string harga (bisa berupa rentang "80.000 - 90.000")
+            pr, ok := extractPriceRange(priceStr)
+            if ok && pr.Mid > 0 {
+                scraped := ScrapedPrice{
+                    Region:     region,
+                    Price:      pr.Mid,
+                    Quality:    "Standard",
+                    Source:     s.GetName(),
+                    SourceType: sourceTypeBAPPEBTI,
+                    Commodity:  commodity,
+                    ScrapedAt:  time.Now(),
+                    SourceURL:  url,
+                }
+                if pr.IsRange() {
+                    scraped.PriceMin, scraped.PriceMax = &pr.Min, &pr.Max
+                }
+                prices = append(prices, scraped)
             }
         })
     }
@@ -96,14 +193,49 @@ func (s *BAPPEBTIScraper) Scrape() ([]ScrapedPrice, error) {
     return prices, nil
 }
 
+// BreakerScraper membungkus scraper dengan circuit breaker: selama open, Scrape langsung
+// gagal sehingga ScraperManager pindah ke fallback tanpa menunggu semua URL timeout.
+// Hasil kosong dihitung gagal karena BAPPEBTI tidak mengembalikan error per URL.
+type BreakerScraper struct {
+    TobaccoScraper
+    Breaker *CircuitBreaker
+}
+
+func (s *BreakerScraper) Scrape(ctx context.Context) ([]ScrapedPrice, error) {
+    if err := s.Breaker.Allow(); err != nil {
+        return nil, err
+    }
+
+    prices, err := s.TobaccoScraper.Scrape(ctx)
+    switch {
+    case ctx.Err() != nil:
+        s.Breaker.Ignore() // dibatalkan oleh request, bukan salah upstream
+    case err != nil || len(prices) == 0:
+        s.Breaker.Failure()
+    default:
+        s.Breaker.Success()
+    }
+    return prices, err
+}
+
+// defaultBAPPEBTIBreakerConfig 3 run gagal beruntun -> BAPPEBTI dilewati selama 5 menit
+var defaultBAPPEBTIBreakerConfig = BreakerConfig{Threshold: 3, Cooldown: 5 * time.Minute}
+
+// bappebtiBreaker dipakai bersama semua ScraperManager (dibuat ulang tiap fetch)
+var bappebtiBreaker = NewCircuitBreaker("bappebti", defaultBAPPEBTIBreakerConfig)
+
 // NewsPortalScraper - scrape dari portal berita (backup method)
 type NewsPortalScraper struct {
     Keywords []string
+    Regions  []string
+    Client   *http.Client
 }
 
 func NewNewsPortalScraper() *NewsPortalScraper {
     return &NewsPortalScraper{
         Keywords: []string{"harga tembakau", "tobacco price"},
+        Regions:  []string{"Jember", "Temanggung", "Lombok", "Klaten", "Pamekasan", "Boyolali", "Madura", "Bojonegoro"},
+        Client:   withClientTimeout(scraperHTTPClient, 10*time.Second),
     }
 }
 
@@ -111,35 +243,102 @@ func (s *NewsPortalScraper) GetName() string {
     return "News Portal Scraper"
 }
 
-func (s *NewsPortalScraper) Scrape() ([]ScrapedPrice, error) {
+// Harga di luar rentang ini hampir pasti salah parse (bukan Rp/kg tembakau)
+const (
+    minPlausibleNewsPrice = 10000
+    maxPlausibleNewsPrice = 1000000
+)
+
+var rupiahAmountRe = regexp.MustCompile(`(?i)rp\.?\s*[\d.,]+`)
+
+// newsSnippetSelectors - blok hasil pencarian Google News (dicoba berurutan)
+var newsSnippetSelectors = []string{"div.SoaBEf", "div.Gx5Zad", "article", "div.g"}
+
+func (s *NewsPortalScraper) Scrape(ctx context.Context) ([]ScrapedPrice, error) {
     // Menggunakan Google Search untuk cari artikel terbaru tentang harga tembakau
     // Kemudian extract harga dari artikel tersebut
     
     query := "harga+tembakau+hari+ini+jember+temanggung"
     searchURL := fmt.Sprintf("https://www.google.com/search?q=%s&tbm=nws", query)
     
-    // Note: Google search User-Agent
-    client := &http.Client{
-        Timeout: 10 * time.Second,
-    }
-    
-    req, err := http.NewRequest("GET", searchURL, nil)
+    // User-Agent diisi scraperHTTPClient
+    req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
     if err != nil {
         return nil, err
     }
     
-    req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
-    
-    resp, err := client.Do(req)
+    resp, err := s.Client.Do(req)
     if err != nil {
         return nil, err
     }
     defer resp.Body.Close()
 
-    // Parse results dan extract harga
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("news portal returned status %d (kemungkinan request diblokir)", resp.StatusCode)
+    }
+
     // Ini adalah fallback method jika BAPPEBTI tidak tersedia
-    
-    return []ScrapedPrice{}, nil
+    return s.parseResults(resp.Body, searchURL)
+}
+
+// parseResults mengambil snippet artikel lalu mencari pasangan region + harga di dalamnya
+func (s *NewsPortalScraper) parseResults(body io.Reader, sourceURL string) ([]ScrapedPrice, error) {
+    doc, err := goquery.NewDocumentFromReader(body)
+    if err != nil {
+        return nil, fmt.Errorf("gagal parsing HTML berita: %w", err)
+    }
+
+    var snippets []string
+    for _, selector := range newsSnippetSelectors {
+        doc.Find(selector).Each(func(i int, sel *goquery.Selection) {
+            if text := strings.Join(strings.Fields(sel.Text()), " "); text != "" {
+                snippets = append(snippets, text)
+            }
+        })
+        if len(snippets) > 0 {
+            break
+        }
+    }
+
+    var prices []ScrapedPrice
+    for _, snippet := range snippets {
+        region := s.matchRegion(snippet)
+        if region == "" {
+            continue
+        }
+
+        for _, amount := range rupiahAmountRe.FindAllString(snippet, -1) {
+            price := extractPrice(amount)
+            if price < minPlausibleNewsPrice || price > maxPlausibleNewsPrice {
+                continue
+            }
+            prices = append(prices, ScrapedPrice{
+                Region:     region,
+                Price:      price,
+                Quality:    "Low Confidence (news snippet)",
+                Source:     s.GetName(),
+                SourceType: sourceTypeNews,
+                ScrapedAt:  time.Now(),
+                SourceURL:  sourceURL,
+            })
+            break // satu harga per snippet
+        }
+    }
+
+    return DistinctBy(prices, func(p ScrapedPrice) string {
+        return strings.ToLower(p.Region)
+    }), nil
+}
+
+// matchRegion mencari nama region pertama yang disebut di teks (case-insensitive)
+func (s *NewsPortalScraper) matchRegion(text string) string {
+    lower := strings.ToLower(text)
+    for _, region := range s.Regions {
+        if strings.Contains(lower, strings.ToLower(region)) {
+            return region
+        }
+    }
+    return ""
 }
 
 // MockScraperWithRealData - Menggunakan data real dari hasil riset manual
@@ -196,7 +395,7 @@ func (s *MockScraperWithRealData) GetName() string {
     return "Real Data Research + Market Simulation"
 }
 
-func (s *MockScraperWithRealData) Scrape() ([]ScrapedPrice, error) {
+func (s *MockScraperWithRealData) Scrape(ctx context.Context) ([]ScrapedPrice, error) {
     var prices []ScrapedPrice
     
     for region, research := range s.LastResearch {
@@ -207,47 +406,207 @@ func (s *MockScraperWithRealData) Scrape() ([]ScrapedPrice, error) {
         currentPrice := research.BasePrice * dailyFactor
         
         prices = append(prices, ScrapedPrice{
-            Region:    region,
-            Price:     currentPrice,
-            Quality:   "Standard",
-            Source:    fmt.Sprintf("%s (Last checked: %s)", research.Source, research.DateChecked.Format("2006-01-02")),
-            ScrapedAt: time.Now(),
-            SourceURL: "Manual Research + Market Data",
+            Region:     region,
+            Price:      currentPrice,
+            Quality:    "Standard",
+            Source:     fmt.Sprintf("%s (Last checked: %s)", research.Source, research.DateChecked.Format("2006-01-02")),
+            SourceType: sourceTypeResearch,
+            ScrapedAt:  time.Now(),
+            SourceURL:  "Manual Research + Market Data",
         })
     }
     
     return prices, nil
 }
 
+// ScraperSourceStatus status terakhir satu scraper
+type ScraperSourceStatus struct {
+    Name           string     `json:"name"`
+    Priority       int        `json:"priority"`
+    LastAttempt    *time.Time `json:"last_attempt,omitempty"`
+    LastSuccess    *time.Time `json:"last_success,omitempty"`
+    SuccessAgo     string     `json:"success_ago,omitempty"`
+    LastPriceCount int        `json:"last_price_count"`
+    LastError      string     `json:"last_error,omitempty"`
+}
+
+// ScrapeRunStatus ringkasan run ScrapeAll terakhir
+type ScrapeRunStatus struct {
+    At           time.Time `json:"at"`
+    Source       string    `json:"source"`
+    PriceCount   int       `json:"price_count"`
+    FromFallback bool      `json:"from_fallback"`
+}
+
+// ScrapeStatusTracker mencatat kapan tiap scraper terakhir berhasil (in-memory)
+type ScrapeStatusTracker struct {
+    mu      sync.RWMutex
+    sources map[string]*ScraperSourceStatus
+    lastRun *ScrapeRunStatus
+}
+
+func NewScrapeStatusTracker() *ScrapeStatusTracker {
+    return &ScrapeStatusTracker{
+        sources: make(map[string]*ScraperSourceStatus),
+    }
+}
+
+func (t *ScrapeStatusTracker) source(name string, priority int) *ScraperSourceStatus {
+    st, ok := t.sources[name]
+    if !ok {
+        st = &ScraperSourceStatus{Name: name}
+        t.sources[name] = st
+    }
+    st.Priority = priority
+    return st
+}
+
+func (t *ScrapeStatusTracker) RecordAttempt(name string, priority, count int, err error) {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    now := time.Now()
+    st := t.source(name, priority)
+    st.LastAttempt = &now
+    if err != nil {
+        st.LastError = err.Error()
+        return
+    }
+    if count == 0 {
+        st.LastError = "tidak ada data harga"
+        return
+    }
+    st.LastSuccess = &now
+    st.LastPriceCount = count
+    st.LastError = ""
+}
+
+func (t *ScrapeStatusTracker) RecordRun(source string, count int, fromFallback bool) {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+    t.lastRun = &ScrapeRunStatus{
+        At:           time.Now(),
+        Source:       source,
+        PriceCount:   count,
+        FromFallback: fromFallback,
+    }
+}
+
+// Snapshot salinan status, diurutkan berdasarkan prioritas scraper
+func (t *ScrapeStatusTracker) Snapshot() ([]ScraperSourceStatus, *ScrapeRunStatus) {
+    t.mu.RLock()
+    defer t.mu.RUnlock()
+
+    sources := make([]ScraperSourceStatus, 0, len(t.sources))
+    for _, st := range t.sources {
+        copied := *st
+        if copied.LastSuccess != nil {
+            copied.SuccessAgo = time.Since(*copied.LastSuccess).Round(time.Second).String()
+        }
+        sources = append(sources, copied)
+    }
+    sort.Slice(sources, func(i, j int) bool {
+        return sources[i].Priority < sources[j].Priority
+    })
+
+    var lastRun *ScrapeRunStatus
+    if t.lastRun != nil {
+        copied := *t.lastRun
+        lastRun = &copied
+    }
+    return sources, lastRun
+}
+
+var scrapeStatus = NewScrapeStatusTracker()
+
+// ScrapeMode menentukan cara ScraperManager memakai hasil dari beberapa scraper
+type ScrapeMode string
+
+const (
+    // ScrapeModeFirstSuccess: berhenti di scraper pertama yang mengembalikan data
+    ScrapeModeFirstSuccess ScrapeMode = "first"
+    // ScrapeModeMerge: gabungkan semua scraper; region yang belum ada diisi
+    // dari scraper prioritas lebih rendah
+    ScrapeModeMerge ScrapeMode = "merge"
+)
+
+func ParseScrapeMode(raw string) (ScrapeMode, error) {
+    switch mode := ScrapeMode(strings.ToLower(strings.TrimSpace(raw))); mode {
+    case ScrapeModeFirstSuccess, ScrapeModeMerge:
+        return mode, nil
+    default:
+        return "", fmt.Errorf("scrape mode harus %q atau %q, didapat %q", ScrapeModeFirstSuccess, ScrapeModeMerge, raw)
+    }
+}
+
+// scrapeMode diisi dari Config.ScrapeMode (env SCRAPE_MODE)
+var scrapeMode = ScrapeModeFirstSuccess
+
 // ScraperManager mengelola multiple scrapers dengan fallback
 type ScraperManager struct {
     Scrapers []TobaccoScraper
+    Status   *ScrapeStatusTracker
+    Mode     ScrapeMode
 }
 
 func NewScraperManager() *ScraperManager {
     return &ScraperManager{
         Scrapers: []TobaccoScraper{
-            NewBAPPEBTIScraper(),           // Primary: BAPPEBTI
+            &BreakerScraper{TobaccoScraper: NewBAPPEBTIScraper(), Breaker: bappebtiBreaker}, // Primary: BAPPEBTI
             NewMockScraperWithRealData(),   // Fallback: Manual research
         },
+        Status: scrapeStatus,
+        Mode:   scrapeMode,
     }
 }
 
 func (sm *ScraperManager) ScrapeAll() ([]ScrapedPrice, error) {
+    return sm.ScrapeAllWithContext(context.Background())
+}
+
+// ScrapeAllWithContext - ScrapeAll yang berhenti mencoba scraper berikutnya saat ctx selesai
+func (sm *ScraperManager) ScrapeAllWithContext(ctx context.Context) ([]ScrapedPrice, error) {
     var allPrices []ScrapedPrice
+    covered := make(map[string]bool)
+    var contributors []string
+    fromFallback := false
     
-    for _, scraper := range sm.Scrapers {
+    for i, scraper := range sm.Scrapers {
+        if err := ctx.Err(); err != nil {
+            return nil, err
+        }
+
         log.Printf("Trying scraper: %s", scraper.GetName())
         
-        prices, err := scraper.Scrape()
+        prices, err := scraper.Scrape(ctx)
+        if sm.Status != nil {
+            sm.Status.RecordAttempt(scraper.GetName(), i, len(prices), err)
+        }
         if err != nil {
             log.Printf("Scraper %s failed: %v", scraper.GetName(), err)
             continue
         }
         
-        if len(prices) > 0 {
-            log.Printf("Scraper %s returned %d prices", scraper.GetName(), len(prices))
-            allPrices = append(allPrices, prices...)
+        if len(prices) == 0 {
+            continue
+        }
+
+        log.Printf("Scraper %s returned %d prices", scraper.GetName(), len(prices))
+
+        // Hanya ambil region yang belum diisi scraper prioritas lebih tinggi
+        fresh := Filter(prices, func(p ScrapedPrice) bool {
+            return !covered[strings.ToLower(p.Region)]
+        })
+        for _, p := range fresh {
+            covered[strings.ToLower(p.Region)] = true
+        }
+        if len(fresh) > 0 {
+            allPrices = append(allPrices, fresh...)
+            contributors = append(contributors, scraper.GetName())
+            fromFallback = fromFallback || i > 0
+        }
+
+        if sm.Mode != ScrapeModeMerge {
             break // Use first successful scraper
         }
     }
@@ -255,28 +614,147 @@ func (sm *ScraperManager) ScrapeAll() ([]ScrapedPrice, error) {
     if len(allPrices) == 0 {
         return nil, fmt.Errorf("all scrapers failed")
     }
+
+    if sm.Status != nil {
+        sm.Status.RecordRun(strings.Join(contributors, " + "), len(allPrices), fromFallback)
+    }
     
     return allPrices, nil
 }
 
-// Helper: Extract price dari string
-func extractPrice(s string) float64 {
-    // Remove non-numeric characters except dots
-    re := regexp.MustCompile(`[^\d.]`)
-    cleaned := re.ReplaceAllString(s, "")
-    
-    price, err := strconv.ParseFloat(cleaned, 64)
+// ErrRegionNotScraped tidak ada scraper yang punya harga untuk region tersebut
+var ErrRegionNotScraped = errors.New("region not found in scraped data")
+
+// ScrapeRegion mencoba scraper sesuai prioritas sampai salah satu punya harga region.
+// fromFallback true jika harga bukan dari scraper utama (mis. data riset manual).
+func (sm *ScraperManager) ScrapeRegion(ctx context.Context, region string) (price ScrapedPrice, fromFallback bool, err error) {
+    region = NormalizeRegion(region)
+
+    for i, scraper := range sm.Scrapers {
+        if err := ctx.Err(); err != nil {
+            return ScrapedPrice{}, false, err
+        }
+
+        prices, err := scraper.Scrape(ctx)
+        if sm.Status != nil {
+            sm.Status.RecordAttempt(scraper.GetName(), i, len(prices), err)
+        }
+        if err != nil {
+            log.Printf("Scraper %s failed: %v", scraper.GetName(), err)
+            continue
+        }
+
+        if found, ok := findScrapedRegion(prices, region).Get(); ok {
+            return found, i > 0, nil
+        }
+    }
+
+    return ScrapedPrice{}, false, ErrRegionNotScraped
+}
+
+var (
+    numberTokenRe    = regexp.MustCompile(`\d[\d.,]*\d|\d`)
+    dotThousandsRe   = regexp.MustCompile(`^\d{1,3}(\.\d{3})+$`)
+    commaThousandsRe = regexp.MustCompile(`^\d{1,3}(,\d{3})+$`)
+    // Pemisah rentang yang umum: "-", "–", "s/d", "sampai", "hingga", "~", "to"
+    rangeSeparatorRe = regexp.MustCompile(`(?i)^\s*(-|–|—|~|s/d|s\.d\.?|sampai|hingga|to)\s*(rp\.?)?\s*$`)
+)
+
+// parseIndonesianNumber mengubah "85.000", "85.000,50", "85,000" atau "85000" menjadi angka.
+// Format Indonesia (titik = ribuan, koma = desimal) diutamakan.
+func parseIndonesianNumber(token string) (float64, bool) {
+    hasDot := strings.Contains(token, ".")
+    hasComma := strings.Contains(token, ",")
+
+    normalized := token
+    switch {
+    case hasDot && hasComma:
+        // Separator yang muncul terakhir adalah desimal
+        if strings.LastIndex(token, ",") > strings.LastIndex(token, ".") {
+            normalized = strings.ReplaceAll(strings.ReplaceAll(token, ".", ""), ",", ".")
+        } else {
+            normalized = strings.ReplaceAll(token, ",", "")
+        }
+    case hasDot:
+        if dotThousandsRe.MatchString(token) {
+            normalized = strings.ReplaceAll(token, ".", "")
+        }
+    case hasComma:
+        if commaThousandsRe.MatchString(token) {
+            normalized = strings.ReplaceAll(token, ",", "")
+        } else {
+            normalized = strings.ReplaceAll(token, ",", ".")
+        }
+    }
+
+    value, err := strconv.ParseFloat(normalized, 64)
     if err != nil {
+        return 0, false
+    }
+    return value, true
+}
+
+// Helper: Extract price dari string (angka pertama, format Indonesia)
+func extractPrice(s string) float64 {
+    token := numberTokenRe.FindString(s)
+    if token == "" {
+        return 0
+    }
+
+    price, ok := parseIndonesianNumber(token)
+    if !ok {
         return 0
     }
     
     return price
 }
 
+// PriceRange hasil parsing sel harga; Min == Max jika hanya satu angka
+type PriceRange struct {
+    Min float64
+    Max float64
+    Mid float64
+}
+
+func (pr PriceRange) IsRange() bool {
+    return pr.Min != pr.Max
+}
+
+// extractPriceRange memahami sel seperti "80.000 - 90.000" atau "Rp 80.000 s/d Rp 90.000".
+// ok=false jika tidak ada angka yang bisa dibaca.
+func extractPriceRange(s string) (PriceRange, bool) {
+    locs := numberTokenRe.FindAllStringIndex(s, -1)
+    if len(locs) == 0 {
+        return PriceRange{}, false
+    }
+
+    first, ok := parseIndonesianNumber(s[locs[0][0]:locs[0][1]])
+    if !ok {
+        return PriceRange{}, false
+    }
+    pr := PriceRange{Min: first, Max: first, Mid: first}
+
+    // Angka kedua hanya dianggap batas atas jika dipisah oleh separator rentang
+    if len(locs) >= 2 && rangeSeparatorRe.MatchString(s[locs[0][1]:locs[1][0]]) {
+        second, ok := parseIndonesianNumber(s[locs[1][0]:locs[1][1]])
+        if !ok {
+            return PriceRange{}, false
+        }
+        pr.Min, pr.Max = math.Min(first, second), math.Max(first, second)
+        pr.Mid = (pr.Min + pr.Max) / 2
+    }
+
+    return pr, true
+}
+
 // AutoFetchPricesFromScraper - fungsi utama untuk fetch via scraping
 func AutoFetchPricesFromScraper() error {
+    return AutoFetchPricesFromScraperWithContext(context.Background())
+}
+
+func AutoFetchPricesFromScraperWithContext(ctx context.Context) error {
     manager := NewScraperManager()
-    prices, err := manager.ScrapeAll()
+    prices, err := manager.ScrapeAllWithContext(ctx)
     if err != nil {
         return err
     }
@@ -294,17 +772,75 @@ func AutoFetchPricesFromScraper() error {
     return nil
 }
 
+// FetchPricesWithFallback scraping dulu, jika gagal pakai harga simulasi.
+// Dipakai scheduler periodik; POST /harga/fetch memakai FetchPricesFromSources.
+func FetchPricesWithFallback(ctx context.Context) error {
+    if err := AutoFetchPricesFromScraperWithContext(ctx); err != nil {
+        // Jangan fallback jika request sudah timeout / dibatalkan
+        if ctxErr := ctx.Err(); ctxErr != nil {
+            return ctxErr
+        }
+        log.Printf("Scraping failed, fallback to simulation: %v", err)
+        return AutoFetchPrices()
+    }
+    return nil
+}
+
+// scrapedToPrice mengubah hasil scraping menjadi baris Price yang akan disimpan
+func scrapedToPrice(data ScrapedPrice) Price {
+    sourceType := data.SourceType
+    if sourceType == "" {
+        sourceType = sourceTypeUnknown
+    }
+    optional := func(s string) *string {
+        if s == "" {
+            return nil
+        }
+        return &s
+    }
+    scrapedAt := data.ScrapedAt.Format("2006-01-02 15:04:05")
+    return Price{
+        Region:     NormalizeRegion(data.Region),
+        Price:      data.Price,
+        PriceMin:   data.PriceMin,
+        PriceMax:   data.PriceMax,
+        Unit:       "kg",
+        Source:     fmt.Sprintf("%s (Scraped: %s)", data.Source, data.Quality),
+        SourceType: sourceType,
+        Quality:    optional(data.Quality),
+        SourceName: optional(data.Source),
+        SourceURL:  optional(data.SourceURL),
+        ScrapedAt:  &scrapedAt,
+        RecordedAt: scrapedAt,
+    }
+}
+
 // SaveScrapedPrice simpan hasil scraping ke database
 func SaveScrapedPrice(data ScrapedPrice) error {
-    _, err := DB.Exec(`INSERT INTO prices (region, price, unit, source, recorded_at) 
-        VALUES (?, ?, ?, ?, ?)`,
-        data.Region,
-        data.Price,
-        "kg",
-        fmt.Sprintf("%s (Scraped: %s)", data.Source, data.Quality),
-        data.ScrapedAt.Format("2006-01-02 15:04:05"),
-    )
-    return err
+    saved, err := priceStore.Add(scrapedToPrice(data))
+    if err != nil {
+        return err
+    }
+
+    priceBroker.Publish(saved)
+    return nil
+}
+
+// PreviewScrapedPrices - varian dry-run dari AutoFetchPricesFromScraper (tidak ada insert)
+func PreviewScrapedPrices(ctx context.Context) ([]Price, error) {
+    prices, err := NewScraperManager().ScrapeAllWithContext(ctx)
+    if err != nil {
+        return nil, err
+    }
+    return Map(prices, scrapedToPrice), nil
+}
+
+// findScrapedRegion harga pertama untuk region (dibandingkan setelah normalisasi)
+func findScrapedRegion(prices []ScrapedPrice, region string) Option[ScrapedPrice] {
+    region = NormalizeRegion(region)
+    return Find(prices, func(p ScrapedPrice) bool {
+        return NormalizeRegion(p.Region) == region
+    })
 }
 
 // GetScrapedPriceJSON untuk API endpoint preview
@@ -315,13 +851,14 @@ func GetScrapedPriceJSON(region string) (string, error) {
         return "", err
     }
     
-    // Find specific region
-    for _, price := range prices {
-        if strings.EqualFold(price.Region, region) {
-            jsonData, _ := json.Marshal(price)
-            return string(jsonData), nil
-        }
+    price, ok := findScrapedRegion(prices, region).Get()
+    if !ok {
+        return "", ErrRegionNotScraped
     }
-    
-    return "", fmt.Errorf("region not found in scraped data")
+
+    jsonData, err := json.Marshal(price)
+    if err != nil {
+        return "", err
+    }
+    return string(jsonData), nil
 }
\ No newline at end of file
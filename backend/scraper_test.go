@@ -0,0 +1,543 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const bappebtiTestPage = `<table><tbody>
+<tr><td>1</td><td>Jember</td><td>40.000 - 44.000</td><td>kg</td></tr>
+</tbody></table>`
+
+func TestBAPPEBTIScraperRetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			http.Error(w, "sibuk", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(bappebtiTestPage))
+	}))
+	defer srv.Close()
+
+	s := &BAPPEBTIScraper{BaseURL: srv.URL, Commodities: []string{"TEMBAKAU BURLEY"}, Client: srv.Client(), Delay: time.Millisecond}
+	prices, err := s.Scrape(context.Background())
+	if err != nil || len(prices) != 1 || prices[0].Price != 42000 {
+		t.Fatalf("Scrape = %+v, %v", prices, err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("%d request, ingin 3 (2 gagal + 1 berhasil)", n)
+	}
+}
+
+func TestBAPPEBTIScraperGivesUpAfterAttempts(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	s := &BAPPEBTIScraper{BaseURL: srv.URL, Commodities: []string{"TEMBAKAU BURLEY"}, Client: srv.Client(), Delay: time.Millisecond}
+	prices, err := s.Scrape(context.Background())
+	if err != nil || len(prices) != 0 {
+		t.Fatalf("Scrape = %+v, %v; ingin kosong tanpa error", prices, err)
+	}
+	if n := calls.Load(); n != scraperFetchAttempts {
+		t.Fatalf("%d request, ingin %d", n, scraperFetchAttempts)
+	}
+}
+
+// roundTripFunc RoundTripper palsu: request tidak pernah keluar ke jaringan
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// useBAPPEBTITransport mengarahkan NewScraperManager ke transport palsu dengan
+// breaker, status, dan mode scraping yang bersih
+func useBAPPEBTITransport(t *testing.T, rt roundTripFunc) {
+	t.Helper()
+	prevClient, prevDelay, prevBreaker := scraperHTTPClient, scraperPoliteDelay, bappebtiBreaker
+	prevStatus, prevMode := scrapeStatus, scrapeMode
+	scraperHTTPClient, scraperPoliteDelay = &http.Client{Transport: rt}, time.Millisecond
+	bappebtiBreaker = NewCircuitBreaker("bappebti", defaultBAPPEBTIBreakerConfig)
+	scrapeStatus, scrapeMode = NewScrapeStatusTracker(), ScrapeModeFirstSuccess
+	t.Cleanup(func() {
+		scraperHTTPClient, scraperPoliteDelay, bappebtiBreaker = prevClient, prevDelay, prevBreaker
+		scrapeStatus, scrapeMode = prevStatus, prevMode
+	})
+}
+
+// bappebtiPage transport yang selalu menjawab status & body yang sama
+func bappebtiPage(status int, body string) roundTripFunc {
+	return func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+	}
+}
+
+// recordUserAgents client scraper (lewat withUserAgent) yang mencatat User-Agent tiap request
+func recordUserAgents(userAgent string) (*http.Client, func() []string) {
+	var mu sync.Mutex
+	var seen []string
+	base := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		mu.Lock()
+		seen = append(seen, r.Header.Get("User-Agent"))
+		mu.Unlock()
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(bappebtiTestPage)), Request: r}, nil
+	})}
+	return withUserAgent(base, userAgent), func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), seen...)
+	}
+}
+
+func TestScrapersSendConfiguredUserAgent(t *testing.T) {
+	const ua = "TobaccoTrack-Test/2.0 (+ops@example.com)"
+
+	t.Run("bappebti", func(t *testing.T) {
+		client, seen := recordUserAgents(ua)
+		s := &BAPPEBTIScraper{BaseURL: "https://bappebti.test", Commodities: []string{"TEMBAKAU BURLEY", "TEMBAKAU KASTURI"}, Client: client}
+		if _, err := s.Scrape(context.Background()); err != nil {
+			t.Fatalf("Scrape: %v", err)
+		}
+		if got := seen(); len(got) != 2 || got[0] != ua || got[1] != ua {
+			t.Fatalf("User-Agent = %q, ingin %q di tiap request", got, ua)
+		}
+	})
+
+	t.Run("news portal lewat scraperHTTPClient", func(t *testing.T) {
+		client, seen := recordUserAgents(ua)
+		prev := scraperHTTPClient
+		scraperHTTPClient = client
+		t.Cleanup(func() { scraperHTTPClient = prev })
+
+		NewNewsPortalScraper().Scrape(context.Background())
+		if got := seen(); len(got) != 1 || got[0] != ua {
+			t.Fatalf("User-Agent = %q, ingin %q", got, ua)
+		}
+	})
+
+	t.Run("header eksplisit tidak ditimpa", func(t *testing.T) {
+		client, seen := recordUserAgents(ua)
+		req, _ := http.NewRequest(http.MethodGet, "https://contoh.test", nil)
+		req.Header.Set("User-Agent", "Khusus/1.0")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if got := seen(); got[0] != "Khusus/1.0" || req.Header.Get("User-Agent") != "Khusus/1.0" {
+			t.Fatalf("User-Agent = %q", got)
+		}
+	})
+}
+
+func TestBAPPEBTIScraperPoliteDelay(t *testing.T) {
+	client, seen := recordUserAgents(defaultScraperUserAgent)
+	s := &BAPPEBTIScraper{BaseURL: "https://bappebti.test", Commodities: []string{"A", "B", "C"}, Client: client, Delay: 30 * time.Millisecond}
+
+	start := time.Now()
+	if _, err := s.Scrape(context.Background()); err != nil {
+		t.Fatalf("Scrape: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Fatalf("3 halaman selesai dalam %s, ingin jeda >= 2x30ms", elapsed)
+	}
+
+	// Jeda ikut batal bersama context
+	s.Delay = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if _, err := s.Scrape(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Scrape saat jeda = %v, ingin DeadlineExceeded", err)
+	}
+	if n := len(seen()); n != 4 {
+		t.Fatalf("%d request, ingin 4 (3 + 1 sebelum jeda dibatalkan)", n)
+	}
+}
+
+func TestScraperManagerModes(t *testing.T) {
+	price := func(region string, p float64) ScrapedPrice { return ScrapedPrice{Region: region, Price: p} }
+	summarize := func(prices []ScrapedPrice) map[string]float64 {
+		got := map[string]float64{}
+		for _, p := range prices {
+			got[p.Region] = p.Price
+		}
+		return got
+	}
+
+	tests := []struct {
+		name      string
+		mode      ScrapeMode
+		primary   *countingScraper
+		fallback  *countingScraper
+		want      map[string]float64
+		wantCalls int32 // panggilan ke fallback
+	}{
+		{"first, tumpang tindih", ScrapeModeFirstSuccess,
+			&countingScraper{prices: []ScrapedPrice{price("Jember", 1)}},
+			&countingScraper{prices: []ScrapedPrice{price("Jember", 2), price("Malang", 3)}},
+			map[string]float64{"Jember": 1}, 0},
+		{"first, terpisah", ScrapeModeFirstSuccess,
+			&countingScraper{prices: []ScrapedPrice{price("Jember", 1)}},
+			&countingScraper{prices: []ScrapedPrice{price("Malang", 3)}},
+			map[string]float64{"Jember": 1}, 0},
+		{"first, utama kosong", ScrapeModeFirstSuccess,
+			&countingScraper{},
+			&countingScraper{prices: []ScrapedPrice{price("Malang", 3)}},
+			map[string]float64{"Malang": 3}, 1},
+		// Region yang sudah diisi sumber prioritas lebih tinggi tidak ditimpa (beda huruf besar tetap sama)
+		{"merge, tumpang tindih", ScrapeModeMerge,
+			&countingScraper{prices: []ScrapedPrice{price("Jember", 1)}},
+			&countingScraper{prices: []ScrapedPrice{price("JEMBER", 2), price("Malang", 3)}},
+			map[string]float64{"Jember": 1, "Malang": 3}, 1},
+		{"merge, terpisah", ScrapeModeMerge,
+			&countingScraper{prices: []ScrapedPrice{price("Jember", 1), price("Bondowoso", 4)}},
+			&countingScraper{prices: []ScrapedPrice{price("Malang", 3)}},
+			map[string]float64{"Jember": 1, "Bondowoso": 4, "Malang": 3}, 1},
+		{"merge, utama gagal", ScrapeModeMerge,
+			&countingScraper{err: errors.New("503")},
+			&countingScraper{prices: []ScrapedPrice{price("Malang", 3)}},
+			map[string]float64{"Malang": 3}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.primary.name, tt.fallback.name = "Utama", "Cadangan"
+			sm := &ScraperManager{Scrapers: []TobaccoScraper{tt.primary, tt.fallback}, Status: NewScrapeStatusTracker(), Mode: tt.mode}
+
+			prices, err := sm.ScrapeAll()
+			if err != nil {
+				t.Fatalf("ScrapeAll: %v", err)
+			}
+			if got := summarize(prices); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ScrapeAll = %v, ingin %v", got, tt.want)
+			}
+			if n := tt.fallback.calls.Load(); n != tt.wantCalls {
+				t.Fatalf("fallback dipanggil %d kali, ingin %d", n, tt.wantCalls)
+			}
+		})
+	}
+
+	t.Run("semua gagal", func(t *testing.T) {
+		sm := &ScraperManager{Mode: ScrapeModeMerge, Scrapers: []TobaccoScraper{
+			&countingScraper{name: "A", err: errors.New("timeout")}, &countingScraper{name: "B"}}}
+		if _, err := sm.ScrapeAll(); err == nil {
+			t.Fatal("ScrapeAll tanpa data tidak error")
+		}
+	})
+}
+
+func TestParseScrapeMode(t *testing.T) {
+	for _, raw := range []string{"first", "merge"} {
+		if mode, err := ParseScrapeMode(raw); err != nil || string(mode) != raw {
+			t.Fatalf("ParseScrapeMode(%q) = %q, %v", raw, mode, err)
+		}
+	}
+	if _, err := ParseScrapeMode("semua"); err == nil {
+		t.Fatal("ParseScrapeMode(semua) tidak error")
+	}
+}
+
+func TestNewsPortalScraperParsesFixture(t *testing.T) {
+	fixture, err := os.Open(filepath.Join("testdata", "google_news_tembakau.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fixture.Close()
+
+	s := NewNewsPortalScraper()
+	prices, err := s.parseResults(fixture, "https://news.test/search")
+	if err != nil {
+		t.Fatalf("parseResults: %v", err)
+	}
+
+	got := map[string]float64{}
+	for _, p := range prices {
+		if p.SourceType != sourceTypeNews || !strings.HasPrefix(p.Quality, "Low Confidence") || p.SourceURL != "https://news.test/search" {
+			t.Fatalf("harga berita tidak ditandai low-confidence: %+v", p)
+		}
+		got[p.Region] = p.Price
+	}
+	// Satu harga per region (snippet pertama); bibit Rp 2.500 & berita tanpa region dilewati
+	want := map[string]float64{"Jember": 45000, "Temanggung": 120000, "Pamekasan": 38500}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("harga = %v, ingin %v", got, want)
+	}
+}
+
+func TestNewsPortalScraperBlocked(t *testing.T) {
+	s := NewNewsPortalScraper()
+	s.Client = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusTooManyRequests, Body: io.NopCloser(strings.NewReader("unusual traffic")), Request: r}, nil
+	})}
+	prices, err := s.Scrape(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "429") || prices != nil {
+		t.Fatalf("Scrape = %v, %v; ingin error status 429", prices, err)
+	}
+}
+
+func TestExtractPriceRange(t *testing.T) {
+	tests := []struct {
+		cell string
+		want PriceRange
+		ok   bool
+	}{
+		{"85.000", PriceRange{85000, 85000, 85000}, true},
+		{"Rp 85.000/kg", PriceRange{85000, 85000, 85000}, true},
+		{"85,000", PriceRange{85000, 85000, 85000}, true},
+		{"85.000,50", PriceRange{85000.5, 85000.5, 85000.5}, true},
+		{"80.000 - 90.000", PriceRange{80000, 90000, 85000}, true},
+		{"80.000–90.000", PriceRange{80000, 90000, 85000}, true},
+		{"Rp 80.000 s/d Rp 90.000", PriceRange{80000, 90000, 85000}, true},
+		{"80.000 sampai 90.000", PriceRange{80000, 90000, 85000}, true},
+		{"80.000 ~ 90.000", PriceRange{80000, 90000, 85000}, true},
+		{"80,000 to 90,000", PriceRange{80000, 90000, 85000}, true},
+		// Batas terbalik tetap menghasilkan min <= max
+		{"90.000 - 80.000", PriceRange{80000, 90000, 85000}, true},
+		// Angka kedua tanpa separator rentang bukan batas atas (mis. satuan/kualitas)
+		{"85.000 per 1 kg", PriceRange{85000, 85000, 85000}, true},
+		{"", PriceRange{}, false},
+		{"-", PriceRange{}, false},
+		{"tidak ada data", PriceRange{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := extractPriceRange(tt.cell)
+		if ok != tt.ok || got != tt.want {
+			t.Fatalf("extractPriceRange(%q) = %+v, %v; ingin %+v, %v", tt.cell, got, ok, tt.want, tt.ok)
+		}
+		if ok && got.IsRange() != (tt.want.Min != tt.want.Max) {
+			t.Fatalf("IsRange(%q) = %v", tt.cell, got.IsRange())
+		}
+	}
+}
+
+func TestBAPPEBTIScraperStoresRangeBounds(t *testing.T) {
+	client, _ := recordUserAgents(defaultScraperUserAgent)
+	s := &BAPPEBTIScraper{BaseURL: "https://bappebti.test", Commodities: []string{"TEMBAKAU BURLEY"}, Client: client}
+	prices, err := s.Scrape(context.Background())
+	if err != nil || len(prices) != 1 {
+		t.Fatalf("Scrape = %+v, %v", prices, err)
+	}
+	p := scrapedToPrice(prices[0])
+	if p.Price != 42000 || p.PriceMin == nil || *p.PriceMin != 40000 || p.PriceMax == nil || *p.PriceMax != 44000 {
+		t.Fatalf("harga = %+v, ingin 42000 (40000-44000)", p)
+	}
+}
+
+func TestBAPPEBTIBreakerSkipsAfterFailureStreak(t *testing.T) {
+	var requests atomic.Int32
+	var down atomic.Bool
+	down.Store(true)
+	useBAPPEBTITransport(t, func(r *http.Request) (*http.Response, error) {
+		requests.Add(1)
+		if down.Load() {
+			return bappebtiPage(http.StatusServiceUnavailable, "")(r)
+		}
+		return bappebtiPage(http.StatusOK, bappebtiTestPage)(r)
+	})
+	var advance func(time.Duration)
+	bappebtiBreaker, advance = newTestBreaker("bappebti", defaultBAPPEBTIBreakerConfig)
+
+	scrape := func() []ScrapedPrice {
+		t.Helper()
+		prices, err := NewScraperManager().ScrapeAll()
+		if err != nil || len(prices) == 0 {
+			t.Fatalf("ScrapeAll = %d harga, %v; ingin fallback tetap jalan", len(prices), err)
+		}
+		return prices
+	}
+
+	// Run gagal beruntun sampai ambang: tiap run tetap mencoba BAPPEBTI
+	scrape()
+	perRun := requests.Load()
+	for i := 1; i < defaultBAPPEBTIBreakerConfig.Threshold; i++ {
+		scrape()
+	}
+	if got := requests.Load(); got != perRun*int32(defaultBAPPEBTIBreakerConfig.Threshold) {
+		t.Fatalf("request BAPPEBTI = %d, ingin %d", got, perRun*int32(defaultBAPPEBTIBreakerConfig.Threshold))
+	}
+
+	// Breaker open: langsung ke data riset tanpa request ke BAPPEBTI
+	before := requests.Load()
+	if prices := scrape(); prices[0].SourceType != sourceTypeResearch {
+		t.Fatalf("sumber saat breaker open = %s, ingin research", prices[0].SourceType)
+	}
+	if requests.Load() != before {
+		t.Fatalf("BAPPEBTI tetap dipanggil saat breaker open")
+	}
+
+	rec := serve(ScrapeStatusHandler, http.MethodGet, "/harga/scrape/status", "")
+	var status struct{ Breakers []BreakerSnapshot }
+	decodeBody(t, rec, &status)
+	if len(status.Breakers) != 1 || status.Breakers[0].State != BreakerOpen || status.Breakers[0].Name != "bappebti" {
+		t.Fatalf("GET /harga/scrape/status = %s", rec.Body.String())
+	}
+
+	// Cooldown habis & BAPPEBTI pulih: probe sukses menutup breaker
+	down.Store(false)
+	advance(defaultBAPPEBTIBreakerConfig.Cooldown)
+	if prices := scrape(); prices[0].SourceType != sourceTypeBAPPEBTI {
+		t.Fatalf("sumber setelah pulih = %s, ingin bappebti", prices[0].SourceType)
+	}
+	if s := bappebtiBreaker.Snapshot(); s.State != BreakerClosed {
+		t.Fatalf("breaker = %s, ingin closed", s.State)
+	}
+}
+
+func TestGetScrapedPriceJSON(t *testing.T) {
+	useBAPPEBTITransport(t, bappebtiPage(http.StatusOK, bappebtiTestPage))
+
+	// Alias region ikut dinormalisasi sebelum dicari
+	raw, err := GetScrapedPriceJSON("kab. jember")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(raw, `"Region":"Jember","Price":42000`) {
+		t.Fatalf("GetScrapedPriceJSON = %s", raw)
+	}
+
+	if _, err := GetScrapedPriceJSON("Merauke"); !errors.Is(err, ErrRegionNotScraped) {
+		t.Fatalf("region tidak ada = %v, ingin ErrRegionNotScraped", err)
+	}
+}
+
+func TestSaveScrapedPricePersistsSourceMetadata(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ps PriceStore, _ WeatherStore) {
+		scrapedAt := time.Date(2026, 3, 10, 8, 30, 0, 0, time.UTC)
+		err := SaveScrapedPrice(ScrapedPrice{
+			Region:     "jember",
+			Price:      42000,
+			PriceMin:   floatPtr(40000),
+			PriceMax:   floatPtr(44000),
+			Quality:    "Grade A",
+			Source:     "BAPPEBTI",
+			SourceType: sourceTypeBAPPEBTI,
+			ScrapedAt:  scrapedAt,
+			SourceURL:  "https://bappebti.go.id/harga",
+		})
+		if err != nil {
+			t.Fatalf("SaveScrapedPrice: %v", err)
+		}
+		mustAdd(t, ps, testPrice("Jember", 41000, "2026-03-09"))
+		flushWrites(t)
+
+		page, err := ps.GetAll(PriceQuery{Region: "Jember", Limit: 10})
+		if err != nil {
+			t.Fatalf("GetAll: %v", err)
+		}
+		var scraped, manual *Price
+		for i := range page.Prices {
+			if page.Prices[i].SourceType == sourceTypeBAPPEBTI {
+				scraped = &page.Prices[i]
+			} else {
+				manual = &page.Prices[i]
+			}
+		}
+		if scraped == nil || manual == nil {
+			t.Fatalf("harga = %+v, ingin satu hasil scraping & satu manual", page.Prices)
+		}
+
+		raw, err := json.Marshal(scraped)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got map[string]any
+		if err := json.Unmarshal(raw, &got); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]string{
+			"quality":     "Grade A",
+			"source_name": "BAPPEBTI",
+			"source_url":  "https://bappebti.go.id/harga",
+			"scraped_at":  "2026-03-10 08:30:00",
+			"region":      "Jember",
+		}
+		for key, v := range want {
+			if got[key] != v {
+				t.Fatalf("%s = %v, ingin %q (%s)", key, got[key], v, raw)
+			}
+		}
+
+		// Harga manual tidak punya metadata scraper, field-nya tidak muncul di JSON
+		raw, err = json.Marshal(manual)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range []string{"quality", "source_name", "source_url", "scraped_at"} {
+			if strings.Contains(string(raw), `"`+key+`"`) {
+				t.Fatalf("JSON harga manual memuat %s: %s", key, raw)
+			}
+		}
+	})
+}
+
+func TestBAPPEBTICommodityURL(t *testing.T) {
+	s := &BAPPEBTIScraper{BaseURL: "https://bappebti.test"}
+	tests := []struct {
+		commodity string
+		want      string
+	}{
+		{"TEMBAKAU BURLEY", "https://bappebti.test/harga_komoditi_pedagang?komoditi=TEMBAKAU%20BURLEY"},
+		{"TEMBAKAU VIRGINIA FC", "https://bappebti.test/harga_komoditi_pedagang?komoditi=TEMBAKAU%20VIRGINIA%20FC"},
+		// Karakter khusus tidak boleh memecah query string
+		{"TEMBAKAU A&B/C+D", "https://bappebti.test/harga_komoditi_pedagang?komoditi=TEMBAKAU%20A%26B%2FC%2BD"},
+	}
+	for _, tt := range tests {
+		if got := s.commodityURL(tt.commodity); got != tt.want {
+			t.Errorf("commodityURL(%q) = %s, ingin %s", tt.commodity, got, tt.want)
+		}
+	}
+}
+
+func TestBAPPEBTIScrapeTagsCommodity(t *testing.T) {
+	t.Setenv("BAPPEBTI_COMMODITIES", "TEMBAKAU BURLEY, TEMBAKAU A&B")
+	cfg, err := LoadConfig(os.Getenv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"TEMBAKAU BURLEY", "TEMBAKAU A&B"}; !reflect.DeepEqual(cfg.BAPPEBTICommodities, want) {
+		t.Fatalf("BAPPEBTICommodities = %q, ingin %q", cfg.BAPPEBTICommodities, want)
+	}
+
+	var mu sync.Mutex
+	var requested []string
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		commodity := r.URL.Query().Get("komoditi")
+		mu.Lock()
+		requested = append(requested, commodity)
+		mu.Unlock()
+		page := `<table><tbody><tr><td>1</td><td>` + commodity + `</td><td>50.000</td><td>kg</td></tr></tbody></table>`
+		return bappebtiPage(http.StatusOK, page)(r)
+	})}
+	s := &BAPPEBTIScraper{BaseURL: "https://bappebti.test", Commodities: cfg.BAPPEBTICommodities, Client: client}
+
+	prices, err := s.Scrape(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(requested, cfg.BAPPEBTICommodities) {
+		t.Fatalf("halaman diminta = %q, ingin %q", requested, cfg.BAPPEBTICommodities)
+	}
+	if len(prices) != 2 {
+		t.Fatalf("harga = %+v, ingin 2", prices)
+	}
+	for _, p := range prices {
+		// Halaman palsu menulis nama komoditas sebagai region: tag harus cocok dengan halaman asal
+		if p.Commodity != p.Region || p.SourceURL != s.commodityURL(p.Commodity) {
+			t.Fatalf("harga %+v tidak ditandai dengan komoditas halamannya", p)
+		}
+	}
+}
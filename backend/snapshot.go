@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// ============================================
+// SNAPSHOT DATABASE (BACKUP / PINDAH ENVIRONMENT)
+// GET /admin/export mengalirkan seluruh isi tabel sebagai satu dokumen JSON;
+// POST /admin/import?confirm=true mengganti seluruh isi PriceStore & WeatherStore
+// ============================================
+
+const (
+	snapshotVersion        = 1
+	maxSnapshotBytes int64 = 100 << 20 // 100MB
+)
+
+// WeatherHistoryRow satu baris weather_history apa adanya (kolom cuaca boleh NULL)
+type WeatherHistoryRow struct {
+	ID        int      `json:"id"`
+	Region    string   `json:"region"`
+	TempC     *float64 `json:"temp_c"`
+	Humidity  *int     `json:"humidity"`
+	RainMM    *float64 `json:"rain_mm"`
+	FetchedAt string   `json:"fetched_at"`
+	CreatedAt string   `json:"created_at"`
+}
+
+type DatabaseSnapshot struct {
+	Version        int                 `json:"version"`
+	ExportedAt     string              `json:"exported_at"`
+	Prices         []Price             `json:"prices"`
+	WeatherHistory []WeatherHistoryRow `json:"weather_history"`
+}
+
+// writeJSONArray menulis item dari each sebagai array JSON satu per satu tanpa
+// menampung seluruh tabel di memori
+func writeJSONArray[T any](w io.Writer, each func(fn func(T) error) error) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	first := true
+	err := each(func(item T) error {
+		encoded, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		if !first {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		first = false
+		_, err = w.Write(encoded)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "]")
+	return err
+}
+
+// WriteDatabaseSnapshot menulis dokumen berbentuk DatabaseSnapshot.
+// Baris lama dengan unit/source/created_at NULL menjadi string kosong.
+func WriteDatabaseSnapshot(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, `{"version":%d,"exported_at":%q,"prices":`,
+		snapshotVersion, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return err
+	}
+	eachPrice := func(fn func(Price) error) error { return priceStore.Each("", fn) }
+	if err := writeJSONArray(w, eachPrice); err != nil {
+		return fmt.Errorf("prices: %w", err)
+	}
+	if _, err := io.WriteString(w, `,"weather_history":`); err != nil {
+		return err
+	}
+	if err := writeJSONArray(w, weatherStore.Each); err != nil {
+		return fmt.Errorf("weather_history: %w", err)
+	}
+	_, err := io.WriteString(w, "}\n")
+	return err
+}
+
+// replaceAllData mengganti isi priceStore lalu weatherStore. Keduanya store terpisah
+// (tidak ada transaksi bersama), jadi jika penggantian weather gagal, harga
+// dikembalikan ke isi sebelumnya: gagal di tengah = tidak ada yang berubah.
+func replaceAllData(prices []Price, weather []WeatherHistoryRow) (clearedPrices, clearedWeather int64, err error) {
+	var previous []Price
+	if err := priceStore.Each("", func(p Price) error {
+		previous = append(previous, p)
+		return nil
+	}); err != nil {
+		return 0, 0, fmt.Errorf("baca prices: %w", err)
+	}
+
+	if clearedPrices, err = priceStore.Replace(prices); err != nil {
+		return 0, 0, fmt.Errorf("prices: %w", err)
+	}
+	if clearedWeather, err = weatherStore.Replace(weather); err != nil {
+		if _, rollbackErr := priceStore.Replace(previous); rollbackErr != nil {
+			log.Printf("⚠️  Gagal mengembalikan prices setelah weather_history gagal: %v", rollbackErr)
+		}
+		return 0, 0, fmt.Errorf("weather_history: %w", err)
+	}
+	return clearedPrices, clearedWeather, nil
+}
+
+// RestoreDatabaseSnapshot mengosongkan data lalu mengisi ulang dari snapshot (ID dipertahankan)
+func RestoreDatabaseSnapshot(snap DatabaseSnapshot) error {
+	_, _, err := replaceAllData(snap.Prices, snap.WeatherHistory)
+	return err
+}
+
+func ExportSnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		// Header dikirim sebelum baris pertama; error setelah ini hanya bisa di-log
+		filename := fmt.Sprintf("tobacco-snapshot-%s.json", time.Now().Format("20060102-150405"))
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		if err := WriteDatabaseSnapshot(w); err != nil {
+			log.Printf("Export snapshot terputus: %v", err)
+		}
+		return nil
+	})(w, r)
+}
+
+func ImportSnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		if r.URL.Query().Get("confirm") != "true" {
+			return NewAPIError(http.StatusBadRequest, "confirmation_required",
+				"Import akan MENGGANTI semua data harga & cuaca; ulangi dengan ?confirm=true")
+		}
+
+		var snap DatabaseSnapshot
+		if err := json.NewDecoder(r.Body).Decode(&snap); err != nil {
+			if isBodyTooLarge(err) {
+				return errBodyTooLarge
+			}
+			return errInvalidBody.WithDetails("body harus berupa snapshot JSON dari GET /admin/export")
+		}
+		if snap.Version != snapshotVersion {
+			return NewAPIError(http.StatusBadRequest, "unsupported_snapshot",
+				fmt.Sprintf("versi snapshot %d tidak didukung (harus %d)", snap.Version, snapshotVersion))
+		}
+
+		if err := RestoreDatabaseSnapshot(snap); err != nil {
+			return err
+		}
+		log.Printf("✓ Snapshot diimpor: %d harga, %d weather_history", len(snap.Prices), len(snap.WeatherHistory))
+
+		return respondJSON(w, http.StatusOK, map[string]interface{}{
+			"status":          "ok",
+			"prices":          len(snap.Prices),
+			"weather_history": len(snap.WeatherHistory),
+		})
+	})(w, r)
+}
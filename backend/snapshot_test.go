@@ -0,0 +1,100 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// exportSnapshot GET /admin/export lalu decode dokumennya
+func exportSnapshot(t *testing.T) DatabaseSnapshot {
+	t.Helper()
+	rec := serve(ExportSnapshotHandler, http.MethodGet, "/admin/export", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /admin/export = %d %s", rec.Code, rec.Body.String())
+	}
+	var snap DatabaseSnapshot
+	decodeBody(t, rec, &snap)
+	return snap
+}
+
+// exportBody dokumen mentah GET /admin/export untuk dikirim ulang ke /admin/import
+func exportBody(t *testing.T) string {
+	t.Helper()
+	return serve(ExportSnapshotHandler, http.MethodGet, "/admin/export", "").Body.String()
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ps PriceStore, ws WeatherStore) {
+		scraped := testPrice("Jember", 42000, "2026-03-02 08:00:00")
+		scraped.PriceMin, scraped.PriceMax = floatPtr(40000), floatPtr(44000)
+		quality, sourceName := "Grade A", "BAPPEBTI"
+		scraped.SourceType, scraped.Quality, scraped.SourceName = sourceTypeBAPPEBTI, &quality, &sourceName
+		mustAdd(t, ps, testPrice("Jember", 41000, "2026-03-01"), scraped, testPrice("Temanggung", 95000, "2026-03-02"))
+		ws.Add(WeatherHistoryRow{Region: "Jember", TempC: floatPtr(27.5), Humidity: intPtr(72), RainMM: floatPtr(0.4), FetchedAt: "2026-03-02 06:00:00"})
+		// Sampel lama dengan kolom cuaca NULL ikut terbawa apa adanya
+		ws.Add(WeatherHistoryRow{Region: "Temanggung", FetchedAt: "2026-03-02 07:00:00"})
+		flushWrites(t)
+
+		before := exportSnapshot(t)
+		if before.Version != snapshotVersion || len(before.Prices) != 3 || len(before.WeatherHistory) != 2 {
+			t.Fatalf("snapshot = v%d, %d harga, %d cuaca; ingin v%d, 3, 2",
+				before.Version, len(before.Prices), len(before.WeatherHistory), snapshotVersion)
+		}
+		body := exportBody(t)
+
+		// Data berubah setelah export; import harus mengembalikan isi persis seperti snapshot
+		mustAdd(t, ps, testPrice("Boyolali", 60000, "2026-03-03"))
+		ws.Add(WeatherHistoryRow{Region: "Boyolali", TempC: floatPtr(30), FetchedAt: "2026-03-03 06:00:00"})
+		flushWrites(t)
+
+		rec := serve(ImportSnapshotHandler, http.MethodPost, "/admin/import", body)
+		var env struct{ Error APIError }
+		decodeBody(t, rec, &env)
+		if rec.Code != http.StatusBadRequest || env.Error.Code != "confirmation_required" {
+			t.Fatalf("import tanpa confirm = %d %s", rec.Code, rec.Body.String())
+		}
+		if got := exportSnapshot(t); len(got.Prices) != 4 || len(got.WeatherHistory) != 3 {
+			t.Fatalf("import tanpa confirm mengubah data: %d harga, %d cuaca", len(got.Prices), len(got.WeatherHistory))
+		}
+
+		rec = serve(ImportSnapshotHandler, http.MethodPost, "/admin/import?confirm=true", body)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("POST /admin/import = %d %s", rec.Code, rec.Body.String())
+		}
+		after := exportSnapshot(t)
+		if !reflect.DeepEqual(after.Prices, before.Prices) {
+			t.Fatalf("prices setelah import = %+v, ingin %+v", after.Prices, before.Prices)
+		}
+		if !reflect.DeepEqual(after.WeatherHistory, before.WeatherHistory) {
+			t.Fatalf("weather_history setelah import = %+v, ingin %+v", after.WeatherHistory, before.WeatherHistory)
+		}
+	})
+}
+
+func TestImportSnapshotHandlerRejectsInvalidSnapshot(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+	mustAdd(t, priceStore, testPrice("Jember", 41000, "2026-03-01"))
+
+	tests := []struct {
+		name string
+		body string
+		code string
+	}{
+		{"bukan JSON", "harga,jember", "invalid_body"},
+		{"versi lain", `{"version":99,"prices":[],"weather_history":[]}`, "unsupported_snapshot"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(ImportSnapshotHandler, http.MethodPost, "/admin/import?confirm=true", tt.body)
+			var env struct{ Error APIError }
+			decodeBody(t, rec, &env)
+			if rec.Code != http.StatusBadRequest || env.Error.Code != tt.code {
+				t.Fatalf("status = %d %s, ingin 400 %s", rec.Code, rec.Body.String(), tt.code)
+			}
+		})
+	}
+	if page, _ := priceStore.GetAll(PriceQuery{Limit: 10}); page.Total != 1 {
+		t.Fatalf("snapshot ditolak tapi data berubah: %d harga", page.Total)
+	}
+}
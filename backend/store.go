@@ -0,0 +1,570 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ============================================
+// PERSISTENCE STORE
+// Semua akses data harga & cuaca lewat PriceStore/WeatherStore, bukan SQL langsung,
+// supaya backend lain (mis. Postgres untuk deployment multi-user) cukup mengimplementasikan
+// interface ini. Implementasi default: SQLite (DB + dbWriter).
+// ============================================
+
+// PriceQuery filter & halaman untuk PriceStore.GetAll
+type PriceQuery struct {
+	Region string // kosong = semua region
+	Limit  int
+	Offset int
+}
+
+// PricePage satu halaman hasil GetAll; Total = jumlah baris yang cocok dengan filter
+type PricePage struct {
+	Prices  []Price
+	Total   int
+	Skipped int // baris rusak yang dilewati saat dibaca
+}
+
+type PriceStore interface {
+	// Add menyimpan harga; hasilnya berisi ID & CreatedAt dari store
+	Add(p Price) (Price, error)
+	// AddBatch menyimpan semua harga atau tidak sama sekali; urutan hasil = urutan input
+	AddBatch(prices []Price) ([]Price, error)
+	// GetAll urut created_at terbaru dulu
+	GetAll(q PriceQuery) (PricePage, error)
+	// GetLatest error membungkus sql.ErrNoRows jika region belum punya harga
+	GetLatest(region string) (Price, error)
+	// GetLatestMany region tanpa data tidak ada di map hasil
+	GetLatestMany(regions []string) (map[string]Price, error)
+	// LatestReal harga non-simulasi terakhir (menurut recorded_at) per region
+	LatestReal(regions []string) (map[string]float64, error)
+	// ByRegion `limit` harga terakhir (menurut recorded_at) sebuah region, urut kronologis.
+	// Baris dengan recorded_at bukan tanggal tidak diikutkan.
+	ByRegion(region string, limit int) ([]Price, error)
+	// Each memanggil fn untuk tiap harga (region kosong = semua) urut recorded_at, id;
+	// berhenti di error pertama dari fn
+	Each(region string, fn func(Price) error) error
+	// Stats statistik per region urut nama region; region kosong = semua region
+	Stats(region string) ([]RegionPriceStats, error)
+	// SourceBreakdown from & to inklusif (YYYY-MM-DD), kosong = tanpa batas
+	SourceBreakdown(from, to string) (PriceSourceBreakdown, error)
+	// Delete sql.ErrNoRows jika id tidak ada
+	Delete(id int) error
+	// Replace mengganti seluruh isi dengan prices secara atomik. ID > 0 dipertahankan,
+	// ID 0 diberi ID baru. Mengembalikan jumlah baris lama yang dihapus.
+	Replace(prices []Price) (int64, error)
+	// DeleteRecordedBefore menghapus harga dengan recorded_at < cutoff (perbandingan string)
+	DeleteRecordedBefore(ctx context.Context, cutoff string, batchSize int) (int64, error)
+	Ping(ctx context.Context) error
+}
+
+type WeatherStore interface {
+	// Add menyimpan satu sampel cuaca; boleh asinkron (fetch cuaca tidak menunggu write)
+	Add(row WeatherHistoryRow)
+	DailyHistory(region string, days int) ([]WeatherDailyAggregate, error)
+	// Nearest sql.ErrNoRows jika tidak ada sampel dalam historicalWeatherMaxGap
+	Nearest(region string, at time.Time) (HistoricalWeather, error)
+	// Each memanggil fn untuk tiap sampel urut id
+	Each(fn func(WeatherHistoryRow) error) error
+	// Replace sama seperti PriceStore.Replace
+	Replace(rows []WeatherHistoryRow) (int64, error)
+	// DeleteFetchedBefore menghapus sampel dengan fetched_at < cutoff (UTC, sqliteUTCLayout)
+	DeleteFetchedBefore(ctx context.Context, cutoff string, batchSize int) (int64, error)
+}
+
+var (
+	priceStore   PriceStore   = SQLitePriceStore{}
+	weatherStore WeatherStore = SQLiteWeatherStore{}
+)
+
+// ============================================
+// SQLITE
+// ============================================
+
+type SQLitePriceStore struct{}
+
+const insertPriceSQL = `INSERT INTO prices (region, price, price_min, price_max, unit, source, source_type,
+		quality, source_name, source_url, scraped_at, recorded_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+func insertPriceArgs(p Price) []interface{} {
+	return []interface{}{p.Region, p.Price, p.PriceMin, p.PriceMax, p.Unit, p.Source, p.SourceType,
+		p.Quality, p.SourceName, p.SourceURL, p.ScrapedAt, p.RecordedAt}
+}
+
+// priceColumnsNullSafe priceColumns untuk baris lama yang unit/source/created_at-nya NULL
+const priceColumnsNullSafe = `id, region, price, price_min, price_max, COALESCE(unit, ''), COALESCE(source, ''),
+	source_type, quality, source_name, source_url, scraped_at, recorded_at, COALESCE(created_at, '')`
+
+// inPlaceholders "?, ?, ?" beserta argumen region yang sudah dinormalisasi
+func inPlaceholders(regions []string) (string, []interface{}) {
+	args := make([]interface{}, len(regions))
+	for i, region := range regions {
+		args[i] = NormalizeRegion(region)
+	}
+	return strings.TrimSuffix(strings.Repeat("?, ", len(regions)), ", "), args
+}
+
+func (SQLitePriceStore) Add(p Price) (Price, error) {
+	res, err := dbWriter.Exec(insertPriceSQL, insertPriceArgs(p)...)
+	if err != nil {
+		return p, err
+	}
+	return insertedPrice(res, p), nil
+}
+
+func (SQLitePriceStore) AddBatch(prices []Price) ([]Price, error) {
+	saved := make([]Price, len(prices))
+	err := dbWriter.Tx(func(tx *sql.Tx) error {
+		stmt, err := tx.Prepare(insertPriceSQL)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for i, p := range prices {
+			res, err := stmt.Exec(insertPriceArgs(p)...)
+			if err != nil {
+				return fmt.Errorf("insert %s: %w", p.Region, err)
+			}
+			saved[i] = insertedPrice(res, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+func (SQLitePriceStore) GetAll(q PriceQuery) (PricePage, error) {
+	where, args := "", []interface{}{}
+	if q.Region != "" {
+		where, args = " WHERE region = ?", append(args, NormalizeRegion(q.Region))
+	}
+
+	var page PricePage
+	if err := DB.QueryRow("SELECT COUNT(*) FROM prices"+where, args...).Scan(&page.Total); err != nil {
+		return page, err
+	}
+
+	rows, err := DB.Query("SELECT "+priceColumns+" FROM prices"+where+
+		" ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?", append(args, q.Limit, q.Offset)...)
+	if err != nil {
+		return page, err
+	}
+	defer rows.Close()
+
+	page.Prices, page.Skipped, err = scanPrices(rows)
+	return page, err
+}
+
+func (SQLitePriceStore) GetLatest(region string) (Price, error) {
+	region = NormalizeRegion(region)
+	p, err := scanPrice(DB.QueryRow(`
+		SELECT `+priceColumns+`
+		FROM prices
+		WHERE region = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1`, region))
+	if err != nil {
+		return p, fmt.Errorf("no price data found for region %s: %w", region, err)
+	}
+	return p, nil
+}
+
+func (SQLitePriceStore) GetLatestMany(regions []string) (map[string]Price, error) {
+	latest := make(map[string]Price, len(regions))
+	if len(regions) == 0 {
+		return latest, nil
+	}
+
+	placeholders, args := inPlaceholders(regions)
+	rows, err := DB.Query(`
+		SELECT `+priceColumns+` FROM (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY region ORDER BY created_at DESC, id DESC) AS rn
+			FROM prices
+			WHERE region IN (`+placeholders+`)
+		)
+		WHERE rn = 1`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prices, _, err := scanPrices(rows)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range prices {
+		latest[p.Region] = p
+	}
+	return latest, nil
+}
+
+func (SQLitePriceStore) LatestReal(regions []string) (map[string]float64, error) {
+	seeds := make(map[string]float64, len(regions))
+	if len(regions) == 0 {
+		return seeds, nil
+	}
+
+	placeholders, args := inPlaceholders(regions)
+	rows, err := DB.Query(`
+		SELECT region, price FROM (
+			SELECT region, price, ROW_NUMBER() OVER (PARTITION BY region ORDER BY recorded_at DESC, id DESC) AS rn
+			FROM prices
+			WHERE source_type != ? AND region IN (`+placeholders+`)
+		)
+		WHERE rn = 1`, append([]interface{}{sourceTypeSimulation}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var region string
+		var price float64
+		if err := rows.Scan(&region, &price); err != nil {
+			return nil, err
+		}
+		seeds[region] = price
+	}
+	return seeds, rows.Err()
+}
+
+func (SQLitePriceStore) ByRegion(region string, limit int) ([]Price, error) {
+	rows, err := DB.Query(`
+		SELECT `+priceColumns+` FROM (
+			SELECT * FROM prices
+			WHERE region = ? AND `+recordedAtIsDate+`
+			ORDER BY recorded_at DESC, id DESC
+			LIMIT ?
+		) ORDER BY recorded_at ASC, id ASC`, NormalizeRegion(region), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prices, _, err := scanPrices(rows)
+	return prices, err
+}
+
+func (SQLitePriceStore) Each(region string, fn func(Price) error) error {
+	where, args := "", []interface{}{}
+	if region != "" {
+		where, args = " WHERE region = ?", append(args, NormalizeRegion(region))
+	}
+
+	rows, err := DB.Query("SELECT "+priceColumnsNullSafe+" FROM prices"+where+" ORDER BY recorded_at ASC, id ASC", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		p, err := scanPrice(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Stats recorded_at yang bukan tanggal tidak ikut dihitung di window
+func (SQLitePriceStore) Stats(region string) ([]RegionPriceStats, error) {
+	where, args := "", []interface{}{}
+	if region != "" {
+		where, args = " WHERE region = ?", append(args, NormalizeRegion(region))
+	}
+
+	rows, err := DB.Query(`
+		SELECT region, COUNT(*), MIN(price), MAX(price), AVG(price),
+			COALESCE(MIN(CASE WHEN `+recordedAtIsDate+` THEN recorded_at END), '')
+		FROM prices`+where+`
+		GROUP BY region
+		ORDER BY region`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []RegionPriceStats
+	index := make(map[string]int)
+	for rows.Next() {
+		var s RegionPriceStats
+		if err := rows.Scan(&s.Region, &s.Count, &s.Min, &s.Max, &s.Avg, &s.FirstSeen); err != nil {
+			return nil, err
+		}
+		s.Windows = newPriceWindows()
+		index[s.Region] = len(stats)
+		stats = append(stats, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, win := range priceStatsWindows {
+		if err := fillPriceWindow(stats, index, win.Key, win.Days, where, args); err != nil {
+			return nil, err
+		}
+	}
+	return stats, nil
+}
+
+func fillPriceWindow(stats []RegionPriceStats, index map[string]int, key string, days int, where string, args []interface{}) error {
+	windowFilter := " WHERE "
+	if where != "" {
+		windowFilter = where + " AND "
+	}
+	offset := fmt.Sprintf("-%d days", days)
+
+	// recorded_at disimpan dalam waktu lokal server, jadi 'now' juga dikonversi ke localtime
+	rows, err := DB.Query(`
+		SELECT region, COUNT(*), MIN(price), MAX(price), AVG(price),
+			(SELECT MIN(julianday(recorded_at)) FROM prices first
+				WHERE first.region = prices.region AND `+recordedAtIsDate+`) <= julianday('now', 'localtime', ?)
+		FROM prices`+windowFilter+recordedAtIsDate+` AND julianday(recorded_at) >= julianday('now', 'localtime', ?)
+		GROUP BY region`, append([]interface{}{offset}, append(args, offset)...)...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var region string
+		var count int
+		var min, max, avg float64
+		var coversWindow bool
+		if err := rows.Scan(&region, &count, &min, &max, &avg, &coversWindow); err != nil {
+			return err
+		}
+		i, ok := index[region]
+		if !ok {
+			continue
+		}
+		win := stats[i].Windows[key]
+		win.Count, win.Min, win.Max, win.Avg = count, &min, &max, &avg
+		win.Partial = !coversWindow
+	}
+	return rows.Err()
+}
+
+func (SQLitePriceStore) SourceBreakdown(from, to string) (PriceSourceBreakdown, error) {
+	var conditions []string
+	var args []interface{}
+	if from != "" {
+		conditions, args = append(conditions, "recorded_at >= ?"), append(args, from)
+	}
+	if to != "" {
+		conditions, args = append(conditions, "recorded_at < date(?, '+1 day')"), append(args, to)
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows, err := DB.Query(`
+		SELECT COALESCE(source, ''), source_type, COUNT(*)
+		FROM prices`+where+`
+		GROUP BY source, source_type
+		ORDER BY COUNT(*) DESC, source, source_type`, args...)
+	if err != nil {
+		return PriceSourceBreakdown{}, err
+	}
+	defer rows.Close()
+
+	var counts []PriceSourceCount
+	for rows.Next() {
+		var c PriceSourceCount
+		if err := rows.Scan(&c.Source, &c.SourceType, &c.Count); err != nil {
+			return PriceSourceBreakdown{}, err
+		}
+		counts = append(counts, c)
+	}
+	if err := rows.Err(); err != nil {
+		return PriceSourceBreakdown{}, err
+	}
+	return newPriceSourceBreakdown(counts), nil
+}
+
+func (SQLitePriceStore) Delete(id int) error {
+	res, err := dbWriter.Exec(`DELETE FROM prices WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// nullIfZero ID 0 menjadi NULL supaya SQLite memberi ID baru
+func nullIfZero(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+func (SQLitePriceStore) Replace(prices []Price) (int64, error) {
+	var removed int64
+	err := dbWriter.Tx(func(tx *sql.Tx) error {
+		res, err := tx.Exec("DELETE FROM prices")
+		if err != nil {
+			return fmt.Errorf("kosongkan prices: %w", err)
+		}
+		removed, _ = res.RowsAffected()
+
+		stmt, err := tx.Prepare(`INSERT INTO prices (id, region, price, price_min, price_max, unit, source, source_type,
+			quality, source_name, source_url, scraped_at, recorded_at, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, COALESCE(NULLIF(?, ''), datetime('now')))`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for _, p := range prices {
+			if p.SourceType == "" {
+				p.SourceType = sourceTypeUnknown
+			}
+			if _, err := stmt.Exec(nullIfZero(p.ID), p.Region, p.Price, p.PriceMin, p.PriceMax, p.Unit, p.Source, p.SourceType,
+				p.Quality, p.SourceName, p.SourceURL, p.ScrapedAt, p.RecordedAt, p.CreatedAt); err != nil {
+				return fmt.Errorf("insert price %s id %d: %w", p.Region, p.ID, err)
+			}
+		}
+		return nil
+	})
+	return removed, err
+}
+
+func (SQLitePriceStore) DeleteRecordedBefore(ctx context.Context, cutoff string, batchSize int) (int64, error) {
+	return deleteOlderThan(ctx, "prices", "recorded_at", cutoff, batchSize)
+}
+
+func (SQLitePriceStore) Ping(ctx context.Context) error {
+	return DB.PingContext(ctx)
+}
+
+type SQLiteWeatherStore struct{}
+
+// Add lewat antrean writer tunggal secara async (retry saat SQLITE_BUSY)
+func (SQLiteWeatherStore) Add(row WeatherHistoryRow) {
+	dbWriter.ExecAsync("weather_history "+row.Region,
+		`INSERT INTO weather_history (region, temp_c, humidity, rain_mm, fetched_at)
+			VALUES (?, ?, ?, ?, ?)`, row.Region, row.TempC, row.Humidity, row.RainMM, row.FetchedAt)
+}
+
+func (SQLiteWeatherStore) DailyHistory(region string, days int) ([]WeatherDailyAggregate, error) {
+	rows, err := DB.Query(`
+		SELECT date(fetched_at, ?) AS day,
+		       COUNT(*),
+		       ROUND(AVG(temp_c), 1),
+		       ROUND(AVG(humidity), 1),
+		       ROUND(SUM(rain_mm), 2)
+		FROM weather_history
+		WHERE region = ?
+		  AND fetched_at >= datetime('now', ?, 'start of day', ?, ?)
+		GROUP BY day
+		ORDER BY day ASC`,
+		jakartaOffsetModifier, NormalizeRegion(region),
+		jakartaOffsetModifier, fmt.Sprintf("-%d days", days-1), utcOffsetModifier)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []WeatherDailyAggregate
+	for rows.Next() {
+		var d WeatherDailyAggregate
+		if err := rows.Scan(&d.Date, &d.Samples, &d.AvgTemp, &d.AvgHumidity, &d.TotalRain); err != nil {
+			return nil, err
+		}
+		result = append(result, d)
+	}
+	return result, rows.Err()
+}
+
+// Nearest sampel sebelum atau sesudah at; sampel tanpa suhu/kelembaban dilewati
+func (SQLiteWeatherStore) Nearest(region string, at time.Time) (HistoricalWeather, error) {
+	region = NormalizeRegion(region)
+	target := formatFetchedAt(at)
+
+	var h HistoricalWeather
+	var rain sql.NullFloat64
+	var gap float64
+	err := DB.QueryRow(`
+		SELECT temp_c, humidity, rain_mm, fetched_at,
+		       ABS(julianday(fetched_at) - julianday(?)) * 1440 AS gap
+		FROM weather_history
+		WHERE region = ?
+		  AND temp_c IS NOT NULL AND humidity IS NOT NULL
+		  AND fetched_at BETWEEN ? AND ?
+		ORDER BY gap ASC, id DESC
+		LIMIT 1`,
+		target, region,
+		formatFetchedAt(at.Add(-historicalWeatherMaxGap)), formatFetchedAt(at.Add(historicalWeatherMaxGap)),
+	).Scan(&h.Temp, &h.Humidity, &rain, &h.FetchedAt, &gap)
+	if err != nil {
+		return HistoricalWeather{}, err
+	}
+
+	h.Region = region
+	h.Rain, h.RainAvailable = rain.Float64, rain.Valid
+	h.GapMinutes = int(gap + 0.5)
+	return h, nil
+}
+
+func (SQLiteWeatherStore) Each(fn func(WeatherHistoryRow) error) error {
+	rows, err := DB.Query(`SELECT id, region, temp_c, humidity, rain_mm, fetched_at, COALESCE(created_at, '')
+		FROM weather_history ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var h WeatherHistoryRow
+		if err := rows.Scan(&h.ID, &h.Region, &h.TempC, &h.Humidity, &h.RainMM, &h.FetchedAt, &h.CreatedAt); err != nil {
+			return err
+		}
+		if err := fn(h); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (SQLiteWeatherStore) Replace(rows []WeatherHistoryRow) (int64, error) {
+	var removed int64
+	err := dbWriter.Tx(func(tx *sql.Tx) error {
+		res, err := tx.Exec("DELETE FROM weather_history")
+		if err != nil {
+			return fmt.Errorf("kosongkan weather_history: %w", err)
+		}
+		removed, _ = res.RowsAffected()
+
+		stmt, err := tx.Prepare(`INSERT INTO weather_history (id, region, temp_c, humidity, rain_mm, fetched_at, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, COALESCE(NULLIF(?, ''), datetime('now')))`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for _, h := range rows {
+			if _, err := stmt.Exec(nullIfZero(h.ID), h.Region, h.TempC, h.Humidity, h.RainMM, h.FetchedAt, h.CreatedAt); err != nil {
+				return fmt.Errorf("insert weather_history %s id %d: %w", h.Region, h.ID, err)
+			}
+		}
+		return nil
+	})
+	return removed, err
+}
+
+func (SQLiteWeatherStore) DeleteFetchedBefore(ctx context.Context, cutoff string, batchSize int) (int64, error) {
+	return deleteOlderThan(ctx, "weather_history", "fetched_at", cutoff, batchSize)
+}
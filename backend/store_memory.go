@@ -0,0 +1,590 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ============================================
+// IN-MEMORY STORE
+// PRICE_STORE=memory: harga & weather history disimpan di slice (hilang saat restart)
+// untuk CI, demo, dan eksperimen tanpa SQLite - file database tidak dibuka sama sekali.
+// Urutan, filter, dan agregasi meniru SQLitePriceStore/SQLiteWeatherStore
+// (diuji bersama di store_test.go).
+// ============================================
+
+const (
+	priceStoreSQLite = "sqlite"
+	priceStoreMemory = "memory"
+)
+
+var validPriceStores = []string{priceStoreSQLite, priceStoreMemory}
+
+// newStores implementasi PriceStore & WeatherStore sesuai PRICE_STORE (sudah divalidasi LoadConfig)
+func newStores(kind string) (PriceStore, WeatherStore) {
+	if kind == priceStoreMemory {
+		return NewMemoryPriceStore(), NewMemoryWeatherStore()
+	}
+	return SQLitePriceStore{}, SQLiteWeatherStore{}
+}
+
+// recordedAtDatePattern padanan recordedAtIsDate (GLOB) di SQL
+var recordedAtDatePattern = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}`)
+
+// sqliteTimeLayouts format waktu yang dikenali julianday()/date() SQLite
+var sqliteTimeLayouts = []string{
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// parseSQLiteTime padanan julianday(): string tanpa zona dibaca apa adanya (jam dinding),
+// string dengan zona dikonversi ke UTC. Hasil selalu ber-Location UTC agar bisa dibandingkan.
+func parseSQLiteTime(s string) (time.Time, bool) {
+	for _, layout := range sqliteTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// localWallClock padanan 'now', 'localtime': jam dinding lokal sebagai waktu tanpa zona
+func localWallClock(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// priceSummary min, max, dan rata-rata harga (prices tidak boleh kosong)
+func priceSummary(prices []Price) (min, max, avg float64) {
+	min, max = prices[0].Price, prices[0].Price
+	sum := 0.0
+	for _, p := range prices {
+		min, max, sum = math.Min(min, p.Price), math.Max(max, p.Price), sum+p.Price
+	}
+	return min, max, sum / float64(len(prices))
+}
+
+type MemoryPriceStore struct {
+	mu     sync.RWMutex
+	prices []Price // urut insert, ID naik
+	nextID int
+}
+
+func NewMemoryPriceStore() *MemoryPriceStore {
+	return &MemoryPriceStore{nextID: 1}
+}
+
+// addLocked pemanggil memegang s.mu
+func (s *MemoryPriceStore) addLocked(p Price) Price {
+	if p.ID == 0 {
+		p.ID = s.nextID
+	}
+	if p.ID >= s.nextID {
+		s.nextID = p.ID + 1
+	}
+	// Samakan dengan default datetime('now') SQLite (UTC)
+	if p.CreatedAt == "" {
+		p.CreatedAt = time.Now().UTC().Format(sqliteUTCLayout)
+	}
+	s.prices = append(s.prices, p)
+	return p
+}
+
+func (s *MemoryPriceStore) Add(p Price) (Price, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p.ID, p.CreatedAt = 0, ""
+	return s.addLocked(p), nil
+}
+
+func (s *MemoryPriceStore) AddBatch(prices []Price) ([]Price, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return Map(prices, func(p Price) Price {
+		p.ID, p.CreatedAt = 0, ""
+		return s.addLocked(p)
+	}), nil
+}
+
+// filter salinan harga yang cocok dengan keep
+func (s *MemoryPriceStore) filter(keep func(Price) bool) []Price {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return Filter(s.prices, keep)
+}
+
+// sortNewestFirst ORDER BY created_at DESC, id DESC
+func sortNewestFirst(prices []Price) {
+	sort.SliceStable(prices, func(i, j int) bool {
+		if prices[i].CreatedAt != prices[j].CreatedAt {
+			return prices[i].CreatedAt > prices[j].CreatedAt
+		}
+		return prices[i].ID > prices[j].ID
+	})
+}
+
+// sortChronological ORDER BY recorded_at ASC, id ASC
+func sortChronological(prices []Price) {
+	sort.SliceStable(prices, func(i, j int) bool {
+		if prices[i].RecordedAt != prices[j].RecordedAt {
+			return prices[i].RecordedAt < prices[j].RecordedAt
+		}
+		return prices[i].ID < prices[j].ID
+	})
+}
+
+func (s *MemoryPriceStore) GetAll(q PriceQuery) (PricePage, error) {
+	region := NormalizeRegion(q.Region)
+	matched := s.filter(func(p Price) bool { return q.Region == "" || p.Region == region })
+
+	sortNewestFirst(matched)
+	page := PricePage{Total: len(matched), Prices: []Price{}}
+	if q.Offset < len(matched) {
+		end := len(matched)
+		if q.Limit >= 0 && q.Offset+q.Limit < end {
+			end = q.Offset + q.Limit
+		}
+		page.Prices = matched[q.Offset:end]
+	}
+	return page, nil
+}
+
+func (s *MemoryPriceStore) GetLatest(region string) (Price, error) {
+	region = NormalizeRegion(region)
+	matched := s.filter(func(p Price) bool { return p.Region == region })
+
+	if len(matched) == 0 {
+		return Price{}, fmt.Errorf("no price data found for region %s: %w", region, sql.ErrNoRows)
+	}
+	sortNewestFirst(matched)
+	return matched[0], nil
+}
+
+func (s *MemoryPriceStore) GetLatestMany(regions []string) (map[string]Price, error) {
+	latest := make(map[string]Price, len(regions))
+	for _, region := range regions {
+		p, err := s.GetLatest(region)
+		if err == nil {
+			latest[p.Region] = p
+		}
+	}
+	return latest, nil
+}
+
+func (s *MemoryPriceStore) LatestReal(regions []string) (map[string]float64, error) {
+	wanted := make(map[string]bool, len(regions))
+	for _, region := range regions {
+		wanted[NormalizeRegion(region)] = true
+	}
+	matched := s.filter(func(p Price) bool { return p.SourceType != sourceTypeSimulation && wanted[p.Region] })
+
+	// Yang terakhir per region setelah urut kronologis = ORDER BY recorded_at DESC, id DESC LIMIT 1
+	sortChronological(matched)
+	seeds := make(map[string]float64, len(regions))
+	for _, p := range matched {
+		seeds[p.Region] = p.Price
+	}
+	return seeds, nil
+}
+
+func (s *MemoryPriceStore) ByRegion(region string, limit int) ([]Price, error) {
+	region = NormalizeRegion(region)
+	matched := s.filter(func(p Price) bool {
+		return p.Region == region && recordedAtDatePattern.MatchString(p.RecordedAt)
+	})
+
+	// ORDER BY recorded_at DESC, id DESC LIMIT ? lalu dibalik menjadi kronologis
+	sortChronological(matched)
+	if limit >= 0 && len(matched) > limit {
+		matched = matched[len(matched)-limit:]
+	}
+	return matched, nil
+}
+
+func (s *MemoryPriceStore) Each(region string, fn func(Price) error) error {
+	normalized := NormalizeRegion(region)
+	matched := s.filter(func(p Price) bool { return region == "" || p.Region == normalized })
+
+	sortChronological(matched)
+	for _, p := range matched {
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MemoryPriceStore) Stats(region string) ([]RegionPriceStats, error) {
+	normalized := NormalizeRegion(region)
+	byRegion := make(map[string][]Price)
+	for _, p := range s.filter(func(p Price) bool { return region == "" || p.Region == normalized }) {
+		byRegion[p.Region] = append(byRegion[p.Region], p)
+	}
+	names := make([]string, 0, len(byRegion))
+	for name := range byRegion {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	now := localWallClock(time.Now())
+	stats := make([]RegionPriceStats, 0, len(names))
+	for _, name := range names {
+		prices := byRegion[name]
+		st := RegionPriceStats{Region: name, Count: len(prices), Windows: newPriceWindows()}
+		st.Min, st.Max, st.Avg = priceSummary(prices)
+
+		// Hanya recorded_at berformat tanggal yang dihitung (recordedAtIsDate)
+		var first time.Time
+		dated := make(map[int]time.Time)
+		for _, p := range prices {
+			if !recordedAtDatePattern.MatchString(p.RecordedAt) {
+				continue
+			}
+			if st.FirstSeen == "" || p.RecordedAt < st.FirstSeen {
+				st.FirstSeen = p.RecordedAt
+			}
+			if t, ok := parseSQLiteTime(p.RecordedAt); ok {
+				dated[p.ID] = t
+				if first.IsZero() || t.Before(first) {
+					first = t
+				}
+			}
+		}
+
+		for _, win := range priceStatsWindows {
+			cutoff := now.AddDate(0, 0, -win.Days)
+			inWindow := Filter(prices, func(p Price) bool {
+				t, ok := dated[p.ID]
+				return ok && !t.Before(cutoff)
+			})
+			if len(inWindow) == 0 {
+				continue
+			}
+			min, max, avg := priceSummary(inWindow)
+			w := st.Windows[win.Key]
+			w.Count, w.Min, w.Max, w.Avg = len(inWindow), &min, &max, &avg
+			w.Partial = first.After(cutoff)
+		}
+		stats = append(stats, st)
+	}
+	if len(stats) == 0 {
+		return nil, nil
+	}
+	return stats, nil
+}
+
+func (s *MemoryPriceStore) SourceBreakdown(from, to string) (PriceSourceBreakdown, error) {
+	// recorded_at < date(to, '+1 day'); to yang tidak terbaca SQLite = tidak ada baris yang cocok
+	toExclusive := ""
+	if to != "" {
+		t, ok := parseSQLiteTime(to)
+		if !ok {
+			return newPriceSourceBreakdown(nil), nil
+		}
+		toExclusive = t.AddDate(0, 0, 1).Format("2006-01-02")
+	}
+	matched := s.filter(func(p Price) bool {
+		return (from == "" || p.RecordedAt >= from) && (toExclusive == "" || p.RecordedAt < toExclusive)
+	})
+
+	type sourceKey struct{ source, sourceType string }
+	index := make(map[sourceKey]int)
+	var counts []PriceSourceCount
+	for _, p := range matched {
+		key := sourceKey{p.Source, p.SourceType}
+		i, ok := index[key]
+		if !ok {
+			i = len(counts)
+			index[key] = i
+			counts = append(counts, PriceSourceCount{Source: p.Source, SourceType: p.SourceType})
+		}
+		counts[i].Count++
+	}
+
+	// ORDER BY COUNT(*) DESC, source, source_type
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		if counts[i].Source != counts[j].Source {
+			return counts[i].Source < counts[j].Source
+		}
+		return counts[i].SourceType < counts[j].SourceType
+	})
+	return newPriceSourceBreakdown(counts), nil
+}
+
+func (s *MemoryPriceStore) Delete(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, p := range s.prices {
+		if p.ID == id {
+			s.prices = append(s.prices[:i], s.prices[i+1:]...)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (s *MemoryPriceStore) Replace(prices []Price) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Cek ID ganda dulu supaya gagal = isi lama utuh (PRIMARY KEY di SQLite)
+	seen := make(map[int]bool, len(prices))
+	for _, p := range prices {
+		if p.ID != 0 && seen[p.ID] {
+			return 0, fmt.Errorf("insert price %s id %d: id ganda", p.Region, p.ID)
+		}
+		seen[p.ID] = true
+	}
+
+	removed := int64(len(s.prices))
+	s.prices, s.nextID = nil, 1
+	// ID eksplisit dulu supaya ID baru untuk baris ID 0 tidak bentrok
+	for _, p := range prices {
+		if p.ID != 0 && p.ID >= s.nextID {
+			s.nextID = p.ID + 1
+		}
+	}
+	for _, p := range prices {
+		if p.SourceType == "" {
+			p.SourceType = sourceTypeUnknown
+		}
+		s.addLocked(p)
+	}
+	return removed, nil
+}
+
+func (s *MemoryPriceStore) DeleteRecordedBefore(ctx context.Context, cutoff string, batchSize int) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := Filter(s.prices, func(p Price) bool { return p.RecordedAt >= cutoff })
+	removed := int64(len(s.prices) - len(kept))
+	s.prices = kept
+	return removed, nil
+}
+
+func (s *MemoryPriceStore) Ping(context.Context) error { return nil }
+
+// ============================================
+// IN-MEMORY WEATHER STORE
+// ============================================
+
+type MemoryWeatherStore struct {
+	mu     sync.RWMutex
+	rows   []WeatherHistoryRow // urut ID
+	nextID int
+}
+
+func NewMemoryWeatherStore() *MemoryWeatherStore {
+	return &MemoryWeatherStore{nextID: 1}
+}
+
+// addLocked pemanggil memegang s.mu
+func (s *MemoryWeatherStore) addLocked(row WeatherHistoryRow) {
+	if row.ID == 0 {
+		row.ID = s.nextID
+	}
+	if row.ID >= s.nextID {
+		s.nextID = row.ID + 1
+	}
+	if row.CreatedAt == "" {
+		row.CreatedAt = time.Now().UTC().Format(sqliteUTCLayout)
+	}
+	s.rows = append(s.rows, row)
+}
+
+func (s *MemoryWeatherStore) Add(row WeatherHistoryRow) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	row.ID, row.CreatedAt = 0, ""
+	s.addLocked(row)
+}
+
+func (s *MemoryWeatherStore) snapshot() []WeatherHistoryRow {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rows := append([]WeatherHistoryRow(nil), s.rows...)
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].ID < rows[j].ID })
+	return rows
+}
+
+// roundTo padanan ROUND(x, digits) SQLite
+func roundTo(x float64, digits int) float64 {
+	scale := math.Pow(10, float64(digits))
+	return math.Round(x*scale) / scale
+}
+
+// avgOf rata-rata (sum=true: jumlah) nilai non-nil, seperti AVG/SUM SQL yang
+// mengabaikan NULL; nil jika semuanya nil
+func avgOf(values []*float64, sum bool) *float64 {
+	total, n := 0.0, 0
+	for _, v := range values {
+		if v != nil {
+			total, n = total+*v, n+1
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+	if !sum {
+		total /= float64(n)
+	}
+	return &total
+}
+
+func (s *MemoryWeatherStore) DailyHistory(region string, days int) ([]WeatherDailyAggregate, error) {
+	region = NormalizeRegion(region)
+	// datetime('now', '+7 hours', 'start of day', '-N days', '-7 hours')
+	nowWIB := time.Now().UTC().Add(7 * time.Hour)
+	startWIB := time.Date(nowWIB.Year(), nowWIB.Month(), nowWIB.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -(days - 1))
+	start := startWIB.Add(-7 * time.Hour).Format(sqliteUTCLayout)
+
+	type dayRows struct {
+		temps, humidity, rain []*float64
+		samples               int
+	}
+	byDay := make(map[string]*dayRows)
+	for _, row := range s.snapshot() {
+		if row.Region != region || row.FetchedAt < start {
+			continue
+		}
+		t, ok := parseSQLiteTime(row.FetchedAt)
+		if !ok {
+			continue
+		}
+		day := t.Add(7 * time.Hour).Format("2006-01-02")
+		d := byDay[day]
+		if d == nil {
+			d = &dayRows{}
+			byDay[day] = d
+		}
+		d.samples++
+		d.temps = append(d.temps, row.TempC)
+		d.rain = append(d.rain, row.RainMM)
+		if row.Humidity != nil {
+			h := float64(*row.Humidity)
+			d.humidity = append(d.humidity, &h)
+		}
+	}
+
+	var result []WeatherDailyAggregate
+	for day, d := range byDay {
+		agg := WeatherDailyAggregate{Date: day, Samples: d.samples}
+		if v := avgOf(d.temps, false); v != nil {
+			r := roundTo(*v, 1)
+			agg.AvgTemp = &r
+		}
+		if v := avgOf(d.humidity, false); v != nil {
+			r := roundTo(*v, 1)
+			agg.AvgHumidity = &r
+		}
+		if v := avgOf(d.rain, true); v != nil {
+			r := roundTo(*v, 2)
+			agg.TotalRain = &r
+		}
+		result = append(result, agg)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Date < result[j].Date })
+	return result, nil
+}
+
+func (s *MemoryWeatherStore) Nearest(region string, at time.Time) (HistoricalWeather, error) {
+	region = NormalizeRegion(region)
+	lo, hi := formatFetchedAt(at.Add(-historicalWeatherMaxGap)), formatFetchedAt(at.Add(historicalWeatherMaxGap))
+
+	var best *WeatherHistoryRow
+	var bestGap float64
+	for _, row := range s.snapshot() {
+		if row.Region != region || row.TempC == nil || row.Humidity == nil || row.FetchedAt < lo || row.FetchedAt > hi {
+			continue
+		}
+		t, ok := parseSQLiteTime(row.FetchedAt)
+		if !ok {
+			continue
+		}
+		// ORDER BY gap ASC, id DESC (snapshot urut ID naik)
+		gap := math.Abs(t.Sub(at.UTC()).Minutes())
+		if best == nil || gap <= bestGap {
+			row := row
+			best, bestGap = &row, gap
+		}
+	}
+	if best == nil {
+		return HistoricalWeather{}, sql.ErrNoRows
+	}
+
+	h := HistoricalWeather{FetchedAt: best.FetchedAt, GapMinutes: int(bestGap + 0.5)}
+	h.Region, h.Temp, h.Humidity = region, *best.TempC, *best.Humidity
+	if best.RainMM != nil {
+		h.Rain, h.RainAvailable = *best.RainMM, true
+	}
+	return h, nil
+}
+
+func (s *MemoryWeatherStore) Each(fn func(WeatherHistoryRow) error) error {
+	for _, row := range s.snapshot() {
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MemoryWeatherStore) Replace(rows []WeatherHistoryRow) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[int]bool, len(rows))
+	for _, row := range rows {
+		if row.ID != 0 && seen[row.ID] {
+			return 0, fmt.Errorf("insert weather_history %s id %d: id ganda", row.Region, row.ID)
+		}
+		seen[row.ID] = true
+	}
+
+	removed := int64(len(s.rows))
+	s.rows, s.nextID = nil, 1
+	for _, row := range rows {
+		if row.ID != 0 && row.ID >= s.nextID {
+			s.nextID = row.ID + 1
+		}
+	}
+	for _, row := range rows {
+		s.addLocked(row)
+	}
+	return removed, nil
+}
+
+func (s *MemoryWeatherStore) DeleteFetchedBefore(ctx context.Context, cutoff string, batchSize int) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := Filter(s.rows, func(row WeatherHistoryRow) bool { return row.FetchedAt >= cutoff })
+	removed := int64(len(s.rows) - len(kept))
+	s.rows = kept
+	return removed, nil
+}
@@ -0,0 +1,529 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// ============================================
+// HELPER TEST
+// Store memakai variabel global (priceStore, weatherStore, DB, dbWriter), jadi test
+// yang menggantinya tidak boleh t.Parallel().
+// ============================================
+
+// openTestDB membuka SQLite baru di direktori sementara lewat InitDB (schema asli)
+func openTestDB(t *testing.T) {
+	t.Helper()
+	openTestDBAt(t, filepath.Join(t.TempDir(), "test.db"))
+}
+
+// openTestDBAt seperti openTestDB untuk file yang mungkin sudah ada (mis. schema lama)
+func openTestDBAt(t *testing.T, path string) {
+	t.Helper()
+	prevDB, prevWriter := DB, dbWriter
+	InitDB(DBConfig{
+		Path:        path,
+		SchemaPath:  filepath.Join("..", "sql", "schema.sql"),
+		BusyTimeout: 5 * time.Second,
+		JournalMode: "wal",
+	})
+	t.Cleanup(func() {
+		dbWriter.Close()
+		DB.Close()
+		DB, dbWriter = prevDB, prevWriter
+	})
+}
+
+// useStores memasang ps & ws sebagai store global selama test
+func useStores(t *testing.T, ps PriceStore, ws WeatherStore) {
+	t.Helper()
+	prevPrice, prevWeather := priceStore, weatherStore
+	priceStore, weatherStore = ps, ws
+	t.Cleanup(func() { priceStore, weatherStore = prevPrice, prevWeather })
+}
+
+// flushWrites menunggu write async (WeatherStore.Add SQLite) yang sudah diantrekan selesai
+func flushWrites(t *testing.T) {
+	t.Helper()
+	if DB == nil {
+		return
+	}
+	if err := dbWriter.Tx(func(*sql.Tx) error { return nil }); err != nil {
+		t.Fatalf("flush dbWriter: %v", err)
+	}
+}
+
+type storeBackend struct {
+	name  string
+	setup func(t *testing.T) (PriceStore, WeatherStore)
+}
+
+var storeBackends = []storeBackend{
+	{"sqlite", func(t *testing.T) (PriceStore, WeatherStore) {
+		openTestDB(t)
+		return SQLitePriceStore{}, SQLiteWeatherStore{}
+	}},
+	{"memory", func(*testing.T) (PriceStore, WeatherStore) {
+		return NewMemoryPriceStore(), NewMemoryWeatherStore()
+	}},
+}
+
+// forEachStore menjalankan fn sekali per backend dengan store baru yang kosong
+func forEachStore(t *testing.T, fn func(t *testing.T, ps PriceStore, ws WeatherStore)) {
+	for _, b := range storeBackends {
+		t.Run(b.name, func(t *testing.T) {
+			ps, ws := b.setup(t)
+			useStores(t, ps, ws)
+			fn(t, ps, ws)
+		})
+	}
+}
+
+func testPrice(region string, price float64, recordedAt string) Price {
+	return Price{Region: region, Price: price, Unit: "kg", Source: "test", SourceType: sourceTypeManual, RecordedAt: recordedAt}
+}
+
+func mustAdd(t *testing.T, ps PriceStore, prices ...Price) []Price {
+	t.Helper()
+	saved := make([]Price, len(prices))
+	for i, p := range prices {
+		var err error
+		if saved[i], err = ps.Add(p); err != nil {
+			t.Fatalf("Add(%+v): %v", p, err)
+		}
+	}
+	return saved
+}
+
+func priceIDs(prices []Price) []int {
+	return Map(prices, func(p Price) int { return p.ID })
+}
+
+func localDaysAgo(days int) string {
+	return time.Now().AddDate(0, 0, -days).Format(sqliteUTCLayout)
+}
+
+func floatPtr(v float64) *float64 { return &v }
+
+func intPtr(v int) *int { return &v }
+
+// ============================================
+// PARITY SQLITE vs MEMORY
+// ============================================
+
+func TestPriceStoreParity(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, ps PriceStore)
+	}{
+		{"add and page newest first", func(t *testing.T, ps PriceStore) {
+			saved := mustAdd(t, ps,
+				testPrice("Jember", 40000, "2026-01-01"),
+				testPrice("Bondowoso", 41000, "2026-01-02"),
+				testPrice("Jember", 42000, "2026-01-03"))
+			if saved[0].ID == 0 || saved[0].CreatedAt == "" {
+				t.Fatalf("Add tidak mengisi ID/CreatedAt: %+v", saved[0])
+			}
+
+			page, err := ps.GetAll(PriceQuery{Region: "Jember", Limit: 1, Offset: 0})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page.Total != 2 || len(page.Prices) != 1 || page.Prices[0].ID != saved[2].ID {
+				t.Fatalf("GetAll region = total %d ids %v, ingin total 2 id [%d]", page.Total, priceIDs(page.Prices), saved[2].ID)
+			}
+
+			page, err = ps.GetAll(PriceQuery{Limit: 10, Offset: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := []int{saved[1].ID, saved[0].ID}; page.Total != 3 || !reflect.DeepEqual(priceIDs(page.Prices), want) {
+				t.Fatalf("GetAll offset = total %d ids %v, ingin 3 %v", page.Total, priceIDs(page.Prices), want)
+			}
+		}},
+		{"add batch", func(t *testing.T, ps PriceStore) {
+			saved, err := ps.AddBatch([]Price{testPrice("Jember", 1, "2026-01-01"), testPrice("Jember", 2, "2026-01-02")})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(saved) != 2 || saved[0].ID == 0 || saved[1].ID <= saved[0].ID || saved[1].Price != 2 {
+				t.Fatalf("AddBatch = %+v", saved)
+			}
+			if page, _ := ps.GetAll(PriceQuery{Limit: 10}); page.Total != 2 {
+				t.Fatalf("total setelah AddBatch = %d, ingin 2", page.Total)
+			}
+		}},
+		{"latest", func(t *testing.T, ps PriceStore) {
+			saved := mustAdd(t, ps,
+				testPrice("Jember", 40000, "2026-01-05"),
+				testPrice("Jember", 41000, "2026-01-01"),
+				testPrice("Situbondo", 39000, "2026-01-01"))
+
+			// Terbaru menurut created_at/id, bukan recorded_at
+			p, err := ps.GetLatest("jember")
+			if err != nil || p.ID != saved[1].ID {
+				t.Fatalf("GetLatest = %+v, %v; ingin id %d", p, err, saved[1].ID)
+			}
+			if _, err := ps.GetLatest("Lumajang"); !errors.Is(err, sql.ErrNoRows) {
+				t.Fatalf("GetLatest region kosong err = %v, ingin sql.ErrNoRows", err)
+			}
+
+			many, err := ps.GetLatestMany([]string{"Jember", "Situbondo", "Lumajang"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(many) != 2 || many["Jember"].ID != saved[1].ID || many["Situbondo"].ID != saved[2].ID {
+				t.Fatalf("GetLatestMany = %+v", many)
+			}
+		}},
+		{"latest real skips simulation", func(t *testing.T, ps PriceStore) {
+			sim := testPrice("Jember", 99999, "2026-02-01")
+			sim.SourceType = sourceTypeSimulation
+			mustAdd(t, ps,
+				testPrice("Jember", 40000, "2026-01-10"),
+				testPrice("Jember", 41000, "2026-01-01"),
+				sim,
+				testPrice("Bondowoso", 38000, "2026-01-01"))
+
+			seeds, err := ps.LatestReal([]string{"jember", "Bondowoso", "Lumajang"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := map[string]float64{"Jember": 40000, "Bondowoso": 38000}; !reflect.DeepEqual(seeds, want) {
+				t.Fatalf("LatestReal = %v, ingin %v", seeds, want)
+			}
+		}},
+		{"by region chronological", func(t *testing.T, ps PriceStore) {
+			saved := mustAdd(t, ps,
+				testPrice("Jember", 3, "2026-01-03"),
+				testPrice("Jember", 1, "2026-01-01"),
+				testPrice("Jember", 0, "bukan tanggal"),
+				testPrice("Jember", 2, "2026-01-02"),
+				testPrice("Bondowoso", 9, "2026-01-04"))
+
+			series, err := ps.ByRegion("Jember", 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := []int{saved[3].ID, saved[0].ID}; !reflect.DeepEqual(priceIDs(series), want) {
+				t.Fatalf("ByRegion = %v, ingin %v", priceIDs(series), want)
+			}
+		}},
+		{"each", func(t *testing.T, ps PriceStore) {
+			saved := mustAdd(t, ps,
+				testPrice("Jember", 2, "2026-01-02"),
+				testPrice("Bondowoso", 1, "2026-01-01"),
+				testPrice("Jember", 3, "2026-01-02"))
+
+			var all []Price
+			if err := ps.Each("", func(p Price) error { all = append(all, p); return nil }); err != nil {
+				t.Fatal(err)
+			}
+			if want := []int{saved[1].ID, saved[0].ID, saved[2].ID}; !reflect.DeepEqual(priceIDs(all), want) {
+				t.Fatalf("Each = %v, ingin %v", priceIDs(all), want)
+			}
+
+			stop := errors.New("stop")
+			calls := 0
+			err := ps.Each("jember", func(p Price) error {
+				calls++
+				if p.Region != "Jember" {
+					t.Errorf("Each region = %s", p.Region)
+				}
+				return stop
+			})
+			if !errors.Is(err, stop) || calls != 1 {
+				t.Fatalf("Each berhenti = %v setelah %d panggilan", err, calls)
+			}
+		}},
+		{"stats", func(t *testing.T, ps PriceStore) {
+			first := localDaysAgo(40)
+			mustAdd(t, ps,
+				testPrice("Jember", 100, first),
+				testPrice("Jember", 200, localDaysAgo(10)),
+				testPrice("Jember", 300, localDaysAgo(2)),
+				testPrice("Jember", 999, "tidak diketahui"),
+				testPrice("Bondowoso", 50, localDaysAgo(1)))
+
+			stats, err := ps.Stats("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(stats) != 2 || stats[0].Region != "Bondowoso" || stats[1].Region != "Jember" {
+				t.Fatalf("Stats region = %+v", stats)
+			}
+
+			jember := stats[1]
+			if jember.Count != 4 || jember.Min != 100 || jember.Max != 999 || jember.Avg != 399.75 {
+				t.Errorf("Jember all-time = %+v", jember)
+			}
+			if jember.FirstSeen != first {
+				t.Errorf("Jember FirstSeen = %q", jember.FirstSeen)
+			}
+			assertWindow(t, "Jember 7d", jember.Windows["7d"], 1, 300, 300, false)
+			assertWindow(t, "Jember 30d", jember.Windows["30d"], 2, 200, 300, false)
+
+			// Data Bondowoso baru 1 hari: window belum tercakup penuh
+			assertWindow(t, "Bondowoso 7d", stats[0].Windows["7d"], 1, 50, 50, true)
+
+			filtered, err := ps.Stats("bondowoso")
+			if err != nil || len(filtered) != 1 || filtered[0].Region != "Bondowoso" {
+				t.Fatalf("Stats(bondowoso) = %+v, %v", filtered, err)
+			}
+			empty, err := ps.Stats("Lumajang")
+			if err != nil || len(empty) != 0 {
+				t.Fatalf("Stats(Lumajang) = %+v, %v", empty, err)
+			}
+		}},
+		{"stats window without rows", func(t *testing.T, ps PriceStore) {
+			mustAdd(t, ps, testPrice("Jember", 100, localDaysAgo(60)))
+			stats, err := ps.Stats("Jember")
+			if err != nil || len(stats) != 1 {
+				t.Fatalf("Stats = %+v, %v", stats, err)
+			}
+			if w := stats[0].Windows["30d"]; w.Count != 0 || w.Avg != nil || !w.Partial {
+				t.Fatalf("window kosong = %+v", w)
+			}
+		}},
+		{"source breakdown", func(t *testing.T, ps PriceStore) {
+			bappebti := testPrice("Jember", 1, "2026-03-01 10:00:00")
+			bappebti.Source, bappebti.SourceType = "BAPPEBTI", sourceTypeBAPPEBTI
+			mustAdd(t, ps,
+				bappebti, bappebti,
+				testPrice("Jember", 1, "2026-03-02"),
+				testPrice("Jember", 1, "2026-03-02 23:59:59"),
+				testPrice("Jember", 1, "2026-03-03"))
+
+			all, err := ps.SourceBreakdown("", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := PriceSourceBreakdown{
+				Total:  5,
+				ByType: map[string]int{sourceTypeManual: 3, sourceTypeBAPPEBTI: 2},
+				Sources: []PriceSourceCount{
+					{Source: "test", SourceType: sourceTypeManual, Count: 3},
+					{Source: "BAPPEBTI", SourceType: sourceTypeBAPPEBTI, Count: 2},
+				},
+			}
+			if !reflect.DeepEqual(all, want) {
+				t.Fatalf("SourceBreakdown = %+v, ingin %+v", all, want)
+			}
+
+			// to inklusif sampai akhir hari
+			ranged, err := ps.SourceBreakdown("2026-03-02", "2026-03-02")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ranged.Total != 2 || len(ranged.Sources) != 1 || ranged.Sources[0].Source != "test" {
+				t.Fatalf("SourceBreakdown rentang = %+v", ranged)
+			}
+
+			none, err := ps.SourceBreakdown("2027-01-01", "")
+			if err != nil || none.Total != 0 || none.Sources == nil || len(none.Sources) != 0 {
+				t.Fatalf("SourceBreakdown kosong = %+v, %v", none, err)
+			}
+		}},
+		{"delete", func(t *testing.T, ps PriceStore) {
+			saved := mustAdd(t, ps, testPrice("Jember", 1, "2026-01-01"))
+			if err := ps.Delete(saved[0].ID); err != nil {
+				t.Fatal(err)
+			}
+			if err := ps.Delete(saved[0].ID); !errors.Is(err, sql.ErrNoRows) {
+				t.Fatalf("Delete kedua = %v, ingin sql.ErrNoRows", err)
+			}
+		}},
+		{"replace", func(t *testing.T, ps PriceStore) {
+			mustAdd(t, ps, testPrice("Jember", 1, "2026-01-01"), testPrice("Jember", 2, "2026-01-02"))
+
+			keep := testPrice("Bondowoso", 10, "2026-02-01")
+			keep.ID, keep.CreatedAt = 50, "2026-02-01 00:00:00"
+			removed, err := ps.Replace([]Price{keep, testPrice("Bondowoso", 11, "2026-02-02")})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if removed != 2 {
+				t.Errorf("Replace removed = %d, ingin 2", removed)
+			}
+
+			var all []Price
+			ps.Each("", func(p Price) error { all = append(all, p); return nil })
+			if len(all) != 2 || all[0].ID != 50 || all[0].CreatedAt != keep.CreatedAt || all[1].ID <= 50 || all[1].CreatedAt == "" {
+				t.Fatalf("isi setelah Replace = %+v", all)
+			}
+			if added := mustAdd(t, ps, testPrice("Bondowoso", 12, "2026-02-03")); added[0].ID <= all[1].ID {
+				t.Fatalf("ID setelah Replace = %d, ingin > %d", added[0].ID, all[1].ID)
+			}
+
+			// ID ganda ditolak tanpa mengubah isi
+			dup := []Price{keep, keep}
+			if _, err := ps.Replace(dup); err == nil {
+				t.Fatal("Replace dengan ID ganda tidak error")
+			}
+			if page, _ := ps.GetAll(PriceQuery{Limit: 10}); page.Total != 3 {
+				t.Fatalf("isi berubah setelah Replace gagal: total %d", page.Total)
+			}
+		}},
+		{"delete recorded before", func(t *testing.T, ps PriceStore) {
+			mustAdd(t, ps,
+				testPrice("Jember", 1, "2026-01-01"),
+				testPrice("Jember", 2, "2026-01-02 08:00:00"),
+				testPrice("Jember", 3, "2026-01-03"))
+
+			removed, err := ps.DeleteRecordedBefore(context.Background(), "2026-01-02 09:00:00", 1)
+			if err != nil || removed != 2 {
+				t.Fatalf("DeleteRecordedBefore = %d, %v; ingin 2", removed, err)
+			}
+			if page, _ := ps.GetAll(PriceQuery{Limit: 10}); page.Total != 1 || page.Prices[0].Price != 3 {
+				t.Fatalf("sisa = %+v", page.Prices)
+			}
+		}},
+		{"ping", func(t *testing.T, ps PriceStore) {
+			if err := ps.Ping(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forEachStore(t, func(t *testing.T, ps PriceStore, _ WeatherStore) { tt.run(t, ps) })
+		})
+	}
+}
+
+func assertWindow(t *testing.T, name string, w *PriceWindowStats, count int, min, max float64, partial bool) {
+	t.Helper()
+	if w == nil || w.Count != count || w.Min == nil || *w.Min != min || *w.Max != max || w.Partial != partial {
+		t.Errorf("%s = %+v, ingin count %d min %v max %v partial %v", name, w, count, min, max, partial)
+	}
+}
+
+func TestWeatherStoreParity(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		run  func(t *testing.T, ws WeatherStore)
+	}{
+		{"add and each", func(t *testing.T, ws WeatherStore) {
+			ws.Add(WeatherHistoryRow{Region: "Jember", TempC: floatPtr(27), Humidity: intPtr(70), FetchedAt: formatFetchedAt(now)})
+			ws.Add(WeatherHistoryRow{Region: "Bondowoso", FetchedAt: formatFetchedAt(now)})
+			flushWrites(t)
+
+			var rows []WeatherHistoryRow
+			if err := ws.Each(func(r WeatherHistoryRow) error { rows = append(rows, r); return nil }); err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != 2 || rows[0].Region != "Jember" || rows[0].ID >= rows[1].ID || rows[1].TempC != nil || rows[0].CreatedAt == "" {
+				t.Fatalf("Each = %+v", rows)
+			}
+		}},
+		{"nearest", func(t *testing.T, ws WeatherStore) {
+			ws.Replace([]WeatherHistoryRow{
+				{Region: "Jember", TempC: floatPtr(25), Humidity: intPtr(80), FetchedAt: formatFetchedAt(now.Add(-2 * time.Hour))},
+				{Region: "Jember", TempC: floatPtr(28), Humidity: intPtr(65), RainMM: floatPtr(1.5), FetchedAt: formatFetchedAt(now.Add(-30 * time.Minute))},
+				// Lebih dekat tapi tanpa suhu: dilewati
+				{Region: "Jember", Humidity: intPtr(60), FetchedAt: formatFetchedAt(now.Add(-5 * time.Minute))},
+				{Region: "Bondowoso", TempC: floatPtr(30), Humidity: intPtr(50), FetchedAt: formatFetchedAt(now)},
+			})
+
+			h, err := ws.Nearest("jember", now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if h.Temp != 28 || h.Humidity != 65 || !h.RainAvailable || h.Rain != 1.5 || h.GapMinutes != 30 || h.Region != "Jember" {
+				t.Fatalf("Nearest = %+v", h)
+			}
+
+			if _, err := ws.Nearest("Jember", now.Add(-24*time.Hour)); !errors.Is(err, sql.ErrNoRows) {
+				t.Fatalf("Nearest jauh err = %v, ingin sql.ErrNoRows", err)
+			}
+		}},
+		{"daily history", func(t *testing.T, ws WeatherStore) {
+			ws.Replace([]WeatherHistoryRow{
+				{Region: "Jember", TempC: floatPtr(26), Humidity: intPtr(70), RainMM: floatPtr(1.25), FetchedAt: formatFetchedAt(now)},
+				{Region: "Jember", TempC: floatPtr(27), Humidity: intPtr(75), FetchedAt: formatFetchedAt(now)},
+				{Region: "Jember", TempC: floatPtr(20), Humidity: intPtr(90), FetchedAt: formatFetchedAt(now.AddDate(0, 0, -30))},
+			})
+
+			days, err := ws.DailyHistory("Jember", 7)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(days) != 1 {
+				t.Fatalf("DailyHistory = %+v, ingin 1 hari", days)
+			}
+			d := days[0]
+			wantDate := now.UTC().Add(7 * time.Hour).Format("2006-01-02")
+			if d.Date != wantDate || d.Samples != 2 || *d.AvgTemp != 26.5 || *d.AvgHumidity != 72.5 || *d.TotalRain != 1.25 {
+				t.Fatalf("DailyHistory = %+v (tanggal %s), ingin %s", d, d.Date, wantDate)
+			}
+		}},
+		{"daily history across WIB days", func(t *testing.T, ws WeatherStore) {
+			nowWIB := now.In(reportTimezone)
+			midnight := time.Date(nowWIB.Year(), nowWIB.Month(), nowWIB.Day(), 0, 0, 0, 0, reportTimezone)
+			at := func(d time.Duration) string { return formatFetchedAt(midnight.Add(d)) }
+			ws.Replace([]WeatherHistoryRow{
+				// 3 hari lalu 23:30 WIB: di luar window days=3
+				{Region: "Jember", TempC: floatPtr(10), Humidity: intPtr(10), FetchedAt: at(-2*24*time.Hour - 30*time.Minute)},
+				{Region: "Jember", TempC: floatPtr(22), Humidity: intPtr(80), RainMM: floatPtr(4), FetchedAt: at(-2*24*time.Hour + 30*time.Minute)},
+				{Region: "Jember", TempC: floatPtr(24), Humidity: intPtr(70), RainMM: floatPtr(0.5), FetchedAt: at(-14 * time.Hour)},
+				// 23:00 WIB kemarin = 16:00 UTC: tetap hari kemarin, bukan tanggal UTC
+				{Region: "Jember", TempC: floatPtr(26), Humidity: intPtr(60), RainMM: floatPtr(1), FetchedAt: at(-time.Hour)},
+				{Region: "Jember", TempC: floatPtr(28), Humidity: intPtr(65), FetchedAt: at(time.Hour)},
+				{Region: "Bondowoso", TempC: floatPtr(35), Humidity: intPtr(40), FetchedAt: at(time.Hour)},
+			})
+
+			days, err := ws.DailyHistory("Jember", 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			day := func(offset int) string { return midnight.AddDate(0, 0, offset).Format("2006-01-02") }
+			if len(days) != 3 || days[0].Date != day(-2) || days[1].Date != day(-1) || days[2].Date != day(0) {
+				t.Fatalf("DailyHistory = %+v, ingin %s..%s", days, day(-2), day(0))
+			}
+			if d := days[0]; d.Samples != 1 || *d.AvgTemp != 22 || *d.TotalRain != 4 {
+				t.Errorf("hari -2 = %+v", d)
+			}
+			if d := days[1]; d.Samples != 2 || *d.AvgTemp != 25 || *d.AvgHumidity != 65 || *d.TotalRain != 1.5 {
+				t.Errorf("kemarin = %+v", d)
+			}
+			if d := days[2]; d.Samples != 1 || *d.AvgTemp != 28 || d.TotalRain != nil {
+				t.Errorf("hari ini = %+v", d)
+			}
+		}},
+		{"replace and delete before", func(t *testing.T, ws WeatherStore) {
+			ws.Add(WeatherHistoryRow{Region: "Jember", FetchedAt: "2026-01-01 00:00:00"})
+			flushWrites(t)
+
+			removed, err := ws.Replace([]WeatherHistoryRow{
+				{ID: 7, Region: "Jember", FetchedAt: "2026-01-01 00:00:00", CreatedAt: "2026-01-01 00:00:00"},
+				{Region: "Jember", FetchedAt: "2026-01-05 00:00:00"},
+			})
+			if err != nil || removed != 1 {
+				t.Fatalf("Replace = %d, %v", removed, err)
+			}
+
+			n, err := ws.DeleteFetchedBefore(context.Background(), "2026-01-02 00:00:00", 10)
+			if err != nil || n != 1 {
+				t.Fatalf("DeleteFetchedBefore = %d, %v", n, err)
+			}
+			var rows []WeatherHistoryRow
+			ws.Each(func(r WeatherHistoryRow) error { rows = append(rows, r); return nil })
+			if len(rows) != 1 || rows[0].ID <= 7 {
+				t.Fatalf("sisa = %+v", rows)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forEachStore(t, func(t *testing.T, _ PriceStore, ws WeatherStore) { tt.run(t, ws) })
+		})
+	}
+}
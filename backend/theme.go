@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+// ============================================
+// TEMA SIMBOL SARAN (?theme=emoji|ascii|none)
+// Teks saran selalu dibangun dengan simbol emoji dari adviceSymbols (termasuk yang
+// disimpan di cache); tema lain diterapkan saat response lewat Theme.Apply.
+// ============================================
+
+type Theme string
+
+const (
+	ThemeEmoji Theme = "emoji" // default
+	ThemeASCII Theme = "ascii" // penanda [OK]/[WARN]/[BAD] untuk terminal & gateway SMS lama
+	ThemeNone  Theme = "none"  // tanpa penanda sama sekali (sama dengan ?plain=true)
+)
+
+// ParseTheme kosong = emoji
+func ParseTheme(raw string) (Theme, error) {
+	switch t := Theme(strings.ToLower(strings.TrimSpace(raw))); t {
+	case "":
+		return ThemeEmoji, nil
+	case ThemeEmoji, ThemeASCII, ThemeNone:
+		return t, nil
+	default:
+		return "", fmt.Errorf("theme %q tidak didukung (pilih emoji, ascii, atau none)", raw)
+	}
+}
+
+type symbolKey string
+
+const (
+	symOptimal   symbolKey = "optimal"
+	symOK        symbolKey = "ok"
+	symWarn      symbolKey = "warn"
+	symBad       symbolKey = "bad"
+	symDanger    symbolKey = "danger"
+	symWater     symbolKey = "water"
+	symDry       symbolKey = "dry"
+	symLightRain symbolKey = "light_rain"
+	symRain      symbolKey = "rain"
+	symStorm     symbolKey = "storm"
+)
+
+// adviceSymbols tabel pusat simbol per tema. ThemeNone tidak perlu entri:
+// simbolnya dibuang lewat StripEmoji.
+var adviceSymbols = map[symbolKey]map[Theme]string{
+	symOptimal:   {ThemeEmoji: "🌟", ThemeASCII: "[BEST]"},
+	symOK:        {ThemeEmoji: "✅", ThemeASCII: "[OK]"},
+	symWarn:      {ThemeEmoji: "⚠️", ThemeASCII: "[WARN]"},
+	symBad:       {ThemeEmoji: "❌", ThemeASCII: "[BAD]"},
+	symDanger:    {ThemeEmoji: "🚨", ThemeASCII: "[ALERT]"},
+	symWater:     {ThemeEmoji: "💧", ThemeASCII: "[WATER]"},
+	symDry:       {ThemeEmoji: "☀️", ThemeASCII: "[DRY]"},
+	symLightRain: {ThemeEmoji: "🌦️", ThemeASCII: "[RAIN]"},
+	symRain:      {ThemeEmoji: "🌧️", ThemeASCII: "[RAIN]"},
+	symStorm:     {ThemeEmoji: "⛈️", ThemeASCII: "[STORM]"},
+}
+
+// withSymbol teks saran dengan simbol emoji di depannya
+func withSymbol(key symbolKey, text string) string {
+	return adviceSymbols[key][ThemeEmoji] + " " + text
+}
+
+// asciiSymbolReplacer emoji -> penanda ASCII, dibangun sekali dari adviceSymbols
+var asciiSymbolReplacer = func() *strings.Replacer {
+	var pairs []string
+	for _, symbols := range adviceSymbols {
+		pairs = append(pairs, symbols[ThemeEmoji], symbols[ThemeASCII])
+	}
+	return strings.NewReplacer(pairs...)
+}()
+
+// asciiTransliterator tanda non-ASCII yang dipakai teks saran (satuan, rentang) -> padanan ASCII
+var asciiTransliterator = strings.NewReplacer(
+	"°", "",
+	"±", "+/-",
+	"²", "2",
+	"³", "3",
+	"–", "-",
+	"—", "-",
+	"…", "...",
+	"×", "x",
+)
+
+// toASCII transliterasi tanda yang dikenal lalu membuang rune non-ASCII yang tersisa
+func toASCII(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII {
+			return -1
+		}
+		return r
+	}, asciiTransliterator.Replace(s))
+}
+
+// Apply mengganti simbol emoji di s sesuai tema. Pada tema ascii semua rune non-ASCII
+// (emoji di luar tabel, "°C", dst.) ditransliterasi atau dibuang supaya keluarannya
+// benar-benar ASCII-safe.
+func (t Theme) Apply(s string) string {
+	switch t {
+	case ThemeASCII:
+		return StripEmoji(toASCII(asciiSymbolReplacer.Replace(s)))
+	case ThemeNone:
+		return StripEmoji(s)
+	default:
+		return s
+	}
+}
+
+// themeFromRequest ?theme=; ?plain=true (lama) setara theme=none. Error sudah berbentuk APIError 400.
+func themeFromRequest(r *http.Request) (Theme, error) {
+	q := r.URL.Query()
+	if q.Get("theme") == "" && q.Get("plain") == "true" {
+		return ThemeNone, nil
+	}
+	t, err := ParseTheme(q.Get("theme"))
+	if err != nil {
+		return "", NewAPIError(http.StatusBadRequest, "invalid_theme", err.Error())
+	}
+	return t, nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestThemeApplySymbolPrefixes(t *testing.T) {
+	for key, symbols := range adviceSymbols {
+		t.Run(string(key), func(t *testing.T) {
+			advice := withSymbol(key, "Kondisi lahan")
+			want := map[Theme]string{
+				ThemeEmoji: symbols[ThemeEmoji] + " Kondisi lahan",
+				ThemeASCII: symbols[ThemeASCII] + " Kondisi lahan",
+				ThemeNone:  "Kondisi lahan",
+			}
+			for theme, w := range want {
+				if got := theme.Apply(advice); got != w {
+					t.Fatalf("%s.Apply(%q) = %q, ingin %q", theme, advice, got, w)
+				}
+			}
+		})
+	}
+}
+
+func TestThemeASCIIOutputIsASCII(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{withSymbol(symWarn, "Suhu 33.0°C di atas 30°C"), "[WARN] Suhu 33.0C di atas 30C"},
+		{"Target ±2°C, luas 1.5 m²", "Target +/-2C, luas 1.5 m2"},
+		// Emoji di luar tabel & rune lain tanpa padanan dibuang
+		{"🌱 Tanam — segera…", "Tanam - segera..."},
+		{"Kelembaban 70% → ideal ✓", "Kelembaban 70% ideal"},
+	}
+	for _, tt := range tests {
+		got := ThemeASCII.Apply(tt.in)
+		if got != tt.want {
+			t.Fatalf("ascii.Apply(%q) = %q, ingin %q", tt.in, got, tt.want)
+		}
+	}
+
+	// Teks saran sungguhan (dengan °C dari explain) harus seluruhnya ASCII
+	result := GetAdvancedRecommendation(34, 92, 12, "Jember").Themed(ThemeASCII)
+	texts := append([]string{result.MainAdvice, result.PlantingAdvice, result.HarvestAdvice,
+		result.DryingAdvice, result.PestWarning, result.IrrigationAdvice}, result.DetailedAdvice...)
+	for _, text := range texts {
+		if i := strings.IndexFunc(text, func(r rune) bool { return r > unicode.MaxASCII }); i >= 0 {
+			t.Fatalf("teks ascii masih berisi %q: %q", text[i:], text)
+		}
+	}
+}
+
+func TestParseTheme(t *testing.T) {
+	for raw, want := range map[string]Theme{"": ThemeEmoji, " ASCII ": ThemeASCII, "none": ThemeNone} {
+		if got, err := ParseTheme(raw); err != nil || got != want {
+			t.Fatalf("ParseTheme(%q) = %q, %v; ingin %q", raw, got, err, want)
+		}
+	}
+	if _, err := ParseTheme("neon"); err == nil {
+		t.Fatal("ParseTheme(neon) tidak error")
+	}
+}
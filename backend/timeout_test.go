@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWithTimeoutSlowHandler(t *testing.T) {
+	lateWrite := make(chan error, 1)
+	handler := withTimeout(20 * time.Millisecond)(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		// Write setelah timeout tidak boleh mencampuri response 504
+		w.WriteHeader(http.StatusOK)
+		_, err := w.Write([]byte("terlambat"))
+		lateWrite <- err
+	})
+
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/lambat", nil))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("timeout baru terasa setelah %s", elapsed)
+	}
+
+	var env struct{ Error APIError }
+	decodeBody(t, rec, &env)
+	if rec.Code != http.StatusGatewayTimeout || env.Error.Code != errRequestTimeout.Code {
+		t.Fatalf("status = %d %s, ingin 504", rec.Code, rec.Body.String())
+	}
+	if err := <-lateWrite; !errors.Is(err, http.ErrHandlerTimeout) {
+		t.Fatalf("write terlambat = %v, ingin ErrHandlerTimeout", err)
+	}
+}
+
+func TestWithTimeoutFastHandler(t *testing.T) {
+	handler := withTimeout(time.Second)(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Error("context handler tidak punya deadline")
+		}
+		w.Header().Set("X-Sumber", "cepat")
+		respondJSON(w, http.StatusCreated, map[string]string{"status": "ok"})
+	})
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodPost, "/cepat", nil))
+	if rec.Code != http.StatusCreated || rec.Header().Get("X-Sumber") != "cepat" || rec.Body.String() == "" {
+		t.Fatalf("response = %d %v %q", rec.Code, rec.Header(), rec.Body.String())
+	}
+}
+
+func TestWithTimeoutClientCancelled(t *testing.T) {
+	handler := withTimeout(time.Second)(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/batal", nil).WithContext(ctx))
+	// Client sudah pergi: tidak ada 504 yang ditulis
+	if rec.Body.Len() != 0 {
+		t.Fatalf("response untuk client yang batal = %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestWithTimeoutPropagatesPanic(t *testing.T) {
+	handler := withTimeout(time.Second)(func(w http.ResponseWriter, r *http.Request) {
+		panic("rusak")
+	})
+	defer func() {
+		if p := recover(); p != "rusak" {
+			t.Fatalf("recover = %v, ingin panic handler", p)
+		}
+	}()
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panik", nil))
+}
+
+func TestWeatherRoutesHaveDeadline(t *testing.T) {
+	checked := 0
+	for _, route := range getRoutes() {
+		if route.Pattern != "/cuaca" && route.Pattern != "/weather" {
+			continue
+		}
+		route.Handler = func(w http.ResponseWriter, r *http.Request) {
+			deadline, ok := r.Context().Deadline()
+			if !ok || time.Until(deadline) > weatherRequestTimeout {
+				t.Errorf("%s: deadline %v (ada %v), ingin <= %s", route.Pattern, deadline, ok, weatherRequestTimeout)
+			}
+		}
+		route.Quiet = true
+		routeHandler(route)(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, route.Pattern, nil))
+		checked++
+	}
+	if checked != 2 {
+		t.Fatalf("%d route cuaca diperiksa, ingin 2", checked)
+	}
+}
+
+func TestWeatherAPIHandlerTimesOutSlowUpstream(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+	useWeatherCache(t)
+	release := make(chan struct{})
+	useOWMServer(t, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte(owmTestBody))
+	})
+
+	handler := withTimeout(20 * time.Millisecond)(WeatherAPIHandler)
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/cuaca?region=Jember", nil))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("timeout baru terasa setelah %s", elapsed)
+	}
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Fatalf("GET /cuaca dengan OWM lambat = %d %s, ingin 504", rec.Code, rec.Body.String())
+	}
+
+	// Request OWM bersama tetap jalan setelah 504; tunggu selesai supaya tidak bocor ke test lain
+	close(release)
+	if _, err := FetchWeatherWithContext(context.Background(), "Jember"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ============================================
+// SATUAN CUACA (?units=metric|imperial|standard)
+// Nama mengikuti parameter units OWM. Data internal (cache, weather_history,
+// mesin rekomendasi) selalu Celsius; konversi hanya di batas API.
+// ============================================
+
+type UnitSystem string
+
+const (
+	UnitsMetric   UnitSystem = "metric"   // °C
+	UnitsImperial UnitSystem = "imperial" // °F
+	UnitsStandard UnitSystem = "standard" // Kelvin
+)
+
+// ParseUnitSystem kosong = metric
+func ParseUnitSystem(raw string) (UnitSystem, error) {
+	switch u := UnitSystem(strings.ToLower(strings.TrimSpace(raw))); u {
+	case "":
+		return UnitsMetric, nil
+	case UnitsMetric, UnitsImperial, UnitsStandard:
+		return u, nil
+	default:
+		return "", fmt.Errorf("units %q tidak didukung (pilih metric, imperial, atau standard)", raw)
+	}
+}
+
+func (u UnitSystem) TemperatureUnit() string {
+	switch u {
+	case UnitsImperial:
+		return "°F"
+	case UnitsStandard:
+		return "K"
+	default:
+		return "°C"
+	}
+}
+
+func (u UnitSystem) FromCelsius(c float64) float64 {
+	switch u {
+	case UnitsImperial:
+		return c*9/5 + 32
+	case UnitsStandard:
+		return c + 273.15
+	default:
+		return c
+	}
+}
+
+func (u UnitSystem) ToCelsius(v float64) float64 {
+	switch u {
+	case UnitsImperial:
+		return (v - 32) * 5 / 9
+	case UnitsStandard:
+		return v - 273.15
+	default:
+		return v
+	}
+}
+
+// InUnits salinan data dengan suhu dalam satuan u dan anotasi satuannya.
+// Curah hujan tetap mm di semua sistem (sama seperti OWM); kecepatan angin tetap m/s.
+func (d WeatherData) InUnits(u UnitSystem) WeatherData {
+	d.Temp = u.FromCelsius(d.Temp)
+	d.Units = string(u)
+	d.TemperatureUnit = u.TemperatureUnit()
+	return d
+}
+
+// unitsFromRequest membaca ?units=; error sudah berbentuk APIError 400
+func unitsFromRequest(r *http.Request) (UnitSystem, error) {
+	u, err := ParseUnitSystem(r.URL.Query().Get("units"))
+	if err != nil {
+		return "", NewAPIError(http.StatusBadRequest, "invalid_units", err.Error())
+	}
+	return u, nil
+}
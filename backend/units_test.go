@@ -0,0 +1,97 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestParseUnitSystem(t *testing.T) {
+	for raw, want := range map[string]UnitSystem{"": UnitsMetric, "metric": UnitsMetric, " Imperial ": UnitsImperial, "STANDARD": UnitsStandard} {
+		if got, err := ParseUnitSystem(raw); err != nil || got != want {
+			t.Fatalf("ParseUnitSystem(%q) = %q, %v; ingin %q", raw, got, err, want)
+		}
+	}
+	if _, err := ParseUnitSystem("kelvin"); err == nil {
+		t.Fatal("ParseUnitSystem(kelvin) tidak error")
+	}
+}
+
+func TestUnitSystemConversion(t *testing.T) {
+	tests := []struct {
+		units   UnitSystem
+		celsius float64
+		want    float64
+		unit    string
+	}{
+		{UnitsMetric, 30, 30, "°C"},
+		{UnitsImperial, 30, 86, "°F"},
+		{UnitsImperial, -40, -40, "°F"},
+		{UnitsStandard, 30, 303.15, "K"},
+	}
+	for _, tt := range tests {
+		got := tt.units.FromCelsius(tt.celsius)
+		if math.Abs(got-tt.want) > 1e-9 || tt.units.TemperatureUnit() != tt.unit {
+			t.Fatalf("%s: %v°C = %v %s, ingin %v %s", tt.units, tt.celsius, got, tt.units.TemperatureUnit(), tt.want, tt.unit)
+		}
+		// Mesin rekomendasi butuh Celsius: konversi balik harus kembali ke nilai asal
+		if back := tt.units.ToCelsius(got); math.Abs(back-tt.celsius) > 1e-9 {
+			t.Fatalf("%s: ToCelsius(%v) = %v, ingin %v", tt.units, got, back, tt.celsius)
+		}
+	}
+}
+
+func TestWeatherHandlerUnits(t *testing.T) {
+	cached := &WeatherData{Temp: 30, Humidity: 70, Rain: 1.5, Region: "Jember"}
+	handler := makeWeatherHandler(func(string) (*WeatherData, error) { return cached, nil })
+
+	tests := []struct {
+		query string
+		temp  float64
+		units string
+		unit  string
+	}{
+		{"", 30, "metric", "°C"},
+		{"&units=metric", 30, "metric", "°C"},
+		{"&units=imperial", 86, "imperial", "°F"},
+		{"&units=standard", 303.15, "standard", "K"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.units+tt.query, func(t *testing.T) {
+			rec := serve(http.HandlerFunc(handler), http.MethodGet, "/cuaca?region=Jember"+tt.query, "")
+			var got WeatherData
+			decodeBody(t, rec, &got)
+			if rec.Code != http.StatusOK || math.Abs(got.Temp-tt.temp) > 1e-9 || got.Units != tt.units || got.TemperatureUnit != tt.unit {
+				t.Fatalf("GET /cuaca%s = %d temp %v %s (%s), ingin %v %s (%s)",
+					tt.query, rec.Code, got.Temp, got.TemperatureUnit, got.Units, tt.temp, tt.unit, tt.units)
+			}
+			// Curah hujan tetap mm di semua sistem
+			if got.Rain != 1.5 {
+				t.Fatalf("rain = %v, ingin 1.5", got.Rain)
+			}
+		})
+	}
+	// Data milik cache tetap Celsius tanpa anotasi
+	if cached.Temp != 30 || cached.Units != "" {
+		t.Fatalf("data cache ikut berubah: %+v", cached)
+	}
+
+	rec := serve(http.HandlerFunc(handler), http.MethodGet, "/cuaca?region=Jember&units=kelvin", "")
+	var env struct{ Error APIError }
+	decodeBody(t, rec, &env)
+	if rec.Code != http.StatusBadRequest || env.Error.Code != "invalid_units" {
+		t.Fatalf("units tidak valid = %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestOWMURLAlwaysMetric(t *testing.T) {
+	u, err := url.Parse(owmURL("weather", "Jember", "kunci"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Konversi dilakukan di batas API; upstream selalu diminta Celsius
+	if q := u.Query(); q.Get("units") != string(UnitsMetric) || q.Get("q") != "Jember" {
+		t.Fatalf("query OWM = %v", q)
+	}
+}
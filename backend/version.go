@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"runtime"
+	"runtime/debug"
+)
+
+// ============================================
+// BUILD INFO
+// Diisi linker saat build, contoh (lihat Makefile):
+//   go build -ldflags "-X main.Version=v1.2.0 -X main.Commit=$(git rev-parse --short HEAD) -X main.BuildTime=..."
+// ============================================
+
+var (
+	Version   string
+	Commit    string
+	BuildTime string
+)
+
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+// currentBuildInfo nilai ldflags; jika kosong (mis. `go run .`) commit & waktu
+// diambil dari info VCS yang disematkan toolchain Go bila tersedia
+func currentBuildInfo() BuildInfo {
+	info := BuildInfo{Version: Version, Commit: Commit, BuildTime: BuildTime, GoVersion: runtime.Version()}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.BuildTime == "":
+				info.BuildTime = s.Value
+			}
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildTime == "" {
+		info.BuildTime = "unknown"
+	}
+	return info
+}
+
+func VersionHandler(w http.ResponseWriter, r *http.Request) {
+	withErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+		return respondJSON(w, http.StatusOK, currentBuildInfo())
+	})(w, r)
+}
@@ -1,19 +1,72 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
+	"math/rand"
 	"net/http"
-	"os"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"golang.org/x/sync/singleflight"
 )
 
 type WeatherData struct {
-	Temp     float64 `json:"temp"`
-	Humidity int     `json:"humidity"`
-	Rain     float64 `json:"rain_mm"`
+	Temp          float64 `json:"temp"`
+	Humidity      int     `json:"humidity"`
+	Rain          float64 `json:"rain_mm"`
+	RainAvailable bool    `json:"rain_available"` // false = OWM tidak mengirim objek rain (bukan berarti pasti kering)
+	Condition     string  `json:"condition"`      // weather[0].main OWM, mis. "Rain", "Clear"
+	Description   string  `json:"description"`    // weather[0].description, mis. "light rain"
+	Region        string  `json:"region"`
+	ForecastTime  string  `json:"forecast_time,omitempty"` // hanya untuk entri forecast (dt_txt OWM, UTC)
+	// RainProbability peluang hujan 0-1 (pop OWM), hanya untuk entri forecast
+	RainProbability float64 `json:"rain_probability,omitempty"`
+	// WindSpeed (m/s) & Pressure (hPa) hanya untuk entri forecast; nil = tidak dikirim OWM
+	WindSpeed *float64 `json:"wind_speed,omitempty"`
+	Pressure  *float64 `json:"pressure,omitempty"`
+	// Units & TemperatureUnit hanya diisi di response API (lihat InUnits); internal selalu °C
+	Units           string `json:"units,omitempty"`
+	TemperatureUnit string `json:"temperature_unit,omitempty"`
+	// Implausible alasan data OWM ditolak Validate (tidak disimpan ke history); kosong = wajar
+	Implausible string `json:"implausible,omitempty"`
+}
+
+// WeatherValidationError satu field WeatherData di luar rentang wajar
+type WeatherValidationError struct {
+	Field  string // nama field JSON, mis. "humidity"
+	Reason string
+}
+
+func (e *WeatherValidationError) Error() string { return e.Field + " " + e.Reason }
+
+// Validate gerbang tunggal sebelum data cuaca dipakai untuk rekomendasi atau disimpan.
+// Field khusus forecast hanya dicek jika terisi. NaN dianggap tidak wajar.
+func (w WeatherData) Validate() error {
+	invalid := func(field, format string, args ...interface{}) error {
+		return &WeatherValidationError{Field: field, Reason: fmt.Sprintf(format, args...)}
+	}
+	switch {
+	case w.Humidity < 0 || w.Humidity > 100:
+		return invalid("humidity", "harus 0-100, didapat %d", w.Humidity)
+	case !(w.Temp >= minPlausibleTemp && w.Temp <= maxPlausibleTemp):
+		return invalid("temp", "harus %.0f sampai %.0f°C, didapat %.1f", minPlausibleTemp, maxPlausibleTemp, w.Temp)
+	case !(w.Rain >= 0):
+		return invalid("rain", "tidak boleh negatif, didapat %.2f", w.Rain)
+	case !(w.RainProbability >= 0 && w.RainProbability <= 1):
+		return invalid("rain_probability", "harus 0-1, didapat %.2f", w.RainProbability)
+	case w.WindSpeed != nil && !(*w.WindSpeed >= 0):
+		return invalid("wind_speed", "tidak boleh negatif, didapat %.2f", *w.WindSpeed)
+	}
+	return nil
 }
 
 // Struct untuk parsing response OpenWeatherMap yang LENGKAP
@@ -22,10 +75,7 @@ type OpenWeatherResponse struct {
 		Temp     float64 `json:"temp"`
 		Humidity int     `json:"humidity"`
 	} `json:"main"`
-	Rain struct {
-		OneHour   float64 `json:"1h"`
-		ThreeHour float64 `json:"3h"`
-	} `json:"rain"`
+	Rain    *owmRain `json:"rain"` // nil jika OWM tidak mengirim objek rain
 	Weather []struct {
 		Main        string `json:"main"`
 		Description string `json:"description"`
@@ -33,20 +83,249 @@ type OpenWeatherResponse struct {
 	Name string `json:"name"`
 }
 
+type owmRain struct {
+	OneHour   float64 `json:"1h"`
+	ThreeHour float64 `json:"3h"`
+}
+
+// defaultOWMBreakerConfig 5x HTTP 429 beruntun -> semua fetch OWM ditolak selama 1 menit
+const defaultOWMBaseURL = "https://api.openweathermap.org/data/2.5"
+
+var defaultOWMBreakerConfig = BreakerConfig{Threshold: 5, Cooldown: time.Minute}
+
+const (
+	defaultOWMRetryAttempts  = 3
+	defaultOWMRetryBaseDelay = 500 * time.Millisecond
+	// owmMaxRetryDelay Retry-After yang lebih lama dari ini tidak ditunggu (langsung gagal)
+	owmMaxRetryDelay = 10 * time.Second
+)
+
+// weatherConfig diisi saat startup lewat ConfigureWeather
+var (
+	weatherConfig = WeatherConfig{
+		BaseURL:          defaultOWMBaseURL,
+		Timeout:          10 * time.Second,
+		RateLimitBreaker: defaultOWMBreakerConfig,
+		RetryAttempts:    defaultOWMRetryAttempts,
+		RetryBaseDelay:   defaultOWMRetryBaseDelay,
+	}
+	weatherHTTPClient = withClientTimeout(sharedHTTPClient, weatherConfig.Timeout)
+	// owmBreaker dipakai bersama semua fetch (cuaca & forecast, semua region)
+	owmBreaker = NewCircuitBreaker("openweathermap", weatherConfig.RateLimitBreaker)
+)
+
+// ConfigureWeather memakai transport client (biasanya sharedHTTPClient) dengan cfg.Timeout
+func ConfigureWeather(cfg WeatherConfig, client *http.Client) {
+	weatherConfig = cfg
+	weatherHTTPClient = withClientTimeout(client, cfg.Timeout)
+	owmBreaker = NewCircuitBreaker("openweathermap", cfg.RateLimitBreaker)
+}
+
+// owmURL membangun URL endpoint OWM (weather/forecast) dari weatherConfig.BaseURL
+func owmURL(endpoint, region, apiKey string) string {
+	query := neturl.Values{}
+	query.Set("q", region)
+	query.Set("appid", apiKey)
+	// Selalu metric: cache, weather_history.temp_c, dan rekomendasi memakai Celsius;
+	// ?units= dari client dikonversi saat response (UnitSystem.InUnits)
+	query.Set("units", string(UnitsMetric))
+	return weatherConfig.BaseURL + "/" + endpoint + "?" + query.Encode()
+}
+
+// doOWMRequest mengirim request lewat owmBreaker. Hanya 429 (rate limit) yang dihitung
+// gagal; respons lain berarti OWM tidak sedang membatasi kita.
+func doOWMRequest(req *http.Request) (*http.Response, error) {
+	if err := owmBreaker.Allow(); err != nil {
+		return nil, err
+	}
+	resp, err := weatherHTTPClient.Do(req)
+	switch {
+	case err != nil:
+		owmBreaker.Ignore()
+	case resp.StatusCode == http.StatusTooManyRequests:
+		owmBreaker.Failure()
+	default:
+		owmBreaker.Success()
+	}
+	return resp, err
+}
+
+// owmStatusError respons 5xx/429 yang masih layak di-retry. Jika percobaan habis,
+// responsnya tetap diteruskan ke pemanggil (yang memeriksa status sendiri).
+type owmStatusError struct{ status int }
+
+func (e *owmStatusError) Error() string { return fmt.Sprintf("status %d", e.status) }
+
+// isOWMRetryable circuit terbuka tidak di-retry (percobaan berikutnya pasti ditolak juga)
+func isOWMRetryable(err error) bool {
+	return !errors.Is(err, ErrCircuitOpen)
+}
+
+// doOWMRequestWithRetry doOWMRequest dengan retry untuk error jaringan, 5xx dan 429
+// (menghormati Retry-After). 4xx lain (mis. 404 kota tidak dikenal) langsung dikembalikan.
+// Jeda antar percobaan ikut batal saat context request selesai.
+func doOWMRequestWithRetry(req *http.Request) (*http.Response, error) {
+	// Diisi percobaan terakhir: dipakai backoff untuk Retry-After & log
+	var last *http.Response
+	var lastErr error
+	var retryAfter time.Duration
+	var hasRetryAfter bool
+
+	backoff := func(attempt int) time.Duration {
+		delay := owmBackoff(attempt)
+		if hasRetryAfter {
+			delay = retryAfter
+		}
+		log.Printf("⚠️  OWM percobaan %d/%d gagal (%s), retry dalam %s",
+			attempt, weatherConfig.RetryAttempts, describeOWMFailure(last, lastErr), delay)
+		return delay
+	}
+
+	resp, err := RetryWithResult(req.Context(), weatherConfig.RetryAttempts, backoff, isOWMRetryable,
+		func() (*http.Response, error) {
+			if last != nil {
+				io.Copy(io.Discard, last.Body)
+				last.Body.Close()
+			}
+			resp, err := doOWMRequest(req)
+			last, lastErr, hasRetryAfter = resp, err, false
+			switch {
+			case err != nil:
+				return nil, err
+			case resp.StatusCode == http.StatusTooManyRequests:
+				if after, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+					if after > owmMaxRetryDelay {
+						return resp, nil
+					}
+					retryAfter, hasRetryAfter = after, true
+				}
+				return resp, &owmStatusError{status: resp.StatusCode}
+			case resp.StatusCode >= 500:
+				return resp, &owmStatusError{status: resp.StatusCode}
+			}
+			return resp, nil
+		})
+
+	var statusErr *owmStatusError
+	if errors.As(err, &statusErr) {
+		return resp, nil
+	}
+	if err != nil && resp != nil {
+		// ctx selesai saat menunggu retry setelah respons 5xx/429
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, err
+}
+
+// owmBackoff jeda eksponensial dengan jitter: base*2^(n-1) dikali acak 0.5-1.5
+func owmBackoff(attempt int) time.Duration {
+	d := weatherConfig.RetryBaseDelay << (attempt - 1)
+	if d <= 0 || d > owmMaxRetryDelay {
+		d = owmMaxRetryDelay
+	}
+	return time.Duration(float64(d) * (0.5 + rand.Float64()))
+}
+
+// parseRetryAfter mendukung dua format header: jumlah detik atau HTTP-date
+func parseRetryAfter(raw string) (time.Duration, bool) {
+	if raw == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(raw); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(raw); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+func describeOWMFailure(resp *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("status %d", resp.StatusCode)
+}
+
+// ErrMissingAPIKey - OWM_API_KEY kosong (salah konfigurasi, bukan gangguan upstream)
+var ErrMissingAPIKey = errors.New("OWM API key belum diset")
+
+// redactAPIKey menyembunyikan appid di URL error agar tidak bocor ke log / response
+func redactAPIKey(err error, apiKey string) error {
+	var urlErr *neturl.Error
+	if apiKey == "" || !errors.As(err, &urlErr) {
+		return err
+	}
+	redacted := *urlErr
+	redacted.URL = strings.ReplaceAll(urlErr.URL, apiKey, "REDACTED")
+	return &redacted
+}
+
 // FetchWeather mengambil data cuaca dari OpenWeatherMap
 func FetchWeather(region string) (*WeatherData, error) {
-	apiKey := os.Getenv("OWM_API_KEY")
+	return FetchWeatherWithContext(context.Background(), region)
+}
+
+// weatherFlight menggabungkan fetch OWM yang bersamaan untuk region yang sama
+// (mis. dashboard dibuka di banyak tab) menjadi satu panggilan upstream
+var weatherFlight singleflight.Group
+
+// FetchWeatherWithContext - FetchWeather yang ikut batal saat ctx selesai (mis. request timeout).
+// Caller yang bersamaan berbagi satu request OWM; request itu tidak ikut batal saat salah
+// satu caller pergi (dibatasi timeout weatherHTTPClient), caller lain tetap menunggu hasilnya.
+func FetchWeatherWithContext(ctx context.Context, region string) (*WeatherData, error) {
+	region = NormalizeRegion(region)
+	ch := weatherFlight.DoChan(weatherCacheKey(region), func() (interface{}, error) {
+		return fetchWeatherUpstream(context.WithoutCancel(ctx), region)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*WeatherData), nil
+	}
+}
+
+func fetchWeatherUpstream(ctx context.Context, region string) (_ *WeatherData, err error) {
+	apiKey := weatherConfig.APIKey
 	if apiKey == "" {
-		return nil, fmt.Errorf("API key belum diset")
+		return nil, ErrMissingAPIKey
 	}
 
 	// Build URL dengan region sebagai query
-	url := fmt.Sprintf("https://api.openweathermap.org/data/2.5/weather?q=%s&appid=%s&units=metric", region, apiKey)
+	url := owmURL("weather", region, apiKey)
+
+	// Catat durasi & hasil panggilan upstream (lihat /debug/weather-stats)
+	start := time.Now()
+	outcome := OutcomeError
+	defer func() {
+		weatherLatency.Record(region, time.Since(start), outcome)
+		// Request yang dibatalkan client bukan indikasi OWM bermasalah
+		if outcome == OutcomeSuccess {
+			weatherHealth.Record(nil)
+		} else if ctx.Err() == nil {
+			weatherHealth.Record(err)
+		}
+	}()
 
 	// HTTP GET request
-	resp, err := http.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := doOWMRequestWithRetry(req)
+	if errors.Is(err, ErrCircuitOpen) {
+		return nil, err
+	}
 	if err != nil {
-		return nil, fmt.Errorf("HTTP request failed: %w", err)
+		outcome = classifyUpstreamError(err)
+		return nil, fmt.Errorf("HTTP request failed: %w", redactAPIKey(err, apiKey))
 	}
 	defer resp.Body.Close()
 
@@ -60,83 +339,208 @@ func FetchWeather(region string) (*WeatherData, error) {
 	// Read response body
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
+		outcome = classifyUpstreamError(err)
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	// 🔍 DEBUG: Print raw response
-	log.Printf("📡 Raw API response for %s: %s", region, string(body))
+	logDebugf("📡 Raw API response for %s: %s", region, string(body))
 
 	// Parse JSON response
 	var apiResp OpenWeatherResponse
 	if err := json.Unmarshal(body, &apiResp); err != nil {
+		// Body rusak/terpotong tetap kegagalan upstream (outcome tetap OutcomeError)
 		return nil, fmt.Errorf("failed to parse JSON: %w", err)
 	}
+	outcome = OutcomeSuccess
 
 	// Extract rain data (prioritas 1h, fallback ke 3h)
-	rain := apiResp.Rain.OneHour
-	if rain == 0 && apiResp.Rain.ThreeHour > 0 {
-		rain = apiResp.Rain.ThreeHour / 3.0
+	// Objek rain tidak ada = dianggap 0mm, tapi ditandai RainAvailable=false
+	rainData := owmRain{}
+	if apiResp.Rain != nil {
+		rainData = *apiResp.Rain
+	}
+	rain := rainData.OneHour
+	if rain == 0 && rainData.ThreeHour > 0 {
+		rain = rainData.ThreeHour / 3.0
 	}
 
 	// 🔍 DEBUG: Print parsed rain data
-	log.Printf("☔ Rain data for %s: 1h=%.2fmm, 3h=%.2fmm, final=%.2fmm", 
-		region, apiResp.Rain.OneHour, apiResp.Rain.ThreeHour, rain)
+	logDebugf("☔ Rain data for %s: present=%t, 1h=%.2fmm, 3h=%.2fmm, final=%.2fmm", 
+		region, apiResp.Rain != nil, rainData.OneHour, rainData.ThreeHour, rain)
 
 	// Get weather condition
-	weatherCondition := ""
+	weatherCondition, weatherDescription := "", ""
 	if len(apiResp.Weather) > 0 {
 		weatherCondition = apiResp.Weather[0].Main
+		weatherDescription = apiResp.Weather[0].Description
 	}
 
 	// Log weather summary
 	log.Printf("🌤️  Weather fetched: %s - temp=%.1f°C, humidity=%d%%, rain=%.2fmm, condition=%s", 
 		region, apiResp.Main.Temp, apiResp.Main.Humidity, rain, weatherCondition)
 
-	// Simpan ke database secara ASYNC (non-blocking)
-	go func() {
-		_, err := DB.Exec(`INSERT INTO weather_history (region, temp_c, humidity, rain_mm, fetched_at)
-			VALUES (?, ?, ?, ?, ?)`, region, apiResp.Main.Temp, apiResp.Main.Humidity, rain, time.Now())
-		if err != nil {
-			log.Printf("⚠️  Warning - Gagal menyimpan history cuaca untuk %s: %v", region, err)
-		} else {
-			log.Printf("✅ Weather history saved: %s (%.1f°C, %d%%, %.2fmm)", 
-				region, apiResp.Main.Temp, apiResp.Main.Humidity, rain)
-		}
-	}()
+	data := &WeatherData{
+		Temp:          apiResp.Main.Temp,
+		Humidity:      apiResp.Main.Humidity,
+		Rain:          rain,
+		RainAvailable: apiResp.Rain != nil,
+		Condition:     weatherCondition,
+		Description:   weatherDescription,
+		Region:        region,
+	}
 
-	return &WeatherData{
-		Temp:     apiResp.Main.Temp,
-		Humidity: apiResp.Main.Humidity,
-		Rain:     rain,
-	}, nil
+	// Data tidak wajar tetap dikembalikan (ditandai) supaya terlihat di dashboard, tapi tidak
+	// mencemari weather_history; rekomendasi menolaknya lewat ValidateRecommendationInput
+	if err := data.Validate(); err != nil {
+		log.Printf("⚠️  Data cuaca OWM tidak wajar untuk %s: %v", region, err)
+		data.Implausible = err.Error()
+		return data, nil
+	}
+
+	// Simpan ke history secara ASYNC (SQLite: lewat writer tunggal, retry saat SQLITE_BUSY)
+	weatherStore.Add(WeatherHistoryRow{
+		Region:    region,
+		TempC:     &apiResp.Main.Temp,
+		Humidity:  &apiResp.Main.Humidity,
+		RainMM:    &rain,
+		FetchedAt: formatFetchedAt(time.Now()),
+	})
+
+	return data, nil
+}
+
+// ============================================
+// WEATHER CACHE
+// Hindari panggilan OWM berulang untuk region yang sama (kuota API)
+// ============================================
+
+const weatherCacheTTL = 10 * time.Minute
+
+type weatherCacheEntry struct {
+	data      *WeatherData
+	fetchedAt time.Time
+}
+
+// WeatherCache menyimpan hasil FetchWeather per region selama TTL
+type WeatherCache struct {
+	mu        sync.RWMutex
+	ttl       time.Duration
+	entries   map[string]weatherCacheEntry
+	onRefresh []func(region string)
+}
+
+func NewWeatherCache(ttl time.Duration) *WeatherCache {
+	return &WeatherCache{
+		ttl:     ttl,
+		entries: make(map[string]weatherCacheEntry),
+	}
+}
+
+func weatherCacheKey(region string) string {
+	return strings.ToLower(NormalizeRegion(region))
+}
+
+// Get mengembalikan data yang masih valid (belum melewati TTL)
+func (c *WeatherCache) Get(region string) (*WeatherData, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.entries[weatherCacheKey(region)]
+	if !ok || time.Since(entry.fetchedAt) > c.ttl {
+		return nil, false
+	}
+	return entry.data, true
+}
+
+func (c *WeatherCache) Set(region string, data *WeatherData) {
+	c.mu.Lock()
+	c.entries[weatherCacheKey(region)] = weatherCacheEntry{data: data, fetchedAt: time.Now()}
+	hooks := c.onRefresh
+	c.mu.Unlock()
+
+	for _, fn := range hooks {
+		fn(region)
+	}
+}
+
+// OnRefresh mendaftarkan fn yang dipanggil setiap data region diperbarui
+// (dipakai cache turunan seperti rekomendasi untuk invalidasi)
+func (c *WeatherCache) OnRefresh(fn func(region string)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onRefresh = append(c.onRefresh, fn)
+}
+
+var weatherCache = NewWeatherCache(weatherCacheTTL)
+
+// FetchWeatherCached - FetchWeather dengan cache per region
+func FetchWeatherCached(region string) (*WeatherData, error) {
+	return FetchWeatherCachedWithContext(context.Background(), region)
+}
+
+func FetchWeatherCachedWithContext(ctx context.Context, region string) (*WeatherData, error) {
+	if data, ok := weatherCache.Get(region); ok {
+		return data, nil
+	}
+
+	data, err := FetchWeatherWithContext(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	weatherCache.Set(region, data)
+	return data, nil
 }
 
 // FetchWeatherForecast - Bonus: ambil data forecast untuk cek rain prediction
 func FetchWeatherForecast(region string) ([]WeatherData, error) {
-	apiKey := os.Getenv("OWM_API_KEY")
+	return FetchWeatherForecastWithContext(context.Background(), region)
+}
+
+// FetchWeatherForecastWithContext - entri per 3 jam (±5 hari), ikut batal saat ctx selesai
+func FetchWeatherForecastWithContext(ctx context.Context, region string) ([]WeatherData, error) {
+	region = NormalizeRegion(region)
+	apiKey := weatherConfig.APIKey
 	if apiKey == "" {
-		return nil, fmt.Errorf("API key belum diset")
+		return nil, ErrMissingAPIKey
 	}
 
-	url := fmt.Sprintf("https://api.openweathermap.org/data/2.5/forecast?q=%s&appid=%s&units=metric", region, apiKey)
+	url := owmURL("forecast", region, apiKey)
 
-	resp, err := http.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
+	resp, err := doOWMRequestWithRetry(req)
+	if err != nil {
+		return nil, redactAPIKey(err, apiKey)
+	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("forecast API returned status %d for %s", resp.StatusCode, region)
+	}
+
 	body, _ := ioutil.ReadAll(resp.Body)
 
 	var forecastResp struct {
 		List []struct {
 			Main struct {
-				Temp     float64 `json:"temp"`
-				Humidity int     `json:"humidity"`
+				Temp     float64  `json:"temp"`
+				Humidity int      `json:"humidity"`
+				Pressure *float64 `json:"pressure"`
 			} `json:"main"`
-			Rain struct {
-				ThreeHour float64 `json:"3h"`
-			} `json:"rain"`
+			Wind struct {
+				Speed *float64 `json:"speed"`
+			} `json:"wind"`
+			Rain    *owmRain `json:"rain"`
+			Pop     float64  `json:"pop"` // tidak ada di respons = 0
+			DtTxt   string   `json:"dt_txt"`
+			Weather []struct {
+				Main        string `json:"main"`
+				Description string `json:"description"`
+			} `json:"weather"`
 		} `json:"list"`
 	}
 
@@ -146,11 +550,23 @@ func FetchWeatherForecast(region string) ([]WeatherData, error) {
 
 	var forecasts []WeatherData
 	for _, item := range forecastResp.List {
-		forecasts = append(forecasts, WeatherData{
-			Temp:     item.Main.Temp,
-			Humidity: item.Main.Humidity,
-			Rain:     item.Rain.ThreeHour,
-		})
+		entry := WeatherData{
+			Temp:            item.Main.Temp,
+			Humidity:        item.Main.Humidity,
+			Region:          region,
+			ForecastTime:    item.DtTxt,
+			RainProbability: item.Pop,
+			WindSpeed:       item.Wind.Speed,
+			Pressure:        item.Main.Pressure,
+		}
+		if item.Rain != nil {
+			entry.Rain, entry.RainAvailable = item.Rain.ThreeHour, true
+		}
+		if len(item.Weather) > 0 {
+			entry.Condition = item.Weather[0].Main
+			entry.Description = item.Weather[0].Description
+		}
+		forecasts = append(forecasts, entry)
 	}
 
 	log.Printf("📊 Forecast data retrieved for %s: %d entries", region, len(forecasts))
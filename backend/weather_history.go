@@ -0,0 +1,49 @@
+package main
+
+import (
+	"time"
+)
+
+// ============================================
+// AGREGASI HARIAN WEATHER HISTORY
+// fetched_at disimpan dalam UTC; batas hari dihitung dalam WIB (Asia/Jakarta, UTC+7,
+// tanpa DST) agar sampel pukul 00:30 WIB tidak masuk ke hari sebelumnya
+// ============================================
+
+const (
+	defaultWeatherHistoryDays = 7
+	maxWeatherHistoryDays     = 90
+
+	// jakartaOffsetModifier modifier SQLite untuk konversi UTC -> WIB
+	jakartaOffsetModifier = "+7 hours"
+	utcOffsetModifier     = "-7 hours"
+
+	// sqliteUTCLayout format waktu yang bisa dibaca date()/datetime() SQLite
+	sqliteUTCLayout = "2006-01-02 15:04:05"
+)
+
+// WeatherDailyAggregate ringkasan sampel cuaca satu region dalam satu hari (WIB);
+// WeatherStore.DailyHistory mengembalikan `days` hari terakhir (termasuk hari ini), urut tanggal
+type WeatherDailyAggregate struct {
+	Date        string   `json:"date"`
+	Samples     int      `json:"samples"`
+	AvgTemp     *float64 `json:"avg_temp"`
+	AvgHumidity *float64 `json:"avg_humidity"`
+	TotalRain   *float64 `json:"total_rain_mm"` // jumlah rain_mm semua sampel hari itu
+}
+
+// formatFetchedAt waktu fetch dalam format UTC yang dipakai kolom weather_history.fetched_at
+func formatFetchedAt(t time.Time) string {
+	return t.UTC().Format(sqliteUTCLayout)
+}
+
+// historicalWeatherMaxGap sampel terdekat yang lebih jauh dari ini dianggap tidak ada data
+const historicalWeatherMaxGap = 3 * time.Hour
+
+// HistoricalWeather sampel weather_history yang paling dekat dengan waktu yang diminta
+// (WeatherStore.Nearest)
+type HistoricalWeather struct {
+	WeatherData
+	FetchedAt  string `json:"fetched_at"`  // UTC
+	GapMinutes int    `json:"gap_minutes"` // selisih dengan waktu yang diminta
+}
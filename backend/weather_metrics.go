@@ -0,0 +1,249 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ============================================
+// UPSTREAM LATENCY METRICS (OWM)
+// Durasi + hasil tiap panggilan ke OpenWeatherMap, per region
+// ============================================
+
+type UpstreamOutcome string
+
+const (
+	OutcomeSuccess UpstreamOutcome = "success"
+	OutcomeTimeout UpstreamOutcome = "timeout"
+	OutcomeError   UpstreamOutcome = "error"
+)
+
+// latencyBuckets batas atas histogram (kumulatif ala Prometheus, +Inf implisit)
+var latencyBuckets = []time.Duration{
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	1 * time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// latencyWindowSize jumlah sampel terakhir untuk rolling p50/p95
+const latencyWindowSize = 100
+
+type regionLatency struct {
+	outcomes  map[UpstreamOutcome]int
+	histogram []int // len(latencyBuckets)+1, indeks terakhir = +Inf
+	window    []time.Duration
+	next      int
+	last      time.Time
+}
+
+// LatencyRecorder aman dipakai concurrent oleh banyak FetchWeather
+type LatencyRecorder struct {
+	mu      sync.Mutex
+	regions map[string]*regionLatency
+}
+
+func NewLatencyRecorder() *LatencyRecorder {
+	return &LatencyRecorder{regions: make(map[string]*regionLatency)}
+}
+
+func (lr *LatencyRecorder) Record(region string, d time.Duration, outcome UpstreamOutcome) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	key := weatherCacheKey(region)
+	rl, ok := lr.regions[key]
+	if !ok {
+		rl = &regionLatency{
+			outcomes:  make(map[UpstreamOutcome]int),
+			histogram: make([]int, len(latencyBuckets)+1),
+		}
+		lr.regions[key] = rl
+	}
+
+	rl.outcomes[outcome]++
+	rl.histogram[bucketIndex(d)]++
+	rl.last = time.Now()
+
+	if len(rl.window) < latencyWindowSize {
+		rl.window = append(rl.window, d)
+	} else {
+		rl.window[rl.next] = d
+		rl.next = (rl.next + 1) % latencyWindowSize
+	}
+}
+
+func bucketIndex(d time.Duration) int {
+	for i, upper := range latencyBuckets {
+		if d <= upper {
+			return i
+		}
+	}
+	return len(latencyBuckets)
+}
+
+// percentile dengan metode nearest-rank; sorted harus sudah terurut
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+type LatencyBucket struct {
+	LE    string `json:"le"`
+	Count int    `json:"count"`
+}
+
+type RegionLatencyStats struct {
+	Total    int                     `json:"total"`
+	Outcomes map[UpstreamOutcome]int `json:"outcomes"`
+	P50Ms    float64                 `json:"p50_ms"`
+	P95Ms    float64                 `json:"p95_ms"`
+	Samples  int                     `json:"window_samples"`
+	Buckets  []LatencyBucket         `json:"buckets"`
+	LastCall time.Time               `json:"last_call"`
+}
+
+func (lr *LatencyRecorder) Snapshot() map[string]RegionLatencyStats {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	stats := make(map[string]RegionLatencyStats, len(lr.regions))
+	for region, rl := range lr.regions {
+		sorted := append([]time.Duration(nil), rl.window...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+		outcomes := make(map[UpstreamOutcome]int, len(rl.outcomes))
+		total := 0
+		for k, v := range rl.outcomes {
+			outcomes[k] = v
+			total += v
+		}
+
+		// Bucket kumulatif: count = jumlah panggilan <= le
+		buckets := make([]LatencyBucket, 0, len(rl.histogram))
+		cumulative := 0
+		for i, count := range rl.histogram {
+			cumulative += count
+			le := "+Inf"
+			if i < len(latencyBuckets) {
+				le = latencyBuckets[i].String()
+			}
+			buckets = append(buckets, LatencyBucket{LE: le, Count: cumulative})
+		}
+
+		stats[region] = RegionLatencyStats{
+			Total:    total,
+			Outcomes: outcomes,
+			P50Ms:    float64(percentile(sorted, 0.50)) / float64(time.Millisecond),
+			P95Ms:    float64(percentile(sorted, 0.95)) / float64(time.Millisecond),
+			Samples:  len(sorted),
+			Buckets:  buckets,
+			LastCall: rl.last,
+		}
+	}
+	return stats
+}
+
+// classifyUpstreamError membedakan timeout dari error lain
+func classifyUpstreamError(err error) UpstreamOutcome {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return OutcomeTimeout
+	}
+	return OutcomeError
+}
+
+var weatherLatency = NewLatencyRecorder()
+
+// ============================================
+// PROVIDER HEALTH (OWM)
+// Status diambil dari hasil fetch sungguhan, jadi readiness probe tidak
+// perlu memanggil OWM (hemat kuota)
+// ============================================
+
+type ProviderStatus string
+
+const (
+	ProviderOK           ProviderStatus = "ok"
+	ProviderDegraded     ProviderStatus = "degraded"
+	ProviderUnknown      ProviderStatus = "unknown"
+	ProviderUnconfigured ProviderStatus = "unconfigured"
+)
+
+type ProviderHealthSnapshot struct {
+	Status              ProviderStatus `json:"status"`
+	LastSuccess         *time.Time     `json:"last_success,omitempty"`
+	LastFailure         *time.Time     `json:"last_failure,omitempty"`
+	LastError           string         `json:"last_error,omitempty"`
+	ConsecutiveFailures int            `json:"consecutive_failures"`
+}
+
+type ProviderHealth struct {
+	mu                  sync.Mutex
+	lastSuccess         time.Time
+	lastFailure         time.Time
+	lastError           string
+	consecutiveFailures int
+}
+
+func (h *ProviderHealth) Record(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err == nil {
+		h.lastSuccess = time.Now()
+		h.consecutiveFailures = 0
+		return
+	}
+	h.lastFailure = time.Now()
+	h.lastError = err.Error()
+	h.consecutiveFailures++
+}
+
+// Snapshot; configured=false jika API key belum diset
+func (h *ProviderHealth) Snapshot(configured bool) ProviderHealthSnapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	snap := ProviderHealthSnapshot{
+		LastError:           h.lastError,
+		ConsecutiveFailures: h.consecutiveFailures,
+	}
+	if !h.lastSuccess.IsZero() {
+		t := h.lastSuccess
+		snap.LastSuccess = &t
+	}
+	if !h.lastFailure.IsZero() {
+		t := h.lastFailure
+		snap.LastFailure = &t
+	}
+
+	switch {
+	case !configured:
+		snap.Status = ProviderUnconfigured
+	case h.lastSuccess.IsZero() && h.lastFailure.IsZero():
+		snap.Status = ProviderUnknown
+	case h.consecutiveFailures > 0:
+		snap.Status = ProviderDegraded
+	default:
+		snap.Status = ProviderOK
+	}
+	return snap
+}
+
+var weatherHealth = &ProviderHealth{}
@@ -0,0 +1,467 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// useOWMServer mengarahkan fetch OWM ke server test dengan metrik & health yang bersih
+func useOWMServer(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	prevCfg, prevClient, prevBreaker := weatherConfig, weatherHTTPClient, owmBreaker
+	prevLatency, prevHealth := weatherLatency, weatherHealth
+
+	cfg := weatherConfig
+	cfg.APIKey, cfg.BaseURL, cfg.RetryBaseDelay = "test-key", srv.URL, time.Millisecond
+	ConfigureWeather(cfg, srv.Client())
+	weatherLatency, weatherHealth = NewLatencyRecorder(), &ProviderHealth{}
+	t.Cleanup(func() {
+		srv.Close()
+		weatherConfig, weatherHTTPClient, owmBreaker = prevCfg, prevClient, prevBreaker
+		weatherLatency, weatherHealth = prevLatency, prevHealth
+	})
+}
+
+const owmTestBody = `{"main":{"temp":27.5,"humidity":72},"rain":{"1h":0.4},"weather":[{"main":"Clouds","description":"berawan"}]}`
+
+// useWeatherCache cache cuaca kosong selama test (hook OnRefresh milik cache asli tidak ikut)
+func useWeatherCache(t *testing.T) *WeatherCache {
+	t.Helper()
+	prev := weatherCache
+	weatherCache = NewWeatherCache(weatherCacheTTL)
+	t.Cleanup(func() { weatherCache = prev })
+	return weatherCache
+}
+
+// owmFake server OWM palsu: /weather menjawab owmTestBody, /forecast menjawab
+// testdata/owm_forecast.json atau forecastStatus jika bukan 200. Tiap request ditunda delay.
+func owmFake(t *testing.T, delay time.Duration, forecastStatus int) http.HandlerFunc {
+	t.Helper()
+	forecast, err := os.ReadFile(filepath.Join("testdata", "owm_forecast.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/weather"):
+			w.Write([]byte(owmTestBody))
+		case forecastStatus != http.StatusOK:
+			w.WriteHeader(forecastStatus)
+		default:
+			w.Write(forecast)
+		}
+	}
+}
+
+// forecastFixture entri forecast hasil parsing testdata/owm_forecast.json lewat FetchWeatherForecast
+func forecastFixture(t *testing.T) []WeatherData {
+	t.Helper()
+	useOWMServer(t, owmFake(t, 0, http.StatusOK))
+	entries, err := FetchWeatherForecast("Jember")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func TestWeatherDataValidate(t *testing.T) {
+	valid := WeatherData{Temp: 26, Humidity: 70, Rain: 2, RainProbability: 0.4, WindSpeed: floatPtr(3)}
+
+	tests := []struct {
+		name   string
+		change func(w *WeatherData)
+		field  string // kosong = valid
+	}{
+		{"valid", func(*WeatherData) {}, ""},
+		{"batas bawah", func(w *WeatherData) { w.Temp, w.Humidity, w.Rain = -40, 0, 0 }, ""},
+		{"batas atas", func(w *WeatherData) { w.Temp, w.Humidity, w.RainProbability = 60, 100, 1 }, ""},
+		{"wind kosong", func(w *WeatherData) { w.WindSpeed = nil }, ""},
+		{"humidity negatif", func(w *WeatherData) { w.Humidity = -1 }, "humidity"},
+		{"humidity di atas 100", func(w *WeatherData) { w.Humidity = 101 }, "humidity"},
+		{"rain negatif", func(w *WeatherData) { w.Rain = -0.1 }, "rain"},
+		{"rain NaN", func(w *WeatherData) { w.Rain = math.NaN() }, "rain"},
+		{"temp terlalu dingin", func(w *WeatherData) { w.Temp = -40.1 }, "temp"},
+		{"temp terlalu panas", func(w *WeatherData) { w.Temp = 60.1 }, "temp"},
+		{"temp NaN", func(w *WeatherData) { w.Temp = math.NaN() }, "temp"},
+		{"rain probability", func(w *WeatherData) { w.RainProbability = 1.5 }, "rain_probability"},
+		{"wind negatif", func(w *WeatherData) { w.WindSpeed = floatPtr(-2) }, "wind_speed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := valid
+			tt.change(&w)
+			err := w.Validate()
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("Validate() = %v, ingin nil", err)
+				}
+				return
+			}
+
+			var invalid *WeatherValidationError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("Validate() = %v, ingin *WeatherValidationError", err)
+			}
+			if invalid.Field != tt.field {
+				t.Fatalf("Field = %q, ingin %q (%v)", invalid.Field, tt.field, err)
+			}
+		})
+	}
+}
+
+func TestFetchWeatherUpstreamRecordsOutcome(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+
+	tests := []struct {
+		name    string
+		body    string
+		outcome UpstreamOutcome
+	}{
+		{"sukses", owmTestBody, OutcomeSuccess},
+		{"JSON rusak", `{"main":{"temp":`, OutcomeError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useOWMServer(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tt.body))
+			})
+
+			_, err := fetchWeatherUpstream(context.Background(), "Jember")
+			if (err == nil) != (tt.outcome == OutcomeSuccess) {
+				t.Fatalf("fetchWeatherUpstream err = %v", err)
+			}
+			stats := weatherLatency.Snapshot()[weatherCacheKey("Jember")]
+			if stats.Total != 1 || stats.Outcomes[tt.outcome] != 1 {
+				t.Fatalf("outcomes = %v, ingin 1x %s", stats.Outcomes, tt.outcome)
+			}
+			if failures := weatherHealth.Snapshot(true).ConsecutiveFailures; (failures == 0) != (tt.outcome == OutcomeSuccess) {
+				t.Fatalf("consecutive failures = %d untuk %s", failures, tt.outcome)
+			}
+		})
+	}
+}
+
+// owmResponses server OWM palsu yang menjawab sesuai urutan status (terakhir diulang)
+func owmResponses(calls *atomic.Int32, statuses []int, header http.Header) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		status := statuses[min(n, len(statuses))-1]
+		if status != http.StatusOK {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte(owmTestBody))
+	}
+}
+
+func TestDoOWMRequestWithRetry(t *testing.T) {
+	tests := []struct {
+		name       string
+		statuses   []int
+		retryAfter string
+		wantStatus int
+		wantCalls  int32
+	}{
+		{"gagal dua kali lalu berhasil", []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK}, "", http.StatusOK, 3},
+		{"percobaan habis", []int{http.StatusInternalServerError}, "", http.StatusInternalServerError, 3},
+		{"404 tidak di-retry", []int{http.StatusNotFound, http.StatusOK}, "", http.StatusNotFound, 1},
+		// Retry-After 0 dipakai menggantikan backoff (base delay 1 jam di bawah)
+		{"429 dengan Retry-After", []int{http.StatusTooManyRequests, http.StatusOK}, "0", http.StatusOK, 2},
+		{"Retry-After terlalu lama", []int{http.StatusTooManyRequests, http.StatusOK}, "3600", http.StatusTooManyRequests, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			header := http.Header{}
+			if tt.retryAfter != "" {
+				header.Set("Retry-After", tt.retryAfter)
+			}
+			useOWMServer(t, owmResponses(&calls, tt.statuses, header))
+			if tt.retryAfter != "" {
+				weatherConfig.RetryBaseDelay = time.Hour
+			}
+
+			req, _ := http.NewRequest(http.MethodGet, owmURL("weather", "Jember", weatherConfig.APIKey), nil)
+			start := time.Now()
+			resp, err := doOWMRequestWithRetry(req)
+			if err != nil {
+				t.Fatalf("doOWMRequestWithRetry: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus || calls.Load() != tt.wantCalls {
+				t.Fatalf("status %d setelah %d request, ingin %d setelah %d", resp.StatusCode, calls.Load(), tt.wantStatus, tt.wantCalls)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Fatalf("retry memakan %s", elapsed)
+			}
+		})
+	}
+}
+
+func TestDoOWMRequestWithRetryCancelled(t *testing.T) {
+	var calls atomic.Int32
+	useOWMServer(t, owmResponses(&calls, []int{http.StatusServiceUnavailable}, nil))
+	weatherConfig.RetryBaseDelay = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, owmURL("weather", "Jember", weatherConfig.APIKey), nil)
+	start := time.Now()
+	_, err := doOWMRequestWithRetry(req)
+	if !errors.Is(err, context.DeadlineExceeded) || calls.Load() != 1 {
+		t.Fatalf("err = %v setelah %d request, ingin DeadlineExceeded setelah 1", err, calls.Load())
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("pembatalan baru terasa setelah %s", elapsed)
+	}
+}
+
+func TestFetchWeatherUpstreamRetriesTransientErrors(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+	var calls atomic.Int32
+	useOWMServer(t, owmResponses(&calls, []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK}, nil))
+
+	data, err := fetchWeatherUpstream(context.Background(), "Jember")
+	if err != nil || data.Temp != 27.5 || data.Humidity != 72 || calls.Load() != 3 {
+		t.Fatalf("fetchWeatherUpstream = %+v, %v setelah %d request", data, err, calls.Load())
+	}
+	if stats := weatherLatency.Snapshot()[weatherCacheKey("Jember")]; stats.Total != 1 || stats.Outcomes[OutcomeSuccess] != 1 {
+		t.Fatalf("retry dicatat sebagai %v, ingin satu panggilan sukses", stats.Outcomes)
+	}
+}
+
+func TestFetchWeatherWithEmptyAPIKey(t *testing.T) {
+	useWeatherCache(t)
+	var calls atomic.Int32
+	useOWMServer(t, owmResponses(&calls, []int{http.StatusOK}, nil))
+
+	t.Setenv("OWM_API_KEY", "  ")
+	cfg, err := LoadConfig(os.Getenv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Weather.APIKey != "" {
+		t.Fatalf("APIKey = %q, ingin kosong", cfg.Weather.APIKey)
+	}
+	cfg.Weather.BaseURL = weatherConfig.BaseURL
+	ConfigureWeather(cfg.Weather, weatherHTTPClient)
+
+	if _, err := FetchWeather("Jember"); !errors.Is(err, ErrMissingAPIKey) {
+		t.Fatalf("FetchWeather err = %v, ingin ErrMissingAPIKey", err)
+	}
+	if _, err := FetchWeatherForecast("Jember"); !errors.Is(err, ErrMissingAPIKey) {
+		t.Fatalf("FetchWeatherForecast err = %v, ingin ErrMissingAPIKey", err)
+	}
+
+	// Salah konfigurasi = 503 weather_not_configured, bukan 500
+	rec := httptest.NewRecorder()
+	WeatherAPIHandler(rec, httptest.NewRequest(http.MethodGet, "/cuaca?region=Jember", nil))
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), errWeatherNotConfigured.Code) {
+		t.Fatalf("GET /cuaca = %d %s", rec.Code, rec.Body.String())
+	}
+	if calls.Load() != 0 {
+		t.Fatalf("upstream dipanggil %d kali tanpa API key", calls.Load())
+	}
+}
+
+func TestOWMBaseURLFromConfig(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+	var mu sync.Mutex
+	var paths []string
+	fake := owmFake(t, 0, http.StatusOK)
+	useOWMServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path+"?appid="+r.URL.Query().Get("appid"))
+		mu.Unlock()
+		fake(w, r)
+	})
+
+	// Proxy self-hosted dengan prefix path; trailing slash dibuang saat load
+	t.Setenv("OWM_API_KEY", "kunci-proxy")
+	t.Setenv("OWM_BASE_URL", weatherConfig.BaseURL+"/proxy/owm/")
+	cfg, err := LoadConfig(os.Getenv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ConfigureWeather(cfg.Weather, weatherHTTPClient)
+
+	data, err := FetchWeather("Jember")
+	if err != nil || data.Temp != 27.5 {
+		t.Fatalf("FetchWeather = %+v, %v", data, err)
+	}
+	forecast, err := FetchWeatherForecast("Jember")
+	if err != nil || len(forecast) == 0 {
+		t.Fatalf("FetchWeatherForecast = %d entri, %v", len(forecast), err)
+	}
+
+	want := []string{"/proxy/owm/weather?appid=kunci-proxy", "/proxy/owm/forecast?appid=kunci-proxy"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("request ke stub = %v, ingin %v", paths, want)
+	}
+}
+
+func TestLoadConfigOWMBaseURL(t *testing.T) {
+	tests := []struct {
+		env     string
+		want    string
+		wantErr bool
+	}{
+		{"", defaultOWMBaseURL, false},
+		{"http://localhost:8081/owm/", "http://localhost:8081/owm", false},
+		{"api.openweathermap.org/data/2.5", "", true},
+		{"ftp://owm.example/data", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			cfg, err := LoadConfig(func(key string) string {
+				if key == "OWM_BASE_URL" {
+					return tt.env
+				}
+				return ""
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadConfig err = %v, ingin error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && cfg.Weather.BaseURL != tt.want {
+				t.Fatalf("BaseURL = %q, ingin %q", cfg.Weather.BaseURL, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchWeatherCachedSharesOneUpstreamCall(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+	useWeatherCache(t)
+	var calls atomic.Int32
+	arrived, release := make(chan struct{}), make(chan struct{})
+	useOWMServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			close(arrived)
+		}
+		<-release
+		w.Write([]byte(owmTestBody))
+	})
+
+	const callers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, err := FetchWeatherCached("Jember")
+			if err == nil && data.Temp != 27.5 {
+				err = errors.New("data tidak sesuai")
+			}
+			errs <- err
+		}()
+	}
+
+	// Caller yang pergi lebih dulu tidak membatalkan request bersama
+	<-arrived
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := FetchWeatherWithContext(ctx, "jember"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("caller batal = %v, ingin context.Canceled", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Burst berikutnya dilayani cache
+	for i := 0; i < 5; i++ {
+		if _, err := FetchWeatherCached("Jember"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("request ke OWM = %d, ingin tepat 1", got)
+	}
+}
+
+func TestWeatherAPIHandlerBurstUsesCache(t *testing.T) {
+	useStores(t, NewMemoryPriceStore(), NewMemoryWeatherStore())
+	useWeatherCache(t)
+	var calls atomic.Int32
+	useOWMServer(t, owmResponses(&calls, []int{http.StatusOK}, nil))
+
+	// Request dashboard yang berselang (tidak overlap) tetap hanya satu panggilan OWM
+	for _, target := range []string{"/cuaca?region=Jember", "/weather?region=jember", "/cuaca?region=Kab.%20Jember&units=imperial"} {
+		rec := serve(WeatherAPIHandler, http.MethodGet, target, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d %s", target, rec.Code, rec.Body.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("request ke OWM = %d, ingin tepat 1", got)
+	}
+
+	// Region lain belum di cache: panggilan baru
+	if rec := serve(WeatherAPIHandler, http.MethodGet, "/cuaca?region=Malang", ""); rec.Code != http.StatusOK || calls.Load() != 2 {
+		t.Fatalf("GET /cuaca?region=Malang = %d, OWM %d kali", rec.Code, calls.Load())
+	}
+}
+
+func TestFetchWeatherForecastRainProbability(t *testing.T) {
+	entries := forecastFixture(t)
+	if len(entries) != 18 {
+		t.Fatalf("%d entri, ingin 18", len(entries))
+	}
+	if entries[0].RainProbability != 0 || entries[10].RainProbability != 0.9 || entries[17].RainProbability != 0.1 {
+		t.Fatalf("pop = %v, %v, %v; ingin 0, 0.9, 0.1",
+			entries[0].RainProbability, entries[10].RainProbability, entries[17].RainProbability)
+	}
+
+	want := []DailyRainProbability{
+		{Date: "2026-03-10", Max: 0.65, Avg: 0.29},
+		{Date: "2026-03-11", Max: 0.9, Avg: 0.7},
+		{Date: "2026-03-12", Max: 0.2, Avg: 0.15},
+	}
+	if got := SummarizeRainProbabilityByDay(entries); !reflect.DeepEqual(got, want) {
+		t.Fatalf("SummarizeRainProbabilityByDay = %+v, ingin %+v", got, want)
+	}
+}
+
+func TestFetchWeatherForecastMissingPopIsZero(t *testing.T) {
+	useOWMServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"list":[
+			{"dt_txt":"2026-03-10 00:00:00","main":{"temp":24,"humidity":75}},
+			{"dt_txt":"2026-03-10 03:00:00","main":{"temp":25,"humidity":70},"pop":0.4}
+		]}`))
+	})
+	entries, err := FetchWeatherForecast("Jember")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].RainProbability != 0 || entries[1].RainProbability != 0.4 {
+		t.Fatalf("entri = %+v", entries)
+	}
+	want := []DailyRainProbability{{Date: "2026-03-10", Max: 0.4, Avg: 0.2}}
+	if got := SummarizeRainProbabilityByDay(entries); !reflect.DeepEqual(got, want) {
+		t.Fatalf("SummarizeRainProbabilityByDay = %+v, ingin %+v", got, want)
+	}
+}
@@ -212,9 +212,11 @@ async function fetchPrices() {
             throw new Error('Network response was not ok');
         }
         
-        const data = await response.json();
+        // GET /harga mengembalikan wrapper Paginated: { data, total, limit, offset }
+        const page = await response.json();
+        const data = page && page.data;
         
-        console.log('Fetched prices:', data); // Debug log
+        console.log('Fetched prices:', page); // Debug log
         
         if (data && Array.isArray(data) && data.length > 0) {
             const table = document.createElement('div');
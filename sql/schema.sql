@@ -3,8 +3,15 @@ CREATE TABLE IF NOT EXISTS prices (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     region TEXT NOT NULL,
     price REAL NOT NULL,
+    price_min REAL,
+    price_max REAL,
     unit TEXT,
     source TEXT,
+    source_type TEXT NOT NULL DEFAULT 'unknown',
+    quality TEXT,
+    source_name TEXT,
+    source_url TEXT,
+    scraped_at TEXT,
     recorded_at TEXT NOT NULL,
     created_at TEXT DEFAULT (datetime('now'))
 );